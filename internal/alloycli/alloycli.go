@@ -25,8 +25,11 @@ func Run() {
 
 	cmd.AddCommand(
 		convertCommand(),
+		diffCommand(),
 		fmtCommand(),
+		lspCommand(),
 		runCommand(),
+		testCommand(),
 		toolsCommand(),
 		validateCommand(),
 	)
@@ -0,0 +1,115 @@
+package alloycli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/grafana/alloy/internal/configtest"
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/runtime/logging"
+)
+
+func testCommand() *cobra.Command {
+	t := &alloyTest{
+		minStability: featuregate.StabilityGenerallyAvailable,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "test [flags] path test-file",
+		Short: "Run unit tests against a configuration",
+		Long: `The test subcommand runs the tests of a test file against a
+configuration file or directory.
+
+Each test sends synthetic log lines, metric samples, or OTLP data to
+components and checks the data received by mocked components. Tests run
+offline: the components named in expect blocks and in the mocks attribute of a
+test are replaced by mocks which never contact external systems.
+
+The command exits with a non-zero status if any test fails.`,
+		Args:         cobra.ExactArgs(2),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return t.Run(cmd.Context(), args[0], args[1])
+		},
+	}
+
+	cmd.Flags().StringVar(&t.run, "run", t.run, "Only run the tests whose name matches the regular expression.")
+	cmd.Flags().BoolVarP(&t.verbose, "verbose", "v", t.verbose, "Write the logs of the components to stderr.")
+	cmd.Flags().Var(&t.minStability, "stability.level", fmt.Sprintf("Minimum stability level of features to enable. Supported values: %s", strings.Join(featuregate.AllowedValues(), ", ")))
+	cmd.Flags().BoolVar(&t.enableCommunityComps, "feature.community-components.enabled", t.enableCommunityComps, "Enable community components.")
+
+	return cmd
+}
+
+type alloyTest struct {
+	run     string
+	verbose bool
+
+	minStability         featuregate.Stability
+	enableCommunityComps bool
+}
+
+func (t *alloyTest) Run(ctx context.Context, configPath, testPath string) error {
+	var filter *regexp.Regexp
+	if t.run != "" {
+		var err error
+		if filter, err = regexp.Compile(t.run); err != nil {
+			return fmt.Errorf("invalid --run expression: %w", err)
+		}
+	}
+
+	sources, err := loadSourceFiles(configPath, "alloy", false, "")
+	if err != nil {
+		return fmt.Errorf("reading config path %q: %w", configPath, err)
+	}
+	testFile, err := configtest.LoadFile(testPath)
+	if err != nil {
+		return err
+	}
+
+	opts := configtest.Options{
+		Sources:              sources,
+		ConfigPath:           configPath,
+		MinStability:         t.minStability,
+		EnableCommunityComps: t.enableCommunityComps,
+	}
+	if t.verbose {
+		if opts.Logger, err = logging.New(os.Stderr, logging.DefaultOptions); err != nil {
+			return err
+		}
+	}
+
+	var ran, failed int
+	for _, test := range testFile.Tests {
+		if filter != nil && !filter.MatchString(test.Name) {
+			continue
+		}
+
+		ran++
+		res := configtest.Run(ctx, opts, test)
+		if res.Passed() {
+			fmt.Printf("--- PASS: %s (%.2fs)\n", res.Name, res.Duration.Seconds())
+			continue
+		}
+
+		failed++
+		fmt.Printf("--- FAIL: %s (%.2fs)\n", res.Name, res.Duration.Seconds())
+		if res.Err != nil {
+			fmt.Printf("    %s\n", res.Err)
+		}
+		for _, failure := range res.Failures {
+			fmt.Printf("    %s\n", strings.ReplaceAll(failure, "\n", "\n    "))
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d tests failed", failed, ran)
+	}
+	fmt.Println("PASS")
+	return nil
+}
@@ -0,0 +1,252 @@
+package alloycli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/cobra"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/featuregate"
+	alloy_runtime "github.com/grafana/alloy/internal/runtime"
+	"github.com/grafana/alloy/internal/runtime/logging"
+	"github.com/grafana/alloy/internal/service"
+	"github.com/grafana/alloy/internal/service/cluster"
+	httpservice "github.com/grafana/alloy/internal/service/http"
+	"github.com/grafana/alloy/internal/service/labelstore"
+	"github.com/grafana/alloy/internal/service/livedebugging"
+	"github.com/grafana/alloy/internal/service/otel"
+	"github.com/grafana/alloy/internal/service/remotecfg"
+	"github.com/grafana/alloy/internal/service/ui"
+)
+
+// exitCodeChanges is the exit status of alloy diff --exit-code when the
+// configuration has changes.
+const exitCodeChanges = 2
+
+func diffCommand() *cobra.Command {
+	d := &alloyDiff{
+		server:       "http://127.0.0.1:12345",
+		output:       "text",
+		configFormat: "alloy",
+		minStability: featuregate.StabilityGenerallyAvailable,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "diff [flags] path",
+		Short: "Show the changes a configuration makes to a running configuration",
+		Long: `The diff subcommand shows the components which are created, deleted,
+updated, or re-evaluated when a configuration file or directory is loaded,
+without loading it.
+
+By default, the configuration is compared to the configuration running in the
+Alloy instance at --server. If --base is set, it's compared to the
+configuration at --base instead, without contacting any Alloy instance.
+
+Components aren't evaluated or run, and modules aren't fetched. The values of
+secret arguments are redacted.
+
+If --exit-code is set, the command exits with status 2 if the configuration
+has changes, 1 if it fails, and 0 otherwise.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return d.Run(cmd.Context(), cmd.OutOrStdout(), args[0])
+		},
+	}
+
+	cmd.Flags().StringVar(&d.server, "server", d.server, "Address of the Alloy instance running the configuration to compare to.")
+	cmd.Flags().StringVar(&d.base, "base", d.base, "Path of the configuration file or directory to compare to, instead of the configuration of --server.")
+	cmd.Flags().StringVarP(&d.output, "output", "o", d.output, "Output format. Supported values: text, json.")
+	cmd.Flags().BoolVar(&d.exitCode, "exit-code", d.exitCode, "Exit with status 2 if the configuration has changes.")
+
+	// Config flags
+	cmd.Flags().StringVar(&d.configFormat, "config.format", d.configFormat, fmt.Sprintf("The format of the source files. Supported formats: %s.", supportedFormatsList()))
+	cmd.Flags().BoolVar(&d.configBypassConversionErrors, "config.bypass-conversion-errors", d.configBypassConversionErrors, "Enable bypassing errors when converting")
+	cmd.Flags().StringVar(&d.configExtraArgs, "config.extra-args", d.configExtraArgs, "Extra arguments from the original format used by the converter. Multiple arguments can be passed by separating them with a space.")
+
+	// Misc flags
+	cmd.Flags().Var(&d.minStability, "stability.level", fmt.Sprintf("Minimum stability level of features to enable with --base. Supported values: %s", strings.Join(featuregate.AllowedValues(), ", ")))
+	cmd.Flags().BoolVar(&d.enableCommunityComps, "feature.community-components.enabled", d.enableCommunityComps, "Enable community components with --base.")
+
+	return cmd
+}
+
+type alloyDiff struct {
+	server   string
+	base     string
+	output   string
+	exitCode bool
+
+	configFormat                 string
+	configBypassConversionErrors bool
+	configExtraArgs              string
+
+	minStability         featuregate.Stability
+	enableCommunityComps bool
+}
+
+func (d *alloyDiff) Run(ctx context.Context, w io.Writer, path string) error {
+	if d.output != "text" && d.output != "json" {
+		return fmt.Errorf("unsupported output format %q", d.output)
+	}
+
+	sources, err := loadSourceFiles(path, d.configFormat, d.configBypassConversionErrors, d.configExtraArgs)
+	if err != nil {
+		return fmt.Errorf("reading config path %q: %w", path, err)
+	}
+
+	var diff *alloy_runtime.ConfigDiff
+	if d.base != "" {
+		diff, err = d.diffFiles(sources, path)
+	} else {
+		diff, err = d.diffServer(ctx, sources)
+	}
+	if err != nil {
+		return err
+	}
+
+	if d.output == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(diff)
+	} else {
+		err = writeConfigDiff(w, diff)
+	}
+	if err != nil {
+		return err
+	}
+
+	if d.exitCode && !diff.Empty() {
+		os.Exit(exitCodeChanges)
+	}
+	return nil
+}
+
+// diffFiles compares sources to the configuration at d.base.
+func (d *alloyDiff) diffFiles(sources map[string][]byte, path string) (*alloy_runtime.ConfigDiff, error) {
+	baseSources, err := loadSourceFiles(d.base, d.configFormat, d.configBypassConversionErrors, d.configExtraArgs)
+	if err != nil {
+		return nil, fmt.Errorf("reading config path %q: %w", d.base, err)
+	}
+	base, err := alloy_runtime.ParseSources(baseSources)
+	if err != nil {
+		return nil, err
+	}
+	candidate, err := alloy_runtime.ParseSources(sources)
+	if err != nil {
+		return nil, err
+	}
+
+	dataPath, err := os.MkdirTemp("", "alloy-diff-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dataPath)
+
+	// The services are only used for their definitions: nothing is run.
+	f := alloy_runtime.New(alloy_runtime.Options{
+		Logger:               logging.NewNop(),
+		DataPath:             dataPath,
+		Reg:                  prometheus.NewRegistry(),
+		MinStability:         d.minStability,
+		EnableCommunityComps: d.enableCommunityComps,
+		ComponentRegistry:    component.NewDefaultRegistry(d.minStability, d.enableCommunityComps),
+		Services: []service.Service{
+			&cluster.Service{},
+			&httpservice.Service{},
+			&labelstore.Service{},
+			&livedebugging.Service{},
+			&otel.Service{},
+			&remotecfg.Service{},
+			&ui.Service{},
+		},
+	})
+	return f.DiffSources(base, candidate, nil, path)
+}
+
+// diffServer compares sources to the configuration running at d.server.
+func (d *alloyDiff) diffServer(ctx context.Context, sources map[string][]byte) (*alloy_runtime.ConfigDiff, error) {
+	files := make(map[string]string, len(sources))
+	for name, content := range sources {
+		files[name] = string(content)
+	}
+	body, err := json.Marshal(httpservice.ConfigDiffRequest{Files: files})
+	if err != nil {
+		return nil, err
+	}
+
+	url := strings.TrimSuffix(d.server, "/") + httpservice.ConfigDiffPath
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	var diff alloy_runtime.ConfigDiff
+	if err := json.NewDecoder(resp.Body).Decode(&diff); err != nil {
+		return nil, fmt.Errorf("decoding the diff: %w", err)
+	}
+	return &diff, nil
+}
+
+var changeSymbols = map[alloy_runtime.ChangeType]string{
+	alloy_runtime.ChangeCreated:     "+",
+	alloy_runtime.ChangeDeleted:     "-",
+	alloy_runtime.ChangeUpdated:     "~",
+	alloy_runtime.ChangeReevaluated: " ",
+}
+
+// writeConfigDiff writes a human readable diff to w.
+func writeConfigDiff(w io.Writer, diff *alloy_runtime.ConfigDiff) error {
+	var buf bytes.Buffer
+	if diff.Empty() {
+		buf.WriteString("No changes.\n")
+	}
+
+	for _, n := range diff.Nodes {
+		if n.Change == alloy_runtime.ChangeReevaluated {
+			fmt.Fprintf(&buf, "%s %s (re-evaluated)\n", changeSymbols[n.Change], n.ID)
+			continue
+		}
+		fmt.Fprintf(&buf, "%s %s\n", changeSymbols[n.Change], n.ID)
+		for _, arg := range n.Arguments {
+			switch arg.Change {
+			case alloy_runtime.ChangeCreated:
+				fmt.Fprintf(&buf, "    + %s = %s\n", arg.Path, arg.New)
+			case alloy_runtime.ChangeDeleted:
+				fmt.Fprintf(&buf, "    - %s = %s\n", arg.Path, arg.Old)
+			default:
+				fmt.Fprintf(&buf, "    ~ %s = %s -> %s\n", arg.Path, arg.Old, arg.New)
+			}
+		}
+	}
+
+	if len(diff.Edges) > 0 {
+		buf.WriteString("\nDependencies:\n")
+		for _, e := range diff.Edges {
+			fmt.Fprintf(&buf, "%s %s -> %s\n", changeSymbols[e.Change], e.From, e.To)
+		}
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
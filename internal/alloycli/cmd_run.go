@@ -99,6 +99,12 @@ Additionally, the HTTP server exposes the following debug endpoints:
 If reloading the config dir/file-path fails, Grafana Alloy will continue running in
 its last valid state. Components which failed may be be listed as unhealthy,
 depending on the nature of the reload error.
+
+If --config.reload-health-window is set, the health of components is watched
+for that duration after a reload, and the previous config is restored if a
+component listed in --config.reload-critical-components becomes unhealthy.
+The reload request doesn't wait for the window unless it's sent to
+/-/reload?wait=true, in which case a rollback is returned as an error.
 `,
 		Args:         cobra.ExactArgs(1),
 		SilenceUsage: true,
@@ -156,6 +162,8 @@ depending on the nature of the reload error.
 	cmd.Flags().StringVar(&r.configFormat, "config.format", r.configFormat, fmt.Sprintf("The format of the source file. Supported formats: %s.", supportedFormatsList()))
 	cmd.Flags().BoolVar(&r.configBypassConversionErrors, "config.bypass-conversion-errors", r.configBypassConversionErrors, "Enable bypassing errors when converting")
 	cmd.Flags().StringVar(&r.configExtraArgs, "config.extra-args", r.configExtraArgs, "Extra arguments from the original format used by the converter. Multiple arguments can be passed by separating them with a space.")
+	cmd.Flags().DurationVar(&r.configReloadHealthWindow, "config.reload-health-window", r.configReloadHealthWindow, "How long to watch the health of components after reloading the config. The previous config is restored if a critical component becomes unhealthy. Zero disables rollbacks")
+	cmd.Flags().StringSliceVar(&r.configReloadCriticalComponents, "config.reload-critical-components", r.configReloadCriticalComponents, "Comma-separated list of IDs or glob patterns of the components watched after reloading the config. All components are watched if empty")
 
 	// Misc flags
 	cmd.Flags().
@@ -172,35 +180,37 @@ depending on the nature of the reload error.
 }
 
 type alloyRun struct {
-	inMemoryAddr                 string
-	httpListenAddr               string
-	storagePath                  string
-	minStability                 featuregate.Stability
-	uiPrefix                     string
-	enablePprof                  bool
-	disableReporting             bool
-	clusterEnabled               bool
-	clusterNodeName              string
-	clusterAdvAddr               string
-	clusterJoinAddr              string
-	clusterDiscoverPeers         string
-	clusterAdvInterfaces         []string
-	clusterRejoinInterval        time.Duration
-	clusterMaxJoinPeers          int
-	clusterName                  string
-	clusterEnableTLS             bool
-	clusterTLSCAPath             string
-	clusterTLSCertPath           string
-	clusterTLSKeyPath            string
-	clusterTLSServerName         string
-	clusterWaitForSize           int
-	clusterWaitTimeout           time.Duration
-	configFormat                 string
-	configBypassConversionErrors bool
-	configExtraArgs              string
-	enableCommunityComps         bool
-	disableSupportBundle         bool
-	windowsPriority              string
+	inMemoryAddr                   string
+	httpListenAddr                 string
+	storagePath                    string
+	minStability                   featuregate.Stability
+	uiPrefix                       string
+	enablePprof                    bool
+	disableReporting               bool
+	clusterEnabled                 bool
+	clusterNodeName                string
+	clusterAdvAddr                 string
+	clusterJoinAddr                string
+	clusterDiscoverPeers           string
+	clusterAdvInterfaces           []string
+	clusterRejoinInterval          time.Duration
+	clusterMaxJoinPeers            int
+	clusterName                    string
+	clusterEnableTLS               bool
+	clusterTLSCAPath               string
+	clusterTLSCertPath             string
+	clusterTLSKeyPath              string
+	clusterTLSServerName           string
+	clusterWaitForSize             int
+	clusterWaitTimeout             time.Duration
+	configFormat                   string
+	configBypassConversionErrors   bool
+	configExtraArgs                string
+	configReloadHealthWindow       time.Duration
+	configReloadCriticalComponents []string
+	enableCommunityComps           bool
+	disableSupportBundle           bool
+	windowsPriority                string
 }
 
 func (fr *alloyRun) Run(cmd *cobra.Command, configPath string) error {
@@ -242,6 +252,22 @@ func (fr *alloyRun) Run(cmd *cobra.Command, configPath string) error {
 		}
 	}
 
+	reloadPolicy := alloy_runtime.ReloadPolicy{
+		HealthWindow:       fr.configReloadHealthWindow,
+		CriticalComponents: fr.configReloadCriticalComponents,
+	}
+	if reloadPolicy.HealthWindow != 0 || len(reloadPolicy.CriticalComponents) > 0 {
+		if err := featuregate.CheckAllowed(
+			featuregate.StabilityExperimental,
+			fr.minStability,
+			"config reload rollbacks"); err != nil {
+			return err
+		}
+		if err := reloadPolicy.Validate(); err != nil {
+			return fmt.Errorf("invalid reload policy: %w", err)
+		}
+	}
+
 	// Set the global tracer provider to catch global traces, but ideally things
 	// use the tracer provider given to them so the appropriate attributes get
 	// injected.
@@ -278,13 +304,14 @@ func (fr *alloyRun) Run(cmd *cobra.Command, configPath string) error {
 	reg.MustRegister(newResourcesCollector(l))
 
 	// There's a cyclic dependency between the definition of the Alloy controller,
-	// the reload/ready functions, and the HTTP service.
+	// the reload/ready/diff functions, and the HTTP service.
 	//
 	// To work around this, we lazily create variables for the functions the HTTP
 	// service needs and set them after the Alloy controller exists.
 	var (
-		reload func() (map[string][]byte, error)
+		reload func() (map[string][]byte, *alloy_runtime.Rollout, error)
 		ready  func() bool
+		diff   func(sources map[string][]byte) (*alloy_runtime.ConfigDiff, error)
 	)
 
 	clusterService, err := buildClusterService(ClusterOptions{
@@ -327,9 +354,15 @@ func (fr *alloyRun) Run(cmd *cobra.Command, configPath string) error {
 		Gatherer: prometheus.DefaultGatherer,
 
 		ReadyFunc: func() bool { return ready() },
-		ReloadFunc: func() error {
-			_, err := reload()
-			return err
+		ReloadFunc: func(ctx context.Context, wait bool) error {
+			_, rollout, err := reload()
+			if err != nil || !wait || rollout == nil {
+				return err
+			}
+			return rollout.Wait(ctx)
+		},
+		DiffFunc: func(sources map[string][]byte) (any, error) {
+			return diff(sources)
 		},
 
 		HTTPListenAddr:   fr.httpListenAddr,
@@ -368,6 +401,34 @@ func (fr *alloyRun) Run(cmd *cobra.Command, configPath string) error {
 	labelService := labelstore.New(l, reg)
 	alloyseed.Init(fr.storagePath, l)
 
+	// activeSource is the source of the last config loaded, which is replaced
+	// by the previous one when a reload is rolled back.
+	var (
+		reloadMut    sync.Mutex
+		activeSource *alloy_runtime.Source
+	)
+	reloadPolicy.OnRollback = func(err *alloy_runtime.RollbackError) {
+		reloadMut.Lock()
+		defer reloadMut.Unlock()
+
+		if activeSource != err.Source {
+			// The config was reloaded again since the rolled back one.
+			return
+		}
+		if err.Err != nil {
+			// The previous config failed to load, so neither config is fully
+			// running. Keep reporting the rolled back config, which is what the
+			// HTTP service shows.
+			level.Error(l).Log("msg", "failed to roll back to the previous config", "err", err)
+			instrumentation.InstrumentConfig(false, hashSourceFiles(err.Source.RawConfigs()), fr.clusterName)
+			return
+		}
+		level.Warn(l).Log("msg", "config reload rolled back, the previous config is running again", "err", err)
+		activeSource = err.Previous
+		httpService.SetSources(activeSource.SourceFiles())
+		instrumentation.InstrumentConfig(false, hashSourceFiles(activeSource.RawConfigs()), fr.clusterName)
+	}
+
 	f := alloy_runtime.New(alloy_runtime.Options{
 		Logger:               l,
 		Tracer:               t,
@@ -375,6 +436,7 @@ func (fr *alloyRun) Run(cmd *cobra.Command, configPath string) error {
 		Reg:                  reg,
 		MinStability:         fr.minStability,
 		EnableCommunityComps: fr.enableCommunityComps,
+		ReloadPolicy:         reloadPolicy,
 		Services: []service.Service{
 			clusterService,
 			httpService,
@@ -387,25 +449,40 @@ func (fr *alloyRun) Run(cmd *cobra.Command, configPath string) error {
 	})
 
 	ready = f.Ready
-	reload = func() (map[string][]byte, error) {
+	// reload returns the rollout of the new config, which is nil if the health
+	// of the components isn't watched after loading it.
+	reload = func() (map[string][]byte, *alloy_runtime.Rollout, error) {
+		reloadMut.Lock()
+		defer reloadMut.Unlock()
+
 		sources, err := loadSourceFiles(configPath, fr.configFormat, fr.configBypassConversionErrors, fr.configExtraArgs)
 		if err != nil {
 			instrumentation.InstrumentConfig(false, [32]byte{}, fr.clusterName)
-			return nil, fmt.Errorf("reading config path %q: %w", configPath, err)
+			return nil, nil, fmt.Errorf("reading config path %q: %w", configPath, err)
 		}
 
 		alloySource, err := alloy_runtime.ParseSources(sources)
-		defer instrumentation.InstrumentConfig(err == nil, hashSourceFiles(sources), fr.clusterName)
+		success, hash := err == nil, hashSourceFiles(sources)
+		defer func() { instrumentation.InstrumentConfig(success, hash, fr.clusterName) }()
 		if err != nil {
-			return sources, fmt.Errorf("reading config path %q: %w", configPath, err)
+			return sources, nil, fmt.Errorf("reading config path %q: %w", configPath, err)
 		}
 
 		httpService.SetSources(alloySource.SourceFiles())
 		if err := f.LoadSource(alloySource, nil, configPath); err != nil {
-			return sources, fmt.Errorf("error during the initial load: %w", err)
+			return sources, nil, fmt.Errorf("error during the initial load: %w", err)
 		}
+		activeSource = alloySource
 
-		return sources, nil
+		return sources, f.LastRollout(), nil
+	}
+
+	diff = func(sources map[string][]byte) (*alloy_runtime.ConfigDiff, error) {
+		alloySource, err := alloy_runtime.ParseSources(sources)
+		if err != nil {
+			return nil, err
+		}
+		return f.DiffSource(alloySource, nil, configPath)
 	}
 
 	// Alloy controller
@@ -434,7 +511,7 @@ func (fr *alloyRun) Run(cmd *cobra.Command, configPath string) error {
 	// Perform the initial reload. This is done after starting the HTTP server so
 	// that /metric and pprof endpoints are available while the Alloy controller
 	// is loading.
-	if source, err := reload(); err != nil {
+	if source, _, err := reload(); err != nil {
 		var diags diag.Diagnostics
 		if errors.As(err, &diags) {
 			p := diag.NewPrinter(diag.PrinterConfig{
@@ -472,7 +549,7 @@ func (fr *alloyRun) Run(cmd *cobra.Command, configPath string) error {
 		case <-ctx.Done():
 			return nil
 		case <-reloadSignal:
-			if _, err := reload(); err != nil {
+			if _, _, err := reload(); err != nil {
 				level.Error(l).Log("msg", "failed to reload config", "err", err)
 			} else {
 				level.Info(l).Log("msg", "config reloaded")
@@ -15,6 +15,7 @@ func toolsCommand() *cobra.Command {
 	}
 
 	cmd.AddCommand(
+		debugCommand(),
 		getTools("prometheus.remote_write", remotewrite.InstallTools),
 	)
 
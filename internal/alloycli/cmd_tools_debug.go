@@ -0,0 +1,127 @@
+package alloycli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/grafana/alloy/internal/web/api"
+)
+
+func debugCommand() *cobra.Command {
+	d := &alloyDebug{
+		server:     "http://127.0.0.1:12345",
+		sampleProb: 1,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "debug [flags] component_id",
+		Short: "Stream the live debugging data of a component",
+		Long: `The debug subcommand streams the live debugging data of a component running
+in the Alloy instance at --server to the terminal, until it's interrupted.
+
+The data can be filtered by type, labels, substring or regex, and sampled or
+rate limited. The filters are applied by the Alloy instance before the data is
+rendered. Live debugging must be enabled in the Alloy instance.
+
+If --cluster is set, the data of the component is streamed from every peer of
+the cluster, and each item is prefixed with the name of the peer.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return d.Run(cmd.Context(), cmd.OutOrStdout(), args[0])
+		},
+	}
+
+	cmd.Flags().StringVar(&d.server, "server", d.server, "Address of the Alloy instance running the component.")
+	cmd.Flags().BoolVar(&d.cluster, "cluster", d.cluster, "Stream the data from every peer of the cluster.")
+	cmd.Flags().StringSliceVar(&d.types, "type", d.types, "Comma-separated list of data types to keep, such as loki_log or prometheus_metric.")
+	cmd.Flags().StringVar(&d.selector, "selector", d.selector, `Label selector the labels of the data must match, such as {job="api"}.`)
+	cmd.Flags().StringVar(&d.contains, "contains", d.contains, "Substring the data must contain.")
+	cmd.Flags().StringVar(&d.regex, "regex", d.regex, "Regex which must match part of the data.")
+	cmd.Flags().Float64Var(&d.sampleProb, "sample-prob", d.sampleProb, "Probability for data to be kept, between 0 and 1.")
+	cmd.Flags().Float64Var(&d.maxPerSecond, "max-per-second", d.maxPerSecond, "Maximum number of items streamed per second. 0 means no limit.")
+
+	return cmd
+}
+
+type alloyDebug struct {
+	server       string
+	cluster      bool
+	types        []string
+	selector     string
+	contains     string
+	regex        string
+	sampleProb   float64
+	maxPerSecond float64
+}
+
+func (d *alloyDebug) Run(ctx context.Context, w io.Writer, componentID string) error {
+	u, err := url.Parse(strings.TrimSuffix(d.server, "/") + "/api/v0/web/debug/" + componentID)
+	if err != nil {
+		return fmt.Errorf("invalid server address %q: %w", d.server, err)
+	}
+	u.RawQuery = d.query().Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(nil, 16*1024*1024)
+	scanner.Split(api.SplitLiveDebuggingData)
+	for scanner.Scan() {
+		if _, err := fmt.Fprintln(w, scanner.Text()); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
+
+// query returns the query parameters of the live debugging request.
+func (d *alloyDebug) query() url.Values {
+	query := url.Values{}
+	if d.cluster {
+		query.Set("cluster", "true")
+	}
+	if len(d.types) > 0 {
+		query.Set("type", strings.Join(d.types, ","))
+	}
+	if d.selector != "" {
+		query.Set("selector", d.selector)
+	}
+	if d.contains != "" {
+		query.Set("contains", d.contains)
+	}
+	if d.regex != "" {
+		query.Set("regex", d.regex)
+	}
+	if d.sampleProb != 1 {
+		query.Set("sampleProb", strconv.FormatFloat(d.sampleProb, 'f', -1, 64))
+	}
+	if d.maxPerSecond != 0 {
+		query.Set("maxPerSecond", strconv.FormatFloat(d.maxPerSecond, 'f', -1, 64))
+	}
+	return query
+}
@@ -41,6 +41,7 @@ func validateCommand() *cobra.Command {
 	cmd.Flags().StringVar(&v.configFormat, "config.format", v.configFormat, fmt.Sprintf("The format of the source file. Supported formats: %s.", supportedFormatsList()))
 	cmd.Flags().BoolVar(&v.configBypassConversionErrors, "config.bypass-conversion-errors", v.configBypassConversionErrors, "Enable bypassing errors when converting")
 	cmd.Flags().StringVar(&v.configExtraArgs, "config.extra-args", v.configExtraArgs, "Extra arguments from the original format used by the converter. Multiple arguments can be passed by separating them with a space.")
+	cmd.Flags().BoolVar(&v.resolveLocalImports, "config.resolve-local-imports", v.resolveLocalImports, "Validate the modules imported with import.file and import.string. Remote modules are never fetched.")
 
 	// Misc flags
 	cmd.Flags().Var(&v.minStability, "stability.level", fmt.Sprintf("Minimum stability level of features to enable. Supported values: %s", strings.Join(featuregate.AllowedValues(), ", ")))
@@ -53,6 +54,7 @@ type alloyValidate struct {
 	configFormat                 string
 	configBypassConversionErrors bool
 	configExtraArgs              string
+	resolveLocalImports          bool
 
 	minStability         featuregate.Stability
 	enableCommunityComps bool
@@ -76,8 +78,10 @@ func (v *alloyValidate) Run(configFile string) error {
 				&remotecfg.Service{},
 				&ui.Service{},
 			),
-			ComponentRegistry: component.NewDefaultRegistry(v.minStability, v.enableCommunityComps),
-			MinStability:      v.minStability,
+			ComponentRegistry:   component.NewDefaultRegistry(v.minStability, v.enableCommunityComps),
+			MinStability:        v.minStability,
+			ResolveLocalImports: v.resolveLocalImports,
+			ConfigPath:          configFile,
 		},
 	); err != nil {
 		validator.Report(os.Stderr, err, sources)
@@ -6,6 +6,7 @@ package loki
 
 import (
 	"context"
+	"reflect"
 	"sync"
 	"time"
 
@@ -13,6 +14,8 @@ import (
 	"github.com/prometheus/common/model"
 
 	"github.com/grafana/loki/v3/pkg/logproto"
+
+	"github.com/grafana/alloy/internal/nodeconf/argument"
 )
 
 // finalEntryTimeout is how long NewEntryMutatorHandler will wait before giving
@@ -29,6 +32,10 @@ type LogsReceiver interface {
 	Chan() chan Entry
 }
 
+func init() {
+	argument.RegisterCapsule("LogsReceiver", reflect.TypeFor[LogsReceiver]())
+}
+
 type logsReceiver struct {
 	entries chan Entry
 }
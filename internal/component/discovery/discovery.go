@@ -3,6 +3,8 @@ package discovery
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
@@ -178,16 +180,21 @@ func (c *Component) runDiscovery(ctx context.Context, d DiscovererWithMetrics) {
 		runExited <- struct{}{}
 	}()
 
+	// targets last sent, used to publish the changes to live debugging
+	var previousTargets []Target
+
 	// function to convert and send targets in format scraper expects
 	send := func() {
 		allTargets := toAlloyTargets(cache)
 		componentID := livedebugging.ComponentID(c.opts.ID)
+		previous := previousTargets
 		c.debugDataPublisher.PublishIfActive(livedebugging.NewData(
 			componentID,
 			livedebugging.Target,
 			uint64(len(allTargets)),
-			func() string { return fmt.Sprintf("%s", allTargets) },
+			func() string { return TargetsDiffString(previous, allTargets) },
 		))
+		previousTargets = allTargets
 		c.opts.OnStateChange(Exports{Targets: allTargets})
 	}
 
@@ -237,4 +244,43 @@ func toAlloyTargets(cache map[string]*targetgroup.Group) []Target {
 	return allTargets
 }
 
+// TargetsDiffString describes the targets added and removed between previous
+// and current for live debugging.
+func TargetsDiffString(previous, current []Target) string {
+	previousSet := targetSet(previous)
+	currentSet := targetSet(current)
+
+	var added, removed []string
+	for t := range currentSet {
+		if _, ok := previousSet[t]; !ok {
+			added = append(added, t)
+		}
+	}
+	for t := range previousSet {
+		if _, ok := currentSet[t]; !ok {
+			removed = append(removed, t)
+		}
+	}
+	slices.Sort(added)
+	slices.Sort(removed)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "targets: %d (+%d, -%d)", len(current), len(added), len(removed))
+	for _, t := range added {
+		sb.WriteString("\n+ " + t)
+	}
+	for _, t := range removed {
+		sb.WriteString("\n- " + t)
+	}
+	return sb.String()
+}
+
+func targetSet(targets []Target) map[string]struct{} {
+	set := make(map[string]struct{}, len(targets))
+	for _, t := range targets {
+		set[t.String()] = struct{}{}
+	}
+	return set
+}
+
 func (c *Component) LiveDebugging() {}
@@ -293,3 +293,19 @@ func (f *fakeDiscoverer) Run(ctx context.Context, publishChan chan<- []*targetgr
 func (f *fakeDiscoverer) Register() error { return nil }
 
 func (f *fakeDiscoverer) Unregister() {}
+
+func TestTargetsDiffString(t *testing.T) {
+	a := NewTargetFromMap(map[string]string{"__address__": "a"})
+	b := NewTargetFromMap(map[string]string{"__address__": "b"})
+	c := NewTargetFromMap(map[string]string{"__address__": "c"})
+
+	require.Equal(t, "targets: 2 (+2, -0)\n"+
+		`+ {"__address__"="a"}`+"\n"+
+		`+ {"__address__"="b"}`,
+		TargetsDiffString(nil, []Target{b, a}))
+	require.Equal(t, "targets: 2 (+1, -1)\n"+
+		`+ {"__address__"="c"}`+"\n"+
+		`- {"__address__"="a"}`,
+		TargetsDiffString([]Target{a, b}, []Target{b, c}))
+	require.Equal(t, "targets: 2 (+0, -0)", TargetsDiffString([]Target{a, b}, []Target{b, a}))
+}
@@ -4,7 +4,6 @@ package process
 
 import (
 	"context"
-	"fmt"
 	"time"
 
 	"github.com/go-kit/log"
@@ -65,15 +64,17 @@ func (c *Component) Run(ctx context.Context) error {
 		if err != nil {
 			return err
 		}
+		previous := c.processes
 		c.processes = convertProcesses(processes)
 		c.changed()
 
 		componentID := livedebugging.ComponentID(c.opts.ID)
+		current := c.processes
 		c.debugDataPublisher.PublishIfActive(livedebugging.NewData(
 			componentID,
 			livedebugging.Target,
-			uint64(len(c.processes)),
-			func() string { return fmt.Sprintf("%s", c.processes) },
+			uint64(len(current)),
+			func() string { return discovery.TargetsDiffString(previous, current) },
 		))
 
 		return nil
@@ -176,6 +176,7 @@ func (c *Component) handleIn(ctx context.Context, wg *sync.WaitGroup) {
 					}
 					return fmt.Sprintf("[IN]: timestamp: %s, entry: %s, labels: %s, structured_metadata: %s", entry.Timestamp.Format(time.RFC3339Nano), entry.Line, entry.Labels.String(), string(structured_metadata))
 				},
+				livedebugging.WithLabelSet(entry.Labels),
 			))
 			select {
 			case <-ctx.Done():
@@ -217,6 +218,7 @@ func (c *Component) handleOut(shutdownCh chan struct{}, wg *sync.WaitGroup) {
 					}
 					return fmt.Sprintf("[OUT]: timestamp: %s, entry: %s, labels: %s, structured_metadata: %s", entry.Timestamp.Format(time.RFC3339Nano), entry.Line, entry.Labels.String(), string(structured_metadata))
 				},
+				livedebugging.WithLabelSet(entry.Labels),
 			))
 
 			for _, f := range fanout {
@@ -0,0 +1,100 @@
+package stages
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/go-kit/log"
+)
+
+// Parsing errors.
+var (
+	ErrMalformedCEF = errors.New("malformed cef")
+)
+
+// cefHeaderFields are the names of the extracted fields of the CEF header,
+// in order.
+var cefHeaderFields = []string{
+	"cef_version",
+	"cef_device_vendor",
+	"cef_device_product",
+	"cef_device_version",
+	"cef_device_event_class_id",
+	"cef_name",
+	"cef_severity",
+}
+
+// cefExtensionKey matches the keys of the CEF extension. Keys can't contain
+// backslashes, so escaped equal signs in values never end a key.
+var cefExtensionKey = regexp.MustCompile(`(?:^|\s)([\w.\[\]-]+)=`)
+
+// cefExtensionEscapes unescapes the values of the CEF extension.
+var cefExtensionEscapes = strings.NewReplacer(`\\`, `\`, `\=`, `=`, `\n`, "\n", `\r`, "\r")
+
+// CEFConfig configures a processing stage which parses messages using the
+// Common Event Format (CEF) into the shared values map.
+type CEFConfig struct {
+	Source        *string `alloy:"source,attr,optional"`
+	DropMalformed bool    `alloy:"drop_malformed,attr,optional"`
+
+	Promote PromoteConfig `alloy:",squash"`
+}
+
+// newCEFStage creates a new cef pipeline stage from a config.
+func newCEFStage(logger log.Logger, config CEFConfig) (Stage, error) {
+	return newFieldsStage(logger, StageTypeCEF, config.Source, config.DropMalformed, config.Promote, parseCEF)
+}
+
+// parseCEF sets the header fields and the extension of the CEF message in
+// input. The message can be preceded by a syslog header.
+func parseCEF(input string, extracted map[string]interface{}) error {
+	start := strings.Index(input, "CEF:")
+	if start < 0 {
+		return ErrMalformedCEF
+	}
+	header, extension, ok := splitHeader(input[start+len("CEF:"):], len(cefHeaderFields))
+	if !ok {
+		return ErrMalformedCEF
+	}
+	for i, name := range cefHeaderFields {
+		extracted[name] = header[i]
+	}
+
+	keys := cefExtensionKey.FindAllStringSubmatchIndex(extension, -1)
+	for i, key := range keys {
+		// The value of a key extends to the next key, and can contain
+		// spaces.
+		end := len(extension)
+		if i+1 < len(keys) {
+			end = keys[i+1][0]
+		}
+		value := strings.TrimRight(extension[key[1]:end], " ")
+		extracted[extension[key[2]:key[3]]] = cefExtensionEscapes.Replace(value)
+	}
+	return nil
+}
+
+// splitHeader splits the n pipe-separated fields of a CEF or LEEF header from
+// the rest of the message. Pipes and backslashes are escaped with a
+// backslash in header fields.
+func splitHeader(input string, n int) (header []string, rest string, ok bool) {
+	header = make([]string, 0, n)
+	var field strings.Builder
+	for i := 0; i < len(input); i++ {
+		switch c := input[i]; {
+		case c == '\\' && i+1 < len(input) && (input[i+1] == '|' || input[i+1] == '\\'):
+			field.WriteByte(input[i+1])
+			i++
+		case c == '|':
+			header = append(header, field.String())
+			field.Reset()
+			if len(header) == n {
+				return header, input[i+1:], true
+			}
+		default:
+			field.WriteByte(c)
+		}
+	}
+	return nil, "", false
+}
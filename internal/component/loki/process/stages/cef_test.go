@@ -0,0 +1,127 @@
+package stages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/loki/pkg/push"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/util"
+)
+
+func TestParseCEF(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		input    string
+		expected map[string]interface{}
+		err      error
+	}{
+		"header and extension": {
+			`CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|10|src=10.0.0.1 dst=2.1.2.2 spt=1232`,
+			map[string]interface{}{
+				"cef_version":               "0",
+				"cef_device_vendor":         "Security",
+				"cef_device_product":        "threatmanager",
+				"cef_device_version":        "1.0",
+				"cef_device_event_class_id": "100",
+				"cef_name":                  "worm successfully stopped",
+				"cef_severity":              "10",
+				"src":                       "10.0.0.1",
+				"dst":                       "2.1.2.2",
+				"spt":                       "1232",
+			},
+			nil,
+		},
+		"syslog header and escaping": {
+			`<134>Feb 14 19:04:54 fw01 CEF:0|Vendor \| Inc|Product\\Name|2.0|sig|Login|High|` +
+				`msg=User logged in with a=b\=c cs1Label=Rule Name cs1=allow all  filePath=C:\\Temp\nnext`,
+			map[string]interface{}{
+				"cef_version":               "0",
+				"cef_device_vendor":         "Vendor | Inc",
+				"cef_device_product":        `Product\Name`,
+				"cef_device_version":        "2.0",
+				"cef_device_event_class_id": "sig",
+				"cef_name":                  "Login",
+				"cef_severity":              "High",
+				"msg":                       "User logged in with",
+				"a":                         "b=c",
+				"cs1Label":                  "Rule Name",
+				"cs1":                       "allow all",
+				"filePath":                  "C:\\Temp\nnext",
+			},
+			nil,
+		},
+		"empty extension": {
+			`CEF:1|Vendor|Product|1|2|Name|3|`,
+			map[string]interface{}{
+				"cef_version":               "1",
+				"cef_device_vendor":         "Vendor",
+				"cef_device_product":        "Product",
+				"cef_device_version":        "1",
+				"cef_device_event_class_id": "2",
+				"cef_name":                  "Name",
+				"cef_severity":              "3",
+			},
+			nil,
+		},
+		"not cef": {
+			`level=info msg=hello`,
+			map[string]interface{}{},
+			ErrMalformedCEF,
+		},
+		"incomplete header": {
+			`CEF:0|Vendor|Product|1.0`,
+			map[string]interface{}{},
+			ErrMalformedCEF,
+		},
+	}
+	for tName, tt := range tests {
+		t.Run(tName, func(t *testing.T) {
+			t.Parallel()
+			extracted := map[string]interface{}{}
+			err := parseCEF(tt.input, extracted)
+			require.Equal(t, tt.err, err)
+			require.Equal(t, tt.expected, extracted)
+		})
+	}
+}
+
+func TestCEFStage(t *testing.T) {
+	t.Parallel()
+
+	pl, err := NewPipeline(util.TestAlloyLogger(t), loadConfig(`
+stage.regex {
+	expression = "^(?P<host>\\S+) (?P<message>.*)$"
+}
+stage.cef {
+	source              = "message"
+	drop_malformed      = true
+	labels              = { vendor = "cef_device_vendor", severity = "cef_severity" }
+	structured_metadata = { src = "", user = "suser" }
+}`), nil, prometheus.NewRegistry(), featuregate.StabilityGenerallyAvailable)
+	require.NoError(t, err)
+
+	out := processEntries(pl,
+		newEntry(nil, model.LabelSet{"job": "syslog"}, `fw01 CEF:0|Acme|Firewall|1.0|42|Blocked|7|src=10.0.0.1 suser=alice`, time.Now()),
+		newEntry(nil, model.LabelSet{"job": "syslog"}, `fw01 not a cef message`, time.Now()),
+	)
+	require.Len(t, out, 1)
+	require.Equal(t, model.LabelSet{"job": "syslog", "vendor": "Acme", "severity": "7"}, out[0].Labels)
+	require.ElementsMatch(t, push.LabelsAdapter{{Name: "src", Value: "10.0.0.1"}, {Name: "user", Value: "alice"}}, out[0].StructuredMetadata)
+	require.Equal(t, "42", out[0].Extracted["cef_device_event_class_id"])
+}
+
+func TestCEFStage_InvalidConfig(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewPipeline(util.TestAlloyLogger(t), loadConfig(`
+stage.cef {
+	source = ""
+}`), nil, prometheus.NewRegistry(), featuregate.StabilityGenerallyAvailable)
+	require.ErrorIs(t, err, ErrEmptyFieldsStageSource)
+}
@@ -0,0 +1,225 @@
+package stages
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+)
+
+// Config Errors
+var (
+	ErrEmptyCSVStageConfig  = errors.New("empty csv stage configuration")
+	ErrEmptyCSVStageSource  = errors.New("empty source")
+	ErrCSVColumnsRequired   = errors.New("csv columns are required")
+	ErrCSVDuplicateColumn   = errors.New("csv column names must be unique")
+	ErrCSVInvalidDelimiter  = errors.New("csv delimiter must be a single character other than the quote character")
+	ErrCSVInvalidQuote      = errors.New("csv quote must be a single character or empty")
+	ErrMalformedCSV         = errors.New("malformed csv")
+	errCSVUnterminatedQuote = errors.New("unterminated quoted field")
+)
+
+// CSVConfig represents a CSV Stage configuration
+type CSVConfig struct {
+	Columns       []string `alloy:"columns,attr"`
+	Source        *string  `alloy:"source,attr,optional"`
+	Delimiter     string   `alloy:"delimiter,attr,optional"`
+	Quote         string   `alloy:"quote,attr,optional"`
+	DropMalformed bool     `alloy:"drop_malformed,attr,optional"`
+}
+
+// DefaultCSVConfig sets the default values of a csv stage.
+var DefaultCSVConfig = CSVConfig{
+	Delimiter: ",",
+	Quote:     `"`,
+}
+
+// SetToDefault implements syntax.Defaulter.
+func (c *CSVConfig) SetToDefault() {
+	*c = DefaultCSVConfig
+}
+
+// Validate implements syntax.Validator.
+func (c *CSVConfig) Validate() error {
+	return validateCSVConfig(c)
+}
+
+// validateCSVConfig validates a csv stage config.
+func validateCSVConfig(c *CSVConfig) error {
+	if c == nil {
+		return ErrEmptyCSVStageConfig
+	}
+
+	if len(c.Columns) == 0 {
+		return ErrCSVColumnsRequired
+	}
+
+	seen := make(map[string]struct{}, len(c.Columns))
+	for _, col := range c.Columns {
+		// Empty column names skip the column.
+		if col == "" {
+			continue
+		}
+		if _, ok := seen[col]; ok {
+			return fmt.Errorf("%w: %q", ErrCSVDuplicateColumn, col)
+		}
+		seen[col] = struct{}{}
+	}
+
+	if c.Source != nil && *c.Source == "" {
+		return ErrEmptyCSVStageSource
+	}
+
+	if utf8.RuneCountInString(c.Quote) > 1 {
+		return ErrCSVInvalidQuote
+	}
+	if utf8.RuneCountInString(c.Delimiter) != 1 || c.Delimiter == c.Quote {
+		return ErrCSVInvalidDelimiter
+	}
+	return nil
+}
+
+// csvStage sets extracted data from the columns of delimited lines
+type csvStage struct {
+	cfg    *CSVConfig
+	logger log.Logger
+}
+
+// newCSVStage creates a new csv pipeline stage from a config.
+func newCSVStage(logger log.Logger, cfg CSVConfig) (Stage, error) {
+	if err := validateCSVConfig(&cfg); err != nil {
+		return nil, err
+	}
+	return &csvStage{
+		cfg:    &cfg,
+		logger: log.With(logger, "component", "stage", "type", "csv"),
+	}, nil
+}
+
+func (c *csvStage) Run(in chan Entry) chan Entry {
+	out := make(chan Entry)
+	go func() {
+		defer close(out)
+		for e := range in {
+			err := c.processEntry(e.Extracted, &e.Line)
+			if err != nil && c.cfg.DropMalformed {
+				continue
+			}
+			out <- e
+		}
+	}()
+	return out
+}
+
+func (c *csvStage) processEntry(extracted map[string]interface{}, entry *string) error {
+	// If a source key is provided, the csv stage should process it
+	// from the extracted map, otherwise should fall back to the entry
+	input := entry
+
+	if c.cfg.Source != nil {
+		if _, ok := extracted[*c.cfg.Source]; !ok {
+			if Debug {
+				level.Debug(c.logger).Log("msg", "source does not exist in the set of extracted values", "source", *c.cfg.Source)
+			}
+			return nil
+		}
+
+		value, err := getString(extracted[*c.cfg.Source])
+		if err != nil {
+			if Debug {
+				level.Debug(c.logger).Log("msg", "failed to convert source value to string", "source", *c.cfg.Source, "err", err, "type", reflect.TypeOf(extracted[*c.cfg.Source]))
+			}
+			return nil
+		}
+
+		input = &value
+	}
+
+	if input == nil {
+		if Debug {
+			level.Debug(c.logger).Log("msg", "cannot parse a nil entry")
+		}
+		return nil
+	}
+
+	fields, err := splitCSV(*input, c.cfg.Delimiter, c.cfg.Quote)
+	if err != nil {
+		if Debug {
+			level.Debug(c.logger).Log("msg", "failed to parse log line", "err", err)
+		}
+		return ErrMalformedCSV
+	}
+
+	// Fields without a column are ignored, and columns without a field
+	// aren't set.
+	for i, col := range c.cfg.Columns {
+		if i >= len(fields) {
+			break
+		}
+		if col != "" {
+			extracted[col] = fields[i]
+		}
+	}
+	if Debug {
+		level.Debug(c.logger).Log("msg", "extracted data debug in csv stage", "extracted_data", fmt.Sprintf("%v", extracted))
+	}
+	return nil
+}
+
+// splitCSV splits a line into its fields. Fields starting with quote are
+// quoted: they may contain the delimiter, and quote when it's doubled.
+// An empty quote disables quoting.
+func splitCSV(line, delimiter, quote string) ([]string, error) {
+	var fields []string
+	for {
+		if quote == "" || !strings.HasPrefix(line, quote) {
+			i := strings.Index(line, delimiter)
+			if i < 0 {
+				return append(fields, line), nil
+			}
+			fields = append(fields, line[:i])
+			line = line[i+len(delimiter):]
+			continue
+		}
+
+		var field strings.Builder
+		line = line[len(quote):]
+		for {
+			i := strings.Index(line, quote)
+			if i < 0 {
+				return nil, errCSVUnterminatedQuote
+			}
+			field.WriteString(line[:i])
+			line = line[i+len(quote):]
+			if !strings.HasPrefix(line, quote) {
+				break
+			}
+			field.WriteString(quote)
+			line = line[len(quote):]
+		}
+		fields = append(fields, field.String())
+
+		switch {
+		case line == "":
+			return fields, nil
+		case strings.HasPrefix(line, delimiter):
+			line = line[len(delimiter):]
+		default:
+			return nil, fmt.Errorf("unexpected data after quoted field %d", len(fields))
+		}
+	}
+}
+
+// Name implements Stage
+func (c *csvStage) Name() string {
+	return StageTypeCSV
+}
+
+// Cleanup implements Stage.
+func (*csvStage) Cleanup() {
+	// no-op
+}
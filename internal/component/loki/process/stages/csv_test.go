@@ -0,0 +1,205 @@
+package stages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/util"
+	"github.com/grafana/alloy/syntax"
+)
+
+func TestPipeline_CSV(t *testing.T) {
+	t.Parallel()
+	logger := util.TestAlloyLogger(t)
+
+	tests := map[string]struct {
+		config          string
+		entry           string
+		expectedExtract map[string]interface{}
+	}{
+		"successfully run a pipeline with 1 csv stage without source": {
+			`stage.csv {
+				columns = ["time", "level", "", "message"]
+			}`,
+			`2024-01-01T00:00:00Z,error,ignored,"disk full, ""/var"" is read-only"`,
+			map[string]interface{}{
+				"time":    "2024-01-01T00:00:00Z",
+				"level":   "error",
+				"message": `disk full, "/var" is read-only`,
+			},
+		},
+		"successfully run a pipeline with a tsv stage with source": {
+			`stage.regex {
+				expression = "^(?P<host>\\S+) (?P<fields>.*)$"
+			}
+			stage.csv {
+				columns   = ["user", "action"]
+				delimiter = "\t"
+				quote     = "'"
+				source    = "fields"
+			}`,
+			"fw01 'alice'\t'login'",
+			map[string]interface{}{
+				"host":   "fw01",
+				"fields": "'alice'\t'login'",
+				"user":   "alice",
+				"action": "login",
+			},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+
+			pl, err := NewPipeline(logger, loadConfig(testData.config), nil, prometheus.DefaultRegisterer, featuregate.StabilityGenerallyAvailable)
+			require.NoError(t, err, "Expected pipeline creation to not result in error")
+			out := processEntries(pl, newEntry(nil, nil, testData.entry, time.Now()))[0]
+			assert.Equal(t, testData.expectedExtract, out.Extracted)
+		})
+	}
+}
+
+func TestCSVConfig_validate(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		config string
+		err    error
+	}{
+		"no columns": {
+			`columns = []`,
+			ErrCSVColumnsRequired,
+		},
+		"duplicate columns": {
+			`columns = ["a", "", "a"]`,
+			ErrCSVDuplicateColumn,
+		},
+		"empty source": {
+			`columns = ["a"]
+			source = ""`,
+			ErrEmptyCSVStageSource,
+		},
+		"empty delimiter": {
+			`columns = ["a"]
+			delimiter = ""`,
+			ErrCSVInvalidDelimiter,
+		},
+		"long delimiter": {
+			`columns = ["a"]
+			delimiter = ";;"`,
+			ErrCSVInvalidDelimiter,
+		},
+		"delimiter is the quote": {
+			`columns = ["a"]
+			delimiter = "'"
+			quote = "'"`,
+			ErrCSVInvalidDelimiter,
+		},
+		"long quote": {
+			`columns = ["a"]
+			quote = "''"`,
+			ErrCSVInvalidQuote,
+		},
+		"valid": {
+			`columns = ["a", "", "b"]
+			delimiter = "|"
+			quote = ""`,
+			nil,
+		},
+	}
+	for tName, tt := range tests {
+		t.Run(tName, func(t *testing.T) {
+			t.Parallel()
+			var cfg CSVConfig
+			err := syntax.Unmarshal([]byte(tt.config), &cfg)
+			if tt.err == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestSplitCSV(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		line      string
+		delimiter string
+		quote     string
+		expected  []string
+		err       string
+	}{
+		"empty line": {
+			line: "", delimiter: ",", quote: `"`,
+			expected: []string{""},
+		},
+		"empty fields": {
+			line: ",a,,", delimiter: ",", quote: `"`,
+			expected: []string{"", "a", "", ""},
+		},
+		"quoted fields": {
+			line: `"a,b","","c""d"""`, delimiter: ",", quote: `"`,
+			expected: []string{"a,b", "", `c"d"`},
+		},
+		"quote in unquoted field": {
+			line: `a"b,c`, delimiter: ",", quote: `"`,
+			expected: []string{`a"b`, "c"},
+		},
+		"quoting disabled": {
+			line: `"a,b"`, delimiter: ",", quote: "",
+			expected: []string{`"a`, `b"`},
+		},
+		"multi-byte delimiter": {
+			line: "a¦'b¦c'¦d", delimiter: "¦", quote: "'",
+			expected: []string{"a", "b¦c", "d"},
+		},
+		"unterminated quote": {
+			line: `a,"b,c`, delimiter: ",", quote: `"`,
+			err: "unterminated quoted field",
+		},
+		"data after quoted field": {
+			line: `"a"b,c`, delimiter: ",", quote: `"`,
+			err: "unexpected data after quoted field 1",
+		},
+	}
+	for tName, tt := range tests {
+		t.Run(tName, func(t *testing.T) {
+			t.Parallel()
+			fields, err := splitCSV(tt.line, tt.delimiter, tt.quote)
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, fields)
+		})
+	}
+}
+
+func TestCSVStage_Columns(t *testing.T) {
+	t.Parallel()
+
+	pl, err := NewPipeline(util.TestAlloyLogger(t), loadConfig(`
+stage.csv {
+	columns        = ["a", "b", "c"]
+	drop_malformed = true
+}`), nil, prometheus.DefaultRegisterer, featuregate.StabilityGenerallyAvailable)
+	require.NoError(t, err)
+
+	out := processEntries(pl,
+		newEntry(nil, nil, `1,2`, time.Now()),
+		newEntry(nil, nil, `1,2,3,4`, time.Now()),
+		newEntry(nil, nil, `1,"2`, time.Now()),
+	)
+	require.Len(t, out, 2)
+	require.Equal(t, map[string]interface{}{"a": "1", "b": "2"}, out[0].Extracted)
+	require.Equal(t, map[string]interface{}{"a": "1", "b": "2", "c": "3"}, out[1].Extracted)
+}
@@ -0,0 +1,297 @@
+package stages
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+)
+
+// Configuration errors.
+var (
+	ErrDedupStageInvalidMode   = errors.New("dedup stage mode must be either \"consecutive\" or \"window\"")
+	ErrDedupStageInvalidWindow = errors.New("dedup stage window must be greater than 0")
+	ErrDedupStageInvalidMax    = errors.New("dedup stage max_lines_per_stream must be greater than 0")
+	ErrDedupStageInvalidRegex  = errors.New("dedup stage ignore expression compilation error")
+)
+
+// Dedup stage modes.
+const (
+	DedupModeConsecutive = "consecutive"
+	DedupModeWindow      = "window"
+)
+
+// DedupConfig contains the configuration for a dedupStage.
+type DedupConfig struct {
+	Mode              string        `alloy:"mode,attr,optional"`
+	Window            time.Duration `alloy:"window,attr,optional"`
+	IgnoreExpressions []string      `alloy:"ignore_expressions,attr,optional"`
+	IgnoreFields      []string      `alloy:"ignore_fields,attr,optional"`
+	CountKey          string        `alloy:"count_key,attr,optional"`
+	DropReason        string        `alloy:"drop_counter_reason,attr,optional"`
+	MaxLinesPerStream int           `alloy:"max_lines_per_stream,attr,optional"`
+}
+
+// DefaultDedupConfig sets the default values of a dedup stage.
+var DefaultDedupConfig = DedupConfig{
+	Mode:              DedupModeConsecutive,
+	Window:            10 * time.Second,
+	CountKey:          "dedup_count",
+	DropReason:        "dedup_stage",
+	MaxLinesPerStream: 1000,
+}
+
+// SetToDefault implements syntax.Defaulter.
+func (args *DedupConfig) SetToDefault() {
+	*args = DefaultDedupConfig
+}
+
+// Validate implements syntax.Validator.
+func (args *DedupConfig) Validate() error {
+	if args.Mode != DedupModeConsecutive && args.Mode != DedupModeWindow {
+		return ErrDedupStageInvalidMode
+	}
+	if args.Window <= 0 {
+		return ErrDedupStageInvalidWindow
+	}
+	if args.MaxLinesPerStream <= 0 {
+		return ErrDedupStageInvalidMax
+	}
+	_, err := compileDedupExpressions(args.IgnoreExpressions)
+	return err
+}
+
+func compileDedupExpressions(exprs []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(exprs))
+	for _, expr := range exprs {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", ErrDedupStageInvalidRegex, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+// dedupStage drops the duplicates of log lines of a stream and sends a
+// summary of the dropped lines when the deduplication window closes.
+type dedupStage struct {
+	logger        log.Logger
+	cfg           DedupConfig
+	ignore        []*regexp.Regexp
+	dropCount     *prometheus.CounterVec
+	evictCount    *prometheus.CounterVec
+	flushInterval time.Duration
+}
+
+// dedupLine is a log line whose duplicates are being dropped.
+type dedupLine struct {
+	firstSeen time.Time // When the first line was sent on.
+	last      Entry     // The last dropped duplicate.
+	count     int       // The number of dropped duplicates.
+}
+
+// dedupStream holds the lines of a stream whose duplicates are being
+// dropped.
+type dedupStream struct {
+	lines map[string]*dedupLine
+	order []string // Keys of lines, oldest first.
+}
+
+func (st *dedupStream) add(key string, l *dedupLine) {
+	st.lines[key] = l
+	st.order = append(st.order, key)
+}
+
+// oldest returns the oldest line of the stream.
+func (st *dedupStream) oldest() *dedupLine {
+	return st.lines[st.order[0]]
+}
+
+// removeOldest removes the oldest line of the stream.
+func (st *dedupStream) removeOldest() {
+	delete(st.lines, st.order[0])
+	st.order = st.order[1:]
+}
+
+// newDedupStage creates a dedupStage from config.
+func newDedupStage(logger log.Logger, cfg DedupConfig, registerer prometheus.Registerer) (Stage, error) {
+	ignore, err := compileDedupExpressions(cfg.IgnoreExpressions)
+	if err != nil {
+		return nil, err
+	}
+	return &dedupStage{
+		logger:        log.With(logger, "component", "stage", "type", "dedup"),
+		cfg:           cfg,
+		ignore:        ignore,
+		dropCount:     getDropCountMetric(registerer),
+		evictCount:    getDedupEvictCountMetric(registerer),
+		flushInterval: min(cfg.Window, time.Second),
+	}, nil
+}
+
+func getDedupEvictCountMetric(registerer prometheus.Registerer) *prometheus.CounterVec {
+	return registerCounterVec(registerer, "loki_process", "dedup_evicted_lines_total",
+		"A count of the lines whose deduplication window was closed early because their stream had too many distinct lines",
+		[]string{"reason"})
+}
+
+func (s *dedupStage) Run(in chan Entry) chan Entry {
+	out := make(chan Entry)
+	go func() {
+		defer close(out)
+
+		counter := s.dropCount.WithLabelValues(s.cfg.DropReason)
+		evicted := s.evictCount.WithLabelValues(s.cfg.DropReason)
+		streams := make(map[model.Fingerprint]*dedupStream)
+		ticker := time.NewTicker(s.flushInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case e, ok := <-in:
+				if !ok {
+					for _, st := range streams {
+						for _, key := range st.order {
+							s.flush(out, st.lines[key])
+						}
+					}
+					return
+				}
+
+				fp := e.Labels.FastFingerprint()
+				key := s.key(e)
+				st := streams[fp]
+				if st == nil {
+					st = &dedupStream{lines: make(map[string]*dedupLine)}
+					streams[fp] = st
+				}
+				if l, ok := st.lines[key]; ok {
+					l.last = e
+					l.count++
+					counter.Inc()
+					continue
+				}
+
+				// In consecutive mode, a different line closes the window of
+				// the previous line.
+				if s.cfg.Mode == DedupModeConsecutive {
+					for len(st.order) > 0 {
+						l := st.oldest()
+						s.flush(out, l)
+						st.removeOldest()
+					}
+				}
+				// The window of the oldest lines is closed early if the
+				// stream has too many distinct lines.
+				for len(st.order) >= s.cfg.MaxLinesPerStream {
+					l := st.oldest()
+					s.flush(out, l)
+					st.removeOldest()
+					evicted.Inc()
+				}
+				st.add(key, &dedupLine{firstSeen: time.Now()})
+				out <- e
+
+			case now := <-ticker.C:
+				for fp, st := range streams {
+					// Lines are ordered by firstSeen, so the windows close in
+					// order.
+					for len(st.order) > 0 {
+						l := st.oldest()
+						if now.Sub(l.firstSeen) < s.cfg.Window {
+							break
+						}
+						s.flush(out, l)
+						st.removeOldest()
+					}
+					if len(st.order) == 0 {
+						delete(streams, fp)
+					}
+				}
+			}
+		}
+	}()
+	return out
+}
+
+// key returns the content of the line which is compared to find duplicates:
+// the line without the values of the ignored fields and the matches of the
+// ignored expressions.
+func (s *dedupStage) key(e Entry) string {
+	line := e.Line
+
+	var values []string
+	for _, field := range s.cfg.IgnoreFields {
+		v, ok := e.Extracted[field]
+		if !ok {
+			continue
+		}
+		value, err := getString(v)
+		if err != nil {
+			level.Debug(s.logger).Log("msg", "failed to convert extracted field to string", "field", field, "err", err)
+			continue
+		}
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+	if len(values) > 0 {
+		// The values are removed in a single pass, longest first, so that
+		// removing a value doesn't change the other values in the line.
+		slices.SortFunc(values, func(a, b string) int { return len(b) - len(a) })
+		oldnew := make([]string, 0, 2*len(values))
+		for _, v := range values {
+			oldnew = append(oldnew, v, "")
+		}
+		line = strings.NewReplacer(oldnew...).Replace(line)
+	}
+
+	for _, re := range s.ignore {
+		line = re.ReplaceAllString(line, "")
+	}
+	return line
+}
+
+// flush sends a summary of the duplicates of l which were dropped, if any.
+// The summary is the last duplicate with the number of duplicates appended
+// to the line and added to the structured metadata.
+func (s *dedupStage) flush(out chan Entry, l *dedupLine) {
+	if l.count == 0 {
+		return
+	}
+
+	summary := l.last
+	summary.Extracted = maps.Clone(l.last.Extracted)
+	summary.Line = fmt.Sprintf("%s (repeated %d times)", l.last.Line, l.count)
+	if s.cfg.CountKey != "" {
+		summary.StructuredMetadata = append(slices.Clone(l.last.StructuredMetadata), logproto.LabelAdapter{
+			Name:  s.cfg.CountKey,
+			Value: strconv.Itoa(l.count),
+		})
+	}
+	l.count = 0
+
+	out <- summary
+}
+
+// Name implements Stage.
+func (s *dedupStage) Name() string {
+	return StageTypeDedup
+}
+
+// Cleanup implements Stage.
+func (*dedupStage) Cleanup() {
+	// no-op
+}
@@ -0,0 +1,265 @@
+package stages
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/loki/pkg/push"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	util_log "github.com/grafana/loki/v3/pkg/util/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/syntax"
+)
+
+func newDedupPipeline(t *testing.T, cfg string) (*Pipeline, *prometheus.Registry) {
+	registry := prometheus.NewRegistry()
+	pl, err := NewPipeline(util_log.Logger, loadConfig(cfg), &plName, registry, featuregate.StabilityGenerallyAvailable)
+	require.NoError(t, err)
+	return pl, registry
+}
+
+func entryLines(entries []Entry) []string {
+	lines := make([]string, 0, len(entries))
+	for _, e := range entries {
+		lines = append(lines, e.Line)
+	}
+	return lines
+}
+
+func TestDedupStage(t *testing.T) {
+	app1 := model.LabelSet{"app": "app1"}
+	app2 := model.LabelSet{"app": "app2"}
+
+	tt := []struct {
+		name     string
+		config   string
+		entries  []Entry
+		expected []string
+	}{
+		{
+			name:   "consecutive duplicates",
+			config: `stage.dedup {}`,
+			entries: []Entry{
+				newEntry(nil, app1, "connection refused", time.Now()),
+				newEntry(nil, app1, "connection refused", time.Now()),
+				newEntry(nil, app1, "connection refused", time.Now()),
+				newEntry(nil, app1, "retrying", time.Now()),
+				newEntry(nil, app1, "connection refused", time.Now()),
+			},
+			expected: []string{
+				"connection refused",
+				"connection refused (repeated 2 times)",
+				"retrying",
+				"connection refused",
+			},
+		},
+		{
+			name:   "streams are deduplicated separately",
+			config: `stage.dedup {}`,
+			entries: []Entry{
+				newEntry(nil, app1, "connection refused", time.Now()),
+				newEntry(nil, app2, "connection refused", time.Now()),
+				newEntry(nil, app1, "connection refused", time.Now()),
+				newEntry(nil, app1, "retrying", time.Now()),
+			},
+			expected: []string{
+				"connection refused",
+				"connection refused",
+				"connection refused (repeated 1 times)",
+				"retrying",
+			},
+		},
+		{
+			name:   "window",
+			config: `stage.dedup { mode = "window" }`,
+			entries: []Entry{
+				newEntry(nil, app1, "connection refused", time.Now()),
+				newEntry(nil, app1, "retrying", time.Now()),
+				newEntry(nil, app1, "connection refused", time.Now()),
+				newEntry(nil, app1, "retrying", time.Now()),
+				newEntry(nil, app1, "connection refused", time.Now()),
+			},
+			expected: []string{
+				"connection refused",
+				"retrying",
+				"connection refused (repeated 2 times)",
+				"retrying (repeated 1 times)",
+			},
+		},
+		{
+			name: "ignored expressions",
+			config: `stage.dedup {
+				ignore_expressions = ["^ts=\\S+ ", "request_id=\\w+"]
+			}`,
+			entries: []Entry{
+				newEntry(nil, app1, "ts=2024-01-01T00:00:01Z msg=timeout request_id=a1", time.Now()),
+				newEntry(nil, app1, "ts=2024-01-01T00:00:02Z msg=timeout request_id=b2", time.Now()),
+				newEntry(nil, app1, "ts=2024-01-01T00:00:03Z msg=ok request_id=c3", time.Now()),
+			},
+			expected: []string{
+				"ts=2024-01-01T00:00:01Z msg=timeout request_id=a1",
+				"ts=2024-01-01T00:00:02Z msg=timeout request_id=b2 (repeated 1 times)",
+				"ts=2024-01-01T00:00:03Z msg=ok request_id=c3",
+			},
+		},
+		{
+			name: "ignored fields",
+			config: `stage.logfmt {
+				mapping = { "ts" = "", "request_id" = "" }
+			}
+			stage.dedup {
+				ignore_fields = ["ts", "request_id", "missing"]
+			}`,
+			entries: []Entry{
+				newEntry(nil, app1, "ts=1 msg=timeout request_id=a1", time.Now()),
+				newEntry(nil, app1, "ts=2 msg=timeout request_id=b2", time.Now()),
+				newEntry(nil, app1, "ts=3 msg=timeout request_id=c3", time.Now()),
+			},
+			expected: []string{
+				"ts=1 msg=timeout request_id=a1",
+				"ts=3 msg=timeout request_id=c3 (repeated 2 times)",
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			pl, _ := newDedupPipeline(t, tc.config)
+			out := processEntries(pl, tc.entries...)
+			actual := entryLines(out)
+			if strings.Contains(tc.config, "window") {
+				// Summaries are sent in no particular order when the input is closed.
+				slices.Sort(actual)
+				slices.Sort(tc.expected)
+			}
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestDedupStageSummary(t *testing.T) {
+	pl, registry := newDedupPipeline(t, `
+stage.dedup {
+	count_key           = "repeated"
+	drop_counter_reason = "crash_loop"
+}`)
+
+	lbls := model.LabelSet{"app": "app1"}
+	first := newEntry(nil, lbls, "panic: nil pointer", time.Unix(1, 0))
+	last := newEntry(map[string]interface{}{"level": "error"}, lbls, "panic: nil pointer", time.Unix(3, 0))
+	last.StructuredMetadata = []logproto.LabelAdapter{{Name: "pod", Value: "app1-abc"}}
+
+	out := processEntries(pl, first, newEntry(nil, lbls, "panic: nil pointer", time.Unix(2, 0)), last)
+	require.Len(t, out, 2)
+
+	summary := out[1]
+	require.Equal(t, "panic: nil pointer (repeated 2 times)", summary.Line)
+	require.Equal(t, time.Unix(3, 0), summary.Timestamp)
+	require.Equal(t, lbls, summary.Labels)
+	require.Equal(t, map[string]interface{}{"app": "app1", "level": "error"}, summary.Extracted)
+	require.Equal(t, push.LabelsAdapter{{Name: "pod", Value: "app1-abc"}, {Name: "repeated", Value: "2"}}, summary.StructuredMetadata)
+
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP loki_process_dropped_lines_total A count of all log lines dropped as a result of a pipeline stage
+# TYPE loki_process_dropped_lines_total counter
+loki_process_dropped_lines_total{reason="crash_loop"} 2
+`), "loki_process_dropped_lines_total"))
+}
+
+func TestDedupStageWindowCloses(t *testing.T) {
+	pl, _ := newDedupPipeline(t, `
+stage.dedup {
+	window = "50ms"
+}`)
+
+	in := make(chan Entry)
+	out := pl.Run(in)
+	defer close(in)
+
+	lbls := model.LabelSet{"app": "app1"}
+	in <- newEntry(nil, lbls, "connection refused", time.Now())
+	require.Equal(t, "connection refused", (<-out).Line)
+	in <- newEntry(nil, lbls, "connection refused", time.Now())
+	in <- newEntry(nil, lbls, "connection refused", time.Now())
+
+	// The summary is sent when the window closes, without new lines.
+	select {
+	case e := <-out:
+		require.Equal(t, "connection refused (repeated 2 times)", e.Line)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the summary wasn't sent when the window closed")
+	}
+
+	// The next duplicate starts a new window.
+	in <- newEntry(nil, lbls, "connection refused", time.Now())
+	require.Equal(t, "connection refused", (<-out).Line)
+}
+
+func TestDedupStageMaxLinesPerStream(t *testing.T) {
+	pl, registry := newDedupPipeline(t, `
+stage.dedup {
+	mode                 = "window"
+	window               = "1h"
+	max_lines_per_stream = 2
+}`)
+
+	lbls := model.LabelSet{"app": "app1"}
+	var entries []Entry
+	for i, line := range []string{"a", "a", "b", "c", "a"} {
+		entries = append(entries, newEntry(nil, lbls, line, time.Unix(int64(i), 0)))
+	}
+
+	// "c" closes the window of "a", the oldest line, and the last "a" closes
+	// the window of "b".
+	out := processEntries(pl, entries...)
+	require.Equal(t, []string{"a", "b", "a (repeated 1 times)", "c", "a"}, entryLines(out))
+
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP loki_process_dedup_evicted_lines_total A count of the lines whose deduplication window was closed early because their stream had too many distinct lines
+# TYPE loki_process_dedup_evicted_lines_total counter
+loki_process_dedup_evicted_lines_total{reason="dedup_stage"} 2
+`), "loki_process_dedup_evicted_lines_total"))
+}
+
+func TestDedupConfigValidate(t *testing.T) {
+	tt := []struct {
+		name     string
+		config   string
+		expected string
+	}{
+		{
+			name:     "invalid mode",
+			config:   `stage.dedup { mode = "always" }`,
+			expected: ErrDedupStageInvalidMode.Error(),
+		},
+		{
+			name:     "invalid window",
+			config:   `stage.dedup { window = "0s" }`,
+			expected: ErrDedupStageInvalidWindow.Error(),
+		},
+		{
+			name:     "invalid max lines per stream",
+			config:   `stage.dedup { max_lines_per_stream = 0 }`,
+			expected: ErrDedupStageInvalidMax.Error(),
+		},
+		{
+			name:     "invalid expression",
+			config:   `stage.dedup { ignore_expressions = ["("] }`,
+			expected: ErrDedupStageInvalidRegex.Error(),
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var config Configs
+			err := syntax.Unmarshal([]byte(tc.config), &config)
+			require.ErrorContains(t, err, tc.expected)
+		})
+	}
+}
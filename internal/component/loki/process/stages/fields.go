@@ -0,0 +1,147 @@
+package stages
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+)
+
+// Config Errors
+var (
+	ErrEmptyFieldsStageSource = errors.New("empty source")
+)
+
+// PromoteConfig configures the fields which a parsing stage promotes from
+// the extracted map to labels or structured metadata. The keys are the names
+// of the labels or structured metadata, and the values are the names of the
+// fields. An empty value uses the key as the name of the field.
+type PromoteConfig struct {
+	Labels             map[string]*string `alloy:"labels,attr,optional"`
+	StructuredMetadata map[string]*string `alloy:"structured_metadata,attr,optional"`
+}
+
+// fieldsParser parses input into fields which are set in extracted.
+type fieldsParser func(input string, extracted map[string]interface{}) error
+
+// fieldsStage parses the log lines or an extracted value into fields of the
+// extracted map, and promotes some fields to labels or structured metadata.
+type fieldsStage struct {
+	name               string
+	source             *string
+	dropMalformed      bool
+	parse              fieldsParser
+	labels             map[string]string
+	structuredMetadata map[string]string
+	logger             log.Logger
+}
+
+// newFieldsStage creates a fieldsStage named name from the common options of
+// the parsing stages.
+func newFieldsStage(logger log.Logger, name string, source *string, dropMalformed bool, promote PromoteConfig, parse fieldsParser) (*fieldsStage, error) {
+	if source != nil && *source == "" {
+		return nil, ErrEmptyFieldsStageSource
+	}
+
+	s := &fieldsStage{
+		name:          name,
+		source:        source,
+		dropMalformed: dropMalformed,
+		parse:         parse,
+		logger:        log.With(logger, "component", "stage", "type", name),
+	}
+
+	var err error
+	if promote.Labels != nil {
+		if s.labels, err = validateLabelsConfig(LabelsConfig{Values: promote.Labels}); err != nil {
+			return nil, err
+		}
+	}
+	if promote.StructuredMetadata != nil {
+		if s.structuredMetadata, err = validateLabelsConfig(LabelsConfig{Values: promote.StructuredMetadata}); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (s *fieldsStage) Run(in chan Entry) chan Entry {
+	out := make(chan Entry)
+	go func() {
+		defer close(out)
+		for e := range in {
+			err := s.processEntry(e.Extracted, e.Line)
+			if err != nil && s.dropMalformed {
+				continue
+			}
+			s.promote(&e)
+			out <- e
+		}
+	}()
+	return out
+}
+
+func (s *fieldsStage) processEntry(extracted map[string]interface{}, line string) error {
+	// If a source key is provided, the stage should process it from the
+	// extracted map, otherwise should fall back to the entry
+	input := line
+
+	if s.source != nil {
+		if _, ok := extracted[*s.source]; !ok {
+			if Debug {
+				level.Debug(s.logger).Log("msg", "source does not exist in the set of extracted values", "source", *s.source)
+			}
+			return nil
+		}
+
+		value, err := getString(extracted[*s.source])
+		if err != nil {
+			if Debug {
+				level.Debug(s.logger).Log("msg", "failed to convert source value to string", "source", *s.source, "err", err, "type", reflect.TypeOf(extracted[*s.source]))
+			}
+			return nil
+		}
+
+		input = value
+	}
+
+	if err := s.parse(input, extracted); err != nil {
+		if Debug {
+			level.Debug(s.logger).Log("msg", "failed to parse log line", "err", err)
+		}
+		return err
+	}
+	if Debug {
+		level.Debug(s.logger).Log("msg", fmt.Sprintf("extracted data debug in %s stage", s.name), "extracted_data", fmt.Sprintf("%v", extracted))
+	}
+	return nil
+}
+
+// promote sets the labels and structured metadata of e from the fields of
+// the extracted map.
+func (s *fieldsStage) promote(e *Entry) {
+	processLabelsConfigs(s.logger, e.Extracted, s.labels, func(labelName model.LabelName, labelValue model.LabelValue) {
+		if e.Labels == nil {
+			e.Labels = model.LabelSet{}
+		}
+		e.Labels[labelName] = labelValue
+	})
+	processLabelsConfigs(s.logger, e.Extracted, s.structuredMetadata, func(labelName model.LabelName, labelValue model.LabelValue) {
+		e.StructuredMetadata = append(e.StructuredMetadata, logproto.LabelAdapter{Name: string(labelName), Value: string(labelValue)})
+	})
+}
+
+// Name implements Stage
+func (s *fieldsStage) Name() string {
+	return s.name
+}
+
+// Cleanup implements Stage.
+func (*fieldsStage) Cleanup() {
+	// no-op
+}
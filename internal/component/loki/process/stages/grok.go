@@ -0,0 +1,185 @@
+package stages
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/elastic/go-grok"
+	"github.com/go-kit/log"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/prometheus/common/model"
+)
+
+// Config Errors.
+var (
+	ErrGrokPatternRequired   = errors.New("grok pattern is required")
+	ErrCouldNotCompileGrok   = errors.New("could not compile grok pattern")
+	ErrCouldNotLoadGrokFile  = errors.New("could not load grok pattern file")
+	ErrEmptyGrokStageSource  = errors.New("empty source")
+	ErrInvalidGrokDefinition = errors.New("invalid grok pattern definition")
+)
+
+// GrokConfig configures a processing stage which uses grok patterns to
+// extract values from log lines into the shared values map.
+type GrokConfig struct {
+	Pattern          string            `alloy:"pattern,attr"`
+	Patterns         map[string]string `alloy:"patterns,attr,optional"`
+	PatternFiles     []string          `alloy:"pattern_files,attr,optional"`
+	Source           *string           `alloy:"source,attr,optional"`
+	LabelsFromGroups bool              `alloy:"labels_from_groups,attr,optional"`
+}
+
+// validateGrokConfig validates the config and returns the compiled grok
+// pattern.
+func validateGrokConfig(c GrokConfig) (*grok.Grok, error) {
+	if c.Pattern == "" {
+		return nil, ErrGrokPatternRequired
+	}
+
+	if c.Source != nil && *c.Source == "" {
+		return nil, ErrEmptyGrokStageSource
+	}
+
+	// The inline patterns override the patterns of the files, which
+	// override the built-in patterns.
+	var custom []map[string]string
+	for _, path := range c.PatternFiles {
+		patterns, err := loadGrokPatternFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: %w", ErrCouldNotLoadGrokFile, path, err)
+		}
+		custom = append(custom, patterns)
+	}
+	custom = append(custom, c.Patterns)
+
+	g, err := grok.NewComplete(custom...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidGrokDefinition, err)
+	}
+	if err := g.Compile(c.Pattern, true); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCouldNotCompileGrok, err)
+	}
+	return g, nil
+}
+
+// loadGrokPatternFile reads grok pattern definitions from a file using the
+// Logstash format: one "NAME definition" per line, and lines starting with #
+// are comments.
+func loadGrokPatternFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	patterns := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.IndexAny(line, " \t")
+		if i < 0 {
+			return nil, fmt.Errorf("line %d: expected a pattern name followed by its definition", lineNum)
+		}
+		patterns[line[:i]] = strings.TrimSpace(line[i:])
+	}
+	return patterns, scanner.Err()
+}
+
+// grokStage sets extracted data using grok patterns
+type grokStage struct {
+	config *GrokConfig
+	grok   *grok.Grok
+	logger log.Logger
+}
+
+// newGrokStage creates a new grok pipeline stage from a config.
+func newGrokStage(logger log.Logger, config GrokConfig) (Stage, error) {
+	g, err := validateGrokConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return toStage(&grokStage{
+		config: &config,
+		grok:   g,
+		logger: log.With(logger, "component", "stage", "type", "grok"),
+	}), nil
+}
+
+// Process implements Stage
+func (g *grokStage) Process(labels model.LabelSet, extracted map[string]interface{}, t *time.Time, entry *string) {
+	// If a source key is provided, the grok stage should process it
+	// from the extracted map, otherwise should fall back to the entry
+	input := entry
+
+	if g.config.Source != nil {
+		if _, ok := extracted[*g.config.Source]; !ok {
+			if Debug {
+				level.Debug(g.logger).Log("msg", "source does not exist in the set of extracted values", "source", *g.config.Source)
+			}
+			return
+		}
+
+		value, err := getString(extracted[*g.config.Source])
+		if err != nil {
+			if Debug {
+				level.Debug(g.logger).Log("msg", "failed to convert source value to string", "source", *g.config.Source, "err", err, "type", reflect.TypeOf(extracted[*g.config.Source]))
+			}
+			return
+		}
+
+		input = &value
+	}
+
+	if input == nil {
+		if Debug {
+			level.Debug(g.logger).Log("msg", "cannot parse a nil entry")
+		}
+		return
+	}
+
+	captures, err := g.grok.ParseString(*input)
+	if err != nil || len(captures) == 0 {
+		if Debug {
+			level.Debug(g.logger).Log("msg", "grok pattern did not match", "input", *input, "pattern", g.config.Pattern)
+		}
+		return
+	}
+
+	for name, value := range captures {
+		extracted[name] = value
+		if !g.config.LabelsFromGroups {
+			continue
+		}
+
+		labelName := model.LabelName(name)
+		labelValue := model.LabelValue(value)
+		if !labelName.IsValid() || !labelValue.IsValid() {
+			if Debug {
+				level.Debug(g.logger).Log("msg", "invalid label from grok capture", "labelName", labelName, "labelValue", labelValue)
+			}
+			continue
+		}
+
+		// Label from capture will override existing label with same name
+		if oldLabelValue, ok := labels[labelName]; Debug && ok {
+			level.Debug(g.logger).Log("msg", "label from grok capture is overriding existing label", "label", labelName, "oldValue", oldLabelValue, "newValue", labelValue)
+		}
+		labels[labelName] = labelValue
+	}
+	if Debug {
+		level.Debug(g.logger).Log("msg", "extracted data debug in grok stage", "extracted data", fmt.Sprintf("%v", extracted))
+	}
+}
+
+// Name implements Stage
+func (g *grokStage) Name() string {
+	return StageTypeGrok
+}
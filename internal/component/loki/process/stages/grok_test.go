@@ -0,0 +1,164 @@
+package stages
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/util"
+)
+
+var testGrokLogLine = `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326`
+
+func TestPipeline_Grok(t *testing.T) {
+	t.Parallel()
+	logger := util.TestAlloyLogger(t)
+
+	patternFile := filepath.Join(t.TempDir(), "patterns")
+	require.NoError(t, os.WriteFile(patternFile, []byte(`
+# Custom patterns
+HTTP_METHOD	GET|POST|PUT|DELETE
+REQUEST    %{HTTP_METHOD:method} %{URIPATHPARAM:path}
+`), 0o644))
+
+	tests := map[string]struct {
+		config          string
+		entry           string
+		expectedExtract map[string]interface{}
+		expectedLabels  model.LabelSet
+	}{
+		"built-in pattern": {
+			`stage.grok {
+				pattern = "%{COMMONAPACHELOG}"
+			}`,
+			testGrokLogLine,
+			map[string]interface{}{
+				"source.address":            "127.0.0.1",
+				"user.name":                 "frank",
+				"timestamp":                 "10/Oct/2000:13:55:36 -0700",
+				"http.request.method":       "GET",
+				"url.original":              "/apache_pb.gif",
+				"http.version":              "1.0",
+				"http.response.status_code": "200",
+				"http.response.body.size":   "2326",
+			},
+			model.LabelSet{},
+		},
+		"custom patterns from files and inline": {
+			`stage.grok {
+				pattern       = "%{IP:client} .* \"%{REQUEST}.*\" %{STATUS:status:int}"
+				patterns      = { STATUS = "[1-5][0-9]{2}" }
+				pattern_files = ["` + filepath.ToSlash(patternFile) + `"]
+			}`,
+			testGrokLogLine,
+			map[string]interface{}{
+				"client": "127.0.0.1",
+				"method": "GET",
+				"path":   "/apache_pb.gif",
+				"status": "200",
+			},
+			model.LabelSet{},
+		},
+		"source and labels from groups": {
+			`stage.grok {
+				pattern = "%{IP:client} "
+			}
+			stage.grok {
+				pattern            = "^%{INT:first_octet}\\."
+				source             = "client"
+				labels_from_groups = true
+			}`,
+			testGrokLogLine,
+			map[string]interface{}{
+				"client":      "127.0.0.1",
+				"first_octet": "127",
+			},
+			model.LabelSet{"first_octet": "127"},
+		},
+		"no match": {
+			`stage.grok {
+				pattern = "^%{IP:client} %{WORD:method}"
+			}`,
+			"not an access log",
+			map[string]interface{}{},
+			model.LabelSet{},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+
+			pl, err := NewPipeline(logger, loadConfig(testData.config), nil, prometheus.DefaultRegisterer, featuregate.StabilityGenerallyAvailable)
+			require.NoError(t, err, "Expected pipeline creation to not result in error")
+			out := processEntries(pl, newEntry(nil, nil, testData.entry, time.Now()))[0]
+			assert.Equal(t, testData.expectedExtract, out.Extracted)
+			assert.Equal(t, testData.expectedLabels, out.Labels)
+		})
+	}
+}
+
+func TestGrokConfig_validate(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	invalidFile := filepath.Join(dir, "invalid")
+	require.NoError(t, os.WriteFile(invalidFile, []byte("VALID \\d+\nINVALID\n"), 0o644))
+
+	emptyString := ""
+	tests := map[string]struct {
+		config GrokConfig
+		err    error
+	}{
+		"missing pattern": {
+			GrokConfig{},
+			ErrGrokPatternRequired,
+		},
+		"empty source": {
+			GrokConfig{Pattern: "%{WORD:word}", Source: &emptyString},
+			ErrEmptyGrokStageSource,
+		},
+		"unknown pattern": {
+			GrokConfig{Pattern: "%{NOT_A_PATTERN:value}"},
+			ErrCouldNotCompileGrok,
+		},
+		"invalid regular expression": {
+			GrokConfig{Pattern: "%{BROKEN:value}", Patterns: map[string]string{"BROKEN": "(?<=a)b"}},
+			ErrCouldNotCompileGrok,
+		},
+		"invalid pattern name": {
+			GrokConfig{Pattern: "%{WORD:word}", Patterns: map[string]string{"A:B": "a"}},
+			ErrInvalidGrokDefinition,
+		},
+		"missing pattern file": {
+			GrokConfig{Pattern: "%{WORD:word}", PatternFiles: []string{filepath.Join(dir, "missing")}},
+			ErrCouldNotLoadGrokFile,
+		},
+		"invalid pattern file": {
+			GrokConfig{Pattern: "%{WORD:word}", PatternFiles: []string{invalidFile}},
+			ErrCouldNotLoadGrokFile,
+		},
+		"valid": {
+			GrokConfig{Pattern: "%{WORD:word} %{NUMBER:count:int}"},
+			nil,
+		},
+	}
+	for tName, tt := range tests {
+		t.Run(tName, func(t *testing.T) {
+			t.Parallel()
+			_, err := validateGrokConfig(tt.config)
+			if tt.err == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, tt.err)
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package stages
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/go-kit/log"
+)
+
+// Config Errors
+var (
+	ErrKVEmptySeparator = errors.New("kv field and value separators can't be empty")
+	ErrKVSameSeparators = errors.New("kv field and value separators must be different")
+	ErrMalformedKV      = errors.New("malformed key-value pairs")
+)
+
+// kvValueEscapes unescapes the quoted values of key-value pairs.
+var kvValueEscapes = strings.NewReplacer(`\\`, `\`, `\"`, `"`)
+
+// KVConfig configures a processing stage which parses key-value pairs, such
+// as the messages of network appliances, into the shared values map.
+type KVConfig struct {
+	Source         *string `alloy:"source,attr,optional"`
+	FieldSeparator string  `alloy:"field_separator,attr,optional"`
+	ValueSeparator string  `alloy:"value_separator,attr,optional"`
+	Prefix         string  `alloy:"prefix,attr,optional"`
+	DropMalformed  bool    `alloy:"drop_malformed,attr,optional"`
+
+	Promote PromoteConfig `alloy:",squash"`
+}
+
+// DefaultKVConfig sets the default values of a kv stage.
+var DefaultKVConfig = KVConfig{
+	FieldSeparator: " ",
+	ValueSeparator: "=",
+}
+
+// SetToDefault implements syntax.Defaulter.
+func (c *KVConfig) SetToDefault() {
+	*c = DefaultKVConfig
+}
+
+// Validate implements syntax.Validator.
+func (c *KVConfig) Validate() error {
+	if c.FieldSeparator == "" || c.ValueSeparator == "" {
+		return ErrKVEmptySeparator
+	}
+	if c.FieldSeparator == c.ValueSeparator {
+		return ErrKVSameSeparators
+	}
+	return nil
+}
+
+// newKVStage creates a new kv pipeline stage from a config.
+func newKVStage(logger log.Logger, config KVConfig) (Stage, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return newFieldsStage(logger, StageTypeKV, config.Source, config.DropMalformed, config.Promote, func(input string, extracted map[string]interface{}) error {
+		return parseKV(input, config, extracted)
+	})
+}
+
+// parseKV sets the key-value pairs of input. Values can be quoted with double
+// quotes to contain the field separator, and quotes and backslashes are
+// escaped with a backslash in quoted values. Fields without a value separator
+// are ignored.
+func parseKV(input string, config KVConfig, extracted map[string]interface{}) error {
+	for input != "" {
+		if strings.HasPrefix(input, config.FieldSeparator) {
+			input = input[len(config.FieldSeparator):]
+			continue
+		}
+
+		field := input
+		if i := strings.Index(input, config.FieldSeparator); i >= 0 {
+			field = input[:i]
+		}
+		key, value, ok := strings.Cut(field, config.ValueSeparator)
+		if !ok || key == "" {
+			input = input[len(field):]
+			continue
+		}
+		input = input[len(key)+len(config.ValueSeparator):]
+
+		if strings.HasPrefix(input, `"`) {
+			end := kvQuoteEnd(input)
+			if end < 0 {
+				return ErrMalformedKV
+			}
+			value = kvValueEscapes.Replace(input[1:end])
+			input = input[end+1:]
+		} else {
+			input = input[len(value):]
+		}
+		extracted[config.Prefix+key] = value
+	}
+	return nil
+}
+
+// kvQuoteEnd returns the index of the quote closing the quoted value at the
+// start of s, or -1 if the value isn't closed.
+func kvQuoteEnd(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
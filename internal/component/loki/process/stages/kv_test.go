@@ -0,0 +1,114 @@
+package stages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/loki/pkg/push"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/util"
+	"github.com/grafana/alloy/syntax"
+)
+
+func TestParseKV(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		input    string
+		config   KVConfig
+		expected map[string]interface{}
+		err      error
+	}{
+		"fortigate": {
+			`date=2024-01-18 time=11:07:53 devname="FGT 60F" action="deny" msg="say \"hi\" \\o/" srcport=443`,
+			DefaultKVConfig,
+			map[string]interface{}{
+				"date":    "2024-01-18",
+				"time":    "11:07:53",
+				"devname": "FGT 60F",
+				"action":  "deny",
+				"msg":     `say "hi" \o/`,
+				"srcport": "443",
+			},
+			nil,
+		},
+		"empty values, separators and fields without values": {
+			`  a= b=1  flag c==2 =3`,
+			DefaultKVConfig,
+			map[string]interface{}{
+				"a": "",
+				"b": "1",
+				"c": "=2",
+			},
+			nil,
+		},
+		"custom separators and prefix": {
+			`user:alice;action:"log;in";result:ok`,
+			KVConfig{FieldSeparator: ";", ValueSeparator: ":", Prefix: "kv_"},
+			map[string]interface{}{
+				"kv_user":   "alice",
+				"kv_action": "log;in",
+				"kv_result": "ok",
+			},
+			nil,
+		},
+		"unterminated quote": {
+			`a=1 b="2`,
+			DefaultKVConfig,
+			map[string]interface{}{
+				"a": "1",
+			},
+			ErrMalformedKV,
+		},
+	}
+	for tName, tt := range tests {
+		t.Run(tName, func(t *testing.T) {
+			t.Parallel()
+			extracted := map[string]interface{}{}
+			err := parseKV(tt.input, tt.config, extracted)
+			require.Equal(t, tt.err, err)
+			require.Equal(t, tt.expected, extracted)
+		})
+	}
+}
+
+func TestKVConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]error{
+		`field_separator = ""`: ErrKVEmptySeparator,
+		`value_separator = ""`: ErrKVEmptySeparator,
+		`field_separator = ":"
+		value_separator = ":"`: ErrKVSameSeparators,
+		`field_separator = "\t"`: nil,
+	}
+	for config, expected := range tests {
+		var cfg KVConfig
+		err := syntax.Unmarshal([]byte(config), &cfg)
+		require.Equal(t, expected, err, config)
+	}
+}
+
+func TestKVStage(t *testing.T) {
+	t.Parallel()
+
+	pl, err := NewPipeline(util.TestAlloyLogger(t), loadConfig(`
+stage.kv {
+	drop_malformed      = true
+	labels              = { action = "" }
+	structured_metadata = { user = "srcuser" }
+}`), nil, prometheus.NewRegistry(), featuregate.StabilityGenerallyAvailable)
+	require.NoError(t, err)
+
+	out := processEntries(pl,
+		newEntry(nil, model.LabelSet{"job": "syslog"}, `action=deny srcuser="alice"`, time.Now()),
+		newEntry(nil, model.LabelSet{"job": "syslog"}, `action="deny`, time.Now()),
+	)
+	require.Len(t, out, 1)
+	require.Equal(t, model.LabelSet{"job": "syslog", "action": "deny"}, out[0].Labels)
+	require.Equal(t, push.LabelsAdapter{{Name: "user", Value: "alice"}}, out[0].StructuredMetadata)
+}
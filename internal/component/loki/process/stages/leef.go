@@ -0,0 +1,123 @@
+package stages
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/log"
+)
+
+// Parsing errors.
+var (
+	ErrMalformedLEEF = errors.New("malformed leef")
+)
+
+// leefHeaderFields are the names of the extracted fields of the LEEF header,
+// in order.
+var leefHeaderFields = []string{
+	"leef_version",
+	"leef_vendor",
+	"leef_product",
+	"leef_product_version",
+	"leef_event_id",
+}
+
+// LEEFConfig configures a processing stage which parses messages using the
+// Log Event Extended Format (LEEF) into the shared values map.
+type LEEFConfig struct {
+	Source        *string `alloy:"source,attr,optional"`
+	DropMalformed bool    `alloy:"drop_malformed,attr,optional"`
+
+	Promote PromoteConfig `alloy:",squash"`
+}
+
+// newLEEFStage creates a new leef pipeline stage from a config.
+func newLEEFStage(logger log.Logger, config LEEFConfig) (Stage, error) {
+	return newFieldsStage(logger, StageTypeLEEF, config.Source, config.DropMalformed, config.Promote, parseLEEF)
+}
+
+// parseLEEF sets the header fields and the attributes of the LEEF message in
+// input. The message can be preceded by a syslog header.
+func parseLEEF(input string, extracted map[string]interface{}) error {
+	start := strings.Index(input, "LEEF:")
+	if start < 0 {
+		return ErrMalformedLEEF
+	}
+	input = input[start+len("LEEF:"):]
+
+	// LEEF 2.0 adds the delimiter of the attributes to the header.
+	n := len(leefHeaderFields)
+	if strings.HasPrefix(input, "2") {
+		n++
+	}
+	header, attributes, ok := splitHeader(input, n)
+	if !ok {
+		return ErrMalformedLEEF
+	}
+
+	delimiter := "\t"
+	if n > len(leefHeaderFields) && header[n-1] != "" {
+		var err error
+		if delimiter, err = parseLEEFDelimiter(header[n-1]); err != nil {
+			return fmt.Errorf("%w: %w", ErrMalformedLEEF, err)
+		}
+	}
+
+	for i, name := range leefHeaderFields {
+		extracted[name] = header[i]
+	}
+
+	// Backslashes escape the delimiter in values.
+	for _, attribute := range splitUnescaped(attributes, delimiter) {
+		key, value, ok := strings.Cut(attribute, "=")
+		if !ok || key == "" {
+			continue
+		}
+		extracted[strings.TrimSpace(key)] = value
+	}
+	return nil
+}
+
+// parseLEEFDelimiter parses the delimiter of a LEEF 2.0 header, which is
+// either a character or its hexadecimal code, such as x5E or 0x5E.
+func parseLEEFDelimiter(s string) (string, error) {
+	hex, isHex := strings.CutPrefix(strings.TrimPrefix(s, "0"), "x")
+	if !isHex || len(hex) == 0 {
+		if len([]rune(s)) != 1 {
+			return "", fmt.Errorf("invalid delimiter %q", s)
+		}
+		return s, nil
+	}
+	code, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return "", fmt.Errorf("invalid delimiter %q: %w", s, err)
+	}
+	return string(rune(code)), nil
+}
+
+// splitUnescaped splits s around the occurrences of sep which aren't
+// preceded by a backslash, and unescapes the escaped occurrences.
+func splitUnescaped(s, sep string) []string {
+	var (
+		parts []string
+		part  strings.Builder
+	)
+	for {
+		i := strings.Index(s, sep)
+		if i < 0 {
+			part.WriteString(s)
+			return append(parts, part.String())
+		}
+		if i > 0 && s[i-1] == '\\' {
+			part.WriteString(s[:i-1])
+			part.WriteString(sep)
+		} else {
+			part.WriteString(s[:i])
+			parts = append(parts, part.String())
+			part.Reset()
+		}
+		s = s[i+len(sep):]
+	}
+}
@@ -0,0 +1,131 @@
+package stages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/loki/pkg/push"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/util"
+)
+
+func TestParseLEEF(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		input    string
+		expected map[string]interface{}
+		err      string
+	}{
+		"leef 1.0": {
+			"LEEF:1.0|Microsoft|MSExchange|4.0 SP1|15345|src=192.0.2.0\tdst=172.50.123.1\tsev=5\tcat=anomaly\tmsg=a=b c",
+			map[string]interface{}{
+				"leef_version":         "1.0",
+				"leef_vendor":          "Microsoft",
+				"leef_product":         "MSExchange",
+				"leef_product_version": "4.0 SP1",
+				"leef_event_id":        "15345",
+				"src":                  "192.0.2.0",
+				"dst":                  "172.50.123.1",
+				"sev":                  "5",
+				"cat":                  "anomaly",
+				"msg":                  "a=b c",
+			},
+			"",
+		},
+		"leef 2.0 with a character delimiter and a syslog header": {
+			`<13>Jan 18 11:07:53 192.168.1.1 LEEF:2.0|Lancope|StealthWatch|1.0|41|^|src=10.0.1.8^dst=10.0.0.5^msg=a\^b^^invalid`,
+			map[string]interface{}{
+				"leef_version":         "2.0",
+				"leef_vendor":          "Lancope",
+				"leef_product":         "StealthWatch",
+				"leef_product_version": "1.0",
+				"leef_event_id":        "41",
+				"src":                  "10.0.1.8",
+				"dst":                  "10.0.0.5",
+				"msg":                  "a^b",
+			},
+			"",
+		},
+		"leef 2.0 with a hexadecimal delimiter": {
+			`LEEF:2.0|Vendor|Product|1.0|42|0x7C|src=10.0.1.8|dst=10.0.0.5`,
+			map[string]interface{}{
+				"leef_version":         "2.0",
+				"leef_vendor":          "Vendor",
+				"leef_product":         "Product",
+				"leef_product_version": "1.0",
+				"leef_event_id":        "42",
+				"src":                  "10.0.1.8",
+				"dst":                  "10.0.0.5",
+			},
+			"",
+		},
+		"leef 2.0 with the default delimiter": {
+			"LEEF:2.0|Vendor|Product|1.0|42||src=10.0.1.8\tdst=10.0.0.5",
+			map[string]interface{}{
+				"leef_version":         "2.0",
+				"leef_vendor":          "Vendor",
+				"leef_product":         "Product",
+				"leef_product_version": "1.0",
+				"leef_event_id":        "42",
+				"src":                  "10.0.1.8",
+				"dst":                  "10.0.0.5",
+			},
+			"",
+		},
+		"invalid delimiter": {
+			`LEEF:2.0|Vendor|Product|1.0|42|xZZ|src=10.0.1.8`,
+			map[string]interface{}{},
+			`malformed leef: invalid delimiter "xZZ"`,
+		},
+		"not leef": {
+			`CEF:0|Vendor|Product|1.0|42|Name|1|`,
+			map[string]interface{}{},
+			"malformed leef",
+		},
+		"incomplete header": {
+			`LEEF:1.0|Vendor|Product`,
+			map[string]interface{}{},
+			"malformed leef",
+		},
+	}
+	for tName, tt := range tests {
+		t.Run(tName, func(t *testing.T) {
+			t.Parallel()
+			extracted := map[string]interface{}{}
+			err := parseLEEF(tt.input, extracted)
+			if tt.err != "" {
+				require.ErrorIs(t, err, ErrMalformedLEEF)
+				require.ErrorContains(t, err, tt.err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.expected, extracted)
+		})
+	}
+}
+
+func TestLEEFStage(t *testing.T) {
+	t.Parallel()
+
+	pl, err := NewPipeline(util.TestAlloyLogger(t), loadConfig(`
+stage.leef {
+	labels              = { event_id = "leef_event_id" }
+	structured_metadata = { src = "" }
+}`), nil, prometheus.NewRegistry(), featuregate.StabilityGenerallyAvailable)
+	require.NoError(t, err)
+
+	out := processEntries(pl,
+		newEntry(nil, model.LabelSet{"job": "syslog"}, "LEEF:1.0|Vendor|Product|1.0|42|src=10.0.1.8\tdst=10.0.0.5", time.Now()),
+		newEntry(nil, model.LabelSet{"job": "syslog"}, "not a leef message", time.Now()),
+	)
+	require.Len(t, out, 2)
+	require.Equal(t, model.LabelSet{"job": "syslog", "event_id": "42"}, out[0].Labels)
+	require.Equal(t, push.LabelsAdapter{{Name: "src", Value: "10.0.1.8"}}, out[0].StructuredMetadata)
+	require.Equal(t, "10.0.0.5", out[0].Extracted["dst"])
+	require.Equal(t, model.LabelSet{"job": "syslog"}, out[1].Labels)
+}
@@ -17,16 +17,22 @@ import (
 // We define these as pointers types so we can use reflection to check that
 // exactly one is set.
 type StageConfig struct {
+	CEFConfig             *CEFConfig             `alloy:"cef,block,optional"`
 	CRIConfig             *CRIConfig             `alloy:"cri,block,optional"`
+	CSVConfig             *CSVConfig             `alloy:"csv,block,optional"`
 	DecolorizeConfig      *DecolorizeConfig      `alloy:"decolorize,block,optional"`
+	DedupConfig           *DedupConfig           `alloy:"dedup,block,optional"`
 	DockerConfig          *DockerConfig          `alloy:"docker,block,optional"`
 	DropConfig            *DropConfig            `alloy:"drop,block,optional"`
 	EventLogMessageConfig *EventLogMessageConfig `alloy:"eventlogmessage,block,optional"`
 	GeoIPConfig           *GeoIPConfig           `alloy:"geoip,block,optional"`
+	GrokConfig            *GrokConfig            `alloy:"grok,block,optional"`
 	JSONConfig            *JSONConfig            `alloy:"json,block,optional"`
+	KVConfig              *KVConfig              `alloy:"kv,block,optional"`
 	LabelAllowConfig      *LabelAllowConfig      `alloy:"label_keep,block,optional"`
 	LabelDropConfig       *LabelDropConfig       `alloy:"label_drop,block,optional"`
 	LabelsConfig          *LabelsConfig          `alloy:"labels,block,optional"`
+	LEEFConfig            *LEEFConfig            `alloy:"leef,block,optional"`
 	LimitConfig           *LimitConfig           `alloy:"limit,block,optional"`
 	LogfmtConfig          *LogfmtConfig          `alloy:"logfmt,block,optional"`
 	LuhnFilterConfig      *LuhnFilterConfig      `alloy:"luhn,block,optional"`
@@ -44,6 +50,7 @@ type StageConfig struct {
 	TenantConfig          *TenantConfig          `alloy:"tenant,block,optional"`
 	TimestampConfig       *TimestampConfig       `alloy:"timestamp,block,optional"`
 	WindowsEventConfig    *WindowsEventConfig    `alloy:"windowsevent,block,optional"`
+	XMLConfig             *XMLConfig             `alloy:"xml,block,optional"`
 }
 
 var rateLimiter *rate.Limiter
@@ -16,17 +16,23 @@ import (
 
 // TODO(@tpaschalis) Let's use this as the list of stages we need to port over.
 const (
+	StageTypeCEF        = "cef"
 	StageTypeCRI        = "cri"
+	StageTypeCSV        = "csv"
 	StageTypeDecolorize = "decolorize"
+	StageTypeDedup      = "dedup"
 	StageTypeDocker     = "docker"
 	StageTypeDrop       = "drop"
 	//TODO(thampiotr): Add support for eventlogmessage stage
 	StageTypeEventLogMessage    = "eventlogmessage"
 	StageTypeGeoIP              = "geoip"
+	StageTypeGrok               = "grok"
 	StageTypeJSON               = "json"
+	StageTypeKV                 = "kv"
 	StageTypeLabel              = "labels"
 	StageTypeLabelAllow         = "labelallow"
 	StageTypeLabelDrop          = "labeldrop"
+	StageTypeLEEF               = "leef"
 	StageTypeLimit              = "limit"
 	StageTypeLogfmt             = "logfmt"
 	StageTypeLuhn               = "luhn"
@@ -45,6 +51,7 @@ const (
 	StageTypeTenant             = "tenant"
 	StageTypeTimestamp          = "timestamp"
 	StageTypeWindowsEvent       = "windowsevent"
+	StageTypeXML                = "xml"
 )
 
 // Add stages that are not GA. Stages that are not specified here are considered GA.
@@ -153,6 +160,31 @@ func New(logger log.Logger, jobName *string, cfg StageConfig, registerer prometh
 		if err != nil {
 			return nil, err
 		}
+	case cfg.XMLConfig != nil:
+		s, err = newXMLStage(logger, *cfg.XMLConfig)
+		if err != nil {
+			return nil, err
+		}
+	case cfg.CSVConfig != nil:
+		s, err = newCSVStage(logger, *cfg.CSVConfig)
+		if err != nil {
+			return nil, err
+		}
+	case cfg.CEFConfig != nil:
+		s, err = newCEFStage(logger, *cfg.CEFConfig)
+		if err != nil {
+			return nil, err
+		}
+	case cfg.LEEFConfig != nil:
+		s, err = newLEEFStage(logger, *cfg.LEEFConfig)
+		if err != nil {
+			return nil, err
+		}
+	case cfg.KVConfig != nil:
+		s, err = newKVStage(logger, *cfg.KVConfig)
+		if err != nil {
+			return nil, err
+		}
 	case cfg.LuhnFilterConfig != nil:
 		s, err = newLuhnFilterStage(*cfg.LuhnFilterConfig)
 		if err != nil {
@@ -178,6 +210,11 @@ func New(logger log.Logger, jobName *string, cfg StageConfig, registerer prometh
 		if err != nil {
 			return nil, err
 		}
+	case cfg.GrokConfig != nil:
+		s, err = newGrokStage(logger, *cfg.GrokConfig)
+		if err != nil {
+			return nil, err
+		}
 	case cfg.TimestampConfig != nil:
 		s, err = newTimestampStage(logger, *cfg.TimestampConfig)
 		if err != nil {
@@ -218,6 +255,11 @@ func New(logger log.Logger, jobName *string, cfg StageConfig, registerer prometh
 		if err != nil {
 			return nil, err
 		}
+	case cfg.DedupConfig != nil:
+		s, err = newDedupStage(logger, *cfg.DedupConfig, registerer)
+		if err != nil {
+			return nil, err
+		}
 	case cfg.MultilineConfig != nil:
 		s, err = newMultilineStage(logger, *cfg.MultilineConfig)
 		if err != nil {
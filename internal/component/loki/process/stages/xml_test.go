@@ -0,0 +1,202 @@
+package stages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/util"
+)
+
+var testXMLLogLine = `<?xml version="1.0"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope">
+	<soap:Body>
+		<order id="42" status="failed">
+			<customer>marco</customer>
+			<item>book</item>
+			<item>pen</item>
+			<total>12.5</total>
+			<extra>&lt;user&gt;alice&lt;/user&gt;</extra>
+		</order>
+	</soap:Body>
+</soap:Envelope>`
+
+func TestPipeline_XML(t *testing.T) {
+	t.Parallel()
+	logger := util.TestAlloyLogger(t)
+
+	tests := map[string]struct {
+		config          string
+		entry           string
+		expectedExtract map[string]interface{}
+	}{
+		"successfully run a pipeline with 1 xml stage without source": {
+			`stage.xml {
+				expressions = {
+					id       = "//order/@id",
+					customer = "",
+					items    = "//item",
+					total    = "sum(//total)",
+					failed   = "//order/@status = 'failed'",
+					body     = "name(/soap:Envelope/soap:Body)",
+					unknown  = "",
+				}
+				namespaces = { soap = "http://www.w3.org/2003/05/soap-envelope" }
+			}`,
+			testXMLLogLine,
+			map[string]interface{}{
+				"id":       "42",
+				"customer": "marco",
+				"items":    `["book","pen"]`,
+				"total":    12.5,
+				"failed":   true,
+				"body":     "soap:Body",
+			},
+		},
+		"successfully run a pipeline with 2 xml stages with source": {
+			`stage.xml {
+				expressions = { extra = "" }
+			}
+			stage.xml {
+				expressions = { user = "/user" }
+				source      = "extra"
+			}`,
+			testXMLLogLine,
+			map[string]interface{}{
+				"extra": "<user>alice</user>",
+				"user":  "alice",
+			},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+
+			pl, err := NewPipeline(logger, loadConfig(testData.config), nil, prometheus.DefaultRegisterer, featuregate.StabilityGenerallyAvailable)
+			require.NoError(t, err, "Expected pipeline creation to not result in error")
+			out := processEntries(pl, newEntry(nil, nil, testData.entry, time.Now()))[0]
+			assert.Equal(t, testData.expectedExtract, out.Extracted)
+		})
+	}
+}
+
+func TestXMLConfig_validate(t *testing.T) {
+	t.Parallel()
+
+	emptyString := ""
+	tests := map[string]struct {
+		config *XMLConfig
+		err    error
+	}{
+		"empty config": {
+			nil,
+			ErrEmptyXMLStageConfig,
+		},
+		"no expressions": {
+			&XMLConfig{},
+			ErrXPathRequired,
+		},
+		"empty source": {
+			&XMLConfig{Expressions: map[string]string{"id": ""}, Source: &emptyString},
+			ErrEmptyXMLStageSource,
+		},
+		"invalid expression": {
+			&XMLConfig{Expressions: map[string]string{"id": "//order[@id"}},
+			ErrCouldNotCompileXPath,
+		},
+		"unknown namespace prefix": {
+			&XMLConfig{Expressions: map[string]string{"body": "//soap:Body"}, Namespaces: map[string]string{"s": "urn:s"}},
+			ErrCouldNotCompileXPath,
+		},
+		"valid": {
+			&XMLConfig{Expressions: map[string]string{"id": "//order/@id", "customer": ""}},
+			nil,
+		},
+	}
+	for tName, tt := range tests {
+		t.Run(tName, func(t *testing.T) {
+			t.Parallel()
+			_, err := validateXMLConfig(tt.config)
+			if tt.err == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestXMLParser_Parse(t *testing.T) {
+	t.Parallel()
+
+	source := "body"
+	tests := map[string]struct {
+		config          XMLConfig
+		extracted       map[string]interface{}
+		entry           string
+		expectedExtract map[string]interface{}
+		expectedErr     error
+	}{
+		"malformed xml": {
+			XMLConfig{Expressions: map[string]string{"id": ""}},
+			map[string]interface{}{},
+			`<order><id>1</order>`,
+			map[string]interface{}{},
+			ErrMalformedXML,
+		},
+		"not xml": {
+			XMLConfig{Expressions: map[string]string{"id": ""}},
+			map[string]interface{}{},
+			`level=info msg=hello`,
+			map[string]interface{}{},
+			ErrMalformedXML,
+		},
+		"missing source": {
+			XMLConfig{Expressions: map[string]string{"id": ""}, Source: &source},
+			map[string]interface{}{"level": "info"},
+			`<id>1</id>`,
+			map[string]interface{}{"level": "info"},
+			nil,
+		},
+		"attribute": {
+			XMLConfig{Expressions: map[string]string{"level": "/event/@level"}},
+			map[string]interface{}{},
+			`<event level="warn"><msg>disk full</msg></event>`,
+			map[string]interface{}{"level": "warn"},
+			nil,
+		},
+	}
+	for tName, tt := range tests {
+		t.Run(tName, func(t *testing.T) {
+			t.Parallel()
+			s, err := newXMLStage(util.TestAlloyLogger(t), tt.config)
+			require.NoError(t, err)
+			err = s.(*xmlStage).processEntry(tt.extracted, &tt.entry)
+			require.Equal(t, tt.expectedErr, err)
+			require.Equal(t, tt.expectedExtract, tt.extracted)
+		})
+	}
+}
+
+func TestXMLStage_DropMalformed(t *testing.T) {
+	t.Parallel()
+
+	pl, err := NewPipeline(util.TestAlloyLogger(t), loadConfig(`
+stage.xml {
+	expressions    = { id = "" }
+	drop_malformed = true
+}`), nil, prometheus.DefaultRegisterer, featuregate.StabilityGenerallyAvailable)
+	require.NoError(t, err)
+
+	out := processEntries(pl,
+		newEntry(nil, nil, `<id>1</id>`, time.Now()),
+		newEntry(nil, nil, `<id>2`, time.Now()),
+	)
+	require.Len(t, out, 1)
+	require.Equal(t, "1", out[0].Extracted["id"])
+}
@@ -0,0 +1,308 @@
+package route
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/grafana/loki/v3/clients/pkg/logentry/logql"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/component/common/loki"
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/service/livedebugging"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:      "loki.route",
+		Stability: featuregate.StabilityExperimental,
+		Args:      Arguments{},
+		Exports:   Exports{},
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Routing modes.
+const (
+	ModeFirstMatch = "first_match"
+	ModeAllMatch   = "all_match"
+)
+
+// defaultRouteName is the name of the route of the entries which match no
+// other route.
+const defaultRouteName = "default"
+
+// Arguments holds values which are used to configure the loki.route
+// component.
+type Arguments struct {
+	// The routes to evaluate for each log entry, in order.
+	Routes []RouteConfig `alloy:"route,block,optional"`
+
+	// Where the log entries which match no route should be forwarded to.
+	DefaultForwardTo []loki.LogsReceiver `alloy:"default_forward_to,attr,optional"`
+
+	// Whether log entries are forwarded by the first matching route only, or
+	// by every matching route.
+	Mode string `alloy:"mode,attr,optional"`
+}
+
+// RouteConfig configures a single route of the loki.route component.
+type RouteConfig struct {
+	// The name of the route in metrics and live debugging. Defaults to the
+	// position of the route.
+	Name string `alloy:"name,attr,optional"`
+
+	// The LogQL stream selector, with optional line filters, which log
+	// entries must match to take the route.
+	Selector string `alloy:"selector,attr"`
+
+	// Where the matching log entries should be forwarded to.
+	ForwardTo []loki.LogsReceiver `alloy:"forward_to,attr"`
+}
+
+// DefaultArguments provides the default arguments for the loki.route
+// component.
+var DefaultArguments = Arguments{
+	Mode: ModeFirstMatch,
+}
+
+// SetToDefault implements syntax.Defaulter.
+func (a *Arguments) SetToDefault() {
+	*a = DefaultArguments
+}
+
+// Validate implements syntax.Validator.
+func (a *Arguments) Validate() error {
+	_, err := compileRoutes(a.Routes)
+	if err != nil {
+		return err
+	}
+	switch a.Mode {
+	case ModeFirstMatch, ModeAllMatch:
+	default:
+		return fmt.Errorf("invalid mode %q, must be one of %q or %q", a.Mode, ModeFirstMatch, ModeAllMatch)
+	}
+	return nil
+}
+
+// Exports holds values which are exported by the loki.route component.
+type Exports struct {
+	Receiver loki.LogsReceiver `alloy:"receiver,attr"`
+}
+
+// route is a compiled RouteConfig.
+type route struct {
+	name      string
+	matchers  []*labels.Matcher
+	filter    logql.Filter
+	forwardTo []loki.LogsReceiver
+}
+
+// matches returns whether the labels and the line of e match the route.
+func (r *route) matches(e loki.Entry) bool {
+	for _, m := range r.matchers {
+		if !m.Matches(string(e.Labels[model.LabelName(m.Name)])) {
+			return false
+		}
+	}
+	return r.filter == nil || r.filter([]byte(e.Line))
+}
+
+// compileRoutes parses the selectors of configs and names the routes.
+func compileRoutes(configs []RouteConfig) ([]*route, error) {
+	routes := make([]*route, 0, len(configs))
+	names := make(map[string]struct{}, len(configs))
+	for i, cfg := range configs {
+		name := cfg.Name
+		if name == "" {
+			name = strconv.Itoa(i)
+		}
+		if name == defaultRouteName {
+			return nil, fmt.Errorf("route name %q is reserved for the default route", name)
+		}
+		if _, ok := names[name]; ok {
+			return nil, fmt.Errorf("duplicate route name %q", name)
+		}
+		names[name] = struct{}{}
+
+		if cfg.Selector == "" {
+			return nil, fmt.Errorf("route %q: selector is required", name)
+		}
+		selector, err := logql.ParseExpr(cfg.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("route %q: invalid selector: %w", name, err)
+		}
+		filter, err := selector.Filter()
+		if err != nil {
+			return nil, fmt.Errorf("route %q: invalid line filter: %w", name, err)
+		}
+
+		routes = append(routes, &route{
+			name:      name,
+			matchers:  selector.Matchers(),
+			filter:    filter,
+			forwardTo: cfg.ForwardTo,
+		})
+	}
+	return routes, nil
+}
+
+// Component implements the loki.route component.
+type Component struct {
+	opts    component.Options
+	metrics *metrics
+
+	mut              sync.RWMutex
+	routes           []*route
+	defaultForwardTo []loki.LogsReceiver
+	allMatch         bool
+	receiver         loki.LogsReceiver
+
+	debugDataPublisher livedebugging.DebugDataPublisher
+}
+
+var (
+	_ component.Component     = (*Component)(nil)
+	_ component.LiveDebugging = (*Component)(nil)
+)
+
+// New creates a new loki.route component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	debugDataPublisher, err := o.GetServiceData(livedebugging.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Component{
+		opts:               o,
+		metrics:            newMetrics(o.Registerer),
+		debugDataPublisher: debugDataPublisher.(livedebugging.DebugDataPublisher),
+	}
+
+	// Create and immediately export the receiver which remains the same for
+	// the component's lifetime.
+	c.receiver = loki.NewLogsReceiver()
+	o.OnStateChange(Exports{Receiver: c.receiver})
+
+	// Call to Update() to set the routes once at the start.
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	componentID := livedebugging.ComponentID(c.opts.ID)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case entry := <-c.receiver.Chan():
+			c.metrics.entriesProcessed.Inc()
+			routes := c.route(entry)
+
+			count := uint64(len(routes))
+			c.debugDataPublisher.PublishIfActive(livedebugging.NewData(
+				componentID,
+				livedebugging.LokiLog,
+				count,
+				func() string {
+					return fmt.Sprintf("entry: %s, labels: %s => %s", entry.Line, entry.Labels.String(), routesString(routes))
+				},
+				livedebugging.WithLabelSet(entry.Labels),
+			))
+
+			if len(routes) == 0 {
+				level.Debug(c.opts.Logger).Log("msg", "dropping entry which matched no route", "labels", entry.Labels.String())
+				c.metrics.entriesDropped.Inc()
+				continue
+			}
+
+			for _, r := range routes {
+				c.metrics.entriesRouted.WithLabelValues(r.name).Inc()
+				for _, f := range r.forwardTo {
+					select {
+					case <-ctx.Done():
+						return nil
+					case f.Chan() <- entry:
+					}
+				}
+			}
+		}
+	}
+}
+
+// route returns the routes which forward e. Entries which match no route
+// take the default route, if it has any receivers.
+func (c *Component) route(e loki.Entry) []*route {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+
+	var matched []*route
+	for _, r := range c.routes {
+		if !r.matches(e) {
+			continue
+		}
+		matched = append(matched, r)
+		if !c.allMatch {
+			break
+		}
+	}
+	if len(matched) == 0 && len(c.defaultForwardTo) > 0 {
+		matched = append(matched, &route{name: defaultRouteName, forwardTo: c.defaultForwardTo})
+	}
+	return matched
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+	routes, err := compileRoutes(newArgs.Routes)
+	if err != nil {
+		return err
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	// Remove the counters of the routes which no longer exist.
+	names := make(map[string]struct{}, len(routes))
+	for _, r := range routes {
+		names[r.name] = struct{}{}
+	}
+	for _, r := range c.routes {
+		if _, ok := names[r.name]; !ok {
+			c.metrics.entriesRouted.DeleteLabelValues(r.name)
+		}
+	}
+
+	c.routes = routes
+	c.defaultForwardTo = newArgs.DefaultForwardTo
+	c.allMatch = newArgs.Mode == ModeAllMatch
+
+	return nil
+}
+
+// routesString returns the names of routes for live debugging.
+func routesString(routes []*route) string {
+	if len(routes) == 0 {
+		return "dropped"
+	}
+	names := make([]string, 0, len(routes))
+	for _, r := range routes {
+		names = append(names, r.name)
+	}
+	return "routes: " + strings.Join(names, ", ")
+}
+
+func (c *Component) LiveDebugging() {}
@@ -0,0 +1,220 @@
+package route
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/component/common/loki"
+	"github.com/grafana/alloy/internal/service/livedebugging"
+	"github.com/grafana/alloy/internal/util"
+	"github.com/grafana/alloy/syntax"
+)
+
+func TestRouting(t *testing.T) {
+	api, errs, other := loki.NewLogsReceiver(), loki.NewLogsReceiver(), loki.NewLogsReceiver()
+
+	tests := map[string]struct {
+		mode     string
+		entry    loki.Entry
+		expected []loki.LogsReceiver
+		routes   map[string]float64
+		dropped  float64
+	}{
+		"first match": {
+			mode:     ModeFirstMatch,
+			entry:    newEntry(model.LabelSet{"app": "api"}, "level=error msg=timeout"),
+			expected: []loki.LogsReceiver{api},
+			routes:   map[string]float64{"api": 1},
+		},
+		"all match": {
+			mode:     ModeAllMatch,
+			entry:    newEntry(model.LabelSet{"app": "api"}, "level=error msg=timeout"),
+			expected: []loki.LogsReceiver{api, errs},
+			routes:   map[string]float64{"api": 1, "1": 1},
+		},
+		"line filter": {
+			mode:     ModeFirstMatch,
+			entry:    newEntry(model.LabelSet{"app": "web"}, "level=error msg=timeout"),
+			expected: []loki.LogsReceiver{errs},
+			routes:   map[string]float64{"1": 1},
+		},
+		"default route": {
+			mode:     ModeAllMatch,
+			entry:    newEntry(model.LabelSet{"app": "web"}, "level=info msg=ok"),
+			expected: []loki.LogsReceiver{other},
+			routes:   map[string]float64{"default": 1},
+		},
+	}
+	for tName, tt := range tests {
+		t.Run(tName, func(t *testing.T) {
+			reg := prometheus.NewRegistry()
+			c, err := New(newOptions(t, reg), Arguments{
+				Routes: []RouteConfig{
+					{Name: "api", Selector: `{app="api"}`, ForwardTo: []loki.LogsReceiver{api}},
+					{Selector: `{app=~".+"} |= "level=error"`, ForwardTo: []loki.LogsReceiver{errs}},
+				},
+				DefaultForwardTo: []loki.LogsReceiver{other},
+				Mode:             tt.mode,
+			})
+			require.NoError(t, err)
+			go c.Run(t.Context())
+
+			c.receiver.Chan() <- tt.entry
+			for _, r := range tt.expected {
+				select {
+				case e := <-r.Chan():
+					require.Equal(t, tt.entry, e)
+				case <-time.After(5 * time.Second):
+					require.FailNow(t, "failed waiting for log line")
+				}
+			}
+			for _, r := range []loki.LogsReceiver{api, errs, other} {
+				select {
+				case e := <-r.Chan():
+					require.FailNow(t, "unexpected log line", e.Line)
+				case <-time.After(100 * time.Millisecond):
+				}
+			}
+
+			require.Equal(t, float64(1), testutil.ToFloat64(c.metrics.entriesProcessed))
+			require.Equal(t, len(tt.routes), testutil.CollectAndCount(c.metrics.entriesRouted))
+			for name, v := range tt.routes {
+				require.Equal(t, v, testutil.ToFloat64(c.metrics.entriesRouted.WithLabelValues(name)))
+			}
+		})
+	}
+}
+
+func TestDropWithoutDefaultRoute(t *testing.T) {
+	api := loki.NewLogsReceiver()
+
+	c, err := New(newOptions(t, prometheus.NewRegistry()), Arguments{
+		Routes: []RouteConfig{{Selector: `{app="api"}`, ForwardTo: []loki.LogsReceiver{api}}},
+		Mode:   ModeFirstMatch,
+	})
+	require.NoError(t, err)
+	go c.Run(t.Context())
+
+	c.receiver.Chan() <- newEntry(model.LabelSet{"app": "web"}, "hello")
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(c.metrics.entriesDropped) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Zero(t, testutil.CollectAndCount(c.metrics.entriesRouted))
+}
+
+func TestUpdateRemovesStaleRoutes(t *testing.T) {
+	api := loki.NewLogsReceiver()
+
+	c, err := New(newOptions(t, prometheus.NewRegistry()), Arguments{
+		Routes: []RouteConfig{{Name: "api", Selector: `{app="api"}`, ForwardTo: []loki.LogsReceiver{api}}},
+		Mode:   ModeFirstMatch,
+	})
+	require.NoError(t, err)
+	c.metrics.entriesRouted.WithLabelValues("api").Inc()
+
+	require.NoError(t, c.Update(Arguments{
+		Routes: []RouteConfig{{Name: "web", Selector: `{app="web"}`, ForwardTo: []loki.LogsReceiver{api}}},
+		Mode:   ModeFirstMatch,
+	}))
+	require.Zero(t, testutil.CollectAndCount(c.metrics.entriesRouted))
+}
+
+func TestArguments(t *testing.T) {
+	tests := map[string]struct {
+		config string
+		err    string
+	}{
+		"valid": {
+			config: `
+			route {
+				name       = "api"
+				selector   = "{app=\"api\"} |= \"error\""
+				forward_to = []
+			}
+			mode = "all_match"`,
+		},
+		"invalid mode": {
+			config: `mode = "any"`,
+			err:    `invalid mode "any"`,
+		},
+		"invalid selector": {
+			config: `
+			route {
+				selector   = "app=api"
+				forward_to = []
+			}`,
+			err: `route "0": invalid selector`,
+		},
+		"duplicate names": {
+			config: `
+			route {
+				name       = "api"
+				selector   = "{app=\"api\"}"
+				forward_to = []
+			}
+			route {
+				name       = "api"
+				selector   = "{app=\"web\"}"
+				forward_to = []
+			}`,
+			err: `duplicate route name "api"`,
+		},
+		"reserved name": {
+			config: `
+			route {
+				name       = "default"
+				selector   = "{app=\"api\"}"
+				forward_to = []
+			}`,
+			err: `route name "default" is reserved for the default route`,
+		},
+	}
+	for tName, tt := range tests {
+		t.Run(tName, func(t *testing.T) {
+			var args Arguments
+			err := syntax.Unmarshal([]byte(tt.config), &args)
+			if tt.err == "" {
+				require.NoError(t, err)
+				require.Equal(t, ModeAllMatch, args.Mode)
+				return
+			}
+			require.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func newEntry(lbls model.LabelSet, line string) loki.Entry {
+	return loki.Entry{
+		Labels: lbls,
+		Entry: logproto.Entry{
+			Timestamp: time.Now(),
+			Line:      line,
+		},
+	}
+}
+
+func newOptions(t *testing.T, reg prometheus.Registerer) component.Options {
+	return component.Options{
+		Logger:         util.TestAlloyLogger(t),
+		Registerer:     reg,
+		OnStateChange:  func(e component.Exports) {},
+		GetServiceData: getServiceData,
+	}
+}
+
+func getServiceData(name string) (interface{}, error) {
+	switch name {
+	case livedebugging.ServiceName:
+		return livedebugging.NewLiveDebugging(), nil
+	default:
+		return nil, fmt.Errorf("service not found %s", name)
+	}
+}
@@ -261,6 +261,7 @@ func (c *Component) Run(ctx context.Context) error {
 				func() string {
 					return fmt.Sprintf("%s => %s", entry.Line, newEntry.Line)
 				},
+				livedebugging.WithLabelSet(entry.Labels),
 			))
 
 			for _, f := range c.fanout {
@@ -1,7 +1,11 @@
 package otelcol
 
 import (
+	"reflect"
+
 	otelconsumer "go.opentelemetry.io/collector/consumer"
+
+	"github.com/grafana/alloy/internal/nodeconf/argument"
 )
 
 // Consumer is a combined OpenTelemetry Collector consumer which can consume
@@ -12,6 +16,10 @@ type Consumer interface {
 	otelconsumer.Logs
 }
 
+func init() {
+	argument.RegisterCapsule("otelcol.Consumer", reflect.TypeFor[Consumer]())
+}
+
 // ComponentMetadata can be implemented by, for example, consumers exported by components, to provide the ID of the component which is exporting given consumer. This is used for the graph and the live debugging.
 type ComponentMetadata interface {
 	ComponentID() string
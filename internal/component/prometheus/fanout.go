@@ -2,6 +2,7 @@ package prometheus
 
 import (
 	"context"
+	"reflect"
 	"sync"
 	"time"
 
@@ -17,9 +18,14 @@ import (
 	"github.com/prometheus/prometheus/storage"
 	"go.uber.org/atomic"
 
+	"github.com/grafana/alloy/internal/nodeconf/argument"
 	"github.com/grafana/alloy/internal/service/labelstore"
 )
 
+func init() {
+	argument.RegisterCapsule("MetricsReceiver", reflect.TypeFor[storage.Appendable]())
+}
+
 var _ storage.Appendable = (*Fanout)(nil)
 
 // Fanout supports the default Alloy style of appendables since it can go to multiple outputs. It also allows the intercepting of appends.
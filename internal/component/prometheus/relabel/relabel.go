@@ -287,6 +287,7 @@ func (c *Component) relabel(val float64, lbls labels.Labels) labels.Labels {
 		func() string {
 			return fmt.Sprintf("%s => %s", lbls.String(), relabelled.String())
 		},
+		livedebugging.WithLabels(lbls),
 	))
 
 	return relabelled
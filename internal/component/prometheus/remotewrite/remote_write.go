@@ -148,6 +148,7 @@ func New(o component.Options, c Arguments) (*Component, error) {
 				func() string {
 					return fmt.Sprintf("sample: ts=%d, labels=%s, value=%f", t, l, v)
 				},
+				livedebugging.WithLabels(l),
 			))
 			return globalRef, nextErr
 		}),
@@ -176,6 +177,7 @@ func New(o component.Options, c Arguments) (*Component, error) {
 					}
 					return data
 				},
+				livedebugging.WithLabels(l),
 			))
 			return globalRef, nextErr
 		}),
@@ -196,6 +198,7 @@ func New(o component.Options, c Arguments) (*Component, error) {
 				func() string {
 					return fmt.Sprintf("metadata: labels=%s, type=%q, unit=%q, help=%q", l, m.Type, m.Unit, m.Help)
 				},
+				livedebugging.WithLabels(l),
 			))
 			return globalRef, nextErr
 		}),
@@ -216,6 +219,7 @@ func New(o component.Options, c Arguments) (*Component, error) {
 				func() string {
 					return fmt.Sprintf("exemplar: ts=%d, labels=%s, exemplar_labels=%s, value=%f", e.Ts, l, e.Labels, e.Value)
 				},
+				livedebugging.WithLabels(l),
 			))
 			return globalRef, nextErr
 		}),
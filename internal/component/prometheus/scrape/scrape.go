@@ -611,6 +611,7 @@ func (c *Component) newInterceptor(ls labelstore.LabelStore) *prometheus.Interce
 				func() string {
 					return fmt.Sprintf("sample: ts=%d, labels=%s, value=%f", t, l, v)
 				},
+				livedebugging.WithLabels(l),
 			))
 			return globalRef, nextErr
 		}),
@@ -631,6 +632,7 @@ func (c *Component) newInterceptor(ls labelstore.LabelStore) *prometheus.Interce
 					}
 					return data
 				},
+				livedebugging.WithLabels(l),
 			))
 			return globalRef, nextErr
 		}),
@@ -643,6 +645,7 @@ func (c *Component) newInterceptor(ls labelstore.LabelStore) *prometheus.Interce
 				func() string {
 					return fmt.Sprintf("metadata: labels=%s, type=%q, unit=%q, help=%q", l, m.Type, m.Unit, m.Help)
 				},
+				livedebugging.WithLabels(l),
 			))
 			return globalRef, nextErr
 		}),
@@ -655,6 +658,7 @@ func (c *Component) newInterceptor(ls labelstore.LabelStore) *prometheus.Interce
 				func() string {
 					return fmt.Sprintf("exemplar: ts=%d, labels=%s, exemplar_labels=%s, value=%f", e.Ts, l, e.Labels, e.Value)
 				},
+				livedebugging.WithLabels(l),
 			))
 			return globalRef, nextErr
 		}),
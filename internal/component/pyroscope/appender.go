@@ -3,12 +3,15 @@ package pyroscope
 import (
 	"context"
 	"net/url"
+	"reflect"
 	"sync"
 	"time"
 
 	"github.com/hashicorp/go-multierror"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/alloy/internal/nodeconf/argument"
 )
 
 const (
@@ -25,6 +28,10 @@ type Appendable interface {
 	Appender() Appender
 }
 
+func init() {
+	argument.RegisterCapsule("ProfilesReceiver", reflect.TypeFor[Appendable]())
+}
+
 type Appender interface {
 	Append(ctx context.Context, labels labels.Labels, samples []*RawSample) error
 	AppendIngest(ctx context.Context, profile *IncomingProfile) error
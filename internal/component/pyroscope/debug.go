@@ -0,0 +1,78 @@
+package pyroscope
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/pprof/profile"
+	"github.com/prometheus/prometheus/model/labels"
+)
+
+// DebugString describes profiles sent with Append for live debugging: their
+// labels, type, number of samples and size. The profiles are only parsed
+// when the string is computed.
+func DebugString(lbls labels.Labels, samples []*RawSample) string {
+	var (
+		size        int
+		sampleCount int
+		sampleTypes []string
+		parsed      = true
+	)
+	for _, s := range samples {
+		size += len(s.RawProfile)
+		p, err := profile.ParseData(s.RawProfile)
+		if err != nil {
+			parsed = false
+			continue
+		}
+		sampleCount += len(p.Sample)
+		sampleTypes = appendSampleTypes(sampleTypes, p)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "profile: labels=%s, type=%q", lbls, lbls.Get(LabelName))
+	if len(sampleTypes) > 0 {
+		fmt.Fprintf(&sb, ", sample_types=[%s]", strings.Join(sampleTypes, ", "))
+	}
+	if parsed {
+		fmt.Fprintf(&sb, ", samples=%d", sampleCount)
+	}
+	fmt.Fprintf(&sb, ", profiles=%d, size=%d", len(samples), size)
+	return sb.String()
+}
+
+// DebugString describes the profile for live debugging: its labels, type,
+// format, number of samples and size. The number of samples is only known
+// for pprof profiles. The profile is only parsed when the string is
+// computed.
+func (p *IncomingProfile) DebugString() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "profile: labels=%s, type=%q", p.Labels, p.Labels.Get(LabelName))
+
+	if p.URL != nil {
+		if format := p.URL.Query().Get("format"); format != "" {
+			fmt.Fprintf(&sb, ", format=%q", format)
+		}
+	}
+
+	if parsed, err := profile.ParseData(p.RawBody); err == nil {
+		if sampleTypes := appendSampleTypes(nil, parsed); len(sampleTypes) > 0 {
+			fmt.Fprintf(&sb, ", sample_types=[%s]", strings.Join(sampleTypes, ", "))
+		}
+		fmt.Fprintf(&sb, ", samples=%d", len(parsed.Sample))
+	}
+	fmt.Fprintf(&sb, ", size=%d", len(p.RawBody))
+	return sb.String()
+}
+
+// appendSampleTypes appends the sample types of p which aren't in types yet.
+func appendSampleTypes(types []string, p *profile.Profile) []string {
+	for _, st := range p.SampleType {
+		t := st.Type + ":" + st.Unit
+		if !slices.Contains(types, t) {
+			types = append(types, t)
+		}
+	}
+	return types
+}
@@ -0,0 +1,64 @@
+package pyroscope
+
+import (
+	"bytes"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/google/pprof/profile"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+)
+
+func testProfile(t *testing.T) []byte {
+	fn := &profile.Function{ID: 1, Name: "main"}
+	loc := &profile.Location{ID: 1, Line: []profile.Line{{Function: fn}}}
+	p := &profile.Profile{
+		SampleType: []*profile.ValueType{{Type: "cpu", Unit: "nanoseconds"}},
+		Sample: []*profile.Sample{
+			{Location: []*profile.Location{loc}, Value: []int64{10}},
+			{Location: []*profile.Location{loc}, Value: []int64{20}},
+		},
+		Location: []*profile.Location{loc},
+		Function: []*profile.Function{fn},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, p.Write(&buf))
+	return buf.Bytes()
+}
+
+func TestDebugString(t *testing.T) {
+	raw := testProfile(t)
+	lbls := labels.FromStrings(LabelName, "process_cpu", LabelServiceName, "app")
+
+	require.Equal(t,
+		`profile: labels={__name__="process_cpu", service_name="app"}, type="process_cpu", sample_types=[cpu:nanoseconds], samples=4, profiles=2, size=`+strconv.Itoa(2*len(raw)),
+		DebugString(lbls, []*RawSample{{RawProfile: raw}, {RawProfile: raw}}))
+
+	// Profiles which can't be parsed are still described.
+	require.Equal(t,
+		`profile: labels={__name__="process_cpu", service_name="app"}, type="process_cpu", profiles=1, size=3`,
+		DebugString(lbls, []*RawSample{{RawProfile: []byte("foo")}}))
+}
+
+func TestIncomingProfileDebugString(t *testing.T) {
+	raw := testProfile(t)
+	u, err := url.Parse("http://localhost/ingest?format=pprof")
+	require.NoError(t, err)
+
+	p := &IncomingProfile{
+		RawBody: raw,
+		URL:     u,
+		Labels:  labels.FromStrings(LabelName, "app.cpu"),
+	}
+	require.Equal(t,
+		`profile: labels={__name__="app.cpu"}, type="app.cpu", format="pprof", sample_types=[cpu:nanoseconds], samples=2, size=`+strconv.Itoa(len(raw)),
+		p.DebugString())
+
+	p.RawBody = []byte("foo;bar 1")
+	p.URL, _ = url.Parse("http://localhost/ingest?format=folded")
+	require.Equal(t,
+		`profile: labels={__name__="app.cpu"}, type="app.cpu", format="folded", size=9`,
+		p.DebugString())
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/grafana/alloy/internal/component/pyroscope/write"
 	"github.com/grafana/alloy/internal/featuregate"
 	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/service/livedebugging"
 	"github.com/grafana/alloy/internal/util"
 	pushv1 "github.com/grafana/pyroscope/api/gen/proto/go/push/v1"
 	"github.com/grafana/pyroscope/api/gen/proto/go/push/v1/pushv1connect"
@@ -66,9 +67,17 @@ type Component struct {
 	uncheckedCollector *util.UncheckedCollector
 	appendables        []pyroscope.Appendable
 	mut                sync.Mutex
+	debugDataPublisher livedebugging.DebugDataPublisher
 }
 
+var _ component.LiveDebugging = (*Component)(nil)
+
 func New(opts component.Options, args Arguments) (*Component, error) {
+	debugDataPublisher, err := opts.GetServiceData(livedebugging.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+
 	uncheckedCollector := util.NewUncheckedCollector(nil)
 	opts.Registerer.MustRegister(uncheckedCollector)
 
@@ -76,6 +85,7 @@ func New(opts component.Options, args Arguments) (*Component, error) {
 		opts:               opts,
 		uncheckedCollector: uncheckedCollector,
 		appendables:        args.ForwardTo,
+		debugDataPublisher: debugDataPublisher.(livedebugging.DebugDataPublisher),
 	}
 
 	if err := c.Update(args); err != nil {
@@ -175,6 +185,25 @@ func (c *Component) Push(ctx context.Context, req *connect.Request[pushv1.PushRe
 
 	appendables := c.getAppendables()
 
+	componentID := livedebugging.ComponentID(c.opts.ID)
+	for _, series := range req.Msg.Series {
+		c.debugDataPublisher.PublishIfActive(livedebugging.NewData(
+			componentID,
+			livedebugging.PyroscopeProfile,
+			uint64(len(series.Samples)),
+			func() string {
+				lb := labels.NewBuilder(nil)
+				setLabelBuilderFromAPI(lb, series.Labels)
+				return pyroscope.DebugString(ensureServiceName(lb.Labels()), apiToAlloySamples(series.Samples))
+			},
+			livedebugging.WithLabelsFunc(func() labels.Labels {
+				lb := labels.NewBuilder(nil)
+				setLabelBuilderFromAPI(lb, series.Labels)
+				return ensureServiceName(lb.Labels())
+			}),
+		))
+	}
+
 	var wg sync.WaitGroup
 	var errs error
 	var errorMut sync.Mutex
@@ -256,6 +285,20 @@ func (c *Component) handleIngest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	debugProfile := &pyroscope.IncomingProfile{
+		RawBody:     buf.Bytes(),
+		ContentType: r.Header.Values(pyroscope.HeaderContentType),
+		URL:         r.URL,
+		Labels:      lbls,
+	}
+	c.debugDataPublisher.PublishIfActive(livedebugging.NewData(
+		livedebugging.ComponentID(c.opts.ID),
+		livedebugging.PyroscopeProfile,
+		1,
+		debugProfile.DebugString,
+		livedebugging.WithLabels(lbls),
+	))
+
 	var wg sync.WaitGroup
 	var errs error
 	var errorMut sync.Mutex
@@ -296,6 +339,8 @@ func (c *Component) handleIngest(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+func (c *Component) LiveDebugging() {}
+
 func (c *Component) shutdownServer() {
 	if c.server != nil {
 		c.server.StopAndShutdown()
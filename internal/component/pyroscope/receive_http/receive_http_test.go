@@ -20,6 +20,7 @@ import (
 	"github.com/grafana/alloy/internal/component"
 	fnet "github.com/grafana/alloy/internal/component/common/net"
 	"github.com/grafana/alloy/internal/component/pyroscope"
+	"github.com/grafana/alloy/internal/service/livedebugging"
 	"github.com/grafana/alloy/internal/util"
 	pushv1 "github.com/grafana/pyroscope/api/gen/proto/go/push/v1"
 	"github.com/grafana/pyroscope/api/gen/proto/go/push/v1/pushv1connect"
@@ -531,9 +532,10 @@ func (a *testAppender) AppendIngest(_ context.Context, profile *pyroscope.Incomi
 
 func testOptions(t *testing.T) component.Options {
 	return component.Options{
-		ID:         "pyroscope.receive_http.test",
-		Logger:     util.TestAlloyLogger(t),
-		Registerer: prometheus.NewRegistry(),
+		ID:             "pyroscope.receive_http.test",
+		Logger:         util.TestAlloyLogger(t),
+		Registerer:     prometheus.NewRegistry(),
+		GetServiceData: getServiceData,
 	}
 }
 
@@ -578,3 +580,12 @@ func TestUpdateArgs(t *testing.T) {
 
 	waitForServerReady(t, ports[1])
 }
+
+func getServiceData(name string) (interface{}, error) {
+	switch name {
+	case livedebugging.ServiceName:
+		return livedebugging.NewLiveDebugging(), nil
+	default:
+		return nil, fmt.Errorf("service not found %s", name)
+	}
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/grafana/alloy/internal/component/pyroscope"
 	"github.com/grafana/alloy/internal/featuregate"
 	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/service/livedebugging"
 
 	lru "github.com/hashicorp/golang-lru/v2"
 	"github.com/prometheus/common/model"
@@ -80,10 +81,13 @@ type Component struct {
 	cache        *lru.Cache[model.Fingerprint, []cacheItem]
 	maxCacheSize int
 	exited       atomic.Bool
+
+	debugDataPublisher livedebugging.DebugDataPublisher
 }
 
 var (
-	_ component.Component = (*Component)(nil)
+	_ component.Component     = (*Component)(nil)
+	_ component.LiveDebugging = (*Component)(nil)
 )
 
 // New creates a new pyroscope.relabel component.
@@ -93,11 +97,17 @@ func New(o component.Options, args Arguments) (*Component, error) {
 		return nil, err
 	}
 
+	debugDataPublisher, err := o.GetServiceData(livedebugging.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+
 	c := &Component{
-		opts:         o,
-		metrics:      newMetrics(o.Registerer),
-		cache:        cache,
-		maxCacheSize: args.MaxCacheSize,
+		opts:               o,
+		metrics:            newMetrics(o.Registerer),
+		cache:              cache,
+		maxCacheSize:       args.MaxCacheSize,
+		debugDataPublisher: debugDataPublisher.(livedebugging.DebugDataPublisher),
 	}
 
 	c.fanout = pyroscope.NewFanout(args.ForwardTo, o.ID, o.Registerer)
@@ -164,11 +174,13 @@ func (c *Component) Append(ctx context.Context, lbls labels.Labels, samples []*p
 	c.metrics.profilesProcessed.Inc()
 
 	if lbls.IsEmpty() {
+		c.publishDebugData(lbls, lbls, true, func() string { return pyroscope.DebugString(lbls, samples) })
 		c.metrics.profilesOutgoing.Inc()
 		return c.fanout.Appender().Append(ctx, lbls, samples)
 	}
 
 	newLabels, keep := c.relabel(lbls)
+	c.publishDebugData(lbls, newLabels, keep, func() string { return pyroscope.DebugString(newLabels, samples) })
 	if !keep {
 		c.metrics.profilesDropped.Inc()
 		level.Debug(c.opts.Logger).Log("msg", "profile dropped by relabel rules", "labels", lbls.String())
@@ -190,11 +202,15 @@ func (c *Component) AppendIngest(ctx context.Context, profile *pyroscope.Incomin
 	c.metrics.profilesProcessed.Inc()
 
 	if profile.Labels.IsEmpty() {
+		c.publishDebugData(profile.Labels, profile.Labels, true, profile.DebugString)
 		c.metrics.profilesOutgoing.Inc()
 		return c.fanout.Appender().AppendIngest(ctx, profile)
 	}
 
 	newLabels, keep := c.relabel(profile.Labels)
+	relabeled := *profile
+	relabeled.Labels = newLabels
+	c.publishDebugData(profile.Labels, newLabels, keep, relabeled.DebugString)
 	if !keep {
 		c.metrics.profilesDropped.Inc()
 		level.Debug(c.opts.Logger).Log("msg", "profile dropped by relabel rules")
@@ -210,6 +226,29 @@ func (c *Component) Appender() pyroscope.Appender {
 	return c
 }
 
+// publishDebugData publishes a relabeled profile to live debugging. describe
+// describes the relabeled profile.
+func (c *Component) publishDebugData(lbls, newLabels labels.Labels, keep bool, describe func() string) {
+	count := uint64(1)
+	if !keep {
+		count = 0 // dropped profiles aren't forwarded
+	}
+	c.debugDataPublisher.PublishIfActive(livedebugging.NewData(
+		livedebugging.ComponentID(c.opts.ID),
+		livedebugging.PyroscopeProfile,
+		count,
+		func() string {
+			if !keep {
+				return fmt.Sprintf("labels: %s => dropped", lbls)
+			}
+			return fmt.Sprintf("labels: %s => %s", lbls, describe())
+		},
+		livedebugging.WithLabels(lbls),
+	))
+}
+
+func (c *Component) LiveDebugging() {}
+
 type cacheItem struct {
 	original  model.LabelSet
 	relabeled model.LabelSet
@@ -9,12 +9,14 @@ import (
 
 	config_util "github.com/prometheus/common/config"
 	"github.com/prometheus/prometheus/discovery/targetgroup"
+	"github.com/prometheus/prometheus/model/labels"
 
 	"github.com/grafana/alloy/internal/component/pyroscope"
 	"github.com/grafana/alloy/internal/featuregate"
 	"github.com/grafana/alloy/internal/runtime/logging/level"
 	"github.com/grafana/alloy/internal/service/cluster"
 	"github.com/grafana/alloy/internal/service/http"
+	"github.com/grafana/alloy/internal/service/livedebugging"
 
 	"github.com/grafana/alloy/internal/component"
 	component_config "github.com/grafana/alloy/internal/component/common/config"
@@ -252,7 +254,10 @@ type Component struct {
 	appendable *pyroscope.Fanout
 }
 
-var _ component.Component = (*Component)(nil)
+var (
+	_ component.Component     = (*Component)(nil)
+	_ component.LiveDebugging = (*Component)(nil)
+)
 
 // New creates a new pprof.scrape component.
 func New(o component.Options, args Arguments) (*Component, error) {
@@ -268,13 +273,32 @@ func New(o component.Options, args Arguments) (*Component, error) {
 	}
 	clusterData := data.(cluster.Cluster)
 
+	data, err = o.GetServiceData(livedebugging.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+	debugDataPublisher := data.(livedebugging.DebugDataPublisher)
+
 	alloyAppendable := pyroscope.NewFanout(args.ForwardTo, o.ID, o.Registerer)
+	componentID := livedebugging.ComponentID(o.ID)
+	// Scraped profiles are published to live debugging before being
+	// forwarded.
+	debugAppendable := pyroscope.AppendableFunc(func(ctx context.Context, lbls labels.Labels, samples []*pyroscope.RawSample) error {
+		debugDataPublisher.PublishIfActive(livedebugging.NewData(
+			componentID,
+			livedebugging.PyroscopeProfile,
+			uint64(len(samples)),
+			func() string { return pyroscope.DebugString(lbls, samples) },
+			livedebugging.WithLabels(lbls),
+		))
+		return alloyAppendable.Appender().Append(ctx, lbls, samples)
+	})
 	scrapeHttpOptions := Options{
 		HTTPClientOptions: []config_util.HTTPClientOption{
 			config_util.WithDialContextFunc(httpData.DialFunc),
 		},
 	}
-	scraper, err := NewManager(scrapeHttpOptions, args, alloyAppendable, o.Logger)
+	scraper, err := NewManager(scrapeHttpOptions, args, debugAppendable, o.Logger)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create scraper manager: %w", err)
 	}
@@ -398,3 +422,5 @@ func (c *Component) DebugInfo() interface{} {
 
 	return scrape.ScraperStatus{TargetStatus: res}
 }
+
+func (c *Component) LiveDebugging() {}
@@ -26,6 +26,7 @@ import (
 	"github.com/grafana/alloy/internal/component/pyroscope"
 	"github.com/grafana/alloy/internal/featuregate"
 	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/service/livedebugging"
 	"github.com/grafana/alloy/internal/useragent"
 	"github.com/grafana/alloy/internal/util"
 	"github.com/grafana/dskit/backoff"
@@ -39,7 +40,8 @@ var (
 	DefaultArguments = func() Arguments {
 		return Arguments{}
 	}
-	_ component.Component = (*Component)(nil)
+	_ component.Component     = (*Component)(nil)
+	_ component.LiveDebugging = (*Component)(nil)
 )
 
 func init() {
@@ -111,6 +113,8 @@ type Component struct {
 	opts    component.Options
 	cfg     Arguments
 	metrics *metrics
+
+	debugDataPublisher livedebugging.DebugDataPublisher
 }
 
 // Exports are the set of fields exposed by the pyroscope.write component.
@@ -120,8 +124,13 @@ type Exports struct {
 
 // New creates a new pyroscope.write component.
 func New(o component.Options, c Arguments) (*Component, error) {
+	debugDataPublisher, err := o.GetServiceData(livedebugging.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+
 	metrics := newMetrics(o.Registerer)
-	receiver, err := NewFanOut(o, c, metrics)
+	receiver, err := NewFanOut(o, c, metrics, debugDataPublisher.(livedebugging.DebugDataPublisher))
 	if err != nil {
 		return nil, err
 	}
@@ -129,9 +138,10 @@ func New(o component.Options, c Arguments) (*Component, error) {
 	o.OnStateChange(Exports{Receiver: receiver})
 
 	return &Component{
-		cfg:     c,
-		opts:    o,
-		metrics: metrics,
+		cfg:                c,
+		opts:               o,
+		metrics:            metrics,
+		debugDataPublisher: debugDataPublisher.(livedebugging.DebugDataPublisher),
 	}, nil
 }
 
@@ -146,7 +156,7 @@ func (c *Component) Run(ctx context.Context) error {
 // Update implements Component.
 func (c *Component) Update(newConfig component.Arguments) error {
 	c.cfg = newConfig.(Arguments)
-	receiver, err := NewFanOut(c.opts, newConfig.(Arguments), c.metrics)
+	receiver, err := NewFanOut(c.opts, newConfig.(Arguments), c.metrics, c.debugDataPublisher)
 	if err != nil {
 		return err
 	}
@@ -154,6 +164,8 @@ func (c *Component) Update(newConfig component.Arguments) error {
 	return nil
 }
 
+func (c *Component) LiveDebugging() {}
+
 type fanOutClient struct {
 	// The list of push clients to fan out to.
 	pushClients   []pushv1connect.PusherServiceClient
@@ -161,10 +173,12 @@ type fanOutClient struct {
 	config        Arguments
 	opts          component.Options
 	metrics       *metrics
+
+	debugDataPublisher livedebugging.DebugDataPublisher
 }
 
 // NewFanOut creates a new fan out client that will fan out to all endpoints.
-func NewFanOut(opts component.Options, config Arguments, metrics *metrics) (*fanOutClient, error) {
+func NewFanOut(opts component.Options, config Arguments, metrics *metrics, debugDataPublisher livedebugging.DebugDataPublisher) (*fanOutClient, error) {
 	pushClients := make([]pushv1connect.PusherServiceClient, 0, len(config.Endpoints))
 	ingestClients := make(map[*EndpointOptions]*http.Client)
 	uid := alloyseed.Get().UID
@@ -186,11 +200,12 @@ func NewFanOut(opts component.Options, config Arguments, metrics *metrics) (*fan
 		ingestClients[endpoint] = httpClient
 	}
 	return &fanOutClient{
-		pushClients:   pushClients,
-		ingestClients: ingestClients,
-		config:        config,
-		opts:          opts,
-		metrics:       metrics,
+		pushClients:        pushClients,
+		ingestClients:      ingestClients,
+		config:             config,
+		opts:               opts,
+		metrics:            metrics,
+		debugDataPublisher: debugDataPublisher,
 	}, nil
 }
 
@@ -322,7 +337,8 @@ func (f *fanOutClient) Append(ctx context.Context, lbs labels.Labels, samples []
 	for name, value := range f.config.ExternalLabels {
 		lbsBuilder.Set(name, value)
 	}
-	for _, l := range lbsBuilder.Labels() {
+	finalLabels := lbsBuilder.Labels()
+	for _, l := range finalLabels {
 		protoLabels = append(protoLabels, &typesv1.LabelPair{
 			Name:  l.Name,
 			Value: l.Value,
@@ -333,6 +349,15 @@ func (f *fanOutClient) Append(ctx context.Context, lbs labels.Labels, samples []
 			RawProfile: sample.RawProfile,
 		})
 	}
+
+	f.debugDataPublisher.PublishIfActive(livedebugging.NewData(
+		livedebugging.ComponentID(f.opts.ID),
+		livedebugging.PyroscopeProfile,
+		uint64(len(samples)),
+		func() string { return pyroscope.DebugString(finalLabels, samples) },
+		livedebugging.WithLabels(finalLabels),
+	))
+
 	// push to all clients
 	_, err := f.Push(ctx, connect.NewRequest(&pushv1.PushRequest{
 		Series: []*pushv1.RawProfileSeries{
@@ -388,6 +413,16 @@ func (f *fanOutClient) AppendIngest(ctx context.Context, profile *pyroscope.Inco
 	}
 	query.Set("name", ls.Normalized())
 
+	debugProfile := *profile
+	debugProfile.Labels = finalLabels
+	f.debugDataPublisher.PublishIfActive(livedebugging.NewData(
+		livedebugging.ComponentID(f.opts.ID),
+		livedebugging.PyroscopeProfile,
+		1,
+		debugProfile.DebugString,
+		livedebugging.WithLabels(finalLabels),
+	))
+
 	// Send to each endpoint concurrently
 	for endpointIdx, endpoint := range f.config.Endpoints {
 		wg.Add(1)
@@ -0,0 +1,215 @@
+package configtest
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/grafana/alloy/internal/component/common/loki"
+)
+
+// counts returns the amount of data in d. Invalid OTLP data is ignored.
+func (d Data) counts() counts {
+	c := counts{logs: len(d.Logs), samples: len(d.Samples)}
+	if ld, err := parseOTLPLogs(d.OTLPLogs); err == nil {
+		c.otlpLogs = ld.LogRecordCount()
+	}
+	if md, err := parseOTLPMetrics(d.OTLPMetrics); err == nil {
+		c.otlpMetrics = md.DataPointCount()
+	}
+	if td, err := parseOTLPTraces(d.OTLPTraces); err == nil {
+		c.otlpTraces = td.SpanCount()
+	}
+	return c
+}
+
+// compare returns the differences between the expected data and the data
+// captured by c.
+func compare(expect Data, c *capture) []string {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	var failures []string
+	failures = append(failures, compareLogs(expect.Logs, c.logs)...)
+	failures = append(failures, compareSamples(expect.Samples, c.samples)...)
+
+	if expect.OTLPLogs != "" || c.otlpLogs.LogRecordCount() > 0 {
+		want, _ := parseOTLPLogs(expect.OTLPLogs)
+		failures = append(failures, compareJSON("OTLP logs", marshalOTLPLogs(want), marshalOTLPLogs(c.otlpLogs))...)
+	}
+	if expect.OTLPMetrics != "" || c.otlpMetrics.DataPointCount() > 0 {
+		want, _ := parseOTLPMetrics(expect.OTLPMetrics)
+		failures = append(failures, compareJSON("OTLP metrics", marshalOTLPMetrics(want), marshalOTLPMetrics(c.otlpMetrics))...)
+	}
+	if expect.OTLPTraces != "" || c.otlpTraces.SpanCount() > 0 {
+		want, _ := parseOTLPTraces(expect.OTLPTraces)
+		failures = append(failures, compareJSON("OTLP traces", marshalOTLPTraces(want), marshalOTLPTraces(c.otlpTraces))...)
+	}
+	return failures
+}
+
+// compareLogs compares log lines in order.
+func compareLogs(want []LogEntry, got []loki.Entry) []string {
+	var failures []string
+	if len(got) != len(want) {
+		failures = append(failures, fmt.Sprintf("got %d log lines, want %d", len(got), len(want)))
+	}
+
+	for i := 0; i < max(len(want), len(got)); i++ {
+		switch {
+		case i >= len(got):
+			failures = append(failures, fmt.Sprintf("log line %d: missing %q", i, want[i].Line))
+			continue
+		case i >= len(want):
+			failures = append(failures, fmt.Sprintf("log line %d: unexpected %q", i, got[i].Line))
+			continue
+		}
+
+		w, g := want[i], got[i]
+		if g.Line != w.Line {
+			failures = append(failures, fmt.Sprintf("log line %d: got %q, want %q", i, g.Line, w.Line))
+		}
+		if w.Labels != nil {
+			if gotLabels, wantLabels := g.Labels.String(), toLabelSet(w.Labels).String(); gotLabels != wantLabels {
+				failures = append(failures, fmt.Sprintf("log line %d: got labels %s, want %s", i, gotLabels, wantLabels))
+			}
+		}
+		if w.StructuredMetadata != nil {
+			gotMetadata := make(map[string]string, len(g.StructuredMetadata))
+			for _, l := range g.StructuredMetadata {
+				gotMetadata[l.Name] = l.Value
+			}
+			if gotStr, wantStr := labels.FromMap(gotMetadata).String(), labels.FromMap(w.StructuredMetadata).String(); gotStr != wantStr {
+				failures = append(failures, fmt.Sprintf("log line %d: got structured metadata %s, want %s", i, gotStr, wantStr))
+			}
+		}
+		if !w.Timestamp.IsZero() && !g.Timestamp.Equal(w.Timestamp) {
+			failures = append(failures, fmt.Sprintf("log line %d: got timestamp %s, want %s", i, g.Timestamp.Format(time.RFC3339Nano), w.Timestamp.Format(time.RFC3339Nano)))
+		}
+	}
+	return failures
+}
+
+// compareSamples compares samples regardless of their order.
+func compareSamples(want []Sample, got []capturedSample) []string {
+	type sample struct {
+		labels    string
+		value     float64
+		timestamp int64 // Zero if the timestamp isn't compared.
+	}
+
+	wantSamples := make([]sample, 0, len(want))
+	for _, s := range want {
+		var ts int64
+		if !s.Timestamp.IsZero() {
+			ts = s.Timestamp.UnixMilli()
+		}
+		wantSamples = append(wantSamples, sample{labels: labels.FromMap(s.Labels).String(), value: s.Value, timestamp: ts})
+	}
+	gotSamples := make([]sample, 0, len(got))
+	for _, s := range got {
+		gotSamples = append(gotSamples, sample{labels: s.labels.String(), value: s.value, timestamp: s.timestamp})
+	}
+
+	sortSamples := func(ss []sample) {
+		sort.SliceStable(ss, func(i, j int) bool {
+			if ss[i].labels != ss[j].labels {
+				return ss[i].labels < ss[j].labels
+			}
+			return ss[i].value < ss[j].value
+		})
+	}
+	sortSamples(wantSamples)
+	sortSamples(gotSamples)
+
+	format := func(s sample) string {
+		if s.timestamp == 0 {
+			return fmt.Sprintf("%s %g", s.labels, s.value)
+		}
+		return fmt.Sprintf("%s %g @%d", s.labels, s.value, s.timestamp)
+	}
+
+	// Match expected samples with the received ones, so that a missing sample
+	// is reported once rather than shifting all the following ones.
+	var (
+		failures []string
+		matched  = make([]bool, len(gotSamples))
+	)
+	for _, w := range wantSamples {
+		found := false
+		for j, g := range gotSamples {
+			if matched[j] || g.labels != w.labels {
+				continue
+			}
+			if g.value != w.value && !(math.IsNaN(g.value) && math.IsNaN(w.value)) {
+				continue
+			}
+			if w.timestamp != 0 && g.timestamp != w.timestamp {
+				continue
+			}
+			matched[j], found = true, true
+			break
+		}
+		if !found {
+			failures = append(failures, fmt.Sprintf("missing sample %s", format(w)))
+		}
+	}
+	for j, g := range gotSamples {
+		if !matched[j] {
+			failures = append(failures, fmt.Sprintf("unexpected sample %s", format(g)))
+		}
+	}
+	return failures
+}
+
+// compareJSON compares the JSON encoding of OTLP data.
+func compareJSON(kind string, want, got []byte) []string {
+	if bytes.Equal(want, got) {
+		return nil
+	}
+	return []string{fmt.Sprintf("%s differ:\n  got:  %s\n  want: %s", kind, strings.TrimSpace(string(got)), strings.TrimSpace(string(want)))}
+}
+
+func parseOTLPLogs(s string) (plog.Logs, error) {
+	if s == "" {
+		return plog.NewLogs(), nil
+	}
+	return (&plog.JSONUnmarshaler{}).UnmarshalLogs([]byte(s))
+}
+
+func parseOTLPMetrics(s string) (pmetric.Metrics, error) {
+	if s == "" {
+		return pmetric.NewMetrics(), nil
+	}
+	return (&pmetric.JSONUnmarshaler{}).UnmarshalMetrics([]byte(s))
+}
+
+func parseOTLPTraces(s string) (ptrace.Traces, error) {
+	if s == "" {
+		return ptrace.NewTraces(), nil
+	}
+	return (&ptrace.JSONUnmarshaler{}).UnmarshalTraces([]byte(s))
+}
+
+func marshalOTLPLogs(ld plog.Logs) []byte {
+	bb, _ := (&plog.JSONMarshaler{}).MarshalLogs(ld)
+	return bb
+}
+
+func marshalOTLPMetrics(md pmetric.Metrics) []byte {
+	bb, _ := (&pmetric.JSONMarshaler{}).MarshalMetrics(md)
+	return bb
+}
+
+func marshalOTLPTraces(td ptrace.Traces) []byte {
+	bb, _ := (&ptrace.JSONMarshaler{}).MarshalTraces(td)
+	return bb
+}
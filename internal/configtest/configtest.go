@@ -0,0 +1,174 @@
+// Package configtest runs unit tests against Alloy configurations.
+//
+// A test injects synthetic log lines, metric samples, or OTLP data into the
+// exports of components and asserts what reaches mocked components. The
+// configuration is run by a full Alloy runtime, so pipelines of components
+// such as loki.process, prometheus.relabel, or otelcol.processor.transform
+// are tested exactly as they would run, without contacting any external
+// system.
+//
+// Tests don't use the componenttest package: its controller builds and runs
+// a single component from already decoded arguments, so it can't evaluate
+// the references between the components of a pipeline, nor imports and
+// custom components. Instead, the runtime is created with a component
+// registry which builds mocks in place of the mocked components.
+package configtest
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/grafana/alloy/syntax"
+)
+
+// DefaultTimeout is the default time a test waits for the expected data to
+// reach the mocked components.
+const DefaultTimeout = 5 * time.Second
+
+// File is a test file, holding a set of tests for a configuration.
+type File struct {
+	Tests []Test `alloy:"test,block"`
+}
+
+// Validate implements syntax.Validator.
+func (f *File) Validate() error {
+	names := make(map[string]struct{}, len(f.Tests))
+	for _, t := range f.Tests {
+		if _, ok := names[t.Name]; ok {
+			return fmt.Errorf("test %q is defined more than once", t.Name)
+		}
+		names[t.Name] = struct{}{}
+	}
+	return nil
+}
+
+// Test is a single test case.
+type Test struct {
+	// Name of the test. Like all block labels, it must be a valid identifier.
+	Name string `alloy:",label"`
+
+	// Timeout is how long to wait for the expected data to reach the mocked
+	// components.
+	Timeout time.Duration `alloy:"timeout,attr,optional"`
+
+	// Mocks is a list of IDs of components to replace with mocks in addition
+	// to the components of the expect blocks. Mocking components which read
+	// from or write to external systems keeps them from running.
+	Mocks []string `alloy:"mocks,attr,optional"`
+
+	Inputs  []Input  `alloy:"input,block,optional"`
+	Expects []Expect `alloy:"expect,block,optional"`
+}
+
+// SetToDefault implements syntax.Defaulter.
+func (t *Test) SetToDefault() {
+	*t = Test{Timeout: DefaultTimeout}
+}
+
+// Validate implements syntax.Validator.
+func (t *Test) Validate() error {
+	if t.Timeout <= 0 {
+		return fmt.Errorf("timeout must be greater than 0")
+	}
+	if len(t.Inputs) == 0 {
+		return fmt.Errorf("test %q must have at least one input block", t.Name)
+	}
+	return nil
+}
+
+// Input is data injected into the exports of a component.
+type Input struct {
+	// Component is the ID of the component, like "loki.process.default".
+	Component string `alloy:"component,attr"`
+
+	// Export is the name of the exported field to send data to. It defaults to
+	// "receiver" for logs and samples and to "input" for OTLP data.
+	Export string `alloy:"export,attr,optional"`
+
+	Data Data `alloy:",squash"`
+}
+
+// Validate implements syntax.Validator.
+func (in *Input) Validate() error { return in.Data.validate() }
+
+// Expect is the data expected to reach a mocked component.
+type Expect struct {
+	// Component is the ID of the mocked component.
+	Component string `alloy:"component,attr"`
+
+	Data Data `alloy:",squash"`
+}
+
+// Validate implements syntax.Validator.
+func (e *Expect) Validate() error { return e.Data.validate() }
+
+// Data is a set of telemetry. OTLP data is written using the OTLP JSON
+// encoding.
+type Data struct {
+	Logs        []LogEntry `alloy:"log,block,optional"`
+	Samples     []Sample   `alloy:"sample,block,optional"`
+	OTLPLogs    string     `alloy:"otlp_logs,attr,optional"`
+	OTLPMetrics string     `alloy:"otlp_metrics,attr,optional"`
+	OTLPTraces  string     `alloy:"otlp_traces,attr,optional"`
+}
+
+// validate checks that the OTLP data of d is valid.
+func (d Data) validate() error {
+	if d.OTLPLogs != "" {
+		if _, err := parseOTLPLogs(d.OTLPLogs); err != nil {
+			return fmt.Errorf("invalid otlp_logs: %w", err)
+		}
+	}
+	if d.OTLPMetrics != "" {
+		if _, err := parseOTLPMetrics(d.OTLPMetrics); err != nil {
+			return fmt.Errorf("invalid otlp_metrics: %w", err)
+		}
+	}
+	if d.OTLPTraces != "" {
+		if _, err := parseOTLPTraces(d.OTLPTraces); err != nil {
+			return fmt.Errorf("invalid otlp_traces: %w", err)
+		}
+	}
+	return nil
+}
+
+// LogEntry is a log line. Labels, structured metadata, and timestamps of
+// expected log lines are only compared when they are set.
+type LogEntry struct {
+	Line               string            `alloy:"line,attr"`
+	Labels             map[string]string `alloy:"labels,attr,optional"`
+	StructuredMetadata map[string]string `alloy:"structured_metadata,attr,optional"`
+	Timestamp          time.Time         `alloy:"timestamp,attr,optional"`
+}
+
+// Sample is a metric sample. The name of the metric is set with the
+// __name__ label. Timestamps of expected samples are only compared when they
+// are set.
+type Sample struct {
+	Labels    map[string]string `alloy:"labels,attr"`
+	Value     float64           `alloy:"value,attr"`
+	Timestamp time.Time         `alloy:"timestamp,attr,optional"`
+}
+
+// ParseFile parses the content of a test file.
+func ParseFile(bb []byte) (*File, error) {
+	var f File
+	if err := syntax.Unmarshal(bb, &f); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// LoadFile reads and parses the test file at path.
+func LoadFile(path string) (*File, error) {
+	bb, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := ParseFile(bb)
+	if err != nil {
+		return nil, fmt.Errorf("parsing test file %q: %w", path, err)
+	}
+	return f, nil
+}
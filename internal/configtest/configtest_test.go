@@ -0,0 +1,379 @@
+package configtest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	otelconsumer "go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/component/otelcol"
+	"github.com/grafana/alloy/internal/featuregate"
+
+	_ "github.com/grafana/alloy/internal/component/loki/process"
+	_ "github.com/grafana/alloy/internal/component/loki/write"
+	_ "github.com/grafana/alloy/internal/component/otelcol/exporter/otlp"
+	_ "github.com/grafana/alloy/internal/component/prometheus/relabel"
+	_ "github.com/grafana/alloy/internal/component/prometheus/remotewrite"
+)
+
+const testConfig = `
+loki.process "default" {
+	forward_to = [loki.write.default.receiver]
+
+	stage.logfmt {
+		mapping = { "level" = "" }
+	}
+
+	stage.labels {
+		values = { "level" = "" }
+	}
+
+	stage.drop {
+		source = "level"
+		value  = "debug"
+	}
+}
+
+loki.write "default" {
+	endpoint {
+		url = "http://localhost:3100/loki/api/v1/push"
+	}
+}
+
+prometheus.relabel "default" {
+	forward_to = [prometheus.remote_write.default.receiver]
+
+	rule {
+		source_labels = ["__name__"]
+		regex         = "go_.*"
+		action        = "drop"
+	}
+
+	rule {
+		target_label = "env"
+		replacement  = "test"
+	}
+}
+
+prometheus.remote_write "default" {
+	endpoint {
+		url = "http://localhost:9009/api/v1/push"
+	}
+}
+
+testcomponents.otlp_passthrough "default" {
+	output {
+		logs = [otelcol.exporter.otlp.default.input]
+	}
+}
+
+otelcol.exporter.otlp "default" {
+	client {
+		endpoint = "localhost:4317"
+	}
+}
+`
+
+const testFile = `
+test "logs" {
+	input {
+		component = "loki.process.default"
+		log {
+			line   = "level=info msg=hello"
+			labels = { "job" = "app" }
+		}
+		log {
+			line = "level=debug msg=noise"
+		}
+		log {
+			line      = "level=error msg=boom"
+			timestamp = "2024-01-02T03:04:05Z"
+		}
+	}
+
+	expect {
+		component = "loki.write.default"
+		log {
+			line   = "level=info msg=hello"
+			labels = { "job" = "app", "level" = "info" }
+		}
+		log {
+			line      = "level=error msg=boom"
+			labels    = { "level" = "error" }
+			timestamp = "2024-01-02T03:04:05Z"
+		}
+	}
+}
+
+test "metrics" {
+	input {
+		component = "prometheus.relabel.default"
+		sample {
+			labels    = { "__name__" = "up", "job" = "app" }
+			value     = 1
+			timestamp = "2024-01-02T03:04:05Z"
+		}
+		sample {
+			labels = { "__name__" = "go_goroutines", "job" = "app" }
+			value  = 10
+		}
+	}
+
+	expect {
+		component = "prometheus.remote_write.default"
+		sample {
+			labels = { "__name__" = "up", "job" = "app", "env" = "test" }
+			value  = 1
+		}
+	}
+}
+
+test "otlp" {
+	input {
+		component = "testcomponents.otlp_passthrough.default"
+		otlp_logs = "{\"resourceLogs\":[{\"scopeLogs\":[{\"logRecords\":[{\"body\":{\"stringValue\":\"hello\"}}]}]}]}"
+	}
+
+	expect {
+		component = "otelcol.exporter.otlp.default"
+		otlp_logs = "{\"resourceLogs\": [{\"scopeLogs\": [{\"logRecords\": [{\"body\": {\"stringValue\": \"hello\"}}]}]}]}"
+	}
+}
+`
+
+func TestRun(t *testing.T) {
+	f, err := ParseFile([]byte(testFile))
+	require.NoError(t, err)
+	require.Len(t, f.Tests, 3)
+
+	for _, test := range f.Tests {
+		t.Run(test.Name, func(t *testing.T) {
+			res := Run(context.Background(), testOptions(), test)
+			require.NoError(t, res.Err)
+			require.Empty(t, res.Failures)
+			require.True(t, res.Passed())
+		})
+	}
+}
+
+func TestRun_Failures(t *testing.T) {
+	f, err := ParseFile([]byte(`
+		test "mismatch" {
+			timeout = "1s"
+
+			input {
+				component = "loki.process.default"
+				log { line = "level=info msg=hello" }
+			}
+			input {
+				component = "prometheus.relabel.default"
+				sample {
+					labels    = { "__name__" = "up" }
+					value     = 1
+					timestamp = "2024-01-02T03:04:05Z"
+				}
+			}
+
+			expect {
+				component = "loki.write.default"
+				log { line = "level=info msg=bye" }
+				log { line = "level=info msg=never" }
+			}
+			expect {
+				component = "prometheus.remote_write.default"
+				sample {
+					labels = { "__name__" = "up" }
+					value  = 1
+				}
+			}
+		}
+	`))
+	require.NoError(t, err)
+
+	res := Run(context.Background(), testOptions(), f.Tests[0])
+	require.NoError(t, res.Err)
+	require.Equal(t, []string{
+		"loki.write.default: got 1 log lines, want 2",
+		`loki.write.default: log line 0: got "level=info msg=hello", want "level=info msg=bye"`,
+		`loki.write.default: log line 1: missing "level=info msg=never"`,
+		`prometheus.remote_write.default: missing sample {__name__="up"} 1`,
+		`prometheus.remote_write.default: unexpected sample {__name__="up", env="test"} 1 @1704164645000`,
+	}, res.Failures)
+	require.False(t, res.Passed())
+}
+
+func TestRun_Errors(t *testing.T) {
+	tt := []struct {
+		name   string
+		test   string
+		expect string
+	}{
+		{
+			name: "unknown mock",
+			test: `test "t" {
+				mocks = ["loki.write.missing"]
+				input {
+					component = "loki.process.default"
+					log { line = "a" }
+				}
+			}`,
+			expect: `mocked component "loki.write.missing" does not exist`,
+		},
+		{
+			name: "unknown input",
+			test: `test "t" {
+				input {
+					component = "loki.process.missing"
+					log { line = "a" }
+				}
+			}`,
+			expect: `sending input to "loki.process.missing"`,
+		},
+		{
+			name: "wrong export type",
+			test: `test "t" {
+				input {
+					component = "prometheus.relabel.default"
+					log { line = "a" }
+				}
+			}`,
+			expect: "export is not a logs receiver",
+		},
+		{
+			name: "input to mock",
+			test: `test "t" {
+				input {
+					component = "loki.write.default"
+					log { line = "a" }
+				}
+				expect {
+					component = "loki.write.default"
+				}
+			}`,
+			expect: `cannot send input to mocked component "loki.write.default"`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := ParseFile([]byte(tc.test))
+			require.NoError(t, err)
+
+			res := Run(context.Background(), testOptions(), f.Tests[0])
+			require.ErrorContains(t, res.Err, tc.expect)
+		})
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	f, err := ParseFile([]byte(`
+		test "defaults" {
+			input {
+				component = "loki.process.default"
+				log { line = "a" }
+			}
+		}
+	`))
+	require.NoError(t, err)
+	require.Equal(t, DefaultTimeout, f.Tests[0].Timeout)
+
+	_, err = ParseFile([]byte(`
+		test "a" {
+			input {
+				component = "c"
+				log { line = "a" }
+			}
+		}
+		test "a" {
+			input {
+				component = "c"
+				log { line = "a" }
+			}
+		}
+	`))
+	require.ErrorContains(t, err, `test "a" is defined more than once`)
+
+	_, err = ParseFile([]byte(`test "a" { }`))
+	require.ErrorContains(t, err, "must have at least one input block")
+
+	_, err = ParseFile([]byte(`
+		test "a" {
+			input {
+				component = "c"
+				otlp_logs = "{"
+			}
+		}
+	`))
+	require.ErrorContains(t, err, "invalid otlp_logs")
+}
+
+func testOptions() Options {
+	return Options{
+		Sources:           map[string][]byte{"config.alloy": []byte(testConfig)},
+		ComponentRegistry: testRegistry{component.NewDefaultRegistry(featuregate.StabilityExperimental, false)},
+		MinStability:      featuregate.StabilityExperimental,
+	}
+}
+
+// testRegistry adds testcomponents.otlp_passthrough to a registry.
+type testRegistry struct {
+	component.Registry
+}
+
+func (r testRegistry) Get(name string) (component.Registration, error) {
+	if name != "testcomponents.otlp_passthrough" {
+		return r.Registry.Get(name)
+	}
+	return component.Registration{
+		Name:      name,
+		Stability: featuregate.StabilityExperimental,
+		Args:      passthroughArgs{},
+		Exports:   otelcol.ConsumerExports{},
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			output := args.(passthroughArgs).Output
+			opts.OnStateChange(otelcol.ConsumerExports{Input: passthrough{output}})
+			return passthroughComponent{}, nil
+		},
+	}, nil
+}
+
+type passthroughArgs struct {
+	Output *otelcol.ConsumerArguments `alloy:"output,block"`
+}
+
+type passthroughComponent struct{}
+
+func (passthroughComponent) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+func (passthroughComponent) Update(component.Arguments) error { return nil }
+
+// passthrough forwards logs to the logs consumers of an output block. Other
+// signals are dropped.
+type passthrough struct {
+	output *otelcol.ConsumerArguments
+}
+
+func (passthrough) Capabilities() otelconsumer.Capabilities {
+	return otelconsumer.Capabilities{}
+}
+
+func (p passthrough) ConsumeLogs(ctx context.Context, ld plog.Logs) error {
+	for _, c := range p.output.Logs {
+		if err := c.ConsumeLogs(ctx, ld); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (passthrough) ConsumeMetrics(context.Context, pmetric.Metrics) error { return nil }
+
+func (passthrough) ConsumeTraces(context.Context, ptrace.Traces) error { return nil }
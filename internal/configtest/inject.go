@@ -0,0 +1,189 @@
+package configtest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/grafana/loki/pkg/push"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/component/common/loki"
+	"github.com/grafana/alloy/internal/component/otelcol"
+	alloy_runtime "github.com/grafana/alloy/internal/runtime"
+)
+
+// inject sends the data of in to the exports of its component.
+func inject(ctx context.Context, f *alloy_runtime.Runtime, in Input) error {
+	info, err := f.GetComponent(component.ParseID(in.Component), component.InfoOptions{GetExports: true})
+	if err != nil {
+		return err
+	}
+
+	d := in.Data
+	if len(d.Logs) > 0 || len(d.Samples) > 0 {
+		v, err := lookupExport(info.Exports, in.Export, "receiver")
+		if err != nil {
+			return err
+		}
+		if len(d.Logs) > 0 {
+			if err := injectLogs(ctx, v, d.Logs); err != nil {
+				return err
+			}
+		}
+		if len(d.Samples) > 0 {
+			if err := injectSamples(ctx, v, d.Samples); err != nil {
+				return err
+			}
+		}
+	}
+
+	if d.OTLPLogs != "" || d.OTLPMetrics != "" || d.OTLPTraces != "" {
+		v, err := lookupExport(info.Exports, in.Export, "input")
+		if err != nil {
+			return err
+		}
+		if err := injectOTLP(ctx, v, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lookupExport returns the value of the export name of a component, or of
+// the export defaultName if name is empty.
+func lookupExport(exports component.Exports, name, defaultName string) (any, error) {
+	if name == "" {
+		name = defaultName
+	}
+
+	switch v := reflect.ValueOf(exports); {
+	case !v.IsValid():
+		return nil, fmt.Errorf("component has no exports")
+
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+		// Custom components export a map.
+		if e := v.MapIndex(reflect.ValueOf(name)); e.IsValid() {
+			return e.Interface(), nil
+		}
+
+	case v.Kind() == reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			tag, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("alloy"), ",")
+			if tag == name {
+				return v.Field(i).Interface(), nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("component does not export %q", name)
+}
+
+func injectLogs(ctx context.Context, v any, entries []LogEntry) error {
+	receiver, ok := v.(loki.LogsReceiver)
+	if !ok {
+		return fmt.Errorf("export is not a logs receiver")
+	}
+
+	for _, e := range entries {
+		ts := e.Timestamp
+		if ts.IsZero() {
+			ts = time.Now()
+		}
+		entry := loki.Entry{
+			Labels: toLabelSet(e.Labels),
+			Entry: logproto.Entry{
+				Timestamp:          ts,
+				Line:               e.Line,
+				StructuredMetadata: toLabelsAdapter(e.StructuredMetadata),
+			},
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case receiver.Chan() <- entry:
+		}
+	}
+	return nil
+}
+
+func injectSamples(ctx context.Context, v any, samples []Sample) error {
+	appendable, ok := v.(storage.Appendable)
+	if !ok {
+		return fmt.Errorf("export is not a metrics receiver")
+	}
+
+	app := appendable.Appender(ctx)
+	for _, s := range samples {
+		ts := s.Timestamp
+		if ts.IsZero() {
+			ts = time.Now()
+		}
+		if _, err := app.Append(0, labels.FromMap(s.Labels), ts.UnixMilli(), s.Value); err != nil {
+			_ = app.Rollback()
+			return err
+		}
+	}
+	return app.Commit()
+}
+
+func injectOTLP(ctx context.Context, v any, d Data) error {
+	consumer, ok := v.(otelcol.Consumer)
+	if !ok {
+		return fmt.Errorf("export is not an OpenTelemetry consumer")
+	}
+
+	if d.OTLPLogs != "" {
+		ld, err := parseOTLPLogs(d.OTLPLogs)
+		if err != nil {
+			return err
+		}
+		if err := consumer.ConsumeLogs(ctx, ld); err != nil {
+			return err
+		}
+	}
+	if d.OTLPMetrics != "" {
+		md, err := parseOTLPMetrics(d.OTLPMetrics)
+		if err != nil {
+			return err
+		}
+		if err := consumer.ConsumeMetrics(ctx, md); err != nil {
+			return err
+		}
+	}
+	if d.OTLPTraces != "" {
+		td, err := parseOTLPTraces(d.OTLPTraces)
+		if err != nil {
+			return err
+		}
+		if err := consumer.ConsumeTraces(ctx, td); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func toLabelSet(m map[string]string) model.LabelSet {
+	set := make(model.LabelSet, len(m))
+	for k, v := range m {
+		set[model.LabelName(k)] = model.LabelValue(v)
+	}
+	return set
+}
+
+func toLabelsAdapter(m map[string]string) push.LabelsAdapter {
+	if len(m) == 0 {
+		return nil
+	}
+	adapter := make(push.LabelsAdapter, 0, len(m))
+	labels.FromMap(m).Range(func(l labels.Label) {
+		adapter = append(adapter, push.LabelAdapter{Name: l.Name, Value: l.Value})
+	})
+	return adapter
+}
@@ -0,0 +1,285 @@
+package configtest
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"github.com/prometheus/prometheus/model/exemplar"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/metadata"
+	"github.com/prometheus/prometheus/storage"
+	otelconsumer "go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/component/common/loki"
+	"github.com/grafana/alloy/internal/component/otelcol"
+)
+
+// mockRegistry is a component registry which builds mocks instead of the
+// components whose ID is in mocks.
+type mockRegistry struct {
+	inner component.Registry
+	mocks map[string]*capture
+}
+
+var _ component.Registry = (*mockRegistry)(nil)
+
+// Get implements component.Registry.
+func (r *mockRegistry) Get(name string) (component.Registration, error) {
+	reg, err := r.inner.Get(name)
+	if err != nil {
+		return reg, err
+	}
+
+	// The arguments of mocks are still decoded into the arguments of the
+	// original component so that the configuration is checked.
+	build, exports := reg.Build, reg.Exports
+	reg.Build = func(opts component.Options, args component.Arguments) (component.Component, error) {
+		c, ok := r.mocks[opts.ID]
+		if !ok {
+			return build(opts, args)
+		}
+		return newMock(opts, exports, c), nil
+	}
+	return reg, nil
+}
+
+// mock is a component which captures the data sent to its exports.
+type mock struct {
+	capture   *capture
+	receivers []loki.LogsReceiver
+}
+
+var _ component.Component = (*mock)(nil)
+
+// newMock creates a mock with the exports of the original component. Exports
+// which receive telemetry are replaced with receivers writing to c.
+func newMock(opts component.Options, exports component.Exports, c *capture) *mock {
+	m := &mock{capture: c}
+	c.setBuilt()
+	if exports == nil {
+		return m
+	}
+
+	v := reflect.New(reflect.TypeOf(exports)).Elem()
+	if v.Kind() == reflect.Struct {
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Field(i)
+			if !field.CanSet() {
+				continue
+			}
+			switch field.Type() {
+			case reflect.TypeOf((*loki.LogsReceiver)(nil)).Elem():
+				receiver := loki.NewLogsReceiver()
+				m.receivers = append(m.receivers, receiver)
+				field.Set(reflect.ValueOf(receiver))
+			case reflect.TypeOf((*storage.Appendable)(nil)).Elem():
+				field.Set(reflect.ValueOf(captureAppendable{c}))
+			case reflect.TypeOf((*otelcol.Consumer)(nil)).Elem():
+				field.Set(reflect.ValueOf(captureConsumer{c}))
+			}
+		}
+	}
+	opts.OnStateChange(v.Interface())
+	return m
+}
+
+// Run implements component.Component.
+func (m *mock) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for _, receiver := range m.receivers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case entry := <-receiver.Chan():
+					m.capture.addLog(entry)
+				}
+			}
+		}()
+	}
+	<-ctx.Done()
+	wg.Wait()
+	return nil
+}
+
+// Update implements component.Component.
+func (m *mock) Update(component.Arguments) error { return nil }
+
+// capture holds the data received by a mock.
+type capture struct {
+	mut         sync.Mutex
+	built       bool
+	logs        []loki.Entry
+	samples     []capturedSample
+	otlpLogs    plog.Logs
+	otlpMetrics pmetric.Metrics
+	otlpTraces  ptrace.Traces
+}
+
+type capturedSample struct {
+	labels    labels.Labels
+	timestamp int64
+	value     float64
+}
+
+func newCapture() *capture {
+	return &capture{
+		otlpLogs:    plog.NewLogs(),
+		otlpMetrics: pmetric.NewMetrics(),
+		otlpTraces:  ptrace.NewTraces(),
+	}
+}
+
+func (c *capture) setBuilt() {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.built = true
+}
+
+func (c *capture) isBuilt() bool {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	return c.built
+}
+
+func (c *capture) addLog(entry loki.Entry) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.logs = append(c.logs, entry)
+}
+
+func (c *capture) addSamples(samples []capturedSample) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.samples = append(c.samples, samples...)
+}
+
+// counts returns the number of log lines, samples, OTLP log records, OTLP
+// data points, and OTLP spans captured so far.
+func (c *capture) counts() counts {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	return counts{
+		logs:        len(c.logs),
+		samples:     len(c.samples),
+		otlpLogs:    c.otlpLogs.LogRecordCount(),
+		otlpMetrics: c.otlpMetrics.DataPointCount(),
+		otlpTraces:  c.otlpTraces.SpanCount(),
+	}
+}
+
+type counts struct {
+	logs, samples, otlpLogs, otlpMetrics, otlpTraces int
+}
+
+// covers reports whether every count of c is at least the count of o.
+func (c counts) covers(o counts) bool {
+	return c.logs >= o.logs &&
+		c.samples >= o.samples &&
+		c.otlpLogs >= o.otlpLogs &&
+		c.otlpMetrics >= o.otlpMetrics &&
+		c.otlpTraces >= o.otlpTraces
+}
+
+// captureAppendable is a storage.Appendable whose appenders write committed
+// float samples to a capture.
+type captureAppendable struct{ c *capture }
+
+func (a captureAppendable) Appender(context.Context) storage.Appender {
+	return &captureAppender{c: a.c}
+}
+
+type captureAppender struct {
+	c       *capture
+	samples []capturedSample
+}
+
+var _ storage.Appender = (*captureAppender)(nil)
+
+func (a *captureAppender) Append(ref storage.SeriesRef, l labels.Labels, t int64, v float64) (storage.SeriesRef, error) {
+	a.samples = append(a.samples, capturedSample{labels: l.Copy(), timestamp: t, value: v})
+	return ref, nil
+}
+
+func (a *captureAppender) Commit() error {
+	a.c.addSamples(a.samples)
+	a.samples = nil
+	return nil
+}
+
+func (a *captureAppender) Rollback() error {
+	a.samples = nil
+	return nil
+}
+
+func (a *captureAppender) AppendExemplar(ref storage.SeriesRef, _ labels.Labels, _ exemplar.Exemplar) (storage.SeriesRef, error) {
+	return ref, nil
+}
+
+func (a *captureAppender) AppendHistogram(ref storage.SeriesRef, _ labels.Labels, _ int64, _ *histogram.Histogram, _ *histogram.FloatHistogram) (storage.SeriesRef, error) {
+	return ref, nil
+}
+
+func (a *captureAppender) UpdateMetadata(ref storage.SeriesRef, _ labels.Labels, _ metadata.Metadata) (storage.SeriesRef, error) {
+	return ref, nil
+}
+
+func (a *captureAppender) AppendCTZeroSample(ref storage.SeriesRef, _ labels.Labels, _, _ int64) (storage.SeriesRef, error) {
+	return ref, nil
+}
+
+func (a *captureAppender) AppendHistogramCTZeroSample(ref storage.SeriesRef, _ labels.Labels, _, _ int64, _ *histogram.Histogram, _ *histogram.FloatHistogram) (storage.SeriesRef, error) {
+	return ref, nil
+}
+
+func (a *captureAppender) SetOptions(*storage.AppendOptions) {}
+
+// captureConsumer is an otelcol.Consumer which writes the data it consumes to
+// a capture. The consumed data may be shared with other consumers, so it's
+// copied before being moved to the capture.
+type captureConsumer struct{ c *capture }
+
+var _ otelcol.Consumer = captureConsumer{}
+
+func (captureConsumer) Capabilities() otelconsumer.Capabilities {
+	return otelconsumer.Capabilities{MutatesData: false}
+}
+
+func (cc captureConsumer) ConsumeLogs(_ context.Context, ld plog.Logs) error {
+	copied := plog.NewLogs()
+	ld.CopyTo(copied)
+
+	cc.c.mut.Lock()
+	defer cc.c.mut.Unlock()
+	copied.ResourceLogs().MoveAndAppendTo(cc.c.otlpLogs.ResourceLogs())
+	return nil
+}
+
+func (cc captureConsumer) ConsumeMetrics(_ context.Context, md pmetric.Metrics) error {
+	copied := pmetric.NewMetrics()
+	md.CopyTo(copied)
+
+	cc.c.mut.Lock()
+	defer cc.c.mut.Unlock()
+	copied.ResourceMetrics().MoveAndAppendTo(cc.c.otlpMetrics.ResourceMetrics())
+	return nil
+}
+
+func (cc captureConsumer) ConsumeTraces(_ context.Context, td ptrace.Traces) error {
+	copied := ptrace.NewTraces()
+	td.CopyTo(copied)
+
+	cc.c.mut.Lock()
+	defer cc.c.mut.Unlock()
+	copied.ResourceSpans().MoveAndAppendTo(cc.c.otlpTraces.ResourceSpans())
+	return nil
+}
@@ -0,0 +1,197 @@
+package configtest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/featuregate"
+	alloy_runtime "github.com/grafana/alloy/internal/runtime"
+	"github.com/grafana/alloy/internal/runtime/logging"
+	"github.com/grafana/alloy/internal/service"
+	"github.com/grafana/alloy/internal/service/labelstore"
+	"github.com/grafana/alloy/internal/service/livedebugging"
+	otel_service "github.com/grafana/alloy/internal/service/otel"
+)
+
+// quietPeriod is how long a test keeps waiting after the expected amount of
+// data was received, so that unexpected extra data is caught.
+const quietPeriod = 250 * time.Millisecond
+
+// Options configure how tests are run.
+type Options struct {
+	// Sources are the files of the configuration to test, keyed by name.
+	Sources map[string][]byte
+
+	// ConfigPath is the path of the configuration, used to resolve relative
+	// paths of imports.
+	ConfigPath string
+
+	// ComponentRegistry is used to look up components. If nil, the components
+	// registered to github.com/grafana/alloy/internal/component are used,
+	// restricted by MinStability and EnableCommunityComps.
+	ComponentRegistry component.Registry
+
+	MinStability         featuregate.Stability
+	EnableCommunityComps bool
+
+	// Logger receives the logs of the components under test. Logs are
+	// discarded if Logger is nil.
+	Logger *logging.Logger
+}
+
+// Result is the result of a test.
+type Result struct {
+	Name     string
+	Duration time.Duration
+
+	// Failures describes the differences between the expected data and the
+	// data received by the mocked components.
+	Failures []string
+
+	// Err is set if the test couldn't run, for example because the
+	// configuration doesn't load.
+	Err error
+}
+
+// Passed reports whether the test passed.
+func (r Result) Passed() bool {
+	return r.Err == nil && len(r.Failures) == 0
+}
+
+// Run runs a single test against the configuration of opts.
+func Run(ctx context.Context, opts Options, t Test) Result {
+	start := time.Now()
+	failures, err := run(ctx, opts, t)
+	return Result{
+		Name:     t.Name,
+		Duration: time.Since(start),
+		Failures: failures,
+		Err:      err,
+	}
+}
+
+func run(ctx context.Context, opts Options, t Test) ([]string, error) {
+	timeout := t.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	mocks := make(map[string]*capture)
+	for _, id := range t.Mocks {
+		mocks[id] = newCapture()
+	}
+	for _, e := range t.Expects {
+		mocks[e.Component] = newCapture()
+	}
+	for _, in := range t.Inputs {
+		if _, ok := mocks[in.Component]; ok {
+			return nil, fmt.Errorf("cannot send input to mocked component %q", in.Component)
+		}
+	}
+
+	inner := opts.ComponentRegistry
+	if inner == nil {
+		inner = component.NewDefaultRegistry(opts.MinStability, opts.EnableCommunityComps)
+	}
+
+	logger := opts.Logger
+	if logger == nil {
+		logger = logging.NewNop()
+	}
+
+	dataPath, err := os.MkdirTemp("", "alloy-test-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dataPath)
+
+	reg := prometheus.NewRegistry()
+	f := alloy_runtime.NewWithComponentRegistry(alloy_runtime.Options{
+		Logger:               logger,
+		DataPath:             dataPath,
+		Reg:                  reg,
+		MinStability:         opts.MinStability,
+		EnableCommunityComps: opts.EnableCommunityComps,
+		Services: []service.Service{
+			labelstore.New(logger, reg),
+			livedebugging.New(),
+			otel_service.New(logger),
+		},
+	}, &mockRegistry{inner: inner, mocks: mocks})
+
+	source, err := alloy_runtime.ParseSources(opts.Sources)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.LoadSource(source, nil, opts.ConfigPath); err != nil {
+		return nil, err
+	}
+	for id, c := range mocks {
+		if !c.isBuilt() {
+			return nil, fmt.Errorf("mocked component %q does not exist", id)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		f.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	for _, in := range t.Inputs {
+		if err := inject(ctx, f, in); err != nil {
+			return nil, fmt.Errorf("sending input to %q: %w", in.Component, err)
+		}
+	}
+
+	wait(ctx, t.Expects, mocks)
+
+	var failures []string
+	for _, e := range t.Expects {
+		for _, failure := range compare(e.Data, mocks[e.Component]) {
+			failures = append(failures, fmt.Sprintf("%s: %s", e.Component, failure))
+		}
+	}
+	return failures, nil
+}
+
+// wait waits until the mocks received at least as much data as expected and
+// for the quiet period after that, or until ctx is done.
+func wait(ctx context.Context, expects []Expect, mocks map[string]*capture) {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	for !received(expects, mocks) {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(quietPeriod):
+	}
+}
+
+func received(expects []Expect, mocks map[string]*capture) bool {
+	for _, e := range expects {
+		if !mocks[e.Component].counts().covers(e.Data.counts()) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,152 @@
+package lsp
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/grafana/alloy/internal/nodeconf/argument"
+	"github.com/grafana/alloy/syntax/token"
+	"github.com/grafana/alloy/syntax/typecheck"
+)
+
+// complete returns the completions for the name being typed at offset.
+//
+// The names of blocks and attributes are proposed at the start of
+// statements, and references to components and their exports are proposed
+// in expressions.
+func (s *Server) complete(doc *document, offset int) []CompletionItem {
+	o := doc.outline
+
+	// Don't propose anything in comments and strings.
+	if t, ok := o.tokenAt(offset - 1); ok {
+		if t.tok == token.COMMENT || (t.tok == token.STRING && offset < t.end()) {
+			return []CompletionItem{}
+		}
+	}
+
+	start := offset
+	for start > 0 && isNameByte(doc.text[start-1]) {
+		start--
+	}
+	var (
+		prefix = string(doc.text[start:offset])
+		edit   = doc.rangeOf(start, offset)
+		state  = o.stateAt(start)
+	)
+
+	var items []CompletionItem
+	switch {
+	case state.inExpression():
+		items = s.completeReference(state.block, prefix)
+	case isModuleBody(state.block):
+		items = s.completeTopLevel(state.block)
+	default:
+		items = s.completeField(state.block)
+	}
+
+	for i := range items {
+		items[i].TextEdit = &TextEdit{Range: edit, NewText: items[i].Label}
+	}
+	return items
+}
+
+// isNameByte reports whether c may be part of a block name or of a
+// reference.
+func isNameByte(c byte) bool {
+	return c == '_' || c == '.' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+// completeTopLevel returns the blocks which may be used in the module body of
+// b.
+func (s *Server) completeTopLevel(b *blockInfo) []CompletionItem {
+	names := s.schema.topLevelNames(b)
+	items := make([]CompletionItem, 0, len(names))
+	for _, name := range names {
+		item := CompletionItem{Label: name, Kind: completionKindKeyword, Detail: "block"}
+		if _, ok := s.schema.component(name); ok {
+			item.Kind, item.Detail = completionKindClass, "component"
+		}
+		items = append(items, item)
+	}
+
+	// Custom components declared in the document.
+	for scope := b; scope != nil; scope = scope.parent {
+		for _, child := range scope.children {
+			if child.name == declareBlockName && child.label != "" {
+				items = append(items, CompletionItem{Label: child.label, Kind: completionKindModule, Detail: "custom component"})
+			}
+		}
+	}
+	return items
+}
+
+// completeField returns the attributes and blocks which may be set in the
+// body of b.
+func (s *Server) completeField(b *blockInfo) []CompletionItem {
+	if t, ok := s.schema.argumentsType(b); ok {
+		fields := typecheck.Fields(t)
+		items := make([]CompletionItem, 0, len(fields))
+		for _, f := range fields {
+			item := CompletionItem{Label: f.Name, Kind: completionKindProperty, Detail: f.TypeName()}
+			if f.Block {
+				item.Kind = completionKindStruct
+			}
+			items = append(items, item)
+		}
+		return items
+	}
+
+	if isModuleBody(b.parent) {
+		if declare, ok := findDeclare(b); ok {
+			args := declareArguments(declare)
+			items := make([]CompletionItem, 0, len(args))
+			for _, arg := range args {
+				items = append(items, CompletionItem{Label: arg.label, Kind: completionKindProperty, Detail: "argument"})
+			}
+			return items
+		}
+	}
+	return []CompletionItem{}
+}
+
+// completeReference returns the components visible from scope and, if
+// prefix names one of them, its exports.
+func (s *Server) completeReference(scope *blockInfo, prefix string) []CompletionItem {
+	var items []CompletionItem
+	for b := scope; b != nil; b = b.parent {
+		if !isModuleBody(b) {
+			continue
+		}
+		for _, child := range b.children {
+			switch {
+			case child.label == "":
+			case child.name == argument.BlockName:
+				items = append(items, CompletionItem{Label: child.id() + ".value", Kind: completionKindVariable, Detail: "argument"})
+			case child.name == declareBlockName:
+			default:
+				if _, ok := configBlocks[child.name]; ok {
+					continue
+				}
+				items = append(items, CompletionItem{Label: child.id(), Kind: completionKindVariable, Detail: child.name})
+			}
+		}
+	}
+
+	// Propose the exports of the referenced component once its name has been
+	// typed.
+	if i := strings.LastIndexByte(prefix, '.'); i > 0 {
+		id := prefix[:i]
+		if b, ok := findBlock(scope, id); ok {
+			if reg, ok := s.schema.component(b.name); ok && reg.Exports != nil {
+				for _, f := range typecheck.Fields(reflect.TypeOf(reg.Exports)) {
+					items = append(items, CompletionItem{Label: id + "." + f.Name, Kind: completionKindField, Detail: f.TypeName()})
+				}
+			}
+		}
+	}
+
+	if items == nil {
+		return []CompletionItem{}
+	}
+	return items
+}
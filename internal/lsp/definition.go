@@ -0,0 +1,31 @@
+package lsp
+
+// definition returns the location of the block referenced by the symbol at
+// offset. References to components resolve to the component blocks, and the
+// names of custom components resolve to their declare blocks.
+func (s *Server) definition(doc *document, offset int) (Location, bool) {
+	sym, ok := doc.outline.symbolAt(offset)
+	if !ok {
+		return Location{}, false
+	}
+
+	var target *blockInfo
+	switch sym.kind {
+	case symbolBlock:
+		if isModuleBody(sym.block.parent) {
+			target, ok = findDeclare(sym.block)
+		}
+	case symbolReference:
+		target, _, ok = resolveReference(sym.block, sym.names)
+	default:
+		ok = false
+	}
+	if !ok {
+		return Location{}, false
+	}
+
+	return Location{
+		URI:   doc.uri,
+		Range: doc.rangeOf(target.nameStart, target.nameEnd),
+	}, true
+}
@@ -0,0 +1,118 @@
+package lsp
+
+import (
+	"bytes"
+	"net/url"
+	"path/filepath"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// document is a text document opened by the client.
+type document struct {
+	uri     string
+	path    string
+	version int
+	text    []byte
+	outline *outline
+}
+
+func newDocument(uri string, version int, text []byte) *document {
+	return &document{
+		uri:     uri,
+		path:    uriToPath(uri),
+		version: version,
+		text:    text,
+		outline: newOutline(text),
+	}
+}
+
+// applyChange applies a change sent by the client to the text of the
+// document.
+func (d *document) applyChange(change textDocumentContentChangeEvent) {
+	if change.Range == nil {
+		d.text = []byte(change.Text)
+	} else {
+		start, end := d.offsetAt(change.Range.Start), d.offsetAt(change.Range.End)
+		if end < start {
+			start, end = end, start
+		}
+
+		text := make([]byte, 0, len(d.text)-(end-start)+len(change.Text))
+		text = append(text, d.text[:start]...)
+		text = append(text, change.Text...)
+		text = append(text, d.text[end:]...)
+		d.text = text
+	}
+	d.outline = newOutline(d.text)
+}
+
+// offsetAt returns the byte offset of pos. Positions past the end of a line
+// or of the document are clamped.
+func (d *document) offsetAt(pos Position) int {
+	offset := 0
+	for line := 0; line < pos.Line; line++ {
+		i := bytes.IndexByte(d.text[offset:], '\n')
+		if i < 0 {
+			return len(d.text)
+		}
+		offset += i + 1
+	}
+
+	for units := 0; units < pos.Character && offset < len(d.text); {
+		r, size := utf8.DecodeRune(d.text[offset:])
+		if r == '\n' {
+			break
+		}
+		units += runeUnits(r)
+		offset += size
+	}
+	return offset
+}
+
+// positionAt returns the position of the byte at offset.
+func (d *document) positionAt(offset int) Position {
+	offset = min(max(offset, 0), len(d.text))
+
+	var pos Position
+	for i := 0; i < offset; {
+		r, size := utf8.DecodeRune(d.text[i:])
+		if r == '\n' {
+			pos.Line++
+			pos.Character = 0
+		} else {
+			pos.Character += runeUnits(r)
+		}
+		i += size
+	}
+	return pos
+}
+
+// rangeOf returns the range of the bytes in [start, end).
+func (d *document) rangeOf(start, end int) Range {
+	return Range{Start: d.positionAt(start), End: d.positionAt(end)}
+}
+
+// runeUnits returns the number of UTF-16 code units encoding r.
+func runeUnits(r rune) int {
+	if utf16.IsSurrogate(r) || r < 0x10000 {
+		return 1
+	}
+	return 2
+}
+
+// uriToPath returns the file path of a file URI. Other URIs are returned
+// unchanged so that they can still be used to name documents.
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+
+	path := u.Path
+	// Windows paths are sent as file:///C:/path.
+	if len(path) >= 3 && path[0] == '/' && path[2] == ':' {
+		path = path[1:]
+	}
+	return filepath.FromSlash(path)
+}
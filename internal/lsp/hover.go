@@ -0,0 +1,190 @@
+package lsp
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/syntax"
+	"github.com/grafana/alloy/syntax/typecheck"
+)
+
+// docsURL is the URL of the reference documentation of components.
+const docsURL = "https://grafana.com/docs/alloy/latest/reference/components/"
+
+// hover returns the documentation of the symbol at offset.
+func (s *Server) hover(doc *document, offset int) (hover, bool) {
+	sym, ok := doc.outline.symbolAt(offset)
+	if !ok {
+		return hover{}, false
+	}
+
+	var text string
+	switch sym.kind {
+	case symbolBlock:
+		text = s.describeBlock(sym.block)
+	case symbolAttribute:
+		text = s.describeAttribute(sym.block, strings.Join(sym.names, "."))
+	case symbolReference:
+		text = s.describeReference(sym.block, sym.names)
+	}
+	if text == "" {
+		return hover{}, false
+	}
+
+	r := doc.rangeOf(sym.start, sym.end)
+	return hover{
+		Contents: markupContent{Kind: "markdown", Value: text},
+		Range:    &r,
+	}, true
+}
+
+// describeBlock documents the block b.
+func (s *Server) describeBlock(b *blockInfo) string {
+	var sb strings.Builder
+
+	if isModuleBody(b.parent) {
+		if reg, ok := s.schema.component(b.name); ok {
+			writeComponent(&sb, reg)
+		} else if declare, ok := findDeclare(b); ok {
+			fmt.Fprintf(&sb, "**%s** custom component\n", b.name)
+			if args := declareArguments(declare); len(args) > 0 {
+				sb.WriteString("\nArguments:\n\n")
+				for _, arg := range args {
+					fmt.Fprintf(&sb, "- `%s`\n", arg.label)
+				}
+			}
+			return sb.String()
+		} else {
+			fmt.Fprintf(&sb, "**%s** block\n", b.name)
+		}
+	} else {
+		parent, ok := s.schema.argumentsType(b.parent)
+		if !ok {
+			return ""
+		}
+		f, ok := lookupField(parent, b.name)
+		if !ok {
+			return ""
+		}
+		fmt.Fprintf(&sb, "**%s** block (%s)\n", b.name, requirement(f))
+	}
+
+	if t, ok := s.schema.argumentsType(b); ok {
+		writeFields(&sb, "Arguments", t, true)
+	}
+	return sb.String()
+}
+
+// describeAttribute documents the attribute name set in the body of b.
+func (s *Server) describeAttribute(b *blockInfo, name string) string {
+	t, ok := s.schema.argumentsType(b)
+	if !ok {
+		if declare, ok := findDeclare(b); ok && isModuleBody(b.parent) {
+			for _, arg := range declareArguments(declare) {
+				if arg.label == name {
+					return fmt.Sprintf("`%s` argument of custom component **%s**", name, b.name)
+				}
+			}
+		}
+		return ""
+	}
+
+	f, ok := lookupField(t, name)
+	if !ok || f.Block {
+		return ""
+	}
+	return describeField(f)
+}
+
+// describeReference documents the component referenced by names and the
+// export accessed by the reference, if any.
+func (s *Server) describeReference(scope *blockInfo, names []string) string {
+	b, n, ok := resolveReference(scope, names)
+	if !ok {
+		return ""
+	}
+	reg, ok := s.schema.component(b.name)
+	if !ok {
+		return fmt.Sprintf("**%s** %s", b.id(), b.name)
+	}
+
+	var sb strings.Builder
+	if n < len(names) && reg.Exports != nil {
+		if f, ok := lookupField(reflect.TypeOf(reg.Exports), names[n]); ok {
+			fmt.Fprintf(&sb, "`%s` %s\n\nExported by **%s**.\n\n", f.Name, f.TypeName(), b.id())
+		}
+	}
+	writeComponent(&sb, reg)
+	return sb.String()
+}
+
+// writeComponent writes the documentation of a component.
+func writeComponent(sb *strings.Builder, reg component.Registration) {
+	fmt.Fprintf(sb, "**%s** component\n", reg.Name)
+	if reg.Stability != featuregate.StabilityGenerallyAvailable {
+		// Stability levels are formatted with quotes.
+		fmt.Fprintf(sb, "\nStability: %s\n", strings.Trim(reg.Stability.String(), `"`))
+	}
+	if reg.Community {
+		sb.WriteString("\nCommunity component\n")
+	}
+
+	namespace, _, _ := strings.Cut(reg.Name, ".")
+	fmt.Fprintf(sb, "\n[Documentation](%s%s/%s/)\n", docsURL, namespace, reg.Name)
+
+	if reg.Exports != nil {
+		writeFields(sb, "Exports", reflect.TypeOf(reg.Exports), false)
+	}
+}
+
+// writeFields writes the list of the fields of t under a title. Whether
+// fields are required is only meaningful for arguments.
+func writeFields(sb *strings.Builder, title string, t reflect.Type, arguments bool) {
+	fields := typecheck.Fields(t)
+	if len(fields) == 0 {
+		return
+	}
+
+	fmt.Fprintf(sb, "\n%s:\n\n", title)
+	for _, f := range fields {
+		if arguments {
+			fmt.Fprintf(sb, "- `%s` %s (%s)\n", f.Name, f.TypeName(), requirement(f))
+		} else {
+			fmt.Fprintf(sb, "- `%s` %s\n", f.Name, f.TypeName())
+		}
+	}
+}
+
+// describeField documents an attribute.
+func describeField(f typecheck.Field) string {
+	text := fmt.Sprintf("`%s` %s (%s)", f.Name, f.TypeName(), requirement(f))
+	if f.Default != nil {
+		if value, err := syntax.MarshalValue(f.Default); err == nil {
+			text += fmt.Sprintf("\n\nDefault: `%s`", value)
+		}
+	}
+	return text
+}
+
+func requirement(f typecheck.Field) string {
+	if f.Optional {
+		return "optional"
+	}
+	return "required"
+}
+
+// resolveReference returns the block referenced by the first n names, using
+// the longest prefix of names which identifies a labeled block visible from
+// scope.
+func resolveReference(scope *blockInfo, names []string) (b *blockInfo, n int, ok bool) {
+	for n := len(names); n > 0; n-- {
+		b, ok := findBlock(scope, strings.Join(names[:n], "."))
+		if ok && b.label != "" {
+			return b, n, true
+		}
+	}
+	return nil, 0, false
+}
@@ -0,0 +1,133 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// JSON-RPC error codes used by the server.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+
+	// codeServerNotInitialized is returned for requests received before the
+	// initialize request.
+	codeServerNotInitialized = -32002
+)
+
+// request is an incoming JSON-RPC request or notification. Notifications
+// don't have an ID.
+type request struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+func (r *request) isNotification() bool { return len(r.ID) == 0 }
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *responseError  `json:"error,omitempty"`
+}
+
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *responseError) Error() string { return e.Message }
+
+type notification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+// conn reads and writes JSON-RPC messages framed with the base protocol of
+// LSP: every message is preceded by a Content-Length header.
+type conn struct {
+	r *bufio.Reader
+
+	mut sync.Mutex
+	w   io.Writer
+}
+
+func newConn(r io.Reader, w io.Writer) *conn {
+	return &conn{r: bufio.NewReader(r), w: w}
+}
+
+// Read reads the content of the next message.
+func (c *conn) Read() ([]byte, error) {
+	header, err := textproto.NewReader(c.r).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	value := header.Get("Content-Length")
+	if value == "" {
+		return nil, fmt.Errorf("missing Content-Length header")
+	}
+	length, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid Content-Length header %q", value)
+	}
+
+	content := make([]byte, length)
+	if _, err := io.ReadFull(c.r, content); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// Write writes v as the content of a message. It's safe to call Write from
+// multiple goroutines.
+func (c *conn) Write(v any) error {
+	content, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if _, err := fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n", len(content)); err != nil {
+		return err
+	}
+	_, err = c.w.Write(content)
+	return err
+}
+
+// Reply writes the response to the request with the given ID. err is
+// reported to the client instead of result if it's non-nil.
+func (c *conn) Reply(id json.RawMessage, result any, err error) error {
+	resp := response{JSONRPC: "2.0", ID: id}
+	if err != nil {
+		rerr, ok := err.(*responseError)
+		if !ok {
+			rerr = &responseError{Code: codeInvalidRequest, Message: err.Error()}
+		}
+		resp.Error = rerr
+	} else {
+		raw, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		resp.Result = raw
+	}
+	return c.Write(resp)
+}
+
+// Notify sends a notification to the client.
+func (c *conn) Notify(method string, params any) error {
+	return c.Write(notification{JSONRPC: "2.0", Method: method, Params: params})
+}
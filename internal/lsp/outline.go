@@ -0,0 +1,307 @@
+package lsp
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/alloy/syntax/scanner"
+	"github.com/grafana/alloy/syntax/token"
+)
+
+// outline is the structure of a document: its blocks, the names of its
+// attributes and the references in its expressions.
+//
+// The outline is built from the tokens of the document rather than from its
+// AST so that it's available while the document is being edited and doesn't
+// parse.
+type outline struct {
+	tokens  []tokenInfo
+	states  []walkState // states[i] is the state of the walk before tokens[i].
+	root    *blockInfo
+	symbols []symbol // Sorted by offset.
+}
+
+type tokenInfo struct {
+	offset int
+	tok    token.Token
+	lit    string
+}
+
+func (t tokenInfo) end() int { return t.offset + len(t.lit) }
+
+// blockInfo is a block of the document. The root block holds the top-level
+// statements of the document and doesn't have a name.
+type blockInfo struct {
+	name      string // Name of the block, like "prometheus.scrape".
+	label     string // Unquoted label of the block.
+	nameStart int    // Offset of the name.
+	nameEnd   int    // Offset after the name.
+	parent    *blockInfo
+	children  []*blockInfo
+}
+
+// id returns the name of the block followed by its label, like
+// "prometheus.scrape.default".
+func (b *blockInfo) id() string {
+	if b.label == "" {
+		return b.name
+	}
+	return b.name + "." + b.label
+}
+
+type symbolKind int
+
+const (
+	symbolBlock     symbolKind = iota // Name of a block.
+	symbolAttribute                   // Name of an attribute.
+	symbolReference                   // Identifier followed by field accesses in an expression.
+)
+
+// symbol is a name in the document.
+type symbol struct {
+	kind  symbolKind
+	names []string
+	start int // Offset of the first name.
+	end   int // Offset after the last name.
+
+	// block is the block named by symbols of kind symbolBlock, or the block
+	// containing other symbols.
+	block *blockInfo
+}
+
+// walkState is the state of the walk over the tokens of a document.
+type walkState struct {
+	block     *blockInfo // Innermost block containing the token.
+	stmtStart bool       // Whether the token starts a statement in the body of block.
+	exprDepth int        // Number of arrays, objects and parentheses containing the token.
+}
+
+// inExpression reports whether the token is part of an expression.
+func (s walkState) inExpression() bool { return !s.stmtStart || s.exprDepth > 0 }
+
+// frame is an opening token whose closing token hasn't been found yet.
+type frame struct {
+	closer token.Token
+	block  *blockInfo // Set for the bodies of blocks.
+}
+
+func newOutline(text []byte) *outline {
+	o := &outline{root: &blockInfo{}}
+
+	file := token.NewFile("")
+	s := scanner.New(file, text, nil, scanner.IncludeComments)
+	for {
+		pos, tok, lit := s.Scan()
+		o.tokens = append(o.tokens, tokenInfo{offset: pos.Offset(), tok: tok, lit: lit})
+		if tok == token.EOF {
+			break
+		}
+	}
+
+	o.walk()
+	return o
+}
+
+// walk builds the blocks and symbols of the outline from its tokens.
+func (o *outline) walk() {
+	var (
+		frames []frame
+		state  = walkState{block: o.root, stmtStart: true}
+	)
+	o.states = make([]walkState, len(o.tokens))
+
+	for i := 0; i < len(o.tokens); {
+		o.states[i] = state
+		t := o.tokens[i]
+
+		if t.tok == token.COMMENT {
+			i++
+			continue
+		}
+
+		if t.tok == token.IDENT {
+			names, next := o.identChain(i)
+			for j := i + 1; j < next; j++ {
+				o.states[j] = state
+			}
+
+			sym := symbol{
+				kind:  symbolReference,
+				names: names,
+				start: t.offset,
+				end:   o.tokens[next-1].end(),
+				block: state.block,
+			}
+
+			if state.stmtStart && state.exprDepth == 0 {
+				switch label, body, ok := o.blockHeader(next); {
+				case ok:
+					for j := next; j <= body; j++ {
+						o.states[j] = state
+					}
+					block := &blockInfo{
+						name:      strings.Join(names, "."),
+						label:     label,
+						nameStart: sym.start,
+						nameEnd:   sym.end,
+						parent:    state.block,
+					}
+					state.block.children = append(state.block.children, block)
+					sym.kind, sym.block = symbolBlock, block
+					o.symbols = append(o.symbols, sym)
+
+					frames = append(frames, frame{closer: token.RCURLY, block: block})
+					state = walkState{block: block, stmtStart: true}
+					i = body + 1
+					continue
+
+				case o.tokens[next].tok == token.ASSIGN:
+					sym.kind = symbolAttribute
+					o.symbols = append(o.symbols, sym)
+				}
+				state.stmtStart = false
+				i = next
+				continue
+			}
+
+			// Identifiers followed by an assignment in objects are keys.
+			if o.tokens[next].tok != token.ASSIGN {
+				o.symbols = append(o.symbols, sym)
+			}
+			state.stmtStart = false
+			i = next
+			continue
+		}
+
+		switch t.tok {
+		case token.LCURLY:
+			frames = append(frames, frame{closer: token.RCURLY})
+			state.exprDepth++
+		case token.LBRACK:
+			frames = append(frames, frame{closer: token.RBRACK})
+			state.exprDepth++
+		case token.LPAREN:
+			frames = append(frames, frame{closer: token.RPAREN})
+			state.exprDepth++
+
+		case token.RCURLY, token.RBRACK, token.RPAREN:
+			// Pop the frames up to the one closed by the token. Unbalanced closing
+			// tokens are ignored.
+			for j := len(frames) - 1; j >= 0; j-- {
+				if frames[j].closer != t.tok {
+					continue
+				}
+				for _, f := range frames[j:] {
+					if f.block != nil {
+						state = walkState{block: f.block.parent}
+					} else {
+						state.exprDepth--
+					}
+				}
+				frames = frames[:j]
+				break
+			}
+			state.stmtStart = false
+
+		case token.TERMINATOR:
+			if state.exprDepth == 0 {
+				state.stmtStart = true
+			}
+
+		default:
+			if state.exprDepth == 0 {
+				state.stmtStart = false
+			}
+		}
+		i++
+	}
+
+	sort.SliceStable(o.symbols, func(i, j int) bool {
+		return o.symbols[i].start < o.symbols[j].start
+	})
+}
+
+// identChain returns the names of the identifier at tokens[i] and of the
+// field accesses following it, and the index of the token after them.
+func (o *outline) identChain(i int) ([]string, int) {
+	names := []string{o.tokens[i].lit}
+	i++
+	for i+1 < len(o.tokens) && o.tokens[i].tok == token.DOT && o.tokens[i+1].tok == token.IDENT {
+		names = append(names, o.tokens[i+1].lit)
+		i += 2
+	}
+	return names, i
+}
+
+// blockHeader reports whether the tokens from i are the optional label and
+// the opening brace of a block. It returns the unquoted label and the index
+// of the opening brace.
+func (o *outline) blockHeader(i int) (label string, body int, ok bool) {
+	if o.tokens[i].tok == token.STRING {
+		label = unquote(o.tokens[i].lit)
+		i++
+	}
+	if o.tokens[i].tok != token.LCURLY {
+		return "", 0, false
+	}
+	return label, i, true
+}
+
+// stateAt returns the state of the walk at offset.
+func (o *outline) stateAt(offset int) walkState {
+	i := sort.Search(len(o.tokens), func(i int) bool {
+		return o.tokens[i].offset >= offset
+	})
+	if i == len(o.tokens) {
+		i--
+	}
+	return o.states[i]
+}
+
+// tokenAt returns the token containing offset, if any.
+func (o *outline) tokenAt(offset int) (tokenInfo, bool) {
+	i := sort.Search(len(o.tokens), func(i int) bool {
+		return o.tokens[i].offset > offset
+	})
+	if i == 0 {
+		return tokenInfo{}, false
+	}
+	t := o.tokens[i-1]
+	return t, offset < t.end()
+}
+
+// symbolAt returns the symbol containing offset, if any. Offsets directly
+// after a symbol are considered to be part of it.
+func (o *outline) symbolAt(offset int) (symbol, bool) {
+	i := sort.Search(len(o.symbols), func(i int) bool {
+		return o.symbols[i].start > offset
+	})
+	if i == 0 {
+		return symbol{}, false
+	}
+	sym := o.symbols[i-1]
+	return sym, offset <= sym.end
+}
+
+// findBlock returns the block identified by id in the body of scope or of
+// the blocks enclosing it.
+func findBlock(scope *blockInfo, id string) (*blockInfo, bool) {
+	for b := scope; b != nil; b = b.parent {
+		for _, child := range b.children {
+			if child.id() == id {
+				return child, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// unquote returns the value of a string literal. Invalid literals, like
+// unterminated strings, are returned without their quotes.
+func unquote(lit string) string {
+	if s, err := strconv.Unquote(lit); err == nil {
+		return s
+	}
+	return strings.Trim(lit, "\"`")
+}
@@ -0,0 +1,157 @@
+package lsp
+
+// This file holds the subset of the Language Server Protocol types used by
+// the server. Refer to the specification for their documentation:
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/
+
+// Position is a zero-based line and character offset in a document. The
+// character offset is counted in UTF-16 code units.
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range is a range in a document. End is exclusive.
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Location is a range in a document.
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+type textDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+type textDocumentItem struct {
+	URI        string `json:"uri"`
+	LanguageID string `json:"languageId"`
+	Version    int    `json:"version"`
+	Text       string `json:"text"`
+}
+
+type versionedTextDocumentIdentifier struct {
+	URI     string `json:"uri"`
+	Version int    `json:"version"`
+}
+
+type textDocumentPositionParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+	Position     Position               `json:"position"`
+}
+
+type didOpenTextDocumentParams struct {
+	TextDocument textDocumentItem `json:"textDocument"`
+}
+
+// textDocumentContentChangeEvent is a change to a document. The whole
+// document is replaced if Range is nil.
+type textDocumentContentChangeEvent struct {
+	Range *Range `json:"range,omitempty"`
+	Text  string `json:"text"`
+}
+
+type didChangeTextDocumentParams struct {
+	TextDocument   versionedTextDocumentIdentifier  `json:"textDocument"`
+	ContentChanges []textDocumentContentChangeEvent `json:"contentChanges"`
+}
+
+type didCloseTextDocumentParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+}
+
+type initializeResult struct {
+	Capabilities serverCapabilities `json:"capabilities"`
+	ServerInfo   serverInfo         `json:"serverInfo"`
+}
+
+type serverInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type serverCapabilities struct {
+	TextDocumentSync   textDocumentSyncOptions `json:"textDocumentSync"`
+	CompletionProvider completionOptions       `json:"completionProvider"`
+	HoverProvider      bool                    `json:"hoverProvider"`
+	DefinitionProvider bool                    `json:"definitionProvider"`
+}
+
+// Values of textDocumentSyncOptions.Change.
+const (
+	syncFull        = 1
+	syncIncremental = 2
+)
+
+type textDocumentSyncOptions struct {
+	OpenClose bool `json:"openClose"`
+	Change    int  `json:"change"`
+}
+
+type completionOptions struct {
+	TriggerCharacters []string `json:"triggerCharacters,omitempty"`
+}
+
+// Values of CompletionItem.Kind.
+const (
+	completionKindField    = 5
+	completionKindVariable = 6
+	completionKindClass    = 7
+	completionKindModule   = 9
+	completionKindProperty = 10
+	completionKindKeyword  = 14
+	completionKindStruct   = 22
+)
+
+// CompletionItem is a completion proposed to the client.
+type CompletionItem struct {
+	Label    string    `json:"label"`
+	Kind     int       `json:"kind,omitempty"`
+	Detail   string    `json:"detail,omitempty"`
+	TextEdit *TextEdit `json:"textEdit,omitempty"`
+}
+
+// TextEdit replaces the text of a range of a document.
+type TextEdit struct {
+	Range   Range  `json:"range"`
+	NewText string `json:"newText"`
+}
+
+type completionList struct {
+	IsIncomplete bool             `json:"isIncomplete"`
+	Items        []CompletionItem `json:"items"`
+}
+
+type hover struct {
+	Contents markupContent `json:"contents"`
+	Range    *Range        `json:"range,omitempty"`
+}
+
+type markupContent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+// Values of Diagnostic.Severity.
+const (
+	severityError   = 1
+	severityWarning = 2
+)
+
+// Diagnostic is a problem reported in a document.
+type Diagnostic struct {
+	Range    Range  `json:"range"`
+	Severity int    `json:"severity"`
+	Source   string `json:"source"`
+	Message  string `json:"message"`
+}
+
+type publishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Version     *int         `json:"version,omitempty"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
@@ -0,0 +1,182 @@
+package lsp
+
+import (
+	"reflect"
+	"slices"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/nodeconf/argument"
+	"github.com/grafana/alloy/internal/nodeconf/export"
+	"github.com/grafana/alloy/internal/nodeconf/foreach"
+	"github.com/grafana/alloy/internal/nodeconf/function"
+	"github.com/grafana/alloy/internal/nodeconf/importsource"
+	"github.com/grafana/alloy/internal/runtime/logging"
+	"github.com/grafana/alloy/internal/runtime/tracing"
+	"github.com/grafana/alloy/internal/service"
+	"github.com/grafana/alloy/syntax/typecheck"
+)
+
+const declareBlockName = "declare"
+
+// configBlocks maps the names of the config blocks to the type of their
+// arguments. Blocks whose body isn't decoded into Go values map to nil.
+var configBlocks = map[string]reflect.Type{
+	"logging":                    reflect.TypeOf(logging.Options{}),
+	"tracing":                    reflect.TypeOf(tracing.Options{}),
+	argument.BlockName:           reflect.TypeOf(argument.Arguments{}),
+	export.BlockName:             reflect.TypeOf(export.Arguments{}),
+	foreach.BlockName:            reflect.TypeOf(foreach.Arguments{}),
+	importsource.BlockNameFile:   reflect.TypeOf(importsource.FileArguments{}),
+	importsource.BlockNameString: reflect.TypeOf(importsource.StringArguments{}),
+	importsource.BlockNameHTTP:   reflect.TypeOf(importsource.HTTPArguments{}),
+	importsource.BlockNameGit:    reflect.TypeOf(importsource.GitArguments{}),
+	importsource.BlockNameOCI:    reflect.TypeOf(importsource.OCIArguments{}),
+	function.BlockName:           nil,
+	declareBlockName:             nil,
+}
+
+// schema describes the blocks which may be used in a document.
+type schema struct {
+	registry       component.Registry
+	componentNames []string
+	services       map[string]service.Definition
+}
+
+func newSchema(opts Options) *schema {
+	services := make(map[string]service.Definition, len(opts.ServiceDefinitions))
+	for _, def := range opts.ServiceDefinitions {
+		if def.ConfigType != nil {
+			services[def.Name] = def
+		}
+	}
+
+	// Only offer the components which can be used with the configured
+	// stability level.
+	names := make([]string, 0, len(opts.ComponentNames))
+	for _, name := range opts.ComponentNames {
+		if _, err := opts.ComponentRegistry.Get(name); err == nil {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	return &schema{
+		registry:       opts.ComponentRegistry,
+		componentNames: names,
+		services:       services,
+	}
+}
+
+// isModuleBody reports whether the body of b holds statements of a module,
+// like components and config blocks, rather than the arguments of b.
+func isModuleBody(b *blockInfo) bool {
+	switch {
+	case b.parent == nil:
+		return true
+	case b.name == declareBlockName:
+		return true
+	case b.name == foreach.TypeTemplate && b.parent.name == foreach.BlockName:
+		return true
+	}
+	return false
+}
+
+// topLevelNames returns the names of the blocks which may be used in the
+// module body of b.
+func (s *schema) topLevelNames(b *blockInfo) []string {
+	names := make([]string, 0, len(configBlocks)+len(s.services)+len(s.componentNames))
+	for name := range configBlocks {
+		switch name {
+		case "logging", "tracing":
+			if b.parent != nil {
+				continue
+			}
+		case argument.BlockName, export.BlockName:
+			if b.name != declareBlockName {
+				continue
+			}
+		}
+		names = append(names, name)
+	}
+	if b.parent == nil {
+		for name := range s.services {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return append(names, s.componentNames...)
+}
+
+// topLevelType returns the type of the arguments of the block name used in a
+// module body.
+func (s *schema) topLevelType(name string) (reflect.Type, bool) {
+	if t, ok := configBlocks[name]; ok {
+		return t, t != nil
+	}
+	if def, ok := s.services[name]; ok {
+		return reflect.TypeOf(def.ConfigType), true
+	}
+	if reg, ok := s.component(name); ok && reg.Args != nil {
+		return reflect.TypeOf(reg.Args), true
+	}
+	return nil, false
+}
+
+// component returns the registration of the component name.
+func (s *schema) component(name string) (component.Registration, bool) {
+	if s.registry == nil {
+		return component.Registration{}, false
+	}
+	reg, err := s.registry.Get(name)
+	return reg, err == nil
+}
+
+// argumentsType returns the Go type that the body of b is decoded into.
+func (s *schema) argumentsType(b *blockInfo) (reflect.Type, bool) {
+	if b.parent == nil {
+		return nil, false
+	}
+	if isModuleBody(b.parent) {
+		return s.topLevelType(b.name)
+	}
+
+	parent, ok := s.argumentsType(b.parent)
+	if !ok {
+		return nil, false
+	}
+	f, ok := lookupField(parent, b.name)
+	if !ok || !f.Block {
+		return nil, false
+	}
+	return f.Type, true
+}
+
+// lookupField returns the field name of the arguments of type t.
+func lookupField(t reflect.Type, name string) (typecheck.Field, bool) {
+	for _, f := range typecheck.Fields(t) {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return typecheck.Field{}, false
+}
+
+// findDeclare returns the declare block defining the custom component used
+// by b.
+func findDeclare(b *blockInfo) (*blockInfo, bool) {
+	if b.parent == nil {
+		return nil, false
+	}
+	return findBlock(b.parent, declareBlockName+"."+b.name)
+}
+
+// declareArguments returns the argument blocks of a declare block.
+func declareArguments(declare *blockInfo) []*blockInfo {
+	var args []*blockInfo
+	for _, child := range declare.children {
+		if child.name == argument.BlockName && child.label != "" {
+			args = append(args, child)
+		}
+	}
+	return args
+}
@@ -0,0 +1,283 @@
+// Package lsp implements a Language Server Protocol server for Alloy
+// configuration files.
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"github.com/grafana/alloy/internal/build"
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/service"
+	"github.com/grafana/alloy/internal/validator"
+	"github.com/grafana/alloy/syntax/diag"
+)
+
+// Options configures a Server.
+type Options struct {
+	// ComponentRegistry is used to look up components.
+	ComponentRegistry component.Registry
+	// ComponentNames are the names of the components offered as completions.
+	// Components which aren't available in ComponentRegistry are ignored.
+	ComponentNames []string
+	// ServiceDefinitions are the services which may be configured with blocks.
+	ServiceDefinitions []service.Definition
+	// MinStability is the minimum stability level of the features which may be
+	// used in documents.
+	MinStability featuregate.Stability
+}
+
+// Server serves the Language Server Protocol for the documents opened by a
+// single client.
+type Server struct {
+	opts   Options
+	schema *schema
+	conn   *conn
+
+	docs        map[string]*document
+	initialized bool
+	shutdown    bool
+}
+
+// New creates a new Server.
+func New(opts Options) *Server {
+	return &Server{
+		opts:   opts,
+		schema: newSchema(opts),
+		docs:   make(map[string]*document),
+	}
+}
+
+// errExitWithoutShutdown is returned by Serve when the client asks the
+// server to exit without shutting it down first.
+var errExitWithoutShutdown = errors.New("exit notification received before shutdown request")
+
+// Serve reads requests from r and writes responses to w until the client
+// asks the server to exit or r is closed.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	s.conn = newConn(r, w)
+
+	for {
+		content, err := s.conn.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("reading message: %w", err)
+		}
+
+		var req request
+		if err := json.Unmarshal(content, &req); err != nil {
+			if err := s.conn.Reply(json.RawMessage("null"), nil, &responseError{Code: codeParseError, Message: err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if req.Method == "exit" {
+			if !s.shutdown {
+				return errExitWithoutShutdown
+			}
+			return nil
+		}
+
+		result, err := s.handle(&req)
+		if req.isNotification() {
+			continue
+		}
+		if err := s.conn.Reply(req.ID, result, err); err != nil {
+			return err
+		}
+	}
+}
+
+// handle handles a request or a notification and returns the result of
+// requests.
+func (s *Server) handle(req *request) (any, error) {
+	if !s.initialized && req.Method != "initialize" {
+		return nil, &responseError{Code: codeServerNotInitialized, Message: "server not initialized"}
+	}
+
+	switch req.Method {
+	case "initialize":
+		s.initialized = true
+		return initializeResult{
+			Capabilities: serverCapabilities{
+				TextDocumentSync:   textDocumentSyncOptions{OpenClose: true, Change: syncIncremental},
+				CompletionProvider: completionOptions{TriggerCharacters: []string{"."}},
+				HoverProvider:      true,
+				DefinitionProvider: true,
+			},
+			ServerInfo: serverInfo{Name: "alloy", Version: build.Version},
+		}, nil
+
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+
+	case "textDocument/didOpen":
+		var params didOpenTextDocumentParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		doc := newDocument(params.TextDocument.URI, params.TextDocument.Version, []byte(params.TextDocument.Text))
+		s.docs[doc.uri] = doc
+		return nil, s.publishDiagnostics(doc)
+
+	case "textDocument/didChange":
+		var params didChangeTextDocumentParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		doc, ok := s.docs[params.TextDocument.URI]
+		if !ok {
+			return nil, nil
+		}
+		for _, change := range params.ContentChanges {
+			doc.applyChange(change)
+		}
+		doc.version = params.TextDocument.Version
+		return nil, s.publishDiagnostics(doc)
+
+	case "textDocument/didClose":
+		var params didCloseTextDocumentParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		delete(s.docs, params.TextDocument.URI)
+		// Clear the diagnostics of the document.
+		return nil, s.conn.Notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+			URI:         params.TextDocument.URI,
+			Diagnostics: []Diagnostic{},
+		})
+
+	case "textDocument/completion":
+		doc, offset, err := s.documentPosition(req)
+		if doc == nil || err != nil {
+			return nil, err
+		}
+		return completionList{Items: s.complete(doc, offset)}, nil
+
+	case "textDocument/hover":
+		doc, offset, err := s.documentPosition(req)
+		if doc == nil || err != nil {
+			return nil, err
+		}
+		if h, ok := s.hover(doc, offset); ok {
+			return h, nil
+		}
+		return nil, nil
+
+	case "textDocument/definition":
+		doc, offset, err := s.documentPosition(req)
+		if doc == nil || err != nil {
+			return nil, err
+		}
+		if loc, ok := s.definition(doc, offset); ok {
+			return loc, nil
+		}
+		return nil, nil
+
+	case "initialized", "$/cancelRequest", "$/setTrace":
+		return nil, nil
+	}
+
+	return nil, &responseError{Code: codeMethodNotFound, Message: fmt.Sprintf("method %q not supported", req.Method)}
+}
+
+// documentPosition returns the document and the offset referenced by the
+// parameters of req. The returned document is nil if it isn't open.
+func (s *Server) documentPosition(req *request) (*document, int, error) {
+	var params textDocumentPositionParams
+	if err := unmarshalParams(req, &params); err != nil {
+		return nil, 0, err
+	}
+	doc, ok := s.docs[params.TextDocument.URI]
+	if !ok {
+		return nil, 0, nil
+	}
+	return doc, doc.offsetAt(params.Position), nil
+}
+
+func unmarshalParams(req *request, v any) error {
+	if err := json.Unmarshal(req.Params, v); err != nil {
+		return &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+// publishDiagnostics validates doc and sends the diagnostics found in it to
+// the client.
+func (s *Server) publishDiagnostics(doc *document) error {
+	version := doc.version
+	return s.conn.Notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+		URI:         doc.uri,
+		Version:     &version,
+		Diagnostics: s.diagnostics(doc),
+	})
+}
+
+// diagnostics validates doc and returns the diagnostics found in it.
+// Diagnostics found in the modules imported by doc aren't returned.
+func (s *Server) diagnostics(doc *document) []Diagnostic {
+	// Local modules can only be resolved for files.
+	isFile := doc.path != doc.uri
+
+	err := validator.Validate(validator.Options{
+		Sources:             map[string][]byte{doc.path: doc.text},
+		ServiceDefinitions:  s.opts.ServiceDefinitions,
+		ComponentRegistry:   s.opts.ComponentRegistry,
+		MinStability:        s.opts.MinStability,
+		ResolveLocalImports: isFile,
+		ConfigPath:          doc.path,
+	})
+	if err == nil {
+		return []Diagnostic{}
+	}
+
+	var diags diag.Diagnostics
+	if !errors.As(err, &diags) {
+		return []Diagnostic{{
+			Severity: severityError,
+			Source:   "alloy",
+			Message:  err.Error(),
+		}}
+	}
+
+	res := make([]Diagnostic, 0, len(diags))
+	for _, d := range diags {
+		if d.StartPos.Filename != "" && d.StartPos.Filename != doc.path {
+			continue
+		}
+
+		var start, end int
+		if d.StartPos.Valid() {
+			start = d.StartPos.Offset
+			end = start
+		}
+		if d.EndPos.Valid() && d.EndPos.Offset >= start {
+			// The end position of diagnostics is the position of their last
+			// character.
+			end = d.EndPos.Offset
+			if end < len(doc.text) {
+				_, size := utf8.DecodeRune(doc.text[end:])
+				end += size
+			}
+		}
+
+		severity := severityError
+		if d.Severity == diag.SeverityLevelWarn {
+			severity = severityWarning
+		}
+		res = append(res, Diagnostic{
+			Range:    doc.rangeOf(start, end),
+			Severity: severity,
+			Source:   "alloy",
+			Message:  d.Message,
+		})
+	}
+	return res
+}
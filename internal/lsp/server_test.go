@@ -0,0 +1,419 @@
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/featuregate"
+)
+
+const testURI = "untitled:config.alloy"
+
+const testConfig = `testcomponents.source "a" {
+	targets = []
+}
+
+testcomponents.sink "b" {
+	targets  = [{"x" = testcomponents.source.a.receiver}]
+	interval = true
+
+	endpoint {
+		url = "http://localhost"
+	}
+}
+
+declare "custom" {
+	argument "input" { }
+}
+
+custom "c" {
+	input = testcomponents.source.a.receiver
+}
+`
+
+func TestServer_Diagnostics(t *testing.T) {
+	c := newTestClient(t)
+	c.open(testConfig)
+
+	diags := c.diagnostics()
+	require.Len(t, diags, 1)
+	require.Equal(t, "expected string, got bool", diags[0].Message)
+	require.Equal(t, severityError, diags[0].Severity)
+	require.Equal(t, Range{
+		Start: Position{Line: 6, Character: 12},
+		End:   Position{Line: 6, Character: 16},
+	}, diags[0].Range)
+
+	// Fix the error with an incremental change.
+	c.notify("textDocument/didChange", didChangeTextDocumentParams{
+		TextDocument: versionedTextDocumentIdentifier{URI: testURI, Version: 2},
+		ContentChanges: []textDocumentContentChangeEvent{{
+			Range: &diags[0].Range,
+			Text:  `"1m"`,
+		}},
+	})
+	require.Empty(t, c.diagnostics())
+
+	// Syntax errors are reported too.
+	c.notify("textDocument/didChange", didChangeTextDocumentParams{
+		TextDocument:   versionedTextDocumentIdentifier{URI: testURI, Version: 3},
+		ContentChanges: []textDocumentContentChangeEvent{{Text: "testcomponents.source \"a\" {\n"}},
+	})
+	diags = c.diagnostics()
+	require.NotEmpty(t, diags)
+	require.Equal(t, 1, diags[0].Range.Start.Line)
+}
+
+func TestServer_Completion(t *testing.T) {
+	// The last block is being typed and isn't closed yet.
+	text := testConfig + "\ntestcomponents.sink \"d\" {\n\tendpoint {\n\t\t\n"
+
+	c := newTestClient(t)
+	c.open(text)
+	c.diagnostics()
+
+	tt := []struct {
+		name   string
+		at     string
+		offset int
+		expect []string
+	}{
+		{
+			name:   "top-level blocks",
+			at:     "declare",
+			expect: []string{"custom", "declare", "import.file", "logging", "testcomponents.sink", "testcomponents.source"},
+		},
+		{
+			name:   "component arguments",
+			at:     "interval",
+			expect: []string{"endpoint", "interval", "targets"},
+		},
+		{
+			name:   "nested block arguments",
+			at:     "\t\t\n",
+			offset: 2,
+			expect: []string{"url"},
+		},
+		{
+			name:   "custom component arguments",
+			at:     "input =",
+			expect: []string{"input"},
+		},
+		{
+			name:   "references",
+			at:     "testcomponents.source.a.receiver\n}",
+			offset: len("testcomponents.source.a."),
+			expect: []string{"testcomponents.sink.b", "testcomponents.source.a", "testcomponents.source.a.receiver"},
+		},
+		{
+			name:   "strings",
+			at:     "http://",
+			expect: nil,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var list completionList
+			c.call("textDocument/completion", c.positionParams(text, tc.at, tc.offset), &list)
+
+			labels := make([]string, 0, len(list.Items))
+			for _, item := range list.Items {
+				labels = append(labels, item.Label)
+			}
+			for _, label := range tc.expect {
+				require.Contains(t, labels, label)
+			}
+			if tc.expect == nil {
+				require.Empty(t, labels)
+			}
+		})
+	}
+
+	t.Run("edit replaces the typed name", func(t *testing.T) {
+		at := "testcomponents.source.a.receiver\n}"
+		var list completionList
+		c.call("textDocument/completion", c.positionParams(text, at, len("testcomponents.source.a.")), &list)
+		require.NotEmpty(t, list.Items)
+		require.Equal(t, Range{
+			Start: Position{Line: 18, Character: 9},
+			End:   Position{Line: 18, Character: 33},
+		}, list.Items[0].TextEdit.Range)
+	})
+}
+
+func TestServer_Hover(t *testing.T) {
+	c := newTestClient(t)
+	c.open(testConfig)
+	c.diagnostics()
+
+	tt := []struct {
+		name   string
+		at     string
+		offset int
+		expect []string
+	}{
+		{
+			name:   "component",
+			at:     "testcomponents.sink",
+			expect: []string{"**testcomponents.sink** component", "Stability: experimental", "reference/components/testcomponents/testcomponents.sink/", "- `interval` string (optional)"},
+		},
+		{
+			name:   "attribute",
+			at:     "interval",
+			expect: []string{"`interval` string (optional)", "Default: `\"1m0s\"`"},
+		},
+		{
+			name:   "block",
+			at:     "endpoint",
+			expect: []string{"**endpoint** block (optional)", "- `url` string (required)"},
+		},
+		{
+			name:   "export",
+			at:     "testcomponents.source.a.receiver",
+			offset: len("testcomponents.source.a.r"),
+			expect: []string{"`receiver` string\n", "Exported by **testcomponents.source.a**."},
+		},
+		{
+			name:   "custom component",
+			at:     "custom \"c\"",
+			expect: []string{"**custom** custom component", "- `input`"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var h hover
+			c.call("textDocument/hover", c.positionParams(testConfig, tc.at, tc.offset), &h)
+			for _, text := range tc.expect {
+				require.Contains(t, h.Contents.Value, text)
+			}
+		})
+	}
+
+	t.Run("no symbol", func(t *testing.T) {
+		var h *hover
+		c.call("textDocument/hover", c.positionParams(testConfig, "\"http", 0), &h)
+		require.Nil(t, h)
+	})
+}
+
+func TestServer_Definition(t *testing.T) {
+	c := newTestClient(t)
+	c.open(testConfig)
+	c.diagnostics()
+
+	tt := []struct {
+		name   string
+		at     string
+		expect Range
+	}{
+		{
+			name: "component reference",
+			at:   "testcomponents.source.a.receiver\n}",
+			expect: Range{
+				Start: Position{Line: 0, Character: 0},
+				End:   Position{Line: 0, Character: 21},
+			},
+		},
+		{
+			name: "custom component",
+			at:   "custom \"c\"",
+			expect: Range{
+				Start: Position{Line: 13, Character: 0},
+				End:   Position{Line: 13, Character: 7},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var loc Location
+			c.call("textDocument/definition", c.positionParams(testConfig, tc.at, 0), &loc)
+			require.Equal(t, Location{URI: testURI, Range: tc.expect}, loc)
+		})
+	}
+}
+
+func TestServer_Lifecycle(t *testing.T) {
+	c := newTestClient(t)
+
+	var result any
+	err := c.request("workspace/symbol", map[string]any{}, &result)
+	var rerr *responseError
+	require.ErrorAs(t, err, &rerr)
+	require.Equal(t, codeMethodNotFound, rerr.Code)
+
+	c.call("shutdown", nil, &result)
+	c.notify("exit", nil)
+	require.NoError(t, <-c.done)
+}
+
+type testArgs struct {
+	Targets  []map[string]string `alloy:"targets,attr"`
+	Interval time.Duration       `alloy:"interval,attr,optional"`
+	Endpoint *testEndpoint       `alloy:"endpoint,block,optional"`
+}
+
+func (a *testArgs) SetToDefault() {
+	*a = testArgs{Interval: time.Minute}
+}
+
+type testEndpoint struct {
+	URL string `alloy:"url,attr"`
+}
+
+type testExports struct {
+	Receiver string `alloy:"receiver,attr"`
+}
+
+type testRegistry map[string]component.Registration
+
+func (r testRegistry) Get(name string) (component.Registration, error) {
+	reg, ok := r[name]
+	if !ok {
+		return component.Registration{}, fmt.Errorf("cannot find the definition of component name %q", name)
+	}
+	return reg, nil
+}
+
+// testClient is a client connected to a Server through pipes.
+type testClient struct {
+	t        *testing.T
+	conn     *conn
+	messages chan json.RawMessage
+	done     chan error
+	nextID   int
+}
+
+func newTestClient(t *testing.T) *testClient {
+	registry := testRegistry{
+		"testcomponents.source": {
+			Name:      "testcomponents.source",
+			Stability: featuregate.StabilityGenerallyAvailable,
+			Args:      testArgs{},
+			Exports:   testExports{},
+		},
+		"testcomponents.sink": {
+			Name:      "testcomponents.sink",
+			Stability: featuregate.StabilityExperimental,
+			Args:      testArgs{},
+		},
+	}
+	srv := New(Options{
+		ComponentRegistry: registry,
+		ComponentNames:    []string{"testcomponents.source", "testcomponents.sink"},
+		MinStability:      featuregate.StabilityExperimental,
+	})
+
+	var (
+		serverIn, clientOut = io.Pipe()
+		clientIn, serverOut = io.Pipe()
+	)
+	c := &testClient{
+		t:        t,
+		conn:     newConn(clientIn, clientOut),
+		messages: make(chan json.RawMessage, 16),
+		done:     make(chan error, 1),
+	}
+	go func() {
+		c.done <- srv.Serve(serverIn, serverOut)
+		serverOut.Close()
+	}()
+	go func() {
+		defer close(c.messages)
+		for {
+			content, err := c.conn.Read()
+			if err != nil {
+				return
+			}
+			c.messages <- content
+		}
+	}()
+	t.Cleanup(func() {
+		clientOut.Close()
+	})
+
+	var result initializeResult
+	c.call("initialize", map[string]any{}, &result)
+	require.True(t, result.Capabilities.HoverProvider)
+	c.notify("initialized", map[string]any{})
+	return c
+}
+
+func (c *testClient) open(text string) {
+	c.notify("textDocument/didOpen", didOpenTextDocumentParams{
+		TextDocument: textDocumentItem{URI: testURI, LanguageID: "alloy", Version: 1, Text: text},
+	})
+}
+
+func (c *testClient) notify(method string, params any) {
+	require.NoError(c.t, c.conn.Write(map[string]any{"jsonrpc": "2.0", "method": method, "params": params}))
+}
+
+// call sends a request and decodes its result into result.
+func (c *testClient) call(method string, params any, result any) {
+	require.NoError(c.t, c.request(method, params, result))
+}
+
+// request sends a request and decodes its result into result.
+func (c *testClient) request(method string, params any, result any) error {
+	c.nextID++
+	id := c.nextID
+	if err := c.conn.Write(map[string]any{"jsonrpc": "2.0", "id": id, "method": method, "params": params}); err != nil {
+		return err
+	}
+
+	for content := range c.messages {
+		var resp struct {
+			ID     *int            `json:"id"`
+			Result json.RawMessage `json:"result"`
+			Error  *responseError  `json:"error"`
+		}
+		if err := json.Unmarshal(content, &resp); err != nil {
+			return err
+		}
+		if resp.ID == nil || *resp.ID != id {
+			continue
+		}
+		if resp.Error != nil {
+			return resp.Error
+		}
+		return json.Unmarshal(resp.Result, result)
+	}
+	return io.ErrUnexpectedEOF
+}
+
+// diagnostics returns the next diagnostics published by the server.
+func (c *testClient) diagnostics() []Diagnostic {
+	for content := range c.messages {
+		var msg struct {
+			Method string                   `json:"method"`
+			Params publishDiagnosticsParams `json:"params"`
+		}
+		require.NoError(c.t, json.Unmarshal(content, &msg))
+		if msg.Method == "textDocument/publishDiagnostics" {
+			return msg.Params.Diagnostics
+		}
+	}
+	c.t.Fatal("connection closed before diagnostics were published")
+	return nil
+}
+
+// positionParams returns the parameters of a request for the position of
+// the first occurrence of at in text, moved by offset bytes.
+func (c *testClient) positionParams(text, at string, offset int) textDocumentPositionParams {
+	i := strings.Index(text, at)
+	require.GreaterOrEqual(c.t, i, 0, "%q not found", at)
+	return textDocumentPositionParams{
+		TextDocument: textDocumentIdentifier{URI: testURI},
+		Position:     newDocument(testURI, 0, []byte(text)).positionAt(i + offset),
+	}
+}
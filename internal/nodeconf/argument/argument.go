@@ -1,9 +1,253 @@
 package argument
 
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/grafana/alloy/syntax/alloytypes"
+)
+
 const BlockName = "argument"
 
 type Arguments struct {
 	Optional bool   `alloy:"optional,attr,optional"`
 	Default  any    `alloy:"default,attr,optional"`
 	Comment  string `alloy:"comment,attr,optional"`
+
+	// Type constrains the type of the value of the argument. See ParseType
+	// for the supported types.
+	Type string `alloy:"type,attr,optional"`
+
+	// AllowedValues lists the values the argument may have.
+	AllowedValues []any `alloy:"allowed_values,attr,optional"`
+
+	// Regex must match the whole value of string arguments.
+	Regex string `alloy:"regex,attr,optional"`
+
+	// Min and Max bound the value of numbers and the length of strings,
+	// lists and maps.
+	Min *float64 `alloy:"min,attr,optional"`
+	Max *float64 `alloy:"max,attr,optional"`
+
+	// typ and regex are Type and Regex parsed by Validate, so that they
+	// aren't parsed again by every call to Check.
+	typ   *Type
+	regex *regexp.Regexp
+}
+
+// Validate implements syntax.Validator.
+func (args *Arguments) Validate() error {
+	typ, err := args.parseType()
+	if err != nil {
+		return err
+	}
+	re, err := args.compileRegex()
+	if err != nil {
+		return err
+	}
+	args.typ, args.regex = typ, re
+
+	if args.Min != nil && args.Max != nil && *args.Min > *args.Max {
+		return fmt.Errorf("min must not be greater than max")
+	}
+	if typ != nil {
+		for i, v := range args.AllowedValues {
+			if err := typ.Check(v); err != nil {
+				return fmt.Errorf("allowed_values element %d: %w", i, err)
+			}
+		}
+	}
+	if args.Default != nil {
+		if err := args.Check(args.Default); err != nil {
+			return fmt.Errorf("invalid default value: %w", err)
+		}
+	}
+	return nil
+}
+
+// Check returns an error if value doesn't satisfy the type and validation
+// rules of the argument. value is a value decoded into an any. A null value
+// is always valid, as it's how a value is omitted.
+//
+// The type and the regex are parsed again by Check if Validate wasn't
+// called.
+func (args *Arguments) Check(value any) error {
+	if value == nil {
+		return nil
+	}
+
+	typ := args.typ
+	if typ == nil {
+		var err error
+		if typ, err = args.parseType(); err != nil {
+			return err
+		}
+	}
+	if typ != nil {
+		if err := typ.Check(value); err != nil {
+			return err
+		}
+	}
+
+	if len(args.AllowedValues) > 0 && !slices.ContainsFunc(args.AllowedValues, func(allowed any) bool {
+		return reflect.DeepEqual(normalize(allowed), normalize(value))
+	}) {
+		return fmt.Errorf("%s is not one of the allowed values %s", formatValue(value), formatValues(args.AllowedValues))
+	}
+
+	re := args.regex
+	if re == nil {
+		var err error
+		if re, err = args.compileRegex(); err != nil {
+			return err
+		}
+	}
+	if re != nil {
+		s, ok := stringValue(value)
+		if !ok {
+			return fmt.Errorf("expected a string to match regex %q, got %s", args.Regex, describeType(value))
+		}
+		if !re.MatchString(s) {
+			return fmt.Errorf("%s doesn't match regex %q", formatValue(value), args.Regex)
+		}
+	}
+
+	if args.Min != nil || args.Max != nil {
+		return args.checkBounds(value)
+	}
+	return nil
+}
+
+func (args *Arguments) checkBounds(value any) error {
+	size, isLength := 0.0, true
+	if s, ok := stringValue(value); ok {
+		size = float64(utf8.RuneCountInString(s))
+	} else if n, ok := toFloat(value); ok {
+		size, isLength = n, false
+	} else if l, ok := value.([]any); ok {
+		size = float64(len(l))
+	} else if m, ok := value.(map[string]any); ok {
+		size = float64(len(m))
+	} else {
+		return fmt.Errorf("min and max require a number, string, list or map, got %s", describeType(value))
+	}
+
+	what := formatValue(value)
+	if isLength {
+		what = fmt.Sprintf("length %g", size)
+	}
+	if args.Min != nil && size < *args.Min {
+		return fmt.Errorf("%s is less than the minimum %g", what, *args.Min)
+	}
+	if args.Max != nil && size > *args.Max {
+		return fmt.Errorf("%s is greater than the maximum %g", what, *args.Max)
+	}
+	return nil
+}
+
+func (args *Arguments) parseType() (*Type, error) {
+	if args.Type == "" {
+		return nil, nil
+	}
+	return ParseType(args.Type)
+}
+
+func (args *Arguments) compileRegex() (*regexp.Regexp, error) {
+	if args.Regex == "" {
+		return nil, nil
+	}
+	// The regex is compiled on its own first so that errors don't mention
+	// the anchors.
+	if _, err := regexp.Compile(args.Regex); err != nil {
+		return nil, fmt.Errorf("invalid regex %q: %w", args.Regex, err)
+	}
+	return regexp.MustCompile("^(?:" + args.Regex + ")$"), nil
+}
+
+// stringValue returns the content of strings and secrets.
+func stringValue(v any) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case alloytypes.Secret:
+		return string(v), true
+	case *alloytypes.Secret:
+		return string(*v), true
+	case alloytypes.OptionalSecret:
+		return v.Value, true
+	default:
+		return "", false
+	}
+}
+
+// normalize converts the numbers of v to float64 so that values decoded
+// into different number types can be compared.
+func normalize(v any) any {
+	switch v := v.(type) {
+	case []any:
+		res := make([]any, len(v))
+		for i, e := range v {
+			res[i] = normalize(e)
+		}
+		return res
+	case map[string]any:
+		res := make(map[string]any, len(v))
+		for k, e := range v {
+			res[k] = normalize(e)
+		}
+		return res
+	}
+	if s, ok := stringValue(v); ok {
+		return s
+	}
+	if n, ok := toFloat(v); ok {
+		return n
+	}
+	return v
+}
+
+// formatValue formats v for error messages. The content of secrets isn't
+// displayed.
+func formatValue(v any) string {
+	switch {
+	case isSecret(v):
+		return "(secret)"
+	case isString(v):
+		s, _ := stringValue(v)
+		return fmt.Sprintf("%q", s)
+	}
+	switch v := v.(type) {
+	case []any:
+		return formatValues(v)
+	case map[string]any:
+		parts := make([]string, 0, len(v))
+		for _, k := range sortedKeys(v) {
+			parts = append(parts, fmt.Sprintf("%q = %s", k, formatValue(v[k])))
+		}
+		return "{" + strings.Join(parts, ", ") + "}"
+	}
+	if n, ok := toFloat(v); ok {
+		return fmt.Sprintf("%g", n)
+	}
+	if isCapsule(v) {
+		return describeType(v)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+func formatValues(values []any) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = formatValue(v)
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+func sortedKeys(m map[string]any) []string {
+	return slices.Sorted(maps.Keys(m))
 }
@@ -0,0 +1,248 @@
+package argument
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/syntax"
+	"github.com/grafana/alloy/syntax/alloytypes"
+)
+
+type testReceiver interface {
+	Receive()
+}
+
+type receiver struct{}
+
+func (receiver) Receive() {}
+
+type otherCapsule struct{}
+
+func init() {
+	RegisterCapsule("TestReceiver", reflect.TypeFor[testReceiver]())
+}
+
+func TestParseType(t *testing.T) {
+	for _, s := range []string{"any", "string", "number", "bool", "secret", "capsule", "list", "map", "list(string)", "map(list(number))", "list( TestReceiver )"} {
+		_, err := ParseType(s)
+		require.NoError(t, err, s)
+	}
+
+	for s, expected := range map[string]string{
+		"":                 `invalid type "": missing type name`,
+		"strings":          `invalid type "strings": unknown type "strings"`,
+		"string(number)":   `invalid type "string(number)": type "string" doesn't take an element type`,
+		"list(string":      `invalid type "list(string": missing closing parenthesis`,
+		"list(string))":    `invalid type "list(string))": unexpected ")"`,
+		"map(UnknownType)": `invalid type "map(UnknownType)": unknown type "UnknownType"`,
+	} {
+		_, err := ParseType(s)
+		require.EqualError(t, err, expected, s)
+	}
+}
+
+func TestTypeCheck(t *testing.T) {
+	tt := []struct {
+		typ      string
+		value    any
+		expected string
+	}{
+		{typ: "any", value: map[string]any{"a": 1}},
+		{typ: "string", value: "a"},
+		{typ: "string", value: alloytypes.OptionalSecret{Value: "a"}},
+		{typ: "string", value: alloytypes.Secret("a"), expected: "expected string, got secret"},
+		{typ: "string", value: 1, expected: "expected string, got number"},
+		{typ: "secret", value: "a"},
+		{typ: "secret", value: alloytypes.Secret("a")},
+		{typ: "number", value: 1.5},
+		{typ: "number", value: uint64(1)},
+		{typ: "number", value: true, expected: "expected number, got bool"},
+		{typ: "bool", value: false},
+		{typ: "list", value: []any{1, "a"}},
+		{typ: "list(string)", value: []any{"a", "b"}},
+		{typ: "list(string)", value: []any{"a", 2}, expected: "element 1: expected string, got number"},
+		{typ: "list(string)", value: "a", expected: "expected list(string), got string"},
+		{typ: "map(number)", value: map[string]any{"a": 1, "b": "c"}, expected: `key "b": expected number, got string`},
+		{typ: "capsule", value: receiver{}},
+		{typ: "capsule", value: "a", expected: "expected capsule, got string"},
+		{typ: "TestReceiver", value: receiver{}},
+		{typ: "TestReceiver", value: &receiver{}},
+		{typ: "list(TestReceiver)", value: []any{receiver{}, otherCapsule{}}, expected: "element 1: expected TestReceiver, got capsule(argument.otherCapsule)"},
+	}
+	for _, tc := range tt {
+		typ, err := ParseType(tc.typ)
+		require.NoError(t, err)
+		err = typ.Check(tc.value)
+		if tc.expected == "" {
+			require.NoError(t, err, "%s: %v", tc.typ, tc.value)
+		} else {
+			require.EqualError(t, err, tc.expected, "%s: %v", tc.typ, tc.value)
+		}
+	}
+}
+
+func TestArgumentsCheck(t *testing.T) {
+	one, three := 1.0, 3.0
+	tt := []struct {
+		name     string
+		args     Arguments
+		value    any
+		expected string
+	}{
+		{
+			name:  "null values are always valid",
+			args:  Arguments{Type: "string", Regex: "a+"},
+			value: nil,
+		},
+		{
+			name:  "allowed value",
+			args:  Arguments{AllowedValues: []any{"debug", "info"}},
+			value: "info",
+		},
+		{
+			name:  "allowed number",
+			args:  Arguments{AllowedValues: []any{1, 2}},
+			value: 2.0,
+		},
+		{
+			name:     "disallowed value",
+			args:     Arguments{AllowedValues: []any{"debug", "info"}},
+			value:    "warn",
+			expected: `"warn" is not one of the allowed values ["debug", "info"]`,
+		},
+		{
+			name:     "disallowed secret",
+			args:     Arguments{AllowedValues: []any{"a"}},
+			value:    alloytypes.Secret("b"),
+			expected: `(secret) is not one of the allowed values ["a"]`,
+		},
+		{
+			name:  "matching regex",
+			args:  Arguments{Regex: "[a-z]+"},
+			value: "abc",
+		},
+		{
+			name:     "regex matches the whole value",
+			args:     Arguments{Regex: "[a-z]+"},
+			value:    "abc1",
+			expected: `"abc1" doesn't match regex "[a-z]+"`,
+		},
+		{
+			name:     "regex on a number",
+			args:     Arguments{Regex: "[0-9]+"},
+			value:    1,
+			expected: `expected a string to match regex "[0-9]+", got number`,
+		},
+		{
+			name:  "number within bounds",
+			args:  Arguments{Min: &one, Max: &three},
+			value: 3,
+		},
+		{
+			name:     "number out of bounds",
+			args:     Arguments{Min: &one, Max: &three},
+			value:    0.5,
+			expected: "0.5 is less than the minimum 1",
+		},
+		{
+			name:     "string too long",
+			args:     Arguments{Max: &three},
+			value:    "abcd",
+			expected: "length 4 is greater than the maximum 3",
+		},
+		{
+			name:     "list too short",
+			args:     Arguments{Min: &one},
+			value:    []any{},
+			expected: "length 0 is less than the minimum 1",
+		},
+		{
+			name:     "bounds on a bool",
+			args:     Arguments{Min: &one},
+			value:    true,
+			expected: "min and max require a number, string, list or map, got bool",
+		},
+		{
+			name:     "type is checked first",
+			args:     Arguments{Type: "number", Min: &one},
+			value:    "a",
+			expected: "expected number, got string",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.args.Check(tc.value)
+			if tc.expected == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expected)
+			}
+		})
+	}
+}
+
+func TestArgumentsValidate(t *testing.T) {
+	one, two := 1.0, 2.0
+	tt := []struct {
+		name     string
+		args     Arguments
+		expected string
+	}{
+		{
+			name: "valid",
+			args: Arguments{Type: "string", AllowedValues: []any{"a", "b"}, Regex: "[a-z]", Default: "a", Optional: true},
+		},
+		{
+			name:     "unknown type",
+			args:     Arguments{Type: "text"},
+			expected: `invalid type "text": unknown type "text"`,
+		},
+		{
+			name:     "invalid regex",
+			args:     Arguments{Regex: "("},
+			expected: "invalid regex \"(\": error parsing regexp: missing closing ): `(`",
+		},
+		{
+			name:     "min greater than max",
+			args:     Arguments{Min: &two, Max: &one},
+			expected: "min must not be greater than max",
+		},
+		{
+			name:     "allowed value of the wrong type",
+			args:     Arguments{Type: "number", AllowedValues: []any{1, "2"}},
+			expected: "allowed_values element 1: expected number, got string",
+		},
+		{
+			name:     "invalid default",
+			args:     Arguments{Type: "string", AllowedValues: []any{"a", "b"}, Default: "c", Optional: true},
+			expected: `invalid default value: "c" is not one of the allowed values ["a", "b"]`,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.args.Validate()
+			if tc.expected == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expected)
+			}
+		})
+	}
+}
+
+func TestArgumentsDecode(t *testing.T) {
+	var args Arguments
+	require.NoError(t, syntax.Unmarshal([]byte(`
+		type  = "string"
+		regex = "[a-z]+"
+	`), &args))
+
+	// The type and the regex are parsed once when decoding.
+	require.NotNil(t, args.typ)
+	require.NotNil(t, args.regex)
+	require.NoError(t, args.Check("abc"))
+	require.EqualError(t, args.Check("ABC"), `"ABC" doesn't match regex "[a-z]+"`)
+	require.EqualError(t, args.Check(1), "expected string, got number")
+}
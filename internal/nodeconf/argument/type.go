@@ -0,0 +1,270 @@
+package argument
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/grafana/alloy/syntax/alloytypes"
+)
+
+type typeKind int
+
+const (
+	kindAny typeKind = iota
+	kindString
+	kindNumber
+	kindBool
+	kindSecret
+	kindList
+	kindMap
+	kindCapsule
+)
+
+var kindNames = map[string]typeKind{
+	"any":     kindAny,
+	"string":  kindString,
+	"number":  kindNumber,
+	"bool":    kindBool,
+	"secret":  kindSecret,
+	"list":    kindList,
+	"map":     kindMap,
+	"capsule": kindCapsule,
+}
+
+// Type is the type constraint of an argument, such as string or
+// list(MetricsReceiver).
+type Type struct {
+	kind    typeKind
+	name    string
+	elem    *Type        // Type of the elements of lists and maps, or nil if they can be of any type.
+	capsule reflect.Type // Go type of named capsules, or nil for any capsule.
+}
+
+var (
+	capsulesMut sync.RWMutex
+	capsules    = map[string]reflect.Type{}
+)
+
+// RegisterCapsule registers a capsule type which can be used by name in the
+// type of an argument. A value satisfies the type if it's assignable to t or
+// implements t when t is an interface. RegisterCapsule panics if name is
+// already registered or is the name of a builtin type.
+func RegisterCapsule(name string, t reflect.Type) {
+	capsulesMut.Lock()
+	defer capsulesMut.Unlock()
+
+	if _, ok := kindNames[name]; ok {
+		panic(fmt.Sprintf("argument: capsule name %q is a builtin type", name))
+	}
+	if _, ok := capsules[name]; ok {
+		panic(fmt.Sprintf("argument: capsule %q registered twice", name))
+	}
+	capsules[name] = t
+}
+
+// ParseType parses a type constraint. Types are one of any, string, number,
+// bool, secret, capsule, list, list(T), map, map(T), or the name of a
+// registered capsule.
+func ParseType(s string) (*Type, error) {
+	t, rest, err := parseType(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("invalid type %q: %w", s, err)
+	}
+	if rest != "" {
+		return nil, fmt.Errorf("invalid type %q: unexpected %q", s, rest)
+	}
+	return t, nil
+}
+
+func parseType(s string) (*Type, string, error) {
+	end := strings.IndexAny(s, "()")
+	if end < 0 {
+		end = len(s)
+	}
+	name := strings.TrimSpace(s[:end])
+	rest := strings.TrimSpace(s[end:])
+	if name == "" {
+		return nil, "", fmt.Errorf("missing type name")
+	}
+
+	kind, ok := kindNames[name]
+	if !ok {
+		capsulesMut.RLock()
+		capsule, ok := capsules[name]
+		capsulesMut.RUnlock()
+		if !ok {
+			return nil, "", fmt.Errorf("unknown type %q", name)
+		}
+		return &Type{kind: kindCapsule, name: name, capsule: capsule}, rest, nil
+	}
+
+	t := &Type{kind: kind, name: name}
+	if !strings.HasPrefix(rest, "(") {
+		return t, rest, nil
+	}
+	if kind != kindList && kind != kindMap {
+		return nil, "", fmt.Errorf("type %q doesn't take an element type", name)
+	}
+
+	elem, rest, err := parseType(strings.TrimSpace(rest[1:]))
+	if err != nil {
+		return nil, "", err
+	}
+	if !strings.HasPrefix(rest, ")") {
+		return nil, "", fmt.Errorf("missing closing parenthesis")
+	}
+	t.elem = elem
+	return t, strings.TrimSpace(rest[1:]), nil
+}
+
+// String returns the type as written in an argument block.
+func (t *Type) String() string {
+	if t.elem != nil {
+		return fmt.Sprintf("%s(%s)", t.name, t.elem)
+	}
+	return t.name
+}
+
+// Check returns an error if v doesn't satisfy t. v is a value decoded into
+// an any.
+func (t *Type) Check(v any) error {
+	if !t.matches(v) {
+		return fmt.Errorf("expected %s, got %s", t, describeType(v))
+	}
+
+	if t.elem == nil {
+		return nil
+	}
+	switch v := v.(type) {
+	case []any:
+		for i, e := range v {
+			if err := t.elem.Check(e); err != nil {
+				return fmt.Errorf("element %d: %w", i, err)
+			}
+		}
+	case map[string]any:
+		for _, k := range sortedKeys(v) {
+			if err := t.elem.Check(v[k]); err != nil {
+				return fmt.Errorf("key %q: %w", k, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (t *Type) matches(v any) bool {
+	switch t.kind {
+	case kindAny:
+		return true
+	case kindString:
+		return isString(v)
+	case kindNumber:
+		_, ok := toFloat(v)
+		return ok
+	case kindBool:
+		_, ok := v.(bool)
+		return ok
+	case kindSecret:
+		return isString(v) || isSecret(v)
+	case kindList:
+		_, ok := v.([]any)
+		return ok
+	case kindMap:
+		_, ok := v.(map[string]any)
+		return ok
+	case kindCapsule:
+		if !isCapsule(v) {
+			return false
+		}
+		if t.capsule == nil {
+			return true
+		}
+		rt := reflect.TypeOf(v)
+		if t.capsule.Kind() == reflect.Interface {
+			return rt.Implements(t.capsule)
+		}
+		return rt.AssignableTo(t.capsule) || (rt.Kind() == reflect.Pointer && rt.Elem().AssignableTo(t.capsule))
+	default:
+		return false
+	}
+}
+
+func isString(v any) bool {
+	switch v := v.(type) {
+	case string:
+		return true
+	case alloytypes.OptionalSecret:
+		return !v.IsSecret
+	default:
+		return false
+	}
+}
+
+func isSecret(v any) bool {
+	switch v := v.(type) {
+	case alloytypes.Secret, *alloytypes.Secret:
+		return true
+	case alloytypes.OptionalSecret:
+		return v.IsSecret
+	default:
+		return false
+	}
+}
+
+func isCapsule(v any) bool {
+	if v == nil || isString(v) || isSecret(v) {
+		return false
+	}
+	switch v.(type) {
+	case bool, []any, map[string]any:
+		return false
+	}
+	if _, ok := toFloat(v); ok {
+		return false
+	}
+	return reflect.TypeOf(v).Kind() != reflect.Func
+}
+
+// describeType returns the name of the type of v used in error messages.
+func describeType(v any) string {
+	switch {
+	case v == nil:
+		return "null"
+	case isString(v):
+		return "string"
+	case isSecret(v):
+		return "secret"
+	}
+	switch v.(type) {
+	case bool:
+		return "bool"
+	case []any:
+		return "list"
+	case map[string]any:
+		return "map"
+	}
+	if _, ok := toFloat(v); ok {
+		return "number"
+	}
+	if reflect.TypeOf(v).Kind() == reflect.Func {
+		return "function"
+	}
+	return fmt.Sprintf("capsule(%T)", v)
+}
+
+// toFloat returns the value of v if it's a number.
+func toFloat(v any) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	default:
+		return 0, false
+	}
+}
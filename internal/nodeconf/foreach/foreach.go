@@ -1,6 +1,11 @@
 package foreach
 
-import "github.com/grafana/alloy/internal/featuregate"
+import (
+	"fmt"
+	"math"
+
+	"github.com/grafana/alloy/internal/featuregate"
+)
 
 const (
 	// BlockName is the block name for foreach blocks.
@@ -17,8 +22,46 @@ type Arguments struct {
 	Id           string `alloy:"id,attr,optional"`
 	HashStringId bool   `alloy:"hash_string_id,attr,optional"`
 
+	// MaxInstances limits the number of items of the collection which are
+	// instantiated. Zero means no limit.
+	MaxInstances int `alloy:"max_instances,attr,optional"`
+
+	// InstantiationRate limits the number of new instances created per
+	// second. Zero means no limit.
+	InstantiationRate float64 `alloy:"instantiation_rate,attr,optional"`
+
+	// InstantiationBurst is the number of new instances which can be created
+	// at once before InstantiationRate applies.
+	InstantiationBurst int `alloy:"instantiation_burst,attr,optional"`
+
 	// EnableMetrics should be false by default.
 	// That way users are protected from an explosion of debug metrics
 	// if there are many items inside "collection".
 	EnableMetrics bool `alloy:"enable_metrics,attr,optional"`
 }
+
+// Validate implements syntax.Validator.
+func (args *Arguments) Validate() error {
+	if args.MaxInstances < 0 {
+		return fmt.Errorf("max_instances must not be negative")
+	}
+	if args.InstantiationRate < 0 {
+		return fmt.Errorf("instantiation_rate must not be negative")
+	}
+	if args.InstantiationBurst < 0 {
+		return fmt.Errorf("instantiation_burst must not be negative")
+	}
+	if args.InstantiationBurst > 0 && args.InstantiationRate == 0 {
+		return fmt.Errorf("instantiation_burst requires instantiation_rate to be set")
+	}
+	return nil
+}
+
+// Burst returns the instantiation burst. It defaults to the instantiation
+// rate rounded up, and is at least 1.
+func (args *Arguments) Burst() int {
+	if args.InstantiationBurst > 0 {
+		return args.InstantiationBurst
+	}
+	return max(1, int(math.Ceil(args.InstantiationRate)))
+}
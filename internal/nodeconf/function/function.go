@@ -0,0 +1,114 @@
+package function
+
+import (
+	"fmt"
+
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/nodeconf/argument"
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/diag"
+)
+
+const (
+	// BlockName is the block name for function blocks.
+	BlockName = "function"
+	// StabilityLevel for function blocks.
+	StabilityLevel = featuregate.StabilityExperimental
+	// ResultAttr is the name of the attribute holding the function result.
+	ResultAttr = "result"
+)
+
+// Definition is a parsed function block.
+type Definition struct {
+	Name string
+	// Arguments holds the argument blocks in the order they were declared,
+	// which is the order in which positional arguments are bound.
+	Arguments []*ast.BlockStmt
+	Result    ast.Expr
+}
+
+// Parse validates the body of a function block and extracts its definition.
+// The body of a function block may only contain argument blocks and a single
+// result attribute.
+func Parse(block *ast.BlockStmt) (*Definition, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	if block.Label == "" {
+		diags.Add(diag.Diagnostic{
+			Severity: diag.SeverityLevelError,
+			StartPos: block.NamePos.Position(),
+			EndPos:   block.NamePos.Add(len(BlockName) - 1).Position(),
+			Message:  "function block must have a label",
+		})
+	}
+
+	def := &Definition{Name: block.Label}
+	seen := make(map[string]struct{})
+
+	for _, stmt := range block.Body {
+		switch stmt := stmt.(type) {
+		case *ast.AttributeStmt:
+			if stmt.Name.Name != ResultAttr {
+				diags.Add(diag.Diagnostic{
+					Severity: diag.SeverityLevelError,
+					StartPos: ast.StartPos(stmt.Name).Position(),
+					EndPos:   ast.EndPos(stmt.Name).Position(),
+					Message:  fmt.Sprintf("unrecognized attribute name %q in function %q", stmt.Name.Name, block.Label),
+				})
+				continue
+			}
+			if def.Result != nil {
+				diags.Add(diag.Diagnostic{
+					Severity: diag.SeverityLevelError,
+					StartPos: ast.StartPos(stmt.Name).Position(),
+					EndPos:   ast.EndPos(stmt.Name).Position(),
+					Message:  fmt.Sprintf("attribute %q may only be provided once", ResultAttr),
+				})
+				continue
+			}
+			def.Result = stmt.Value
+
+		case *ast.BlockStmt:
+			if stmt.GetBlockName() != argument.BlockName {
+				diags.Add(diag.Diagnostic{
+					Severity: diag.SeverityLevelError,
+					StartPos: ast.StartPos(stmt).Position(),
+					EndPos:   ast.EndPos(stmt).Position(),
+					Message:  fmt.Sprintf("only argument blocks are allowed in function %q, got %s", block.Label, stmt.GetBlockName()),
+				})
+				continue
+			}
+			if stmt.Label == "" {
+				diags.Add(diag.Diagnostic{
+					Severity: diag.SeverityLevelError,
+					StartPos: stmt.NamePos.Position(),
+					EndPos:   stmt.NamePos.Add(len(argument.BlockName) - 1).Position(),
+					Message:  "argument block must have a label",
+				})
+				continue
+			}
+			if _, ok := seen[stmt.Label]; ok {
+				diags.Add(diag.Diagnostic{
+					Severity: diag.SeverityLevelError,
+					StartPos: ast.StartPos(stmt).Position(),
+					EndPos:   ast.EndPos(stmt).Position(),
+					Message:  fmt.Sprintf("argument %q already declared in function %q", stmt.Label, block.Label),
+				})
+				continue
+			}
+			seen[stmt.Label] = struct{}{}
+			def.Arguments = append(def.Arguments, stmt)
+		}
+	}
+
+	if def.Result == nil {
+		diags.Add(diag.Diagnostic{
+			Severity: diag.SeverityLevelError,
+			StartPos: ast.StartPos(block).Position(),
+			EndPos:   ast.EndPos(block).Position(),
+			Message:  fmt.Sprintf("missing required attribute %q in function %q", ResultAttr, block.Label),
+		})
+	}
+
+	return def, diags
+}
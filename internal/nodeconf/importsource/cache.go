@@ -0,0 +1,116 @@
+package importsource
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-kit/log"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+)
+
+// moduleCacheFile is the name of the file storing the last known good module
+// in the data path of an import source.
+const moduleCacheFile = "last_known_good.json"
+
+// moduleCache persists the last module loaded from a remote source so that
+// the module can still be loaded when the source is unavailable at startup.
+type moduleCache struct {
+	path string
+	log  log.Logger
+
+	last *cachedModule // Last module stored, to avoid rewriting it on every poll.
+}
+
+// cachedModule is the content of the cache file.
+type cachedModule struct {
+	// Source identifies the configuration the module was fetched with, so that
+	// a module cached for another source is never loaded.
+	Source   string            `json:"source"`
+	StoredAt time.Time         `json:"stored_at"`
+	Content  map[string]string `json:"content"`
+}
+
+func newModuleCache(opts component.Options) *moduleCache {
+	return &moduleCache{
+		path: filepath.Join(opts.DataPath, moduleCacheFile),
+		log:  opts.Logger,
+	}
+}
+
+// store persists the content of a module fetched from source. Failing to
+// store the module is logged but doesn't prevent the module from loading.
+// store isn't safe for concurrent use.
+func (c *moduleCache) store(source string, content map[string]string) {
+	if c.last != nil && c.last.Source == source && maps.Equal(c.last.Content, content) {
+		return
+	}
+
+	cached := &cachedModule{
+		Source:   source,
+		StoredAt: time.Now().UTC(),
+		Content:  maps.Clone(content),
+	}
+	bb, err := json.Marshal(cached)
+	if err == nil {
+		err = writeFileAtomic(c.path, bb)
+	}
+	if err != nil {
+		level.Warn(c.log).Log("msg", "failed to cache module", "path", c.path, "err", err)
+		return
+	}
+	c.last = cached
+}
+
+// load returns the module cached for source, if any.
+func (c *moduleCache) load(source string) (*cachedModule, bool) {
+	bb, err := os.ReadFile(c.path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			level.Warn(c.log).Log("msg", "failed to read cached module", "path", c.path, "err", err)
+		}
+		return nil, false
+	}
+
+	var cached cachedModule
+	if err := json.Unmarshal(bb, &cached); err != nil {
+		level.Warn(c.log).Log("msg", "ignoring invalid cached module", "path", c.path, "err", err)
+		return nil, false
+	}
+	if cached.Source != source || len(cached.Content) == 0 {
+		return nil, false
+	}
+	return &cached, true
+}
+
+// writeFileAtomic writes a file through a temporary file so that a crash
+// never leaves a partially written file behind.
+func writeFileAtomic(path string, bb []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, bb, 0o640); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// cachedModuleError is reported as the health of a source while its module
+// was loaded from the cache because the source is unavailable.
+type cachedModuleError struct {
+	storedAt time.Time
+	err      error
+}
+
+func (e cachedModuleError) Error() string {
+	return fmt.Sprintf("source unavailable, using the module cached at %s: %s", e.storedAt.Format(time.RFC3339), e.err)
+}
+
+func (e cachedModuleError) Unwrap() error { return e.err }
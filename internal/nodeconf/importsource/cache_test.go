@@ -0,0 +1,53 @@
+package importsource
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/component"
+)
+
+func TestModuleCache(t *testing.T) {
+	opts := component.Options{DataPath: t.TempDir(), Logger: log.NewNopLogger()}
+	content := map[string]string{"math.alloy": string(module)}
+
+	cache := newModuleCache(opts)
+	_, ok := cache.load("http GET http://example.com/math.alloy")
+	require.False(t, ok)
+
+	cache.store("http GET http://example.com/math.alloy", content)
+
+	// The module is loaded by a new cache, as after a restart.
+	cached, ok := newModuleCache(opts).load("http GET http://example.com/math.alloy")
+	require.True(t, ok)
+	require.Equal(t, content, cached.Content)
+	require.WithinDuration(t, time.Now(), cached.StoredAt, time.Minute)
+
+	// A module cached for another source is never loaded.
+	_, ok = cache.load("http GET http://example.com/other.alloy")
+	require.False(t, ok)
+
+	// Storing the same module again doesn't rewrite the cache.
+	path := filepath.Join(opts.DataPath, moduleCacheFile)
+	require.NoError(t, os.Remove(path))
+	cache.store("http GET http://example.com/math.alloy", content)
+	require.NoFileExists(t, path)
+
+	// Invalid cache files are ignored.
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0o640))
+	_, ok = cache.load("http GET http://example.com/math.alloy")
+	require.False(t, ok)
+}
+
+func TestCachedModuleError(t *testing.T) {
+	cause := errors.New("connection refused")
+	err := cachedModuleError{storedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), err: cause}
+	require.EqualError(t, err, "source unavailable, using the module cached at 2024-01-02T03:04:05Z: connection refused")
+	require.ErrorIs(t, err, cause)
+}
@@ -265,3 +265,35 @@ func (im *ImportFile) ModulePath() string {
 	}
 	return path
 }
+
+// ReadModuleFiles reads the files of a module imported from path, which may
+// be a file or a directory. When path is a directory, only the .alloy files at
+// its top level are read. The contents are keyed by the path of each file.
+func ReadModuleFiles(path string) (map[string][]byte, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{path}
+	if fi.IsDir() {
+		names, err := collectFilesFromDir(path)
+		if err != nil {
+			return nil, err
+		}
+		files = files[:0]
+		for _, name := range names {
+			files = append(files, filepath.Join(path, name))
+		}
+	}
+
+	contents := make(map[string][]byte, len(files))
+	for _, f := range files {
+		bb, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		contents[f] = bb
+	}
+	return contents, nil
+}
@@ -30,7 +30,11 @@ type ImportGit struct {
 	repoOpts        vcs.GitRepoOptions
 	args            GitArguments
 	repoPath        string
+	loaded          bool      // Whether a module was loaded.
+	cachedAt        time.Time // When the loaded module was cached, if it was loaded from the cache.
 	onContentChange func(map[string]string)
+	verifyMetrics   *verifyMetrics
+	cache           *moduleCache
 
 	argsChanged chan struct{}
 
@@ -50,6 +54,7 @@ type GitArguments struct {
 	Path          string            `alloy:"path,attr"`
 	PullFrequency time.Duration     `alloy:"pull_frequency,attr,optional"`
 	GitAuthConfig vcs.GitAuthConfig `alloy:",squash"`
+	Verify        *VerifyArguments  `alloy:"verify,block,optional"`
 }
 
 var DefaultGitArguments = GitArguments{
@@ -84,9 +89,17 @@ func NewImportGit(managedOpts component.Options, eval *vm.Evaluator, onContentCh
 		eval:            eval,
 		argsChanged:     make(chan struct{}, 1),
 		onContentChange: onContentChange,
+		verifyMetrics:   newVerifyMetrics(managedOpts.Registerer),
+		cache:           newModuleCache(managedOpts),
 	}
 }
 
+// cacheSource identifies the module and its verification settings in the
+// cache.
+func (args GitArguments) cacheSource() string {
+	return fmt.Sprintf("git %s %s %s", args.Repository, args.Revision, args.Path) + args.Verify.cacheKey()
+}
+
 func (im *ImportGit) Evaluate(scope *vm.Scope) error {
 	var arguments GitArguments
 	if err := im.eval.Evaluate(scope, &arguments); err != nil {
@@ -153,6 +166,9 @@ func (im *ImportGit) updateTicker(pullFrequency time.Duration, ticker *time.Tick
 func (im *ImportGit) tickPollFile(ctx context.Context) {
 	im.mut.Lock()
 	err := im.pollFile(ctx, im.args)
+	if err != nil && !im.cachedAt.IsZero() {
+		err = cachedModuleError{storedAt: im.cachedAt, err: err}
+	}
 	pullFrequency := im.args.PullFrequency
 	im.mut.Unlock()
 
@@ -186,8 +202,17 @@ func (im *ImportGit) updateHealth(err error) {
 // Only acknowledge the error from Update if it's not a
 // vcs.UpdateFailedError; vcs.UpdateFailedError means that the Git repo
 // exists, but we were unable to update it. It makes sense to retry on the next poll and it may succeed.
+// If the repository can't be reached before a module was loaded, the module
+// cached from the last successful poll is loaded instead.
 func (im *ImportGit) Update(args component.Arguments) (err error) {
+	// healthErr is reported as the health of the source when Update succeeds
+	// without being able to update the module.
+	var healthErr error
 	defer func() {
+		if err == nil && healthErr != nil {
+			im.updateHealth(healthErr)
+			return
+		}
 		im.updateHealth(err)
 	}()
 	im.mut.Lock()
@@ -208,13 +233,18 @@ func (im *ImportGit) Update(args component.Arguments) (err error) {
 
 	// Create or update the repo field.
 	// Failure to update repository makes the module loader temporarily use cached contents on disk
+	var cloneErr error
 	if im.repo == nil || !equality.DeepEqual(repoOpts, im.repoOpts) {
 		r, err := vcs.NewGitRepo(context.Background(), im.repoPath, repoOpts)
 		if err != nil {
-			if errors.As(err, &vcs.UpdateFailedError{}) {
+			switch {
+			case errors.As(err, &vcs.UpdateFailedError{}):
 				level.Error(im.log).Log("msg", "failed to update repository", "err", err)
 				im.updateHealth(err)
-			} else {
+			case errors.As(err, &vcs.DownloadFailedError{}) && im.hasCachedModule(newArgs):
+				// The repository is cloned again on the next poll.
+				cloneErr = err
+			default:
 				return err
 			}
 		}
@@ -222,13 +252,21 @@ func (im *ImportGit) Update(args component.Arguments) (err error) {
 		im.repoOpts = repoOpts
 	}
 
-	if err := im.pollFile(context.Background(), newArgs); err != nil {
-		if errors.As(err, &vcs.UpdateFailedError{}) {
+	if cloneErr != nil {
+		healthErr = im.loadCachedModule(newArgs, cloneErr)
+	} else if err := im.pollFile(context.Background(), newArgs); err != nil {
+		switch {
+		case errors.As(err, &vcs.UpdateFailedError{}):
 			level.Error(im.log).Log("msg", "failed to poll file from repository", "err", err)
-			// We don't update the health here because it will be updated via the defer call.
-			// This is not very good because if we reassign the err before exiting the function it will not update the health correctly.
-			// TODO improve the error  health handling.
-		} else {
+			healthErr = err
+			if im.hasCachedModule(newArgs) {
+				healthErr = im.loadCachedModule(newArgs, err)
+			}
+		case errors.Is(err, ErrSignatureVerification) && im.loaded:
+			// Keep the last verified module until signed content is pushed.
+			level.Error(im.log).Log("msg", "rejected module from repository, keeping the module loaded", "err", err)
+			healthErr = err
+		default:
 			return err
 		}
 	}
@@ -243,11 +281,44 @@ func (im *ImportGit) Update(args component.Arguments) (err error) {
 	return nil
 }
 
+// hasCachedModule returns whether no module was loaded yet and a module is
+// cached for args. im.mut must be held.
+func (im *ImportGit) hasCachedModule(args GitArguments) bool {
+	if im.loaded {
+		return false
+	}
+	_, ok := im.cache.load(args.cacheSource())
+	return ok
+}
+
+// loadCachedModule loads the module cached for args because the repository
+// is unavailable, and returns the error to report as health. im.mut must be
+// held.
+func (im *ImportGit) loadCachedModule(args GitArguments, err error) error {
+	cached, ok := im.cache.load(args.cacheSource())
+	if !ok {
+		return err
+	}
+	level.Warn(im.log).Log("msg", "repository unavailable, loading the cached module", "cached_at", cached.StoredAt, "err", err)
+	im.onContentChange(cached.Content)
+	im.loaded = true
+	im.cachedAt = cached.StoredAt
+	return cachedModuleError{storedAt: cached.StoredAt, err: err}
+}
+
 // pollFile fetches the latest content from the repository and updates the
 // controller. pollFile must only be called with im.mut held.
 func (im *ImportGit) pollFile(ctx context.Context, args GitArguments) error {
-	// Make sure our repo is up-to-date.
-	if err := im.repo.Update(ctx); err != nil {
+	if im.repo == nil {
+		// The repository couldn't be cloned yet, the module was loaded from
+		// the cache.
+		r, err := vcs.NewGitRepo(ctx, im.repoPath, im.repoOpts)
+		im.repo = r
+		if err != nil {
+			return err
+		}
+	} else if err := im.repo.Update(ctx); err != nil {
+		// Make sure our repo is up-to-date.
 		return err
 	}
 
@@ -256,17 +327,45 @@ func (im *ImportGit) pollFile(ctx context.Context, args GitArguments) error {
 		return err
 	}
 
+	verifier, err := newSignatureVerifier(args.Verify)
+	if err != nil {
+		return err
+	}
+
+	var content map[string]string
 	if info.IsDir() {
-		return im.handleDirectory(args.Path)
+		content, err = im.readDirectory(args.Path, verifier)
+	} else {
+		content, err = im.readFile(args.Path, verifier)
+	}
+	if err != nil {
+		return err
 	}
 
-	return im.handleFile(args.Path)
+	im.onContentChange(content)
+	im.loaded = true
+	im.cachedAt = time.Time{}
+	im.cache.store(args.cacheSource(), content)
+	return nil
 }
 
-func (im *ImportGit) handleDirectory(path string) error {
+// verifyFile checks the signature of the file at path, if verification is
+// enabled.
+func (im *ImportGit) verifyFile(verifier *signatureVerifier, path string, content []byte) error {
+	if verifier == nil {
+		return nil
+	}
+	// A missing signature file is reported by verify.
+	signature, _ := im.repo.ReadFile(verifier.signaturePath(path))
+	err := verifier.verify(path, content, signature)
+	im.verifyMetrics.observe(err)
+	return err
+}
+
+func (im *ImportGit) readDirectory(path string, verifier *signatureVerifier) (map[string]string, error) {
 	filesInfo, err := im.repo.ReadDir(path)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	content := make(map[string]string)
@@ -274,23 +373,28 @@ func (im *ImportGit) handleDirectory(path string) error {
 		if fi.IsDir() || !strings.HasSuffix(fi.Name(), ".alloy") {
 			continue
 		}
-		bb, err := im.repo.ReadFile(filepath.Join(path, fi.Name()))
+		filePath := filepath.Join(path, fi.Name())
+		bb, err := im.repo.ReadFile(filePath)
 		if err != nil {
-			return err
+			return nil, err
+		}
+		if err := im.verifyFile(verifier, filePath, bb); err != nil {
+			return nil, err
 		}
 		content[fi.Name()] = string(bb)
 	}
-	im.onContentChange(content)
-	return nil
+	return content, nil
 }
 
-func (im *ImportGit) handleFile(path string) error {
+func (im *ImportGit) readFile(path string, verifier *signatureVerifier) (map[string]string, error) {
 	bb, err := im.repo.ReadFile(path)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	im.onContentChange(map[string]string{path: string(bb)})
-	return nil
+	if err := im.verifyFile(verifier, path, bb); err != nil {
+		return nil, err
+	}
+	return map[string]string{path: string(bb)}, nil
 }
 
 // CurrentHealth implements component.HealthComponent.
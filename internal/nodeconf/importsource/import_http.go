@@ -2,37 +2,76 @@ package importsource
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	prom_config "github.com/prometheus/common/config"
+
 	"github.com/grafana/alloy/internal/component"
 	common_config "github.com/grafana/alloy/internal/component/common/config"
 	remote_http "github.com/grafana/alloy/internal/component/remote/http"
 	"github.com/grafana/alloy/internal/runtime/equality"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/useragent"
 	"github.com/grafana/alloy/syntax/vm"
 )
 
 // ImportHTTP imports a module from a HTTP server via the remote.http component.
 type ImportHTTP struct {
-	managedRemoteHTTP *remote_http.Component
+	// managedRemoteHTTP is nil while the module is loaded from the cache
+	// because remote.http couldn't be created. remoteMut must be held to create
+	// it.
+	managedRemoteHTTP atomic.Pointer[remote_http.Component]
+	remoteMut         sync.Mutex
+	remoteArgs        remote_http.Arguments
 	arguments         HTTPArguments
 	managedOpts       component.Options
 	eval              *vm.Evaluator
+	onContentChange   func(map[string]string)
+	verifyMetrics     *verifyMetrics
+	cache             *moduleCache
+
+	// mut protects the fields used to pass the content fetched by remote.http
+	// on. It must not be held when calling into managedRemoteHTTP, which
+	// reports new content synchronously.
+	mut       sync.Mutex
+	fetchArgs HTTPArguments // Latest arguments, used to fetch signatures.
+	content   string        // Latest content fetched by remote.http.
+	rejected  bool          // Whether the latest content failed verification.
+	loaded    bool          // Whether a module was loaded.
+	cachedAt  time.Time     // When the module loaded from the cache was stored.
+	verifyErr error         // Result of the latest verification.
+
+	healthMut    sync.RWMutex
+	verifyHealth component.Health
+	cacheHealth  component.Health // Health while the module is loaded from the cache.
 }
 
 var _ ImportSource = (*ImportHTTP)(nil)
 
 func NewImportHTTP(managedOpts component.Options, eval *vm.Evaluator, onContentChange func(map[string]string)) *ImportHTTP {
+	im := &ImportHTTP{
+		eval:            eval,
+		onContentChange: onContentChange,
+		verifyMetrics:   newVerifyMetrics(managedOpts.Registerer),
+		cache:           newModuleCache(managedOpts),
+	}
 	opts := managedOpts
 	opts.OnStateChange = func(e component.Exports) {
-		onContentChange(map[string]string{opts.ID: e.(remote_http.Exports).Content.Value})
-	}
-	return &ImportHTTP{
-		managedOpts: opts,
-		eval:        eval,
+		im.mut.Lock()
+		im.content = e.(remote_http.Exports).Content.Value
+		im.mut.Unlock()
+		_ = im.updateContent(context.Background())
 	}
+	im.managedOpts = opts
+	return im
 }
 
 // HTTPArguments holds values which are used to configure the remote.http component.
@@ -46,6 +85,7 @@ type HTTPArguments struct {
 	Body    string            `alloy:"body,attr,optional"`
 
 	Client common_config.HTTPClientConfig `alloy:"client,block,optional"`
+	Verify *VerifyArguments               `alloy:"verify,block,optional"`
 }
 
 // DefaultHTTPArguments holds default settings for HTTPArguments.
@@ -61,6 +101,12 @@ func (args *HTTPArguments) SetToDefault() {
 	*args = DefaultHTTPArguments
 }
 
+// cacheSource identifies the module and its verification settings in the
+// cache.
+func (args HTTPArguments) cacheSource() string {
+	return fmt.Sprintf("http %s %s", args.Method, args.URL) + args.Verify.cacheKey()
+}
+
 func (im *ImportHTTP) Evaluate(scope *vm.Scope) error {
 	var arguments HTTPArguments
 	if err := im.eval.Evaluate(scope, &arguments); err != nil {
@@ -75,12 +121,38 @@ func (im *ImportHTTP) Evaluate(scope *vm.Scope) error {
 		Body:          arguments.Body,
 		Client:        arguments.Client,
 	}
-	if im.managedRemoteHTTP == nil {
+
+	im.mut.Lock()
+	verifyChanged := !equality.DeepEqual(im.fetchArgs.Verify, arguments.Verify)
+	im.fetchArgs = arguments
+	im.mut.Unlock()
+
+	im.remoteMut.Lock()
+	defer im.remoteMut.Unlock()
+
+	managedRemoteHTTP := im.managedRemoteHTTP.Load()
+	if managedRemoteHTTP == nil {
 		var err error
-		im.managedRemoteHTTP, err = remote_http.New(im.managedOpts, remoteHttpArguments)
+		managedRemoteHTTP, err = remote_http.New(im.managedOpts, remoteHttpArguments)
 		if err != nil {
-			return fmt.Errorf("creating http component: %w", err)
+			// The server may be unavailable, fall back to the module cached
+			// from the last successful poll if there is one. Run keeps trying
+			// to create the component.
+			if !im.useCachedModule(arguments, err) {
+				return fmt.Errorf("creating http component: %w", err)
+			}
+			im.remoteArgs = remoteHttpArguments
+			im.arguments = arguments
+			return nil
+		}
+		// Unlike later updates, rejecting the initial content is an error
+		// since there is no module to keep.
+		if err := im.initialVerifyError(); err != nil {
+			return err
 		}
+		im.managedRemoteHTTP.Store(managedRemoteHTTP)
+		im.updateCacheHealth(nil)
+		im.remoteArgs = remoteHttpArguments
 		im.arguments = arguments
 	}
 
@@ -89,19 +161,296 @@ func (im *ImportHTTP) Evaluate(scope *vm.Scope) error {
 	}
 
 	// Update the existing managed component
-	if err := im.managedRemoteHTTP.Update(remoteHttpArguments); err != nil {
+	if err := managedRemoteHTTP.Update(remoteHttpArguments); err != nil {
 		return fmt.Errorf("updating component: %w", err)
 	}
+	im.remoteArgs = remoteHttpArguments
 	im.arguments = arguments
+
+	// remote.http only reports content which changed, so the content must be
+	// checked again with the new verification settings.
+	if verifyChanged {
+		_ = im.updateContent(context.Background())
+		if err := im.initialVerifyError(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// useCachedModule loads the module cached for args because remote.http
+// couldn't be created, and returns false if no module is cached for args.
+func (im *ImportHTTP) useCachedModule(args HTTPArguments, err error) bool {
+	im.mut.Lock()
+	defer im.mut.Unlock()
+
+	cached, ok := im.cache.load(args.cacheSource())
+	if !ok {
+		return false
+	}
+	level.Warn(im.managedOpts.Logger).Log("msg", "server unavailable, loading the cached module", "cached_at", cached.StoredAt, "err", err)
+	im.loaded = true
+	im.cachedAt = cached.StoredAt
+	im.onContentChange(cached.Content)
+	im.updateCacheHealth(cachedModuleError{storedAt: cached.StoredAt, err: err})
+	return true
+}
+
+// initialVerifyError returns the error of the latest verification if no
+// module was loaded yet.
+func (im *ImportHTTP) initialVerifyError() error {
+	im.mut.Lock()
+	defer im.mut.Unlock()
+	if im.loaded {
+		return nil
+	}
+	return im.verifyErr
+}
+
+// updateContent passes the latest content fetched by remote.http on, after
+// checking its signature if verification is enabled. Rejected content is
+// dropped so that the last verified module stays loaded.
+func (im *ImportHTTP) updateContent(ctx context.Context) error {
+	im.mut.Lock()
+	defer im.mut.Unlock()
+
+	args := im.fetchArgs
+	content := im.content
+	if args.Verify != nil {
+		var err error
+		content, err = im.fetchVerified(ctx, args)
+		im.updateVerifyHealth(args.Verify, err)
+		im.verifyErr = err
+		if err != nil {
+			im.rejected = true
+			level.Error(im.managedOpts.Logger).Log("msg", "rejected module, keeping the last verified module loaded", "err", err)
+			return err
+		}
+	} else {
+		im.updateVerifyHealth(nil, nil)
+		im.verifyErr = nil
+	}
+
+	im.rejected = false
+	im.loaded = true
+	module := map[string]string{im.managedOpts.ID: content}
+	im.onContentChange(module)
+	im.cache.store(args.cacheSource(), module)
+	return nil
+}
+
+// fetchVerified fetches the module and its signature, and returns the module
+// if the signature is valid. The module is fetched again instead of using the
+// content of remote.http, which strips surrounding whitespace from the signed
+// bytes.
+func (im *ImportHTTP) fetchVerified(ctx context.Context, args HTTPArguments) (string, error) {
+	verifier, err := newSignatureVerifier(args.Verify)
+	if err != nil {
+		return "", err
+	}
+
+	userAgent, ok := args.Headers["User-Agent"]
+	if !ok {
+		userAgent = useragent.Get()
+	}
+	cli, err := prom_config.NewClientFromConfig(*args.Client.Convert(), im.managedOpts.ID, prom_config.WithUserAgent(userAgent))
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, args.PollTimeout)
+	defer cancel()
+
+	bb, err := fetch(ctx, cli, args, args.Method, args.URL, args.Body)
+	if err != nil {
+		return "", err
+	}
+	signatureURL, err := verifier.signatureURL(args.URL)
+	if err != nil {
+		return "", err
+	}
+	// A missing signature is reported by verify, but failing to fetch it isn't
+	// a verification failure.
+	signature, err := fetch(ctx, cli, args, http.MethodGet, signatureURL, "")
+	if err != nil && !errors.Is(err, errNotFound) {
+		return "", fmt.Errorf("fetching signature: %w", err)
+	}
+
+	err = verifier.verify(args.URL, bb, signature)
+	im.verifyMetrics.observe(err)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(bb)), nil
+}
+
+// errNotFound is wrapped by the errors returned by fetch when the server
+// responds with 404 Not Found.
+var errNotFound = errors.New("not found")
+
+func fetch(ctx context.Context, cli *http.Client, args HTTPArguments, method, url, body string) ([]byte, error) {
+	var rd io.Reader
+	if body != "" {
+		rd = strings.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, rd)
+	if err != nil {
+		return nil, fmt.Errorf("building request: %w", err)
+	}
+	for name, value := range args.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := cli.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("performing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	bb, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", errNotFound, url)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %s from %s", resp.Status, url)
+	}
+	return bb, nil
+}
+
+func (im *ImportHTTP) updateVerifyHealth(verify *VerifyArguments, err error) {
+	im.healthMut.Lock()
+	defer im.healthMut.Unlock()
+
+	switch {
+	case verify == nil:
+		im.verifyHealth = component.Health{}
+	case err != nil:
+		im.verifyHealth = component.Health{
+			Health:     component.HealthTypeUnhealthy,
+			Message:    fmt.Sprintf("verifying module: %s", err),
+			UpdateTime: time.Now(),
+		}
+	default:
+		im.verifyHealth = component.Health{
+			Health:     component.HealthTypeHealthy,
+			Message:    "module verified",
+			UpdateTime: time.Now(),
+		}
+	}
+}
+
+func (im *ImportHTTP) updateCacheHealth(err error) {
+	im.healthMut.Lock()
+	defer im.healthMut.Unlock()
+
+	if err == nil {
+		im.cacheHealth = component.Health{}
+		return
+	}
+	im.cacheHealth = component.Health{
+		Health:     component.HealthTypeUnhealthy,
+		Message:    err.Error(),
+		UpdateTime: time.Now(),
+	}
+}
+
 func (im *ImportHTTP) Run(ctx context.Context) error {
-	return im.managedRemoteHTTP.Run(ctx)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		im.retryRejected(ctx)
+	}()
+
+	managedRemoteHTTP := im.managedRemoteHTTP.Load()
+	if managedRemoteHTTP == nil {
+		managedRemoteHTTP = im.createRemoteHTTP(ctx)
+		if managedRemoteHTTP == nil {
+			return nil
+		}
+	}
+	return managedRemoteHTTP.Run(ctx)
+}
+
+// createRemoteHTTP tries to create remote.http at every poll while the module
+// is loaded from the cache. It returns nil if ctx is canceled first.
+func (im *ImportHTTP) createRemoteHTTP(ctx context.Context) *remote_http.Component {
+	for {
+		im.mut.Lock()
+		pollFrequency := im.fetchArgs.PollFrequency
+		im.mut.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(pollFrequency):
+		}
+
+		im.remoteMut.Lock()
+		managedRemoteHTTP := im.managedRemoteHTTP.Load()
+		if managedRemoteHTTP == nil {
+			var err error
+			managedRemoteHTTP, err = remote_http.New(im.managedOpts, im.remoteArgs)
+			if err != nil {
+				level.Error(im.managedOpts.Logger).Log("msg", "failed to poll the server, keeping the cached module loaded", "err", err)
+				im.mut.Lock()
+				im.updateCacheHealth(cachedModuleError{storedAt: im.cachedAt, err: err})
+				im.mut.Unlock()
+			} else {
+				im.managedRemoteHTTP.Store(managedRemoteHTTP)
+				im.updateCacheHealth(nil)
+			}
+		}
+		im.remoteMut.Unlock()
+
+		if managedRemoteHTTP != nil {
+			return managedRemoteHTTP
+		}
+	}
+}
+
+// retryRejected checks rejected content again at every poll, since
+// remote.http doesn't report content which didn't change, but its signature
+// may have been fixed.
+func (im *ImportHTTP) retryRejected(ctx context.Context) {
+	for {
+		im.mut.Lock()
+		pollFrequency := im.fetchArgs.PollFrequency
+		im.mut.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(pollFrequency):
+		}
+
+		im.mut.Lock()
+		rejected := im.rejected
+		im.mut.Unlock()
+		if rejected {
+			_ = im.updateContent(ctx)
+		}
+	}
 }
 
 func (im *ImportHTTP) CurrentHealth() component.Health {
-	return im.managedRemoteHTTP.CurrentHealth()
+	im.healthMut.RLock()
+	defer im.healthMut.RUnlock()
+
+	managedRemoteHTTP := im.managedRemoteHTTP.Load()
+	if managedRemoteHTTP == nil {
+		return im.cacheHealth
+	}
+	health := managedRemoteHTTP.CurrentHealth()
+	if im.verifyHealth.UpdateTime.IsZero() {
+		return health
+	}
+	return component.LeastHealthy(health, im.verifyHealth)
 }
 
 // Update the evaluator.
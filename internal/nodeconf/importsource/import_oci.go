@@ -0,0 +1,382 @@
+package importsource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/oci"
+	"github.com/grafana/alloy/internal/runtime/equality"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/syntax"
+	"github.com/grafana/alloy/syntax/vm"
+)
+
+// StabilityLevelOCI is the stability level of import.oci blocks.
+const StabilityLevelOCI = featuregate.StabilityExperimental
+
+// ImportOCI imports a module from an artifact stored in an OCI registry.
+type ImportOCI struct {
+	opts            component.Options
+	log             log.Logger
+	eval            *vm.Evaluator
+	mut             sync.RWMutex
+	repo            *oci.Repository
+	repoOpts        oci.RepositoryOptions
+	args            OCIArguments
+	digest          string    // Digest of the artifact currently loaded.
+	loaded          bool      // Whether a module was loaded.
+	cachedAt        time.Time // When the loaded module was cached, if it was loaded from the cache.
+	modulePath      string
+	onContentChange func(map[string]string)
+	verifyMetrics   *verifyMetrics
+	cache           *moduleCache
+
+	argsChanged chan struct{}
+
+	healthMut sync.RWMutex
+	health    component.Health
+}
+
+var (
+	_ ImportSource              = (*ImportOCI)(nil)
+	_ component.Component       = (*ImportOCI)(nil)
+	_ component.HealthComponent = (*ImportOCI)(nil)
+)
+
+type OCIArguments struct {
+	Reference     string           `alloy:"reference,attr"`
+	Path          string           `alloy:"path,attr,optional"`
+	PullFrequency time.Duration    `alloy:"pull_frequency,attr,optional"`
+	PlainHTTP     bool             `alloy:"plain_http,attr,optional"`
+	Auth          oci.AuthConfig   `alloy:",squash"`
+	Verify        *VerifyArguments `alloy:"verify,block,optional"`
+}
+
+var DefaultOCIArguments = OCIArguments{
+	PullFrequency: time.Minute,
+}
+
+var (
+	_ syntax.Validator = (*OCIArguments)(nil)
+	_ syntax.Defaulter = (*OCIArguments)(nil)
+)
+
+// Validate implements syntax.Validator.
+func (args *OCIArguments) Validate() error {
+	if args.PullFrequency < 0 {
+		return fmt.Errorf("pull_frequency must not be negative")
+	}
+	return args.Auth.Validate()
+}
+
+// SetToDefault implements syntax.Defaulter.
+func (args *OCIArguments) SetToDefault() {
+	*args = DefaultOCIArguments
+}
+
+func NewImportOCI(managedOpts component.Options, eval *vm.Evaluator, onContentChange func(map[string]string)) *ImportOCI {
+	return &ImportOCI{
+		opts:            managedOpts,
+		log:             managedOpts.Logger,
+		eval:            eval,
+		modulePath:      filepath.Join(managedOpts.DataPath, "oci"),
+		argsChanged:     make(chan struct{}, 1),
+		onContentChange: onContentChange,
+		verifyMetrics:   newVerifyMetrics(managedOpts.Registerer),
+		cache:           newModuleCache(managedOpts),
+	}
+}
+
+// cacheSource identifies the module and its verification settings in the
+// cache.
+func (args OCIArguments) cacheSource() string {
+	return fmt.Sprintf("oci %s %s", args.Reference, args.Path) + args.Verify.cacheKey()
+}
+
+func (im *ImportOCI) Evaluate(scope *vm.Scope) error {
+	var arguments OCIArguments
+	if err := im.eval.Evaluate(scope, &arguments); err != nil {
+		return fmt.Errorf("decoding configuration: %w", err)
+	}
+
+	if equality.DeepEqual(im.args, arguments) {
+		return nil
+	}
+
+	if err := im.Update(arguments); err != nil {
+		return fmt.Errorf("updating component: %w", err)
+	}
+	return nil
+}
+
+func (im *ImportOCI) Run(ctx context.Context) error {
+	var (
+		ticker  *time.Ticker
+		tickerC <-chan time.Time
+	)
+	defer func() {
+		if ticker != nil {
+			ticker.Stop()
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case <-im.argsChanged:
+			im.mut.RLock()
+			pullFrequency := im.args.PullFrequency
+			pinned := im.repo != nil && im.repo.Pinned()
+			im.mut.RUnlock()
+
+			// Artifacts pinned to a digest never change.
+			if pinned {
+				pullFrequency = 0
+			}
+			ticker, tickerC = im.updateTicker(pullFrequency, ticker)
+
+		case <-tickerC:
+			im.tickPoll(ctx)
+		}
+	}
+}
+
+func (im *ImportOCI) updateTicker(pullFrequency time.Duration, ticker *time.Ticker) (*time.Ticker, <-chan time.Time) {
+	if pullFrequency > 0 {
+		if ticker == nil {
+			ticker = time.NewTicker(pullFrequency)
+		} else {
+			ticker.Reset(pullFrequency)
+		}
+		return ticker, ticker.C
+	}
+
+	if ticker != nil {
+		ticker.Stop()
+	}
+	return nil, nil
+}
+
+func (im *ImportOCI) tickPoll(ctx context.Context) {
+	im.mut.Lock()
+	err := im.poll(ctx, im.args)
+	if err != nil && !im.cachedAt.IsZero() {
+		err = cachedModuleError{storedAt: im.cachedAt, err: err}
+	}
+	im.mut.Unlock()
+
+	im.updateHealth(err)
+	if err != nil {
+		level.Error(im.log).Log("msg", "failed to update module from registry", "err", err)
+	}
+}
+
+func (im *ImportOCI) updateHealth(err error) {
+	im.healthMut.Lock()
+	defer im.healthMut.Unlock()
+
+	if err != nil {
+		im.health = component.Health{
+			Health:     component.HealthTypeUnhealthy,
+			Message:    err.Error(),
+			UpdateTime: time.Now(),
+		}
+	} else {
+		im.health = component.Health{
+			Health:     component.HealthTypeHealthy,
+			Message:    "module updated",
+			UpdateTime: time.Now(),
+		}
+	}
+}
+
+// Update implements component.Component.
+// Like import.git, failing to pull an artifact is only an error if no module
+// was loaded yet: a module which was already loaded is kept until the next
+// poll succeeds. If the registry can't be reached before a module was loaded,
+// the module cached from the last successful poll is loaded instead.
+func (im *ImportOCI) Update(args component.Arguments) (err error) {
+	// healthErr is reported as the health of the source when Update succeeds
+	// without being able to update the module.
+	var healthErr error
+	defer func() {
+		if err == nil && healthErr != nil {
+			im.updateHealth(healthErr)
+			return
+		}
+		im.updateHealth(err)
+	}()
+	im.mut.Lock()
+	defer im.mut.Unlock()
+
+	newArgs := args.(OCIArguments)
+
+	repoOpts := oci.RepositoryOptions{
+		Reference: newArgs.Reference,
+		Auth:      newArgs.Auth,
+		PlainHTTP: newArgs.PlainHTTP,
+	}
+	if im.repo == nil || !equality.DeepEqual(repoOpts, im.repoOpts) {
+		repo, err := oci.NewRepository(repoOpts)
+		if err != nil {
+			return err
+		}
+		im.repo = repo
+		im.repoOpts = repoOpts
+		im.digest = ""
+		im.loaded = false
+	}
+
+	if err := im.poll(context.Background(), newArgs); err != nil {
+		switch {
+		case im.loaded && newArgs.Path == im.args.Path:
+			level.Error(im.log).Log("msg", "failed to pull artifact, keeping the module loaded", "err", err)
+			healthErr = err
+		case !im.loaded && !errors.Is(err, ErrSignatureVerification):
+			cached, ok := im.cache.load(newArgs.cacheSource())
+			if !ok {
+				return err
+			}
+			level.Warn(im.log).Log("msg", "registry unavailable, loading the cached module", "cached_at", cached.StoredAt, "err", err)
+			im.onContentChange(cached.Content)
+			im.loaded = true
+			im.cachedAt = cached.StoredAt
+			healthErr = cachedModuleError{storedAt: cached.StoredAt, err: err}
+		default:
+			return err
+		}
+	}
+
+	// Schedule an update for handling the changed arguments.
+	select {
+	case im.argsChanged <- struct{}{}:
+	default:
+	}
+
+	im.args = newArgs
+	return nil
+}
+
+// poll loads the module if the artifact changed since the last poll, or if
+// the path of the module or its verification changed. poll must only be
+// called with im.mut held.
+func (im *ImportOCI) poll(ctx context.Context, args OCIArguments) error {
+	if im.digest != "" && args.Path == im.args.Path && equality.DeepEqual(args.Verify, im.args.Verify) {
+		d, err := im.repo.Resolve(ctx)
+		if err != nil {
+			return err
+		}
+		if d.String() == im.digest {
+			return nil
+		}
+	}
+
+	verifier, err := newSignatureVerifier(args.Verify)
+	if err != nil {
+		return err
+	}
+
+	artifact, err := im.repo.Pull(ctx)
+	if err != nil {
+		return err
+	}
+	content, err := moduleContent(artifact.Files, args.Path, func(name string, bb []byte) error {
+		if verifier == nil {
+			return nil
+		}
+		err := verifier.verify(name, bb, artifact.Files[verifier.signaturePath(name)])
+		im.verifyMetrics.observe(err)
+		return err
+	})
+	if err != nil {
+		// Check the artifact again on the next poll, even if it doesn't change.
+		im.digest = ""
+		return err
+	}
+	if err := writeArtifact(im.modulePath, artifact.Files); err != nil {
+		return err
+	}
+
+	level.Info(im.log).Log("msg", "loaded module from registry", "reference", args.Reference, "digest", artifact.Digest)
+	im.digest = artifact.Digest.String()
+	im.onContentChange(content)
+	im.loaded = true
+	im.cachedAt = time.Time{}
+	im.cache.store(args.cacheSource(), content)
+	return nil
+}
+
+// moduleContent returns the module at p in the files of an artifact. p is
+// either the path of a file or of a directory whose .alloy files are loaded.
+// The root directory of the artifact is used if p is empty. verify is called
+// for every file of the module.
+func moduleContent(files map[string][]byte, p string, verify func(name string, bb []byte) error) (map[string]string, error) {
+	dir := path.Clean(p)
+	if bb, ok := files[dir]; ok && p != "" {
+		if err := verify(dir, bb); err != nil {
+			return nil, err
+		}
+		return map[string]string{dir: string(bb)}, nil
+	}
+
+	content := make(map[string]string)
+	for name, bb := range files {
+		if path.Dir(name) == dir && strings.HasSuffix(name, ".alloy") {
+			if err := verify(name, bb); err != nil {
+				return nil, err
+			}
+			content[path.Base(name)] = string(bb)
+		}
+	}
+	if len(content) == 0 {
+		return nil, fmt.Errorf("no .alloy files found at path %q of the artifact", p)
+	}
+	return content, nil
+}
+
+// writeArtifact replaces the content of dir with the files of an artifact so
+// that modules can import other files of the artifact through module_path.
+func writeArtifact(dir string, files map[string][]byte) error {
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	for name, bb := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+			return err
+		}
+		if err := os.WriteFile(p, bb, 0o640); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CurrentHealth implements component.HealthComponent.
+func (im *ImportOCI) CurrentHealth() component.Health {
+	im.healthMut.RLock()
+	defer im.healthMut.RUnlock()
+	return im.health
+}
+
+// Update the evaluator.
+func (im *ImportOCI) SetEval(eval *vm.Evaluator) {
+	im.eval = eval
+}
+
+func (im *ImportOCI) ModulePath() string {
+	return im.modulePath
+}
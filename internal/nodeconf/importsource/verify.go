@@ -0,0 +1,269 @@
+package importsource
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/alloy/internal/util"
+	"github.com/grafana/alloy/syntax"
+)
+
+// VerifyArguments configures the verification of the signatures of imported
+// modules. Every imported file must have a detached signature made with one
+// of the public keys.
+type VerifyArguments struct {
+	PublicKeys      []string `alloy:"public_keys,attr"`
+	SignatureSuffix string   `alloy:"signature_suffix,attr,optional"`
+}
+
+// DefaultVerifyArguments holds default settings for VerifyArguments.
+var DefaultVerifyArguments = VerifyArguments{
+	SignatureSuffix: ".sig",
+}
+
+var (
+	_ syntax.Validator = (*VerifyArguments)(nil)
+	_ syntax.Defaulter = (*VerifyArguments)(nil)
+)
+
+// SetToDefault implements syntax.Defaulter.
+func (args *VerifyArguments) SetToDefault() {
+	*args = DefaultVerifyArguments
+}
+
+// Validate implements syntax.Validator.
+func (args *VerifyArguments) Validate() error {
+	if args.SignatureSuffix == "" {
+		return fmt.Errorf("signature_suffix must not be empty")
+	}
+	_, err := parsePublicKeys(args.PublicKeys)
+	return err
+}
+
+// cacheKey returns a digest of the verification settings, so that modules
+// cached without verification or verified with other keys are never loaded
+// from the cache. It returns an empty string if verification is disabled.
+func (args *VerifyArguments) cacheKey() string {
+	if args == nil {
+		return ""
+	}
+	h := sha256.New()
+	for _, key := range args.PublicKeys {
+		fmt.Fprintf(h, "%d:%s", len(key), key)
+	}
+	fmt.Fprintf(h, "%d:%s", len(args.SignatureSuffix), args.SignatureSuffix)
+	return fmt.Sprintf(" verify=%x", h.Sum(nil))
+}
+
+// ErrSignatureVerification is wrapped by the errors returned when imported
+// content doesn't have a valid signature.
+var ErrSignatureVerification = errors.New("signature verification failed")
+
+// signatureVerifier verifies the detached signatures of imported files.
+type signatureVerifier struct {
+	keys   []crypto.PublicKey
+	suffix string
+}
+
+// newSignatureVerifier returns a verifier for args, or nil if args is nil
+// and verification is disabled.
+func newSignatureVerifier(args *VerifyArguments) (*signatureVerifier, error) {
+	if args == nil {
+		return nil, nil
+	}
+	keys, err := parsePublicKeys(args.PublicKeys)
+	if err != nil {
+		return nil, err
+	}
+	return &signatureVerifier{keys: keys, suffix: args.SignatureSuffix}, nil
+}
+
+// signaturePath returns where the signature of the file at p is stored.
+func (v *signatureVerifier) signaturePath(p string) string {
+	return p + v.suffix
+}
+
+// signatureURL returns where the signature of the file at rawURL is stored.
+// The suffix is appended to the path of the URL, so that the query and the
+// fragment are kept.
+func (v *signatureVerifier) signatureURL(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	u.Path += v.suffix
+	if u.RawPath != "" {
+		u.RawPath += url.PathEscape(v.suffix)
+	}
+	return u.String(), nil
+}
+
+// verify checks that signature is a valid signature of content made with one
+// of the public keys. The signature is either raw, base64 encoded, or a
+// sigstore bundle.
+func (v *signatureVerifier) verify(name string, content, signature []byte) error {
+	if len(signature) == 0 {
+		return fmt.Errorf("%w: missing signature for %q", ErrSignatureVerification, name)
+	}
+	sig, err := decodeSignature(content, signature)
+	if err != nil {
+		return fmt.Errorf("%w: invalid signature for %q: %s", ErrSignatureVerification, name, err)
+	}
+
+	digest := sha256.Sum256(content)
+	for _, key := range v.keys {
+		if verifySignature(key, content, digest[:], sig) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: signature of %q doesn't match any public key", ErrSignatureVerification, name)
+}
+
+func verifySignature(key crypto.PublicKey, content, digest, sig []byte) bool {
+	switch key := key.(type) {
+	case ed25519.PublicKey:
+		return ed25519.Verify(key, content, sig)
+	case *ecdsa.PublicKey:
+		return ecdsa.VerifyASN1(key, digest, sig)
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest, sig) == nil
+	default:
+		return false
+	}
+}
+
+// sigstoreBundle holds the fields of sigstore bundles, and of the legacy
+// bundles written by cosign, which are needed to verify them offline.
+// Transparency log entries and certificates are ignored.
+type sigstoreBundle struct {
+	MessageSignature *sigstoreMessageSignature `json:"messageSignature,omitempty"`
+
+	Base64Signature string `json:"base64Signature,omitempty"`
+}
+
+type sigstoreMessageSignature struct {
+	MessageDigest struct {
+		Algorithm string `json:"algorithm"`
+		Digest    []byte `json:"digest"`
+	} `json:"messageDigest"`
+	Signature []byte `json:"signature"`
+}
+
+func decodeSignature(content, signature []byte) ([]byte, error) {
+	// Only text encoded signatures may be surrounded by whitespace.
+	text := bytes.TrimSpace(signature)
+
+	if len(text) > 0 && text[0] == '{' && json.Valid(text) {
+		var bundle sigstoreBundle
+		if err := json.Unmarshal(text, &bundle); err != nil {
+			return nil, fmt.Errorf("decoding bundle: %w", err)
+		}
+		switch {
+		case bundle.MessageSignature != nil:
+			md := bundle.MessageSignature.MessageDigest
+			if md.Algorithm != "" && md.Algorithm != "SHA2_256" {
+				return nil, fmt.Errorf("unsupported digest algorithm %q", md.Algorithm)
+			}
+			if digest := sha256.Sum256(content); md.Digest != nil && !bytes.Equal(md.Digest, digest[:]) {
+				return nil, fmt.Errorf("bundle digest doesn't match the content")
+			}
+			return bundle.MessageSignature.Signature, nil
+		case bundle.Base64Signature != "":
+			return base64.StdEncoding.DecodeString(bundle.Base64Signature)
+		default:
+			return nil, fmt.Errorf("bundle has no message signature")
+		}
+	}
+
+	if sig, err := base64.StdEncoding.DecodeString(string(text)); err == nil {
+		return sig, nil
+	}
+	return signature, nil
+}
+
+// parsePublicKeys parses PEM encoded public keys. Every entry may contain
+// several keys.
+func parsePublicKeys(entries []string) ([]crypto.PublicKey, error) {
+	var keys []crypto.PublicKey
+	for i, entry := range entries {
+		rest := []byte(entry)
+		for {
+			var block *pem.Block
+			block, rest = pem.Decode(rest)
+			if block == nil {
+				break
+			}
+			if block.Type != "PUBLIC KEY" {
+				return nil, fmt.Errorf("public_keys[%d]: unexpected PEM block %q", i, block.Type)
+			}
+			key, err := x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("public_keys[%d]: %w", i, err)
+			}
+			switch key.(type) {
+			case ed25519.PublicKey, *ecdsa.PublicKey, *rsa.PublicKey:
+			default:
+				return nil, fmt.Errorf("public_keys[%d]: unsupported key type %T", i, key)
+			}
+			keys = append(keys, key)
+		}
+		if len(bytes.TrimSpace(rest)) > 0 {
+			return nil, fmt.Errorf("public_keys[%d]: not a PEM encoded public key", i)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("at least one public key must be set")
+	}
+	return keys, nil
+}
+
+// verifyMetrics reports the result of the signature verifications of a
+// source.
+type verifyMetrics struct {
+	verifications *prometheus.CounterVec
+	verified      prometheus.Gauge
+}
+
+func newVerifyMetrics(reg prometheus.Registerer) *verifyMetrics {
+	m := &verifyMetrics{
+		verifications: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "alloy_import_signature_verifications_total",
+			Help: "Total number of signature verifications of imported modules, by result.",
+		}, []string{"result"}),
+		verified: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "alloy_import_signature_verified",
+			Help: "Whether the signature of the last content fetched by the import source was valid.",
+		}),
+	}
+	if reg != nil {
+		m.verifications = util.MustRegisterOrGet(reg, m.verifications).(*prometheus.CounterVec)
+		m.verified = util.MustRegisterOrGet(reg, m.verified).(prometheus.Gauge)
+	}
+	return m
+}
+
+// observe records the result of a verification. Errors which aren't caused
+// by invalid signatures, such as failing to fetch the content, are ignored.
+func (m *verifyMetrics) observe(err error) {
+	switch {
+	case err == nil:
+		m.verifications.WithLabelValues("success").Inc()
+		m.verified.Set(1)
+	case errors.Is(err, ErrSignatureVerification):
+		m.verifications.WithLabelValues("failure").Inc()
+		m.verified.Set(0)
+	}
+}
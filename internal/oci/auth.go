@@ -0,0 +1,29 @@
+package oci
+
+import (
+	"fmt"
+
+	"github.com/grafana/alloy/syntax/alloytypes"
+)
+
+// AuthConfig configures how to authenticate to a registry.
+type AuthConfig struct {
+	BasicAuth   *BasicAuth        `alloy:"basic_auth,block,optional"`
+	BearerToken alloytypes.Secret `alloy:"bearer_token,attr,optional"`
+}
+
+// Validate implements syntax.Validator.
+func (a *AuthConfig) Validate() error {
+	if a.BasicAuth != nil && a.BearerToken != "" {
+		return fmt.Errorf("at most one of basic_auth and bearer_token can be set")
+	}
+	return nil
+}
+
+// BasicAuth holds the credentials of a registry user. The credentials are
+// sent directly to registries accepting basic authentication, and exchanged
+// for a token with registries using token authentication.
+type BasicAuth struct {
+	Username string            `alloy:"username,attr"`
+	Password alloytypes.Secret `alloy:"password,attr"`
+}
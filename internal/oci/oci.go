@@ -0,0 +1,456 @@
+// Package oci pulls artifacts from registries implementing the OCI
+// distribution specification.
+package oci
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/distribution/reference"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	// maxManifestSize is the maximum size of a manifest.
+	maxManifestSize = 4 << 20
+	// maxBlobSize is the maximum size of a layer.
+	maxBlobSize = 64 << 20
+
+	mediaTypeDockerManifest  = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeDockerLayerGzip = "application/vnd.docker.image.rootfs.diff.tar.gzip"
+
+	dockerHubDomain   = "docker.io"
+	dockerHubRegistry = "registry-1.docker.io"
+)
+
+// manifestMediaTypes are the media types of the manifests accepted from
+// registries.
+var manifestMediaTypes = []string{ocispec.MediaTypeImageManifest, mediaTypeDockerManifest}
+
+// RepositoryOptions configure a Repository.
+type RepositoryOptions struct {
+	// Reference of the artifact, like "registry.example.com/modules/math:v1"
+	// or "registry.example.com/modules/math@sha256:...". The "latest" tag is
+	// used if the reference has neither a tag nor a digest.
+	Reference string
+
+	Auth AuthConfig
+
+	// PlainHTTP connects to the registry with HTTP instead of HTTPS.
+	PlainHTTP bool
+
+	// Client is used to send requests. http.DefaultClient is used if nil.
+	Client *http.Client
+}
+
+// Repository pulls an artifact from a registry.
+type Repository struct {
+	opts       RepositoryOptions
+	client     *http.Client
+	registry   string // Host of the registry.
+	repository string // Name of the repository in the registry.
+	tag        string
+	digest     digest.Digest // Set if the reference is pinned to a digest.
+
+	mut   sync.Mutex
+	token string // Token obtained from the token service of the registry.
+}
+
+// Artifact is the content of an artifact.
+type Artifact struct {
+	// Digest is the digest of the manifest of the artifact.
+	Digest digest.Digest
+	// Files maps the paths of the files of the artifact to their content.
+	Files map[string][]byte
+}
+
+// NewRepository creates a Repository for the artifact referenced by
+// opts.Reference. No request is sent to the registry.
+func NewRepository(opts RepositoryOptions) (*Repository, error) {
+	named, err := reference.ParseNormalizedNamed(opts.Reference)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reference %q: %w", opts.Reference, err)
+	}
+
+	r := &Repository{
+		opts:       opts,
+		client:     opts.Client,
+		registry:   reference.Domain(named),
+		repository: reference.Path(named),
+		tag:        "latest",
+	}
+	if r.client == nil {
+		r.client = http.DefaultClient
+	}
+	if r.registry == dockerHubDomain {
+		r.registry = dockerHubRegistry
+	}
+
+	if tagged, ok := named.(reference.Tagged); ok {
+		r.tag = tagged.Tag()
+	}
+	if digested, ok := named.(reference.Digested); ok {
+		r.digest = digested.Digest()
+	}
+	return r, nil
+}
+
+// Pinned reports whether the reference is pinned to a digest, so that its
+// content never changes.
+func (r *Repository) Pinned() bool {
+	return r.digest != ""
+}
+
+// manifestReference returns the reference used to request the manifest.
+func (r *Repository) manifestReference() string {
+	if r.digest != "" {
+		return r.digest.String()
+	}
+	return r.tag
+}
+
+// Resolve returns the digest of the manifest of the artifact without
+// downloading its content.
+func (r *Repository) Resolve(ctx context.Context) (digest.Digest, error) {
+	if r.digest != "" {
+		return r.digest, nil
+	}
+
+	resp, err := r.get(ctx, http.MethodHead, "manifests/"+r.manifestReference(), manifestMediaTypes)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	if d, err := digest.Parse(resp.Header.Get("Docker-Content-Digest")); err == nil {
+		return d, nil
+	}
+
+	// Registries don't have to send the digest, fall back to downloading the
+	// manifest.
+	_, d, err := r.fetchManifest(ctx)
+	return d, err
+}
+
+// Pull downloads the artifact. Layers annotated with a title are stored
+// under their title, and tar archives are extracted. Other layers are
+// ignored.
+func (r *Repository) Pull(ctx context.Context) (*Artifact, error) {
+	manifest, d, err := r.fetchManifest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	artifact := &Artifact{Digest: d, Files: make(map[string][]byte)}
+	for _, layer := range manifest.Layers {
+		if err := r.pullLayer(ctx, layer, artifact.Files); err != nil {
+			return nil, fmt.Errorf("pulling layer %s: %w", layer.Digest, err)
+		}
+	}
+	return artifact, nil
+}
+
+func (r *Repository) fetchManifest(ctx context.Context) (*ocispec.Manifest, digest.Digest, error) {
+	resp, err := r.get(ctx, http.MethodGet, "manifests/"+r.manifestReference(), manifestMediaTypes)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	bb, err := readAll(resp.Body, maxManifestSize)
+	if err != nil {
+		return nil, "", fmt.Errorf("reading manifest: %w", err)
+	}
+
+	d := digest.FromBytes(bb)
+	if r.digest != "" && r.digest != d {
+		return nil, "", fmt.Errorf("manifest digest %s does not match the reference digest %s", d, r.digest)
+	}
+
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(bb, &manifest); err != nil {
+		return nil, "", fmt.Errorf("decoding manifest: %w", err)
+	}
+	mediaType := manifest.MediaType
+	if mediaType == "" {
+		mediaType = resp.Header.Get("Content-Type")
+	}
+	switch mediaType {
+	case ocispec.MediaTypeImageManifest, mediaTypeDockerManifest:
+	case ocispec.MediaTypeImageIndex:
+		return nil, "", fmt.Errorf("image indexes are not supported, reference an artifact manifest instead")
+	default:
+		return nil, "", fmt.Errorf("unsupported manifest media type %q", mediaType)
+	}
+	return &manifest, d, nil
+}
+
+func (r *Repository) pullLayer(ctx context.Context, layer ocispec.Descriptor, files map[string][]byte) error {
+	title := layer.Annotations[ocispec.AnnotationTitle]
+
+	var archive, compressed bool
+	switch layer.MediaType {
+	case ocispec.MediaTypeImageLayer:
+		archive = true
+	case ocispec.MediaTypeImageLayerGzip, mediaTypeDockerLayerGzip:
+		archive, compressed = true, true
+	}
+	if !archive && title == "" {
+		return nil
+	}
+	if layer.Size > maxBlobSize {
+		return fmt.Errorf("layer is larger than %d bytes", maxBlobSize)
+	}
+	if err := layer.Digest.Validate(); err != nil {
+		return err
+	}
+
+	resp, err := r.get(ctx, http.MethodGet, "blobs/"+layer.Digest.String(), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	bb, err := readAll(resp.Body, maxBlobSize)
+	if err != nil {
+		return err
+	}
+	if d := layer.Digest.Algorithm().FromBytes(bb); d != layer.Digest {
+		return fmt.Errorf("content digest %s does not match the layer digest", d)
+	}
+
+	if !archive {
+		name, err := cleanPath(title)
+		if err != nil {
+			return err
+		}
+		files[name] = bb
+		return nil
+	}
+	return extractTar(bb, compressed, files)
+}
+
+// extractTar stores the regular files of a tar archive in files.
+func extractTar(bb []byte, compressed bool, files map[string][]byte) error {
+	var rd io.Reader = bytes.NewReader(bb)
+	if compressed {
+		gz, err := gzip.NewReader(rd)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		rd = gz
+	}
+
+	tr := tar.NewReader(rd)
+	var total int64
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name, err := cleanPath(hdr.Name)
+		if err != nil {
+			return err
+		}
+		total += hdr.Size
+		if total > maxBlobSize {
+			return fmt.Errorf("archive content is larger than %d bytes", maxBlobSize)
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		files[name] = content
+	}
+}
+
+// cleanPath returns the cleaned relative path of a file of an artifact.
+func cleanPath(name string) (string, error) {
+	cleaned := path.Clean(strings.TrimPrefix(name, "./"))
+	if path.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("invalid file path %q in artifact", name)
+	}
+	return cleaned, nil
+}
+
+func readAll(rd io.Reader, limit int64) ([]byte, error) {
+	bb, err := io.ReadAll(io.LimitReader(rd, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(bb)) > limit {
+		return nil, fmt.Errorf("content is larger than %d bytes", limit)
+	}
+	return bb, nil
+}
+
+// get sends a request for a resource of the repository, authenticating to
+// the registry when it requires it.
+func (r *Repository) get(ctx context.Context, method, resource string, accept []string) (*http.Response, error) {
+	u := url.URL{
+		Scheme: "https",
+		Host:   r.registry,
+		Path:   "/v2/" + r.repository + "/" + resource,
+	}
+	if r.opts.PlainHTTP {
+		u.Scheme = "http"
+	}
+
+	send := func() (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(accept) > 0 {
+			req.Header.Set("Accept", strings.Join(accept, ", "))
+		}
+		r.authorize(req)
+		return r.client.Do(req)
+	}
+
+	resp, err := send()
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		if err := r.authenticate(ctx, challenge); err != nil {
+			return nil, fmt.Errorf("authenticating to %s: %w", r.registry, err)
+		}
+		if resp, err = send(); err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s %s: unexpected status %s", method, u.String(), resp.Status)
+	}
+	return resp, nil
+}
+
+// authorize sets the credentials of a request.
+func (r *Repository) authorize(req *http.Request) {
+	r.mut.Lock()
+	token := r.token
+	r.mut.Unlock()
+
+	switch {
+	case token != "":
+		req.Header.Set("Authorization", "Bearer "+token)
+	case r.opts.Auth.BearerToken != "":
+		req.Header.Set("Authorization", "Bearer "+string(r.opts.Auth.BearerToken))
+	case r.opts.Auth.BasicAuth != nil:
+		req.SetBasicAuth(r.opts.Auth.BasicAuth.Username, string(r.opts.Auth.BasicAuth.Password))
+	}
+}
+
+// authenticate gets a token from the token service named by the challenge
+// of an unauthorized response.
+func (r *Repository) authenticate(ctx context.Context, challenge string) error {
+	scheme, params := parseChallenge(challenge)
+	if !strings.EqualFold(scheme, "bearer") || params["realm"] == "" {
+		return fmt.Errorf("registry requires unsupported authentication %q", challenge)
+	}
+
+	realm, err := url.Parse(params["realm"])
+	if err != nil {
+		return fmt.Errorf("invalid token realm: %w", err)
+	}
+	q := realm.Query()
+	if service := params["service"]; service != "" {
+		q.Set("service", service)
+	}
+	scope := params["scope"]
+	if scope == "" {
+		scope = "repository:" + r.repository + ":pull"
+	}
+	q.Set("scope", scope)
+	realm.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return err
+	}
+	if auth := r.opts.Auth.BasicAuth; auth != nil {
+		req.SetBasicAuth(auth.Username, string(auth.Password))
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("token request failed with status %s", resp.Status)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxManifestSize)).Decode(&body); err != nil {
+		return fmt.Errorf("decoding token response: %w", err)
+	}
+	token := body.Token
+	if token == "" {
+		token = body.AccessToken
+	}
+	if token == "" {
+		return fmt.Errorf("token response does not contain a token")
+	}
+
+	r.mut.Lock()
+	r.token = token
+	r.mut.Unlock()
+	return nil
+}
+
+// parseChallenge parses the value of a WWW-Authenticate header, like
+// `Bearer realm="https://auth.example.com/token",service="registry"`.
+func parseChallenge(challenge string) (scheme string, params map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	params = make(map[string]string)
+
+	for rest = strings.TrimSpace(rest); rest != ""; rest = strings.TrimSpace(rest) {
+		key, value, ok := strings.Cut(rest, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+
+		if strings.HasPrefix(value, `"`) {
+			end := strings.Index(value[1:], `"`)
+			if end < 0 {
+				params[key] = value[1:]
+				break
+			}
+			params[key] = value[1 : end+1]
+			rest = strings.TrimPrefix(strings.TrimSpace(value[end+2:]), ",")
+		} else {
+			value, rest, _ = strings.Cut(value, ",")
+			params[key] = strings.TrimSpace(value)
+		}
+	}
+	return scheme, params
+}
@@ -0,0 +1,131 @@
+package oci_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/oci"
+	"github.com/grafana/alloy/internal/oci/ocitest"
+)
+
+func TestRepository_Pull(t *testing.T) {
+	registry := ocitest.NewRegistry(t)
+	files := map[string]string{
+		"math.alloy":     "declare \"add\" { }",
+		"lib/util.alloy": "declare \"util\" { }",
+	}
+
+	tt := []struct {
+		name string
+		push func(repository, tag string, files map[string]string) string
+	}{
+		{
+			name: "one layer per file",
+			push: func(repository, tag string, files map[string]string) string {
+				return registry.Push(repository, tag, files).String()
+			},
+		},
+		{
+			name: "archive",
+			push: func(repository, tag string, files map[string]string) string {
+				return registry.PushArchive(repository, tag, files).String()
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			d := tc.push("modules/math", "v1", files)
+
+			repo, err := oci.NewRepository(oci.RepositoryOptions{
+				Reference: registry.Host() + "/modules/math:v1",
+				PlainHTTP: true,
+			})
+			require.NoError(t, err)
+			require.False(t, repo.Pinned())
+
+			artifact, err := repo.Pull(t.Context())
+			require.NoError(t, err)
+			require.Equal(t, d, artifact.Digest.String())
+			require.Equal(t, map[string][]byte{
+				"math.alloy":     []byte(files["math.alloy"]),
+				"lib/util.alloy": []byte(files["lib/util.alloy"]),
+			}, artifact.Files)
+
+			resolved, err := repo.Resolve(t.Context())
+			require.NoError(t, err)
+			require.Equal(t, d, resolved.String())
+		})
+	}
+}
+
+func TestRepository_Digest(t *testing.T) {
+	registry := ocitest.NewRegistry(t)
+	d := registry.Push("modules/math", "", map[string]string{"math.alloy": "v1"})
+	registry.Push("modules/math", "v2", map[string]string{"math.alloy": "v2"})
+
+	repo, err := oci.NewRepository(oci.RepositoryOptions{
+		Reference: registry.Host() + "/modules/math@" + d.String(),
+		PlainHTTP: true,
+	})
+	require.NoError(t, err)
+	require.True(t, repo.Pinned())
+
+	artifact, err := repo.Pull(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, d, artifact.Digest)
+	require.Equal(t, []byte("v1"), artifact.Files["math.alloy"])
+}
+
+func TestRepository_TokenAuth(t *testing.T) {
+	registry := ocitest.NewRegistry(t, ocitest.WithTokenAuth("user", "pass"))
+	registry.Push("modules/math", "v1", map[string]string{"math.alloy": "content"})
+
+	repo, err := oci.NewRepository(oci.RepositoryOptions{
+		Reference: registry.Host() + "/modules/math:v1",
+		PlainHTTP: true,
+		Auth: oci.AuthConfig{
+			BasicAuth: &oci.BasicAuth{Username: "user", Password: "pass"},
+		},
+	})
+	require.NoError(t, err)
+	artifact, err := repo.Pull(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, []byte("content"), artifact.Files["math.alloy"])
+
+	repo, err = oci.NewRepository(oci.RepositoryOptions{
+		Reference: registry.Host() + "/modules/math:v1",
+		PlainHTTP: true,
+		Auth: oci.AuthConfig{
+			BasicAuth: &oci.BasicAuth{Username: "user", Password: "wrong"},
+		},
+	})
+	require.NoError(t, err)
+	_, err = repo.Pull(t.Context())
+	require.ErrorContains(t, err, "token request failed with status 401")
+}
+
+func TestRepository_Errors(t *testing.T) {
+	registry := ocitest.NewRegistry(t)
+	registry.Push("modules/escape", "v1", map[string]string{"../escape.alloy": "content"})
+
+	_, err := oci.NewRepository(oci.RepositoryOptions{Reference: "Invalid Reference"})
+	require.ErrorContains(t, err, "invalid reference")
+
+	repo, err := oci.NewRepository(oci.RepositoryOptions{
+		Reference: registry.Host() + "/modules/missing:v1",
+		PlainHTTP: true,
+	})
+	require.NoError(t, err)
+	_, err = repo.Pull(t.Context())
+	require.ErrorContains(t, err, "unexpected status 404")
+
+	repo, err = oci.NewRepository(oci.RepositoryOptions{
+		Reference: registry.Host() + "/modules/escape:v1",
+		PlainHTTP: true,
+	})
+	require.NoError(t, err)
+	_, err = repo.Pull(t.Context())
+	require.ErrorContains(t, err, `invalid file path "../escape.alloy"`)
+}
@@ -0,0 +1,218 @@
+// Package ocitest provides an in-memory OCI registry for tests.
+package ocitest
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	artifactType = "application/vnd.grafana.alloy.module.v1"
+	fileType     = "application/vnd.grafana.alloy.config"
+	testToken    = "ocitest-token"
+)
+
+// Registry is an in-memory registry serving the distribution API over plain
+// HTTP. Only the requests needed to pull artifacts are supported.
+type Registry struct {
+	srv *httptest.Server
+
+	username, password string // Credentials required by the token service, if set.
+
+	mut       sync.Mutex
+	manifests map[string][]byte // Keyed by repository and tag or digest.
+	blobs     map[digest.Digest][]byte
+	pulls     int
+}
+
+// Option configures a Registry.
+type Option func(*Registry)
+
+// WithTokenAuth makes the registry require token authentication, with a
+// token service accepting the given credentials.
+func WithTokenAuth(username, password string) Option {
+	return func(r *Registry) {
+		r.username, r.password = username, password
+	}
+}
+
+// NewRegistry starts a Registry which is stopped at the end of the test.
+func NewRegistry(t testing.TB, opts ...Option) *Registry {
+	r := &Registry{
+		manifests: make(map[string][]byte),
+		blobs:     make(map[digest.Digest][]byte),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	r.srv = httptest.NewServer(http.HandlerFunc(r.serveHTTP))
+	t.Cleanup(r.srv.Close)
+	return r
+}
+
+// Host returns the host of the registry, used in references.
+func (r *Registry) Host() string {
+	u, _ := url.Parse(r.srv.URL)
+	return u.Host
+}
+
+// Close stops the registry, making it unreachable.
+func (r *Registry) Close() {
+	r.srv.Close()
+}
+
+// Pulls returns the number of manifests downloaded from the registry.
+func (r *Registry) Pulls() int {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	return r.pulls
+}
+
+// Push stores an artifact with one layer per file, like the artifacts
+// pushed with oras, and tags it. It returns the digest of the manifest.
+func (r *Registry) Push(repository, tag string, files map[string]string) digest.Digest {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	layers := make([]ocispec.Descriptor, 0, len(files))
+	for _, name := range names {
+		desc := r.pushBlob(fileType, []byte(files[name]))
+		desc.Annotations = map[string]string{ocispec.AnnotationTitle: name}
+		layers = append(layers, desc)
+	}
+	return r.pushManifest(repository, tag, layers)
+}
+
+// PushArchive stores an artifact whose files are in a single gzipped tar
+// layer, and tags it. It returns the digest of the manifest.
+func (r *Registry) PushArchive(repository, tag string, files map[string]string) digest.Digest {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		_ = tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		_, _ = tw.Write([]byte(content))
+	}
+	_ = tw.Close()
+	_ = gz.Close()
+
+	layer := r.pushBlob(ocispec.MediaTypeImageLayerGzip, buf.Bytes())
+	return r.pushManifest(repository, tag, []ocispec.Descriptor{layer})
+}
+
+func (r *Registry) pushBlob(mediaType string, content []byte) ocispec.Descriptor {
+	d := digest.FromBytes(content)
+
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.blobs[d] = content
+	return ocispec.Descriptor{MediaType: mediaType, Digest: d, Size: int64(len(content))}
+}
+
+func (r *Registry) pushManifest(repository, tag string, layers []ocispec.Descriptor) digest.Digest {
+	config := r.pushBlob(ocispec.MediaTypeEmptyJSON, []byte("{}"))
+	bb, err := json.Marshal(ocispec.Manifest{
+		Versioned:    specs.Versioned{SchemaVersion: 2},
+		MediaType:    ocispec.MediaTypeImageManifest,
+		ArtifactType: artifactType,
+		Config:       config,
+		Layers:       layers,
+	})
+	if err != nil {
+		panic(err)
+	}
+	d := digest.FromBytes(bb)
+
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.manifests[repository+"@"+d.String()] = bb
+	if tag != "" {
+		r.manifests[repository+":"+tag] = bb
+	}
+	return d
+}
+
+func (r *Registry) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path == "/token" {
+		r.serveToken(w, req)
+		return
+	}
+
+	if r.username != "" && req.Header.Get("Authorization") != "Bearer "+testToken {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="ocitest"`, r.srv.URL))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	path, ok := strings.CutPrefix(req.URL.Path, "/v2/")
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+
+	if i := strings.LastIndex(path, "/manifests/"); i >= 0 {
+		repository, ref := path[:i], path[i+len("/manifests/"):]
+		sep := ":"
+		if strings.Contains(ref, ":") {
+			sep = "@"
+		}
+
+		r.mut.Lock()
+		bb, ok := r.manifests[repository+sep+ref]
+		if ok && req.Method == http.MethodGet {
+			r.pulls++
+		}
+		r.mut.Unlock()
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+
+		w.Header().Set("Content-Type", ocispec.MediaTypeImageManifest)
+		w.Header().Set("Docker-Content-Digest", digest.FromBytes(bb).String())
+		if req.Method == http.MethodGet {
+			_, _ = w.Write(bb)
+		}
+		return
+	}
+
+	if i := strings.LastIndex(path, "/blobs/"); i >= 0 {
+		r.mut.Lock()
+		bb, ok := r.blobs[digest.Digest(path[i+len("/blobs/"):])]
+		r.mut.Unlock()
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		_, _ = w.Write(bb)
+		return
+	}
+
+	http.NotFound(w, req)
+}
+
+func (r *Registry) serveToken(w http.ResponseWriter, req *http.Request) {
+	username, password, ok := req.BasicAuth()
+	if !ok || username != r.username || password != r.password {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]string{"token": testToken})
+}
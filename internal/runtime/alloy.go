@@ -114,6 +114,10 @@ type Options struct {
 
 	// EnableCommunityComps enables the use of community components.
 	EnableCommunityComps bool
+
+	// ReloadPolicy configures the rollout of sources loaded after the initial
+	// load. It is ignored by module controllers.
+	ReloadPolicy ReloadPolicy
 }
 
 // Runtime is the Alloy system.
@@ -131,6 +135,13 @@ type Runtime struct {
 
 	loadMut    sync.RWMutex
 	loadedOnce atomic.Bool
+
+	rolloutMut     sync.Mutex
+	lastApplied    *appliedSource     // Last source committed by the reload policy.
+	cancelRollout  context.CancelFunc // Cancels the rollout in progress, if any.
+	lastRollout    *Rollout           // Rollout of the last source loaded, if any.
+	rollouts       sync.WaitGroup
+	rolloutMetrics *rolloutMetrics
 }
 
 // New creates a new, unstarted Alloy controller. Call Run to run the controller.
@@ -143,6 +154,20 @@ func New(o Options) *Runtime {
 	})
 }
 
+// NewWithComponentRegistry creates a new, unstarted Alloy controller which
+// looks up components in registry instead of the components registered to
+// github.com/grafana/alloy/internal/component. It's used to replace
+// components with test doubles when running a configuration offline.
+func NewWithComponentRegistry(o Options, registry component.Registry) *Runtime {
+	return newController(controllerOptions{
+		Options:           o,
+		ComponentRegistry: registry,
+		ModuleRegistry:    newModuleRegistry(),
+		IsModule:          false, // We are creating a new root controller.
+		WorkerPool:        worker.NewDefaultWorkerPool(),
+	})
+}
+
 // controllerOptions are internal options used to create both root Alloy
 // controller and controllers for modules.
 type controllerOptions struct {
@@ -191,6 +216,9 @@ func newController(o controllerOptions) *Runtime {
 
 		loadFinished: make(chan struct{}, 1),
 	}
+	if !o.IsModule && o.ReloadPolicy.enabled() {
+		f.rolloutMetrics = newRolloutMetrics(o.Reg)
+	}
 
 	serviceMap := controller.NewServiceMap(o.Services)
 
@@ -254,6 +282,7 @@ func (f *Runtime) Run(ctx context.Context) {
 	defer func() { _ = f.sched.Close() }()
 	defer f.loader.Cleanup(!f.opts.IsModule)
 	defer level.Debug(f.log).Log("msg", "Alloy controller exiting")
+	defer f.stopRollout()
 
 	for {
 		select {
@@ -312,20 +341,37 @@ func (f *Runtime) Run(ctx context.Context) {
 // The controller will only start running components after Load is called once
 // without any configuration errors.
 // LoadSource uses default loader configuration.
+//
+// If a reload policy is set, the health of the components is watched in the
+// background after every load except the initial one. LoadSource doesn't wait
+// for the health window; rollbacks are reported to ReloadPolicy.OnRollback
+// and by the Rollout returned from LastRollout.
 func (f *Runtime) LoadSource(source *Source, args map[string]any, configPath string) error {
+	if !f.opts.IsModule && f.opts.ReloadPolicy.enabled() {
+		return f.rollout(source, args, configPath)
+	}
+	return f.applySource(source, args, configPath)
+}
+
+func (f *Runtime) applySource(source *Source, args map[string]any, configPath string) error {
+	return f.applyLoaderConfig(f.applyOptions(source, args, configPath))
+}
+
+func (f *Runtime) applyOptions(source *Source, args map[string]any, configPath string) controller.ApplyOptions {
 	modulePath, err := util.ExtractDirPath(configPath)
 	if err != nil {
 		level.Warn(f.log).Log("msg", "failed to extract directory path from configPath", "configPath", configPath, "err", err)
 	}
-	return f.applyLoaderConfig(controller.ApplyOptions{
+	return controller.ApplyOptions{
 		Args:            args,
 		ComponentBlocks: source.Components(),
 		ConfigBlocks:    source.Configs(),
 		DeclareBlocks:   source.Declares(),
+		FunctionBlocks:  source.Functions(),
 		ArgScope: vm.NewScope(map[string]interface{}{
 			importsource.ModulePath: modulePath,
 		}),
-	})
+	}
 }
 
 // Same as above but with a customComponentRegistry that provides custom component definitions.
@@ -335,6 +381,7 @@ func (f *Runtime) loadSource(source *Source, args map[string]any, customComponen
 		ComponentBlocks:         source.Components(),
 		ConfigBlocks:            source.Configs(),
 		DeclareBlocks:           source.Declares(),
+		FunctionBlocks:          source.Functions(),
 		CustomComponentRegistry: customComponentRegistry,
 		ArgScope:                customComponentRegistry.Scope(),
 	})
@@ -131,6 +131,10 @@ func (f *Runtime) getComponentDetail(cn controller.ComponentNode, graph *dag.Gra
 		}
 	}
 
+	if foreachNode, ok := cn.(*controller.ForeachConfigNode); ok && opts.GetDebugInfo {
+		componentInfo.DebugInfo = foreachNode.DebugInfo()
+	}
+
 	_, liveDebuggingEnabled := componentInfo.Component.(component.LiveDebugging)
 	componentInfo.LiveDebuggingEnabled = liveDebuggingEnabled
 
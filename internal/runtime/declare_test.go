@@ -55,6 +55,45 @@ func TestDeclare(t *testing.T) {
 			`,
 			expected: 10,
 		},
+		{
+			name: "TypedArguments",
+			config: `
+			declare "test" {
+				argument "input" {
+					type = "number"
+					min  = 0
+				}
+				argument "lag" {
+					type     = "string"
+					optional = true
+					default  = "1ms"
+					regex    = "[0-9]+(ms|s)"
+				}
+
+				testcomponents.passthrough "pt" {
+					input = argument.input.value
+					lag = argument.lag.value
+				}
+
+				export "output" {
+					value = testcomponents.passthrough.pt.output
+				}
+			}
+			testcomponents.count "inc" {
+				frequency = "10ms"
+				max = 10
+			}
+
+			test "myModule" {
+				input = testcomponents.count.inc.count
+			}
+
+			testcomponents.summation "sum" {
+				input = test.myModule.output
+			}
+			`,
+			expected: 10,
+		},
 		{
 			name: "NestedDeclares",
 			config: `
@@ -482,6 +521,66 @@ func TestDeclareError(t *testing.T) {
 			`,
 			expectedError: regexp.MustCompile(`'declare' is not a valid label for a declare block`),
 		},
+		{
+			name: "InvalidArgumentType",
+			config: `
+			declare "a" {
+				argument "input" {
+					type = "list(string)"
+				}
+			}
+			a "example" {
+				input = ["a", 1]
+			}
+			`,
+			expectedError: regexp.MustCompile(`:8:13: invalid value for argument "input": element 1: expected string, got number`),
+		},
+		{
+			name: "DisallowedArgumentValue",
+			config: `
+			declare "a" {
+				argument "level" {
+					allowed_values = ["debug", "info"]
+				}
+			}
+			a "example" {
+				level = "warn"
+			}
+			`,
+			expectedError: regexp.MustCompile(`invalid value for argument "level": "warn" is not one of the allowed values \["debug", "info"\]`),
+		},
+		{
+			name: "ArgumentOutOfBounds",
+			config: `
+			declare "a" {
+				argument "port" {
+					type = "number"
+					min  = 1
+					max  = 65535
+				}
+			}
+			a "example" {
+				port = 0
+			}
+			`,
+			expectedError: regexp.MustCompile(`invalid value for argument "port": 0 is less than the minimum 1`),
+		},
+		{
+			name: "InvalidArgumentDefault",
+			config: `
+			declare "a" {
+				argument "name" {
+					optional = true
+					default  = "-"
+					regex    = "[a-z]+"
+				}
+			}
+			a "example" {
+				name = "example"
+			}
+			`,
+			expectedError: regexp.MustCompile(`invalid default value: "-" doesn't match regex "\[a-z\]\+"`),
+		},
 	}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
@@ -0,0 +1,470 @@
+package runtime
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/dag"
+	"github.com/grafana/alloy/internal/nodeconf/argument"
+	"github.com/grafana/alloy/internal/nodeconf/export"
+	"github.com/grafana/alloy/internal/nodeconf/foreach"
+	"github.com/grafana/alloy/internal/nodeconf/importsource"
+	"github.com/grafana/alloy/internal/runtime/internal/controller"
+	"github.com/grafana/alloy/syntax/alloytypes"
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/printer"
+	"github.com/grafana/alloy/syntax/typecheck"
+)
+
+// ChangeType describes how a node, an edge or an argument changes.
+type ChangeType string
+
+const (
+	ChangeCreated ChangeType = "created"
+	ChangeDeleted ChangeType = "deleted"
+	ChangeUpdated ChangeType = "updated"
+
+	// ChangeReevaluated is used for nodes whose block doesn't change but which
+	// depend on a node which changes, and so may get new arguments.
+	ChangeReevaluated ChangeType = "reevaluated"
+)
+
+// redacted replaces the values of secret arguments in a ConfigDiff.
+const redacted = "(secret)"
+
+// ConfigDiff describes the changes loading a source would make to the
+// graph of a controller.
+type ConfigDiff struct {
+	Nodes []NodeDiff `json:"nodes"`
+	Edges []EdgeDiff `json:"edges"`
+}
+
+// Empty returns whether d doesn't have any change.
+func (d *ConfigDiff) Empty() bool {
+	return len(d.Nodes) == 0 && len(d.Edges) == 0
+}
+
+// NodeDiff describes the change of a node. Arguments lists the arguments of
+// created nodes and the changed arguments of updated nodes.
+type NodeDiff struct {
+	ID        string         `json:"id"`
+	Change    ChangeType     `json:"change"`
+	Arguments []ArgumentDiff `json:"arguments,omitempty"`
+}
+
+// ArgumentDiff describes the change of an attribute of a block. Path is the
+// path of the attribute in the block, such as "endpoint[1].url", and Old and
+// New are the expressions assigned to the attribute.
+type ArgumentDiff struct {
+	Path   string     `json:"path"`
+	Change ChangeType `json:"change"`
+	Old    string     `json:"old,omitempty"`
+	New    string     `json:"new,omitempty"`
+}
+
+// EdgeDiff describes the change of a dependency of the node From on the node
+// To.
+type EdgeDiff struct {
+	From   string     `json:"from"`
+	To     string     `json:"to"`
+	Change ChangeType `json:"change"`
+}
+
+// DiffSource returns the changes which loading source would make to the
+// graph of the controller. The nodes of source are neither evaluated nor
+// run: only the blocks of the nodes and the references between them are
+// compared.
+func (f *Runtime) DiffSource(source *Source, args map[string]any, configPath string) (*ConfigDiff, error) {
+	candidate, err := f.dryRun(source, args, configPath)
+	if err != nil {
+		return nil, err
+	}
+	return f.differ().diffGraphs(f.loader.Graph(), candidate), nil
+}
+
+// DiffSources is like DiffSource, but compares the graph of base rather than
+// the graph of the controller to the graph of candidate. The controller
+// doesn't need to be running.
+func (f *Runtime) DiffSources(base, candidate *Source, args map[string]any, configPath string) (*ConfigDiff, error) {
+	baseGraph, err := f.dryRun(base, args, configPath)
+	if err != nil {
+		return nil, err
+	}
+	candidateGraph, err := f.dryRun(candidate, args, configPath)
+	if err != nil {
+		return nil, err
+	}
+	return f.differ().diffGraphs(baseGraph, candidateGraph), nil
+}
+
+func (f *Runtime) dryRun(source *Source, args map[string]any, configPath string) (*dag.Graph, error) {
+	g, diags := f.loader.DryRun(f.applyOptions(source, args, configPath))
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return g, nil
+}
+
+// differ compares the graphs of two sources.
+type differ struct {
+	// registry resolves the types of the component blocks in declare and
+	// foreach templates.
+	registry component.Registry
+}
+
+func (f *Runtime) differ() differ {
+	return differ{registry: f.loader.ComponentRegistry()}
+}
+
+func (d differ) diffGraphs(current, candidate *dag.Graph) *ConfigDiff {
+	diff := &ConfigDiff{Nodes: []NodeDiff{}, Edges: []EdgeDiff{}}
+
+	changed := make(map[string]struct{})
+	for _, n := range candidate.Nodes() {
+		old := current.GetByID(n.NodeID())
+		if old == nil {
+			diff.Nodes = append(diff.Nodes, NodeDiff{
+				ID:        n.NodeID(),
+				Change:    ChangeCreated,
+				Arguments: d.diffBody("", nil, blockBody(n), nodeSchema(n)),
+			})
+			changed[n.NodeID()] = struct{}{}
+			continue
+		}
+		if args := d.diffBody("", blockBody(old), blockBody(n), nodeSchema(n)); len(args) > 0 {
+			diff.Nodes = append(diff.Nodes, NodeDiff{ID: n.NodeID(), Change: ChangeUpdated, Arguments: args})
+			changed[n.NodeID()] = struct{}{}
+		}
+	}
+	for _, n := range current.Nodes() {
+		if candidate.GetByID(n.NodeID()) == nil {
+			diff.Nodes = append(diff.Nodes, NodeDiff{ID: n.NodeID(), Change: ChangeDeleted})
+		}
+	}
+
+	// Nodes depending on changed nodes are evaluated again.
+	for _, n := range reevaluatedNodes(candidate, changed) {
+		diff.Nodes = append(diff.Nodes, NodeDiff{ID: n, Change: ChangeReevaluated})
+	}
+
+	currentEdges, candidateEdges := edgeSet(current), edgeSet(candidate)
+	for e := range candidateEdges {
+		if _, ok := currentEdges[e]; !ok {
+			diff.Edges = append(diff.Edges, EdgeDiff{From: e[0], To: e[1], Change: ChangeCreated})
+		}
+	}
+	for e := range currentEdges {
+		if _, ok := candidateEdges[e]; !ok {
+			diff.Edges = append(diff.Edges, EdgeDiff{From: e[0], To: e[1], Change: ChangeDeleted})
+		}
+	}
+
+	slices.SortFunc(diff.Nodes, func(a, b NodeDiff) int { return strings.Compare(a.ID, b.ID) })
+	slices.SortFunc(diff.Edges, func(a, b EdgeDiff) int {
+		if c := strings.Compare(a.From, b.From); c != 0 {
+			return c
+		}
+		return strings.Compare(a.To, b.To)
+	})
+	return diff
+}
+
+// reevaluatedNodes returns the IDs of the unchanged nodes which depend
+// directly or indirectly on the changed nodes.
+func reevaluatedNodes(g *dag.Graph, changed map[string]struct{}) []string {
+	var (
+		queue = make([]dag.Node, 0, len(changed))
+		seen  = make(map[string]struct{})
+		ids   []string
+	)
+	for id := range changed {
+		queue = append(queue, g.GetByID(id))
+	}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, dependant := range g.Dependants(n) {
+			id := dependant.NodeID()
+			if _, ok := seen[id]; ok {
+				continue
+			}
+			seen[id] = struct{}{}
+			queue = append(queue, dependant)
+			if _, ok := changed[id]; !ok {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+func edgeSet(g *dag.Graph) map[[2]string]struct{} {
+	edges := make(map[[2]string]struct{})
+	for _, e := range g.Edges() {
+		edges[[2]string{e.From.NodeID(), e.To.NodeID()}] = struct{}{}
+	}
+	return edges
+}
+
+func blockBody(n dag.Node) ast.Body {
+	bn, ok := n.(controller.BlockNode)
+	if !ok || bn.Block() == nil {
+		return nil
+	}
+	return bn.Block().Body
+}
+
+// importArgumentTypes maps the names of import blocks to the type of their
+// arguments.
+var importArgumentTypes = map[string]reflect.Type{
+	importsource.BlockNameFile:   reflect.TypeOf(importsource.FileArguments{}),
+	importsource.BlockNameString: reflect.TypeOf(importsource.StringArguments{}),
+	importsource.BlockNameHTTP:   reflect.TypeOf(importsource.HTTPArguments{}),
+	importsource.BlockNameGit:    reflect.TypeOf(importsource.GitArguments{}),
+	importsource.BlockNameOCI:    reflect.TypeOf(importsource.OCIArguments{}),
+}
+
+// bodySchema describes how the body of a block is decoded.
+type bodySchema struct {
+	// t is the type the body is decoded into, or nil if it isn't known.
+	t reflect.Type
+
+	// template is set for the bodies of declare and foreach templates, whose
+	// blocks are components and config blocks.
+	template bool
+
+	// custom is set for the arguments of custom components, whose types
+	// aren't known.
+	custom bool
+}
+
+// nodeSchema returns the schema of the block of n.
+func nodeSchema(n dag.Node) bodySchema {
+	switch n := n.(type) {
+	case *controller.BuiltinComponentNode:
+		return bodySchema{t: reflect.TypeOf(n.Registration().Args)}
+	case *controller.CustomComponentNode:
+		return bodySchema{custom: true}
+	case *controller.DeclareNode:
+		return bodySchema{template: true}
+	case *controller.ForeachConfigNode:
+		return bodySchema{t: foreachArgumentsType}
+	case *controller.ServiceNode:
+		return bodySchema{t: reflect.TypeOf(n.Definition().ConfigType)}
+	case *controller.ImportConfigNode:
+		return bodySchema{t: importArgumentTypes[n.Block().GetBlockName()]}
+	default:
+		return bodySchema{}
+	}
+}
+
+var (
+	argumentArgumentsType = reflect.TypeOf(argument.Arguments{})
+	exportArgumentsType   = reflect.TypeOf(export.Arguments{})
+	foreachArgumentsType  = reflect.TypeOf(foreach.Arguments{})
+)
+
+// blockSchema returns the schema of the blocks called name in a body with
+// the schema parent.
+func (d differ) blockSchema(parent bodySchema, name string) bodySchema {
+	switch {
+	case parent.custom:
+		return bodySchema{custom: true}
+	case parent.template:
+		return d.templateBlockSchema(name)
+	case parent.t == foreachArgumentsType && name == foreach.TypeTemplate:
+		return bodySchema{template: true}
+	}
+	if field, ok := lookupField(parent.t, name); ok {
+		return bodySchema{t: field.Type}
+	}
+	return bodySchema{}
+}
+
+// templateBlockSchema returns the schema of the blocks called name in a
+// declare or foreach template. Blocks which are neither builtin components
+// nor config blocks are custom components.
+func (d differ) templateBlockSchema(name string) bodySchema {
+	switch name {
+	case "argument":
+		return bodySchema{t: argumentArgumentsType}
+	case "export":
+		return bodySchema{t: exportArgumentsType}
+	case "declare":
+		return bodySchema{template: true}
+	case foreach.BlockName:
+		return bodySchema{t: foreachArgumentsType}
+	}
+	if t, ok := importArgumentTypes[name]; ok {
+		return bodySchema{t: t}
+	}
+	if reg, err := d.registry.Get(name); err == nil {
+		return bodySchema{t: reflect.TypeOf(reg.Args)}
+	}
+	return bodySchema{custom: true}
+}
+
+var (
+	secretType         = reflect.TypeOf(alloytypes.Secret(""))
+	optionalSecretType = reflect.TypeOf(alloytypes.OptionalSecret{})
+
+	// secretName matches the names of attributes which are treated as
+	// secrets when the type of the block isn't known.
+	secretName = regexp.MustCompile(`(?i)(password|secret|token|credential|api_?key|private_key|access_key)`)
+)
+
+// isSecret returns whether the value of the attribute name of a block with
+// the given schema must be redacted. Every literal value of a custom
+// component argument is redacted, since its type isn't known.
+func isSecret(schema bodySchema, name string, value ast.Expr) bool {
+	if schema.custom {
+		return value != nil && hasLiteral(value)
+	}
+	return isSecretField(schema.t, name)
+}
+
+// isSecretField returns whether the attribute name of a block decoded into t
+// must be redacted.
+func isSecretField(t reflect.Type, name string) bool {
+	field, ok := lookupField(t, name)
+	if !ok {
+		return secretName.MatchString(name)
+	}
+	ft := field.Type
+	for ft.Kind() == reflect.Pointer || ft.Kind() == reflect.Slice || ft.Kind() == reflect.Array || ft.Kind() == reflect.Map {
+		ft = ft.Elem()
+	}
+	return ft == secretType || ft == optionalSecretType
+}
+
+func lookupField(t reflect.Type, name string) (typecheck.Field, bool) {
+	for _, f := range typecheck.Fields(t) {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return typecheck.Field{}, false
+}
+
+// hasLiteral returns whether e contains a literal value.
+func hasLiteral(e ast.Expr) bool {
+	var v literalFinder
+	ast.Walk(&v, e)
+	return v.found
+}
+
+type literalFinder struct{ found bool }
+
+func (v *literalFinder) Visit(n ast.Node) ast.Visitor {
+	if _, ok := n.(*ast.LiteralExpr); ok {
+		v.found = true
+	}
+	if v.found {
+		return nil
+	}
+	return v
+}
+
+// diffBody returns the changes of the attributes from old to new, which are
+// decoded with schema.
+func (d differ) diffBody(prefix string, old, new ast.Body, schema bodySchema) []ArgumentDiff {
+	var (
+		oldAttrs, oldBlocks = splitBody(old)
+		newAttrs, newBlocks = splitBody(new)
+		diffs               []ArgumentDiff
+	)
+
+	for _, name := range sortedKeys(oldAttrs, newAttrs) {
+		oldExpr, inOld := oldAttrs[name]
+		newExpr, inNew := newAttrs[name]
+		diff := ArgumentDiff{Path: prefix + name, Old: printExpr(oldExpr), New: printExpr(newExpr)}
+		switch {
+		case !inOld:
+			diff.Change = ChangeCreated
+		case !inNew:
+			diff.Change = ChangeDeleted
+		case diff.Old != diff.New:
+			diff.Change = ChangeUpdated
+		default:
+			continue
+		}
+		if isSecret(schema, name, oldExpr) || isSecret(schema, name, newExpr) {
+			diff.Old, diff.New = redactValue(diff.Old), redactValue(diff.New)
+		}
+		diffs = append(diffs, diff)
+	}
+
+	for _, name := range sortedKeys(oldBlocks, newBlocks) {
+		blockSchema := d.blockSchema(schema, name)
+		oldList, newList := oldBlocks[name], newBlocks[name]
+		for i := range max(len(oldList), len(newList)) {
+			path := prefix + name
+			if len(oldList) > 1 || len(newList) > 1 {
+				path = fmt.Sprintf("%s[%d]", path, i)
+			}
+			var oldBody, newBody ast.Body
+			if i < len(oldList) {
+				oldBody = oldList[i]
+			}
+			if i < len(newList) {
+				newBody = newList[i]
+			}
+			diffs = append(diffs, d.diffBody(path+".", oldBody, newBody, blockSchema)...)
+		}
+	}
+	return diffs
+}
+
+func redactValue(v string) string {
+	if v == "" {
+		return ""
+	}
+	return redacted
+}
+
+// splitBody returns the expressions of the attributes of a body and its
+// blocks grouped by name.
+func splitBody(body ast.Body) (map[string]ast.Expr, map[string][]ast.Body) {
+	attrs := make(map[string]ast.Expr)
+	blocks := make(map[string][]ast.Body)
+	for _, stmt := range body {
+		switch stmt := stmt.(type) {
+		case *ast.AttributeStmt:
+			attrs[stmt.Name.Name] = stmt.Value
+		case *ast.BlockStmt:
+			name := stmt.GetBlockName()
+			blocks[name] = append(blocks[name], stmt.Body)
+		}
+	}
+	return attrs, blocks
+}
+
+func printExpr(e ast.Expr) string {
+	if e == nil {
+		return ""
+	}
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, e); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+func sortedKeys[V any](a, b map[string]V) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
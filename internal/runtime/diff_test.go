@@ -0,0 +1,174 @@
+package runtime
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	_ "github.com/grafana/alloy/internal/runtime/internal/testcomponents"
+	_ "github.com/grafana/alloy/internal/runtime/internal/testcomponents/module/http"
+)
+
+func TestDiffSource(t *testing.T) {
+	defer verifyNoGoroutineLeaks(t)
+	ctrl := New(testOptions(t))
+	defer cleanUpController(t.Context(), ctrl)
+
+	require.NoError(t, ctrl.LoadSource(parseSource(t, `
+		testcomponents.passthrough "a" {
+			input = "hello"
+		}
+		testcomponents.passthrough "b" {
+			input = testcomponents.passthrough.a.output
+		}
+		testcomponents.passthrough "c" {
+			input = testcomponents.passthrough.b.output
+		}
+		testcomponents.passthrough "removed" {
+			input = "bye"
+		}
+	`), nil, ""))
+
+	diff, err := ctrl.DiffSource(parseSource(t, `
+		testcomponents.passthrough "a" {
+			input = "hello, world"
+			lag   = "1s"
+		}
+		testcomponents.passthrough "b" {
+			input = testcomponents.passthrough.a.output
+		}
+		testcomponents.passthrough "c" {
+			input = testcomponents.passthrough.b.output
+		}
+		testcomponents.passthrough "added" {
+			input = testcomponents.passthrough.c.output
+		}
+	`), nil, "")
+	require.NoError(t, err)
+	require.Equal(t, &ConfigDiff{
+		Nodes: []NodeDiff{
+			{ID: "testcomponents.passthrough.a", Change: ChangeUpdated, Arguments: []ArgumentDiff{
+				{Path: "input", Change: ChangeUpdated, Old: `"hello"`, New: `"hello, world"`},
+				{Path: "lag", Change: ChangeCreated, New: `"1s"`},
+			}},
+			{ID: "testcomponents.passthrough.added", Change: ChangeCreated, Arguments: []ArgumentDiff{
+				{Path: "input", Change: ChangeCreated, New: "testcomponents.passthrough.c.output"},
+			}},
+			{ID: "testcomponents.passthrough.b", Change: ChangeReevaluated},
+			{ID: "testcomponents.passthrough.c", Change: ChangeReevaluated},
+			{ID: "testcomponents.passthrough.removed", Change: ChangeDeleted},
+		},
+		Edges: []EdgeDiff{
+			{From: "testcomponents.passthrough.added", To: "testcomponents.passthrough.c", Change: ChangeCreated},
+		},
+	}, diff)
+
+	// The dry run doesn't change the loaded graph.
+	diff, err = ctrl.DiffSource(parseSource(t, `
+		testcomponents.passthrough "a" {
+			input = "hello"
+		}
+		testcomponents.passthrough "b" {
+			input = testcomponents.passthrough.a.output
+		}
+		testcomponents.passthrough "c" {
+			input = testcomponents.passthrough.b.output
+		}
+		testcomponents.passthrough "removed" {
+			input = "bye"
+		}
+	`), nil, "")
+	require.NoError(t, err)
+	require.True(t, diff.Empty())
+
+	_, err = ctrl.DiffSource(parseSource(t, `testcomponents.passthrough "a" { input = testcomponents.passthrough.missing.output }`), nil, "")
+	require.ErrorContains(t, err, "component \"testcomponents.passthrough.missing.output\" does not exist")
+}
+
+func TestDiffSources_Secrets(t *testing.T) {
+	defer verifyNoGoroutineLeaks(t)
+	ctrl := New(testOptions(t))
+	defer cleanUpController(t.Context(), ctrl)
+
+	base := parseSource(t, `
+		import.http "remote" {
+			url = "http://example.com/module.alloy"
+			client {
+				basic_auth {
+					username = "alloy"
+					password = "old"
+				}
+			}
+		}
+		declare "custom" {
+			argument "endpoint" { }
+			argument "input" { }
+
+			module.http "remote" {
+				url = "http://example.com/module.alloy"
+				client {
+					tls_config {
+						key_pem = "old"
+					}
+				}
+			}
+		}
+		testcomponents.passthrough "a" { }
+		testcomponents.passthrough "b" { }
+		custom "a" {
+			endpoint = "old"
+			input    = testcomponents.passthrough.a.output
+		}
+	`)
+	candidate := parseSource(t, `
+		import.http "remote" {
+			url = "http://example.com/module.alloy"
+			client {
+				basic_auth {
+					username = "alloy"
+					password = "new"
+				}
+			}
+		}
+		declare "custom" {
+			argument "endpoint" { }
+			argument "input" { }
+
+			module.http "remote" {
+				url = "http://example.com/module.alloy"
+				client {
+					tls_config {
+						key_pem = "new"
+					}
+				}
+			}
+		}
+		testcomponents.passthrough "a" { }
+		testcomponents.passthrough "b" { }
+		custom "a" {
+			endpoint = "new"
+			input    = testcomponents.passthrough.b.output
+		}
+	`)
+
+	diff, err := ctrl.DiffSources(base, candidate, nil, "")
+	require.NoError(t, err)
+	require.Equal(t, &ConfigDiff{
+		Nodes: []NodeDiff{
+			{ID: "custom.a", Change: ChangeUpdated, Arguments: []ArgumentDiff{
+				{Path: "endpoint", Change: ChangeUpdated, Old: redacted, New: redacted},
+				{Path: "input", Change: ChangeUpdated, Old: "testcomponents.passthrough.a.output", New: "testcomponents.passthrough.b.output"},
+			}},
+			{ID: "declare.custom", Change: ChangeUpdated, Arguments: []ArgumentDiff{
+				{Path: "module.http.client.tls_config.key_pem", Change: ChangeUpdated, Old: redacted, New: redacted},
+			}},
+			{ID: "import.http.remote", Change: ChangeUpdated, Arguments: []ArgumentDiff{
+				{Path: "client.basic_auth.password", Change: ChangeUpdated, Old: redacted, New: redacted},
+			}},
+		},
+		Edges: []EdgeDiff{
+			{From: "custom.a", To: "testcomponents.passthrough.a", Change: ChangeDeleted},
+			{From: "custom.a", To: "testcomponents.passthrough.b", Change: ChangeCreated},
+		},
+	}, diff)
+}
@@ -0,0 +1,81 @@
+package runtime_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/runtime/internal/testcomponents"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileFunctions_ReevaluateOnChange(t *testing.T) {
+	dir := t.TempDir()
+	valuePath := filepath.Join(dir, "value.json")
+	flagPath := filepath.Join(dir, "flag")
+	require.NoError(t, os.WriteFile(valuePath, []byte("5"), 0o644))
+
+	tt := []struct {
+		name     string
+		config   string
+		update   func(t *testing.T)
+		expected int
+	}{
+		{
+			name:   "read",
+			config: fmt.Sprintf(`encoding.from_json(file.read(%q))`, valuePath),
+			update: func(t *testing.T) {
+				require.NoError(t, os.WriteFile(valuePath, []byte("7"), 0o644))
+			},
+			expected: 7,
+		},
+		{
+			name:   "exists",
+			config: fmt.Sprintf(`file.exists(%q) ? 2 : 1`, flagPath),
+			update: func(t *testing.T) {
+				require.NoError(t, os.WriteFile(flagPath, nil, 0o644))
+			},
+			expected: 2,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			defer verifyNoGoroutineLeaks(t)
+			config := fmt.Sprintf(`
+			testcomponents.summation "sum" {
+				input = %s
+			}
+			`, tc.config)
+
+			ctrl, f := setup(t, config, nil, featuregate.StabilityExperimental)
+			err := ctrl.LoadSource(f, nil, "")
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithCancel(t.Context())
+			done := make(chan struct{})
+			go func() {
+				ctrl.Run(ctx)
+				close(done)
+			}()
+			defer func() {
+				cancel()
+				<-done
+			}()
+
+			before := getExport[testcomponents.SummationExports](t, ctrl, "", "testcomponents.summation.sum")
+			require.NotEqual(t, tc.expected, before.LastAdded)
+
+			tc.update(t)
+
+			require.Eventually(t, func() bool {
+				export := getExport[testcomponents.SummationExports](t, ctrl, "", "testcomponents.summation.sum")
+				return export.LastAdded == tc.expected
+			}, 3*time.Second, 10*time.Millisecond)
+		})
+	}
+}
@@ -0,0 +1,330 @@
+package runtime_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/runtime/internal/testcomponents"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFunction(t *testing.T) {
+	tt := []testCase{
+		{
+			name: "LocalFunction",
+			config: `
+			function "double" {
+				argument "x" {}
+				result = argument.x.value * 2
+			}
+
+			testcomponents.count "inc" {
+				frequency = "10ms"
+				max = 10
+			}
+
+			testcomponents.summation "sum" {
+				input = double(testcomponents.count.inc.count)
+			}
+			`,
+			expected: 20,
+		},
+		{
+			name: "OptionalArgument",
+			config: `
+			function "scale" {
+				argument "x" {}
+				argument "factor" {
+					optional = true
+					default = 3
+				}
+				result = argument.x.value * argument.factor.value
+			}
+
+			testcomponents.count "inc" {
+				frequency = "10ms"
+				max = 10
+			}
+
+			testcomponents.summation "sum" {
+				input = scale(testcomponents.count.inc.count)
+			}
+			`,
+			expected: 30,
+		},
+		{
+			name: "FunctionCallingFunction",
+			config: `
+			function "double" {
+				argument "x" {}
+				result = argument.x.value * 2
+			}
+
+			function "quadruple" {
+				argument "x" {}
+				result = double(double(argument.x.value))
+			}
+
+			testcomponents.count "inc" {
+				frequency = "10ms"
+				max = 10
+			}
+
+			testcomponents.summation "sum" {
+				input = quadruple(testcomponents.count.inc.count)
+			}
+			`,
+			expected: 40,
+		},
+		{
+			name: "FunctionWithConditional",
+			config: `
+			function "clamp" {
+				argument "x" {}
+				argument "max" {}
+				result = argument.x.value > argument.max.value ? argument.max.value : argument.x.value
+			}
+
+			testcomponents.count "inc" {
+				frequency = "10ms"
+				max = 10
+			}
+
+			testcomponents.summation "sum" {
+				input = clamp(testcomponents.count.inc.count, 5)
+			}
+			`,
+			expected: 5,
+		},
+		{
+			name: "FunctionInDeclare",
+			config: `
+			function "double" {
+				argument "x" {}
+				result = argument.x.value * 2
+			}
+
+			declare "test" {
+				argument "input" {}
+
+				export "output" {
+					value = double(argument.input.value)
+				}
+			}
+
+			testcomponents.count "inc" {
+				frequency = "10ms"
+				max = 10
+			}
+
+			test "myModule" {
+				input = testcomponents.count.inc.count
+			}
+
+			testcomponents.summation "sum" {
+				input = test.myModule.output
+			}
+			`,
+			expected: 20,
+		},
+		{
+			name: "ImportedFunction",
+			config: `
+			import.string "math" {
+				content = ` + "`" + `
+					function "double" {
+						argument "x" {}
+						result = argument.x.value * 2
+					}
+
+					function "quadruple" {
+						argument "x" {}
+						result = double(double(argument.x.value))
+					}
+				` + "`" + `
+			}
+
+			testcomponents.count "inc" {
+				frequency = "10ms"
+				max = 10
+			}
+
+			testcomponents.summation "sum" {
+				input = math.quadruple(testcomponents.count.inc.count)
+			}
+			`,
+			expected: 40,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl, f := setup(t, tc.config, nil, featuregate.StabilityExperimental)
+			err := ctrl.LoadSource(f, nil, "")
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithCancel(t.Context())
+			done := make(chan struct{})
+			go func() {
+				ctrl.Run(ctx)
+				close(done)
+			}()
+			defer func() {
+				cancel()
+				<-done
+			}()
+
+			require.Eventually(t, func() bool {
+				export := getExport[testcomponents.SummationExports](t, ctrl, "", "testcomponents.summation.sum")
+				return export.LastAdded == tc.expected
+			}, 3*time.Second, 10*time.Millisecond)
+		})
+	}
+}
+
+func TestFunctionError(t *testing.T) {
+	tt := []errorTestCase{
+		{
+			name: "CycleBetweenFunctions",
+			config: `
+			function "a" {
+				result = b()
+			}
+			function "b" {
+				result = a()
+			}
+			`,
+			// Functions are visited in name order, so the cycle is reported on
+			// b, which references a.
+			expectedError: regexp.MustCompile(`5:4: cycle between functions: a -> b -> a`),
+		},
+		{
+			name: "MissingResult",
+			config: `
+			function "a" {
+				argument "x" {}
+			}
+			`,
+			expectedError: regexp.MustCompile(`missing required attribute "result" in function "a"`),
+		},
+		{
+			name: "RequiredArgumentAfterOptional",
+			config: `
+			function "a" {
+				argument "x" {
+					optional = true
+				}
+				argument "y" {}
+				result = argument.y.value
+			}
+			`,
+			expectedError: regexp.MustCompile(`required argument "y" of function "a" must be declared before optional arguments`),
+		},
+		{
+			name: "ConflictWithDeclare",
+			config: `
+			declare "a" {}
+			function "a" {
+				result = 1
+			}
+			`,
+			expectedError: regexp.MustCompile(`function "a" conflicts with a declare or import block with the same label`),
+		},
+		{
+			name: "ConflictWithComponent",
+			config: `
+			function "testcomponents" {
+				result = 1
+			}
+			testcomponents.summation "sum" {
+				input = 1
+			}
+			`,
+			expectedError: regexp.MustCompile(`function "testcomponents" conflicts with a component namespace with the same name`),
+		},
+		{
+			name: "MissingArgument",
+			config: `
+			function "a" {
+				argument "x" {}
+				result = argument.x.value
+			}
+			testcomponents.summation "sum" {
+				input = a()
+			}
+			`,
+			expectedError: regexp.MustCompile(`missing required argument "x" to function "a"`),
+		},
+		{
+			name: "TooManyArguments",
+			config: `
+			function "a" {
+				result = 1
+			}
+			testcomponents.summation "sum" {
+				input = a(1)
+			}
+			`,
+			expectedError: regexp.MustCompile(`function "a" expects at most 0 arguments, got 1`),
+		},
+		{
+			name: "InvalidArgumentType",
+			config: `
+			function "a" {
+				argument "x" {
+					type = "number"
+				}
+				result = argument.x.value
+			}
+			testcomponents.summation "sum" {
+				input = a("1")
+			}
+			`,
+			expectedError: regexp.MustCompile(`invalid value for argument "x" to function "a": expected number, got string`),
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			defer verifyNoGoroutineLeaks(t)
+			ctrl, f := setup(t, tc.config, nil, featuregate.StabilityExperimental)
+			err := ctrl.LoadSource(f, nil, "")
+			if err == nil {
+				t.Errorf("Expected error to match regex %q, but got: nil", tc.expectedError)
+			} else if !tc.expectedError.MatchString(err.Error()) {
+				t.Errorf("Expected error to match regex %q, but got: %v", tc.expectedError, err)
+			}
+
+			ctx, cancel := context.WithCancel(t.Context())
+			done := make(chan struct{})
+			go func() {
+				ctrl.Run(ctx)
+				close(done)
+			}()
+			cancel()
+			<-done
+		})
+	}
+}
+
+func TestFunctionStability(t *testing.T) {
+	config := `
+	function "a" {
+		result = 1
+	}
+	`
+	ctrl, f := setup(t, config, nil, featuregate.StabilityPublicPreview)
+	err := ctrl.LoadSource(f, nil, "")
+	require.ErrorContains(t, err, `function block "a" is at stability level "experimental"`)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		ctrl.Run(ctx)
+		close(done)
+	}()
+	cancel()
+	<-done
+}
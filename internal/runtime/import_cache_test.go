@@ -0,0 +1,225 @@
+package runtime_test
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/oci/ocitest"
+	alloy_runtime "github.com/grafana/alloy/internal/runtime"
+	"github.com/grafana/alloy/internal/runtime/logging"
+	"github.com/grafana/alloy/internal/service"
+)
+
+func TestImportHTTP_LastKnownGood(t *testing.T) {
+	srv := newModuleServer(t)
+	srv.set("/math.alloy", ociModule)
+
+	main := `
+import.http "testImport" {
+	url            = "` + srv.URL + `/math.alloy"
+	poll_frequency = "100ms"
+	poll_timeout   = "50ms"
+}
+
+testImport.add "cc" {
+	a = 1
+	b = 1
+}
+`
+	defer verifyNoGoroutineLeaks(t)
+	dataPath := t.TempDir()
+
+	ctrl, stop := runWithDataPath(t, main, dataPath, featuregate.StabilityGenerallyAvailable)
+	require.Eventually(t, func() bool {
+		export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+		return export["sum"] == 2
+	}, 5*time.Second, 50*time.Millisecond)
+	stop()
+
+	// The module cached by the previous run is loaded while the server is
+	// unavailable.
+	srv.remove("/math.alloy")
+	ctrl, stop = runWithDataPath(t, main, dataPath, featuregate.StabilityGenerallyAvailable)
+	defer stop()
+	export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+	require.Equal(t, 2, export["sum"])
+
+	// The module is updated once the server recovers.
+	srv.set("/math.alloy", ociModuleMore)
+	require.Eventually(t, func() bool {
+		export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+		return export["sum"] == 3
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func TestImportHTTP_LastKnownGood_Verify(t *testing.T) {
+	signer := newTestSigner(t)
+	other := newTestSigner(t)
+	srv := newModuleServer(t)
+	srv.set("/math.alloy", ociModule)
+	srv.set("/math.alloy.sig", signer.sign(ociModule))
+
+	config := func(verify string) string {
+		return `
+import.http "testImport" {
+	url            = "` + srv.URL + `/math.alloy"
+	poll_frequency = "100ms"
+	poll_timeout   = "50ms"
+` + verify + `
+}
+
+testImport.add "cc" {
+	a = 1
+	b = 1
+}
+`
+	}
+	verifyWith := func(s *testSigner) string {
+		return `verify { public_keys = [` + s.publicKey + `] }`
+	}
+
+	defer verifyNoGoroutineLeaks(t)
+
+	tt := []struct {
+		name         string
+		cachedVerify string
+		verify       string
+	}{
+		{name: "verify enabled after caching", verify: verifyWith(signer)},
+		{name: "signing key removed after caching", cachedVerify: verifyWith(signer), verify: verifyWith(other)},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			srv.set("/math.alloy", ociModule)
+			dataPath := t.TempDir()
+
+			ctrl, stop := runWithDataPath(t, config(tc.cachedVerify), dataPath, featuregate.StabilityGenerallyAvailable)
+			require.Eventually(t, func() bool {
+				export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+				return export["sum"] == 2
+			}, 5*time.Second, 50*time.Millisecond)
+			stop()
+
+			// The module cached with other verification settings isn't loaded
+			// while the server is unavailable.
+			srv.remove("/math.alloy")
+			ctrl = alloy_runtime.New(alloy_runtime.Options{
+				Logger:       logging.NewNop(),
+				DataPath:     dataPath,
+				MinStability: featuregate.StabilityGenerallyAvailable,
+				Services:     []service.Service{},
+			})
+			f, err := alloy_runtime.ParseSource(t.Name(), []byte(config(tc.verify)))
+			require.NoError(t, err)
+			require.ErrorContains(t, ctrl.LoadSource(f, nil, ""), "creating http component: unexpected status code 404 Not Found")
+
+			ctx, cancel := context.WithCancel(t.Context())
+			var wg sync.WaitGroup
+			defer func() {
+				cancel()
+				wg.Wait()
+			}()
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ctrl.Run(ctx)
+			}()
+		})
+	}
+}
+
+func TestImportOCI_LastKnownGood(t *testing.T) {
+	registry := ocitest.NewRegistry(t)
+	registry.Push("modules/math", "v1", map[string]string{"math.alloy": ociModule})
+
+	main := `
+import.oci "testImport" {
+	reference      = "` + registry.Host() + `/modules/math:v1"
+	path           = "math.alloy"
+	plain_http     = true
+	pull_frequency = "100ms"
+}
+
+testImport.add "cc" {
+	a = 1
+	b = 1
+}
+`
+	defer verifyNoGoroutineLeaks(t)
+	defer http.DefaultClient.CloseIdleConnections()
+	dataPath := t.TempDir()
+
+	ctrl, stop := runWithDataPath(t, main, dataPath, featuregate.StabilityExperimental)
+	require.Eventually(t, func() bool {
+		export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+		return export["sum"] == 2
+	}, 5*time.Second, 50*time.Millisecond)
+	stop()
+
+	registry.Close()
+	http.DefaultClient.CloseIdleConnections()
+	ctrl, stop = runWithDataPath(t, main, dataPath, featuregate.StabilityExperimental)
+	defer stop()
+	export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+	require.Equal(t, 2, export["sum"])
+}
+
+func TestImportLastKnownGood_NoCache(t *testing.T) {
+	srv := newModuleServer(t)
+
+	main := `
+import.http "testImport" {
+	url = "` + srv.URL + `/math.alloy"
+}`
+	defer verifyNoGoroutineLeaks(t)
+	ctrl, f := setup(t, main, nil, featuregate.StabilityGenerallyAvailable)
+	require.ErrorContains(t, ctrl.LoadSource(f, nil, ""), "creating http component: unexpected status code 404 Not Found")
+
+	ctx, cancel := context.WithCancel(t.Context())
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ctrl.Run(ctx)
+	}()
+}
+
+// runWithDataPath loads and runs config with a controller storing its data
+// in dataPath, so that the data outlives the controller.
+func runWithDataPath(t *testing.T, config, dataPath string, stability featuregate.Stability) (*alloy_runtime.Runtime, func()) {
+	s, err := logging.New(os.Stderr, logging.DefaultOptions)
+	require.NoError(t, err)
+	ctrl := alloy_runtime.New(alloy_runtime.Options{
+		Logger:       s,
+		DataPath:     dataPath,
+		MinStability: stability,
+		Services:     []service.Service{},
+	})
+	f, err := alloy_runtime.ParseSource(t.Name(), []byte(config))
+	require.NoError(t, err)
+	require.NoError(t, ctrl.LoadSource(f, nil, ""))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ctrl.Run(ctx)
+	}()
+	return ctrl, func() {
+		cancel()
+		wg.Wait()
+	}
+}
@@ -16,6 +16,7 @@ import (
 
 	"github.com/grafana/alloy/internal/featuregate"
 	"github.com/grafana/alloy/internal/vcs"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/require"
 )
 
@@ -381,3 +382,136 @@ testImport.add "cc" {
 		ctrl.Run(ctx)
 	}()
 }
+
+func TestPullVerified(t *testing.T) {
+	testRepo := t.TempDir()
+	signer := newTestSigner(t)
+
+	main := `
+import.git "testImport" {
+	repository     = "` + testRepo + `"
+	path           = "modules"
+	pull_frequency = "1s"
+
+	verify {
+		public_keys = [` + signer.publicKey + `]
+	}
+}
+
+testImport.add "cc" {
+	a = 1
+	b = 1
+}
+`
+	initializeRepo(t, testRepo)
+	runGit(t, testRepo, "checkout", "-b", "main")
+
+	modules := filepath.Join(testRepo, "modules")
+	require.NoError(t, os.Mkdir(modules, 0777))
+	math := filepath.Join(modules, "math.alloy")
+	require.NoError(t, os.WriteFile(math, []byte(contents), 0666))
+	require.NoError(t, os.WriteFile(math+".sig", []byte(signer.sign(contents)), 0666))
+	runGit(t, testRepo, "add", ".")
+	runGit(t, testRepo, "commit", "-m \"test\"")
+
+	reg := prometheus.NewRegistry()
+	defer verifyNoGoroutineLeaks(t)
+	ctrl, f := setup(t, main, reg, featuregate.StabilityPublicPreview)
+	err := ctrl.LoadSource(f, nil, "")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(t.Context())
+
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ctrl.Run(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+		return export["sum"] == 2
+	}, 5*time.Second, 100*time.Millisecond)
+
+	// Content without a matching signature is rejected and the last verified
+	// module is kept.
+	require.NoError(t, os.WriteFile(math, []byte(contentsMore), 0666))
+	runGit(t, testRepo, "add", ".")
+	runGit(t, testRepo, "commit", "-m \"test2\"")
+
+	require.Eventually(t, func() bool {
+		return verifications(t, reg, "failure") > 0
+	}, 5*time.Second, 100*time.Millisecond)
+	export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+	require.Equal(t, 2, export["sum"])
+
+	require.NoError(t, os.WriteFile(math+".sig", []byte(signer.sign(contentsMore)), 0666))
+	runGit(t, testRepo, "add", ".")
+	runGit(t, testRepo, "commit", "-m \"test3\"")
+
+	require.Eventually(t, func() bool {
+		export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+		return export["sum"] == 3
+	}, 5*time.Second, 100*time.Millisecond)
+}
+
+func TestPullLastKnownGood(t *testing.T) {
+	testRepo := t.TempDir()
+
+	main := `
+import.git "testImport" {
+	repository     = "` + testRepo + `"
+	path           = "math.alloy"
+	pull_frequency = "1s"
+}
+
+testImport.add "cc" {
+	a = 1
+	b = 1
+}
+`
+	initializeRepo(t, testRepo)
+	runGit(t, testRepo, "checkout", "-b", "main")
+
+	math := filepath.Join(testRepo, "math.alloy")
+	require.NoError(t, os.WriteFile(math, []byte(contents), 0666))
+	runGit(t, testRepo, "add", ".")
+	runGit(t, testRepo, "commit", "-m \"test\"")
+
+	defer verifyNoGoroutineLeaks(t)
+	dataPath := t.TempDir()
+
+	ctrl, stop := runWithDataPath(t, main, dataPath, featuregate.StabilityGenerallyAvailable)
+	require.Eventually(t, func() bool {
+		export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+		return export["sum"] == 2
+	}, 5*time.Second, 100*time.Millisecond)
+	stop()
+
+	// Neither the repository nor the previous clone are available, the module
+	// cached by the previous run is loaded.
+	movedRepo := testRepo + ".moved"
+	require.NoError(t, os.Rename(testRepo, movedRepo))
+	defer os.RemoveAll(movedRepo)
+	require.NoError(t, os.RemoveAll(filepath.Join(dataPath, "import.git.testImport", "repo")))
+	ctrl, stop = runWithDataPath(t, main, dataPath, featuregate.StabilityGenerallyAvailable)
+	defer stop()
+	export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+	require.Equal(t, 2, export["sum"])
+
+	// The module is updated once the repository is available again.
+	require.NoError(t, os.Rename(movedRepo, testRepo))
+	require.NoError(t, os.WriteFile(math, []byte(contentsMore), 0666))
+	runGit(t, testRepo, "add", ".")
+	runGit(t, testRepo, "commit", "-m \"test2\"")
+
+	require.Eventually(t, func() bool {
+		export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+		return export["sum"] == 3
+	}, 5*time.Second, 100*time.Millisecond)
+}
@@ -0,0 +1,192 @@
+package runtime_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/oci/ocitest"
+	alloy_runtime "github.com/grafana/alloy/internal/runtime"
+)
+
+const ociModule = `declare "add" {
+	argument "a" {}
+	argument "b" {}
+
+	export "sum" {
+		value = argument.a.value + argument.b.value
+	}
+}`
+
+const ociModuleMore = `declare "add" {
+	argument "a" {}
+	argument "b" {}
+
+	export "sum" {
+		value = argument.a.value + argument.b.value + 1
+	}
+}`
+
+func TestImportOCI(t *testing.T) {
+	registry := ocitest.NewRegistry(t, ocitest.WithTokenAuth("user", "pass"))
+	registry.Push("modules/math", "v1", map[string]string{"math.alloy": ociModule})
+
+	main := `
+import.oci "testImport" {
+	reference      = "` + registry.Host() + `/modules/math:v1"
+	plain_http     = true
+	pull_frequency = "100ms"
+
+	basic_auth {
+		username = "user"
+		password = "pass"
+	}
+}
+
+testImport.add "cc" {
+	a = 1
+	b = 1
+}
+`
+	defer verifyNoGoroutineLeaks(t)
+	defer http.DefaultClient.CloseIdleConnections()
+	ctrl, stop := runOCIController(t, main)
+	defer stop()
+
+	require.Eventually(t, func() bool {
+		export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+		return export["sum"] == 2
+	}, 5*time.Second, 50*time.Millisecond)
+
+	// Moving the tag updates the module.
+	registry.Push("modules/math", "v1", map[string]string{"math.alloy": ociModuleMore})
+	require.Eventually(t, func() bool {
+		export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+		return export["sum"] == 3
+	}, 5*time.Second, 50*time.Millisecond)
+
+	// The artifact is only downloaded again when its digest changes.
+	pulls := registry.Pulls()
+	time.Sleep(500 * time.Millisecond)
+	require.Equal(t, pulls, registry.Pulls())
+}
+
+func TestImportOCI_DirectoryWithNestedImport(t *testing.T) {
+	registry := ocitest.NewRegistry(t)
+	d := registry.PushArchive("modules/math", "", map[string]string{
+		"modules/math.alloy": `
+import.file "lib" {
+	filename = file.path_join(module_path, "lib/add.alloy")
+}
+
+declare "add" {
+	argument "a" {}
+	argument "b" {}
+
+	lib.add "inner" {
+		a = argument.a.value
+		b = argument.b.value
+	}
+
+	export "sum" {
+		value = lib.add.inner.sum
+	}
+}`,
+		"lib/add.alloy": ociModule,
+	})
+
+	main := `
+import.oci "testImport" {
+	reference  = "` + registry.Host() + `/modules/math@` + d.String() + `"
+	path       = "modules"
+	plain_http = true
+}
+
+testImport.add "cc" {
+	a = 1
+	b = 2
+}
+`
+	defer verifyNoGoroutineLeaks(t)
+	defer http.DefaultClient.CloseIdleConnections()
+	ctrl, stop := runOCIController(t, main)
+	defer stop()
+
+	require.Eventually(t, func() bool {
+		export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+		return export["sum"] == 3
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func TestImportOCI_Errors(t *testing.T) {
+	registry := ocitest.NewRegistry(t)
+
+	main := `
+import.oci "testImport" {
+	reference  = "` + registry.Host() + `/modules/missing:v1"
+	plain_http = true
+}
+`
+	tt := []struct {
+		name          string
+		stability     featuregate.Stability
+		expectedError string
+	}{
+		{
+			name:          "missing artifact",
+			stability:     featuregate.StabilityExperimental,
+			expectedError: "unexpected status 404",
+		},
+		{
+			name:          "stability level",
+			stability:     featuregate.StabilityPublicPreview,
+			expectedError: `config block "import.oci" is at stability level "experimental"`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			defer verifyNoGoroutineLeaks(t)
+			defer http.DefaultClient.CloseIdleConnections()
+			ctrl, f := setup(t, main, nil, tc.stability)
+			require.ErrorContains(t, ctrl.LoadSource(f, nil, ""), tc.expectedError)
+
+			ctx, cancel := context.WithCancel(t.Context())
+			var wg sync.WaitGroup
+			defer func() {
+				cancel()
+				wg.Wait()
+			}()
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ctrl.Run(ctx)
+			}()
+		})
+	}
+}
+
+// runOCIController loads config and runs the controller until the returned
+// function is called.
+func runOCIController(t *testing.T, config string) (*alloy_runtime.Runtime, func()) {
+	ctrl, f := setup(t, config, nil, featuregate.StabilityExperimental)
+	require.NoError(t, ctrl.LoadSource(f, nil, ""))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ctrl.Run(ctx)
+	}()
+	return ctrl, func() {
+		cancel()
+		wg.Wait()
+	}
+}
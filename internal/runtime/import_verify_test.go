@@ -0,0 +1,380 @@
+package runtime_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/oci/ocitest"
+)
+
+func TestImportHTTP_Verify(t *testing.T) {
+	signer := newTestSigner(t)
+	srv := newModuleServer(t)
+	srv.set("/math.alloy", ociModule)
+	srv.set("/math.alloy.sig", signer.sign(ociModule))
+
+	main := `
+import.http "testImport" {
+	url            = "` + srv.URL + `/math.alloy"
+	poll_frequency = "100ms"
+	poll_timeout   = "50ms"
+
+	verify {
+		public_keys = [` + signer.publicKey + `]
+	}
+}
+
+testImport.add "cc" {
+	a = 1
+	b = 1
+}
+`
+	reg := prometheus.NewRegistry()
+	defer verifyNoGoroutineLeaks(t)
+	ctrl, f := setup(t, main, reg, featuregate.StabilityGenerallyAvailable)
+	require.NoError(t, ctrl.LoadSource(f, nil, ""))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ctrl.Run(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+		return export["sum"] == 2
+	}, 5*time.Second, 50*time.Millisecond)
+
+	// Tampered content is rejected and the last verified module is kept.
+	srv.set("/math.alloy", ociModuleMore)
+	require.Eventually(t, func() bool {
+		return verifications(t, reg, "failure") > 0
+	}, 5*time.Second, 50*time.Millisecond)
+	export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+	require.Equal(t, 2, export["sum"])
+
+	// Signing the content loads it, even though the content didn't change.
+	srv.set("/math.alloy.sig", signer.sign(ociModuleMore))
+	require.Eventually(t, func() bool {
+		export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+		return export["sum"] == 3
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func TestImportOCI_Verify(t *testing.T) {
+	signer := newTestSigner(t)
+	registry := ocitest.NewRegistry(t)
+	registry.Push("modules/math", "v1", map[string]string{
+		"math.alloy":     ociModule,
+		"math.alloy.sig": signer.sign(ociModule),
+	})
+
+	main := `
+import.oci "testImport" {
+	reference      = "` + registry.Host() + `/modules/math:v1"
+	path           = "math.alloy"
+	plain_http     = true
+	pull_frequency = "100ms"
+
+	verify {
+		public_keys = [` + signer.publicKey + `]
+	}
+}
+
+testImport.add "cc" {
+	a = 1
+	b = 1
+}
+`
+	reg := prometheus.NewRegistry()
+	defer verifyNoGoroutineLeaks(t)
+	defer http.DefaultClient.CloseIdleConnections()
+	ctrl, f := setup(t, main, reg, featuregate.StabilityExperimental)
+	require.NoError(t, ctrl.LoadSource(f, nil, ""))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ctrl.Run(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+		return export["sum"] == 2
+	}, 5*time.Second, 50*time.Millisecond)
+
+	// Tampered content is rejected and the last verified module is kept.
+	registry.Push("modules/math", "v1", map[string]string{
+		"math.alloy":     ociModuleMore,
+		"math.alloy.sig": signer.sign(ociModule),
+	})
+	require.Eventually(t, func() bool {
+		return verifications(t, reg, "failure") > 0
+	}, 5*time.Second, 50*time.Millisecond)
+	export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+	require.Equal(t, 2, export["sum"])
+}
+
+func TestImportVerify_Errors(t *testing.T) {
+	signer := newTestSigner(t)
+	other := newTestSigner(t)
+	srv := newModuleServer(t)
+	srv.set("/unsigned.alloy", ociModule)
+	srv.set("/signed.alloy", ociModule)
+	srv.set("/signed.alloy.sig", other.sign(ociModule))
+	srv.set("/unavailable.alloy", ociModule)
+	srv.setStatus("/unavailable.alloy.sig", http.StatusInternalServerError)
+
+	tt := []struct {
+		name          string
+		config        string
+		expectedError string
+	}{
+		{
+			name: "missing signature",
+			config: `
+import.http "testImport" {
+	url = "` + srv.URL + `/unsigned.alloy"
+
+	verify {
+		public_keys = [` + signer.publicKey + `]
+	}
+}`,
+			expectedError: `signature verification failed: missing signature for "` + srv.URL + `/unsigned.alloy"`,
+		},
+		{
+			name: "unknown key",
+			config: `
+import.http "testImport" {
+	url = "` + srv.URL + `/signed.alloy"
+
+	verify {
+		public_keys = [` + signer.publicKey + `]
+	}
+}`,
+			expectedError: `signature verification failed: signature of "` + srv.URL + `/signed.alloy" doesn't match any public key`,
+		},
+		{
+			name: "signature fetch error",
+			config: `
+import.http "testImport" {
+	url = "` + srv.URL + `/unavailable.alloy"
+
+	verify {
+		public_keys = [` + signer.publicKey + `]
+	}
+}`,
+			expectedError: `fetching signature: unexpected status code 500 Internal Server Error from ` + srv.URL + `/unavailable.alloy.sig`,
+		},
+		{
+			name: "invalid key",
+			config: `
+import.http "testImport" {
+	url = "` + srv.URL + `/signed.alloy"
+
+	verify {
+		public_keys = ["not a key"]
+	}
+}`,
+			expectedError: "public_keys[0]: not a PEM encoded public key",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			defer verifyNoGoroutineLeaks(t)
+			ctrl, f := setup(t, tc.config, nil, featuregate.StabilityGenerallyAvailable)
+			require.ErrorContains(t, ctrl.LoadSource(f, nil, ""), tc.expectedError)
+
+			ctx, cancel := context.WithCancel(t.Context())
+			var wg sync.WaitGroup
+			defer func() {
+				cancel()
+				wg.Wait()
+			}()
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ctrl.Run(ctx)
+			}()
+		})
+	}
+}
+
+func TestImportHTTP_VerifyURLWithQuery(t *testing.T) {
+	signer := newTestSigner(t)
+	srv := newModuleServer(t)
+	srv.set("/math.alloy", ociModule)
+	srv.set("/math.alloy.sig", signer.sign(ociModule))
+
+	main := `
+import.http "testImport" {
+	url = "` + srv.URL + `/math.alloy?ref=main"
+
+	verify {
+		public_keys = [` + signer.publicKey + `]
+	}
+}
+
+testImport.add "cc" {
+	a = 1
+	b = 1
+}
+`
+	defer verifyNoGoroutineLeaks(t)
+	ctrl, f := setup(t, main, nil, featuregate.StabilityGenerallyAvailable)
+	require.NoError(t, ctrl.LoadSource(f, nil, ""))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ctrl.Run(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+		return export["sum"] == 2
+	}, 5*time.Second, 50*time.Millisecond)
+	require.Equal(t, []string{"ref=main", "ref=main"}, srv.queries("/math.alloy", "/math.alloy.sig"))
+}
+
+type testSigner struct {
+	key       ed25519.PrivateKey
+	publicKey string // Quoted PEM encoded public key.
+}
+
+func newTestSigner(t *testing.T) *testSigner {
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+	return &testSigner{
+		key:       key,
+		publicKey: strconv.Quote(string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))),
+	}
+}
+
+func (s *testSigner) sign(content string) string {
+	return string(ed25519.Sign(s.key, []byte(content)))
+}
+
+// moduleServer serves files which can be changed while the test runs.
+type moduleServer struct {
+	*httptest.Server
+
+	mut      sync.Mutex
+	files    map[string]string
+	statuses map[string]int    // Status codes returned instead of files.
+	query    map[string]string // Last query of each path.
+}
+
+func newModuleServer(t *testing.T) *moduleServer {
+	s := &moduleServer{
+		files:    make(map[string]string),
+		statuses: make(map[string]int),
+		query:    make(map[string]string),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mut.Lock()
+		content, ok := s.files[r.URL.Path]
+		status, failed := s.statuses[r.URL.Path]
+		s.query[r.URL.Path] = r.URL.RawQuery
+		s.mut.Unlock()
+		// Don't keep connections open, they would be reported as leaked.
+		w.Header().Set("Connection", "close")
+		if failed {
+			w.WriteHeader(status)
+			return
+		}
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(content))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *moduleServer) set(path, content string) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.files[path] = content
+}
+
+func (s *moduleServer) setStatus(path string, status int) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.statuses[path] = status
+}
+
+// queries returns the last query of each path.
+func (s *moduleServer) queries(paths ...string) []string {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	res := make([]string, 0, len(paths))
+	for _, path := range paths {
+		res = append(res, s.query[path])
+	}
+	return res
+}
+
+func (s *moduleServer) remove(path string) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	delete(s.files, path)
+}
+
+func verifications(t *testing.T, reg *prometheus.Registry, result string) float64 {
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	var total float64
+	for _, family := range families {
+		if family.GetName() != "alloy_import_signature_verifications_total" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "result" && l.GetValue() == result {
+					total += m.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	return total
+}
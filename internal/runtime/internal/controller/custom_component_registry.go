@@ -14,20 +14,26 @@ import (
 type CustomComponentRegistry struct {
 	parent *CustomComponentRegistry // nil if root config
 
-	mut      sync.RWMutex
-	scope    *vm.Scope
-	imports  map[string]*CustomComponentRegistry // importNamespace: importScope
-	declares map[string]ast.Body                 // customComponentName: template
+	mut        sync.RWMutex
+	scope      *vm.Scope
+	imports    map[string]*CustomComponentRegistry // importNamespace: importScope
+	declares   map[string]ast.Body                 // customComponentName: template
+	functions  map[string]*customFunction          // functionName: function
+	generation uint64                              // Incremented when a definition changes.
+
+	functionVars    map[string]any // Cached result of functionVariables.
+	functionVarsGen uint64         // Generation functionVars was built at.
 }
 
 // NewCustomComponentRegistry creates a new CustomComponentRegistry with a parent.
 // parent can be nil.
 func NewCustomComponentRegistry(parent *CustomComponentRegistry, scope *vm.Scope) *CustomComponentRegistry {
 	return &CustomComponentRegistry{
-		parent:   parent,
-		scope:    scope,
-		declares: make(map[string]ast.Body),
-		imports:  make(map[string]*CustomComponentRegistry),
+		parent:    parent,
+		scope:     scope,
+		declares:  make(map[string]ast.Body),
+		imports:   make(map[string]*CustomComponentRegistry),
+		functions: make(map[string]*customFunction),
 	}
 }
 
@@ -45,6 +51,17 @@ func (s *CustomComponentRegistry) getImport(name string) (*CustomComponentRegist
 	return im, ok
 }
 
+// Generation returns a number which changes whenever a definition of the
+// registry or of one of its parents changes. s may be nil.
+func (s *CustomComponentRegistry) Generation() uint64 {
+	if s == nil {
+		return 0
+	}
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	return s.generation + s.parent.Generation()
+}
+
 func (s *CustomComponentRegistry) Scope() *vm.Scope {
 	s.mut.RLock()
 	defer s.mut.RUnlock()
@@ -56,6 +73,60 @@ func (s *CustomComponentRegistry) registerDeclare(declare *ast.BlockStmt) {
 	s.mut.Lock()
 	defer s.mut.Unlock()
 	s.declares[declare.Label] = declare.Body
+	s.generation++
+}
+
+// registerFunction stores a function. The function is bound to the registry
+// so that it can call the other functions available in the registry.
+func (s *CustomComponentRegistry) registerFunction(fn *customFunction) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.functions[fn.name] = fn.bind(s)
+	s.generation++
+}
+
+// functionVariables returns the functions available in the registry as
+// variables which can be used in a vm.Scope. Local functions are exposed by
+// name and imported functions are exposed under their import namespace.
+//
+// The result is cached until a definition changes and must not be modified.
+func (s *CustomComponentRegistry) functionVariables() map[string]any {
+	gen := s.Generation()
+
+	s.mut.RLock()
+	if s.functionVars != nil && s.functionVarsGen == gen {
+		defer s.mut.RUnlock()
+		return s.functionVars
+	}
+	s.mut.RUnlock()
+
+	vars := make(map[string]any)
+	if s.parent != nil {
+		for name, v := range s.parent.functionVariables() {
+			vars[name] = v
+		}
+	}
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	for namespace, im := range s.imports {
+		imported := make(map[string]any)
+		// The content of the import may not be loaded yet.
+		if im != nil {
+			im.mut.RLock()
+			for name, fn := range im.functions {
+				imported[name] = fn.Call
+			}
+			im.mut.RUnlock()
+		}
+		vars[namespace] = imported
+	}
+	for name, fn := range s.functions {
+		vars[name] = fn.Call
+	}
+	s.functionVars = vars
+	s.functionVarsGen = gen
+	return vars
 }
 
 // registerImport stores the import namespace.
@@ -66,6 +137,7 @@ func (s *CustomComponentRegistry) registerImport(importNamespace string) {
 	s.mut.Lock()
 	defer s.mut.Unlock()
 	s.imports[importNamespace] = nil
+	s.generation++
 }
 
 // updateImportContent updates the content of a registered import.
@@ -80,8 +152,10 @@ func (s *CustomComponentRegistry) updateImportContent(importNode *ImportConfigNo
 	}
 	importScope := NewCustomComponentRegistry(nil, importNode.Scope())
 	importScope.declares = importNode.ImportedDeclares()
+	importScope.bindFunctions(importNode.ImportedFunctions())
 	importScope.updateImportContentChildren(importNode)
 	s.imports[importNode.label] = importScope
+	s.generation++
 }
 
 // updateImportContentChildren recurse through the children of an import node
@@ -90,7 +164,16 @@ func (s *CustomComponentRegistry) updateImportContentChildren(importNode *Import
 	for _, child := range importNode.ImportConfigNodesChildren() {
 		childScope := NewCustomComponentRegistry(nil, child.Scope())
 		childScope.declares = child.ImportedDeclares()
+		childScope.bindFunctions(child.ImportedFunctions())
 		childScope.updateImportContentChildren(child)
 		s.imports[child.label] = childScope
 	}
 }
+
+// bindFunctions binds imported functions to the registry. The registry must
+// not be in use yet, as bindFunctions doesn't take the lock.
+func (s *CustomComponentRegistry) bindFunctions(functions map[string]*customFunction) {
+	for name, fn := range functions {
+		s.functions[name] = fn.bind(s)
+	}
+}
@@ -0,0 +1,204 @@
+package controller
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/grafana/alloy/internal/nodeconf/argument"
+	"github.com/grafana/alloy/internal/nodeconf/function"
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/diag"
+	"github.com/grafana/alloy/syntax/vm"
+)
+
+// customFunction is a user-defined function declared with a function block.
+//
+// The result of a customFunction is evaluated every time it is called. The
+// evaluation scope only contains the arguments of the call and the functions
+// visible from the registry the function is bound to, so a customFunction
+// can't reference component exports.
+type customFunction struct {
+	name     string
+	block    *ast.BlockStmt
+	args     []functionArgument
+	expr     ast.Expr
+	idents   []string // Identifiers referenced by expr, other than argument.
+	result   *vm.Evaluator
+	registry *CustomComponentRegistry // Registry used to resolve other functions; nil until bound.
+}
+
+type functionArgument struct {
+	name string
+	argument.Arguments
+}
+
+// newCustomFunction creates a new customFunction from a function block. The
+// argument blocks of the function are evaluated using scope. The returned
+// function must be bound to a registry before it can be called.
+func newCustomFunction(block *ast.BlockStmt, scope *vm.Scope) (*customFunction, error) {
+	def, diags := function.Parse(block)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	fn := &customFunction{
+		name:   def.Name,
+		block:  block,
+		expr:   def.Result,
+		idents: referencedIdentifiers(def.Result),
+		result: vm.New(def.Result),
+	}
+
+	var optionalSeen bool
+	for _, argBlock := range def.Arguments {
+		arg := functionArgument{name: argBlock.Label}
+		if err := vm.New(argBlock.Body).Evaluate(scope, &arg.Arguments); err != nil {
+			return nil, err
+		}
+
+		// Arguments are positional, so a required argument can't be declared
+		// after an argument which may be omitted.
+		if optionalSeen && !arg.Optional {
+			return nil, diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				StartPos: ast.StartPos(argBlock).Position(),
+				EndPos:   ast.EndPos(argBlock).Position(),
+				Message:  fmt.Sprintf("required argument %q of function %q must be declared before optional arguments", arg.name, fn.name),
+			}
+		}
+		optionalSeen = optionalSeen || arg.Optional
+
+		fn.args = append(fn.args, arg)
+	}
+
+	return fn, nil
+}
+
+// bind returns a copy of fn which resolves other functions from reg.
+func (fn *customFunction) bind(reg *CustomComponentRegistry) *customFunction {
+	bound := *fn
+	bound.registry = reg
+	return &bound
+}
+
+// Call invokes the function with positional arguments. Call is exposed to
+// Alloy expressions as a function value.
+func (fn *customFunction) Call(args ...any) (any, error) {
+	if len(args) > len(fn.args) {
+		return nil, fmt.Errorf("function %q expects at most %d arguments, got %d", fn.name, len(fn.args), len(args))
+	}
+
+	argValues := make(map[string]any, len(fn.args))
+	for i, arg := range fn.args {
+		var value any
+		switch {
+		case i < len(args):
+			value = args[i]
+		case arg.Optional:
+			value = arg.Default
+		default:
+			return nil, fmt.Errorf("missing required argument %q to function %q", arg.name, fn.name)
+		}
+		if err := arg.Check(value); err != nil {
+			return nil, fmt.Errorf("invalid value for argument %q to function %q: %w", arg.name, fn.name, err)
+		}
+		argValues[arg.name] = map[string]any{"value": value}
+	}
+
+	// Only the functions referenced by the result are added to the scope, so
+	// that a call doesn't copy the whole function namespace.
+	functions := fn.registry.functionVariables()
+	vars := make(map[string]any, len(fn.idents)+1)
+	for _, ident := range fn.idents {
+		if v, ok := functions[ident]; ok {
+			vars[ident] = v
+		}
+	}
+	vars[argumentLabel] = argValues
+
+	var res any
+	if err := fn.result.Evaluate(vm.NewScope(vars), &res); err != nil {
+		return nil, fmt.Errorf("function %q: %w", fn.name, err)
+	}
+	return res, nil
+}
+
+// referencedIdentifiers returns the sorted, deduplicated identifiers at the
+// root of the traversals in expr, other than argument.
+func referencedIdentifiers(expr ast.Expr) []string {
+	var w traversalWalker
+	ast.Walk(&w, expr)
+	w.flush()
+
+	var idents []string
+	for _, t := range w.traversals {
+		if t[0].Name != argumentLabel {
+			idents = append(idents, t[0].Name)
+		}
+	}
+	slices.Sort(idents)
+	return slices.Compact(idents)
+}
+
+// functionReferences returns the names of the functions in names which are
+// referenced by the result of fn.
+func (fn *customFunction) functionReferences(names map[string]*customFunction) []string {
+	var refs []string
+	for _, ident := range fn.idents {
+		if _, ok := names[ident]; ok {
+			refs = append(refs, ident)
+		}
+	}
+	return refs
+}
+
+// checkFunctionCycles returns a diagnostic if functions reference each other
+// in a cycle. Recursive functions aren't supported. Functions are visited in
+// name order, and the diagnostic points at the block of the function which
+// closes the first cycle found.
+func checkFunctionCycles(functions map[string]*customFunction) diag.Diagnostics {
+	var (
+		visiting = make(map[string]bool, len(functions))
+		visited  = make(map[string]bool, len(functions))
+	)
+
+	var visit func(name string, path []string) *diag.Diagnostic
+	visit = func(name string, path []string) *diag.Diagnostic {
+		if visiting[name] {
+			closing := functions[path[len(path)-1]].block
+			return &diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				StartPos: ast.StartPos(closing).Position(),
+				EndPos:   ast.EndPos(closing).Position(),
+				Message:  fmt.Sprintf("cycle between functions: %s", strings.Join(append(path, name), " -> ")),
+			}
+		}
+		if visited[name] {
+			return nil
+		}
+
+		visiting[name] = true
+		for _, ref := range functions[name].functionReferences(functions) {
+			if d := visit(ref, append(path, name)); d != nil {
+				return d
+			}
+		}
+		visiting[name] = false
+		visited[name] = true
+		return nil
+	}
+
+	names := make([]string, 0, len(functions))
+	for name := range functions {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		if d := visit(name, nil); d != nil {
+			return diag.Diagnostics{*d}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,278 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+
+	"github.com/grafana/alloy/internal/filedetector"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/syntax/vm"
+)
+
+// fileNamespace is the name of the stdlib namespace holding file functions.
+const fileNamespace = "file"
+
+// fileWatchPollFrequency is how often watched paths are polled. Paths are
+// also watched with fsnotify when possible, so polling is usually only a
+// fallback.
+const fileWatchPollFrequency = time.Minute
+
+// stdlibFile holds the functions of the file namespace of the stdlib.
+var stdlibFile = func() map[string]any {
+	ns, _ := vm.NewScope(nil).Lookup(fileNamespace)
+	return ns.(map[string]any)
+}()
+
+// watchedFileFunctions are the functions of the file namespace whose results
+// depend on the filesystem. Each function takes a single path argument.
+var watchedFileFunctions = map[string]func(path string) (any, error){
+	"read":     func(path string) (any, error) { return callFileFunction[string]("read", path) },
+	"exists":   func(path string) (any, error) { return callFileFunction[bool]("exists", path) },
+	"glob":     func(path string) (any, error) { return callFileFunction[[]string]("glob", path) },
+	"read_dir": func(path string) (any, error) { return callFileFunction[[]string]("read_dir", path) },
+}
+
+func callFileFunction[T any](name string, path string) (any, error) {
+	return stdlibFile[name].(func(string) (T, error))(path)
+}
+
+// fileObservation is a call to one of the watchedFileFunctions.
+type fileObservation struct {
+	function string
+	path     string
+}
+
+// watchPath returns the path to watch for changes to the result of the
+// observation. Globs are watched at the longest leading directory of the
+// pattern which has no wildcards, since wildcards can appear in any
+// directory of the pattern.
+func (o fileObservation) watchPath() string {
+	if o.function == "glob" {
+		return globBase(o.path)
+	}
+	return o.path
+}
+
+// globBase returns the longest leading directory of pattern which has no
+// wildcards.
+func globBase(pattern string) string {
+	dir := filepath.Dir(pattern)
+	for hasGlobMeta(dir) {
+		dir = filepath.Dir(dir)
+	}
+	return dir
+}
+
+// hasGlobMeta reports whether path contains any of the special characters of
+// filepath.Match.
+func hasGlobMeta(path string) bool {
+	magicChars := `*?[`
+	if runtime.GOOS != "windows" {
+		magicChars = `*?[\`
+	}
+	return strings.ContainsAny(path, magicChars)
+}
+
+// check calls the observed function again and returns the fingerprint of its
+// result.
+func (o fileObservation) check() string {
+	return fileFingerprint(watchedFileFunctions[o.function](o.path))
+}
+
+// fileFingerprint summarizes the result of a file function so that changes to
+// it can be detected without keeping the contents of read files around.
+func fileFingerprint(res any, err error) string {
+	sum := sha256.Sum256(fmt.Appendf(nil, "%#v\x00%v", res, err))
+	return hex.EncodeToString(sum[:])
+}
+
+// fileRecorder records the fileObservations made while evaluating a node.
+type fileRecorder struct {
+	observations map[fileObservation]string // Fingerprint of the result of each observation.
+}
+
+// functions returns the file namespace with the watchedFileFunctions replaced
+// by variants which record their calls.
+func (r *fileRecorder) functions() map[string]any {
+	fns := maps.Clone(stdlibFile)
+	for name, fn := range watchedFileFunctions {
+		fns[name] = func(path string) (any, error) {
+			res, err := fn(path)
+			r.observations[fileObservation{function: name, path: path}] = fileFingerprint(res, err)
+			return res, err
+		}
+	}
+	return fns
+}
+
+// fileWatcher re-evaluates nodes when the results of the file functions they
+// called during their last evaluation change.
+type fileWatcher struct {
+	log           log.Logger
+	pollFrequency time.Duration
+	onChange      func(BlockNode)
+
+	mut   sync.Mutex
+	nodes map[string]*nodeFileWatch // Watches by node ID.
+}
+
+type nodeFileWatch struct {
+	node         BlockNode
+	observations map[fileObservation]string
+	detectors    map[string]io.Closer // Detectors by watched path.
+}
+
+// newFileWatcher creates a new fileWatcher which calls onChange with nodes
+// that must be re-evaluated.
+func newFileWatcher(logger log.Logger, onChange func(BlockNode)) *fileWatcher {
+	return &fileWatcher{
+		log:           logger,
+		pollFrequency: fileWatchPollFrequency,
+		onChange:      onChange,
+		nodes:         make(map[string]*nodeFileWatch),
+	}
+}
+
+// record prepares scope for the evaluation of a node. The returned recorder
+// must be passed to track once the evaluation is done.
+func (fw *fileWatcher) record(scope *vm.Scope) *fileRecorder {
+	r := &fileRecorder{observations: make(map[fileObservation]string)}
+	// Variables shadow the stdlib, so there's nothing to record if a variable
+	// is named after the namespace.
+	if _, ok := scope.Variables[fileNamespace]; !ok {
+		scope.Variables[fileNamespace] = r.functions()
+	}
+	return r
+}
+
+// track replaces the watches of n with the observations recorded during its
+// latest evaluation.
+func (fw *fileWatcher) track(n BlockNode, r *fileRecorder) {
+	fw.mut.Lock()
+	defer fw.mut.Unlock()
+
+	w, ok := fw.nodes[n.NodeID()]
+	if !ok {
+		if len(r.observations) == 0 {
+			return
+		}
+		w = &nodeFileWatch{detectors: make(map[string]io.Closer)}
+		fw.nodes[n.NodeID()] = w
+	}
+	w.node = n
+	w.observations = r.observations
+
+	paths := make(map[string]struct{}, len(r.observations))
+	for o := range r.observations {
+		paths[o.watchPath()] = struct{}{}
+	}
+	for path, detector := range w.detectors {
+		if _, ok := paths[path]; !ok {
+			fw.closeDetector(path, detector)
+			delete(w.detectors, path)
+		}
+	}
+	for path := range paths {
+		if _, ok := w.detectors[path]; !ok {
+			w.detectors[path] = fw.newDetector(path, n.NodeID())
+		}
+	}
+
+	if len(w.detectors) == 0 {
+		delete(fw.nodes, n.NodeID())
+	}
+}
+
+// newDetector returns a detector which checks the observations of the node
+// with the given ID when path changes. If path doesn't exist yet, its parent
+// directory is watched instead so that its creation is detected. Paths which
+// can't be watched are polled.
+func (fw *fileWatcher) newDetector(path string, nodeID string) io.Closer {
+	reload := func() { fw.check(nodeID) }
+
+	watched := path
+	if _, err := os.Stat(watched); err != nil {
+		watched = filepath.Dir(path)
+	}
+	if _, err := os.Stat(watched); err == nil {
+		detector, err := filedetector.NewFSNotify(filedetector.FSNotifyOptions{
+			Logger:        log.With(fw.log, "node_id", nodeID, "path", watched),
+			Filename:      watched,
+			ReloadFile:    reload,
+			PollFrequency: fw.pollFrequency,
+		})
+		if err == nil {
+			return detector
+		}
+		level.Warn(fw.log).Log("msg", "failed to watch file with fsnotify, falling back to polling", "node_id", nodeID, "path", path, "err", err)
+	}
+
+	return filedetector.NewPoller(filedetector.PollerOptions{
+		Filename:      path,
+		ReloadFile:    reload,
+		PollFrequency: fw.pollFrequency,
+	})
+}
+
+func (fw *fileWatcher) closeDetector(path string, detector io.Closer) {
+	if err := detector.Close(); err != nil {
+		level.Warn(fw.log).Log("msg", "failed to stop watching file", "path", path, "err", err)
+	}
+}
+
+// check calls onChange with the node with the given ID if the result of any
+// of its observations changed. Detectors may call check for events which
+// don't change anything, like polling, so results are always compared.
+func (fw *fileWatcher) check(nodeID string) {
+	fw.mut.Lock()
+	w, ok := fw.nodes[nodeID]
+	if !ok {
+		fw.mut.Unlock()
+		return
+	}
+	var (
+		node         = w.node
+		observations = w.observations
+	)
+	fw.mut.Unlock()
+
+	for o, fingerprint := range observations {
+		if o.check() != fingerprint {
+			level.Debug(fw.log).Log("msg", "file changed, re-evaluating node", "node_id", nodeID, "function", o.function, "path", o.path)
+			fw.onChange(node)
+			return
+		}
+	}
+}
+
+// retain stops watching files for nodes which don't satisfy keep.
+func (fw *fileWatcher) retain(keep func(BlockNode) bool) {
+	fw.mut.Lock()
+	defer fw.mut.Unlock()
+
+	for id, w := range fw.nodes {
+		if keep(w.node) {
+			continue
+		}
+		for path, detector := range w.detectors {
+			fw.closeDetector(path, detector)
+		}
+		delete(fw.nodes, id)
+	}
+}
+
+// Close stops watching files for all nodes.
+func (fw *fileWatcher) Close() {
+	fw.retain(func(BlockNode) bool { return false })
+}
@@ -0,0 +1,28 @@
+package controller
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileObservation_WatchPath(t *testing.T) {
+	tests := map[string]struct {
+		observation fileObservation
+		expected    string
+	}{
+		"read":                  {fileObservation{function: "read", path: "/etc/app/conf.yaml"}, "/etc/app/conf.yaml"},
+		"glob in file name":     {fileObservation{function: "glob", path: "/etc/app/*.yaml"}, "/etc/app"},
+		"glob in directory":     {fileObservation{function: "glob", path: "/etc/*/conf.yaml"}, "/etc"},
+		"glob in several parts": {fileObservation{function: "glob", path: "/etc/a?/*/b[0-9]/*.yaml"}, "/etc"},
+		"relative glob":         {fileObservation{function: "glob", path: "*/conf.yaml"}, "."},
+		"glob at root":          {fileObservation{function: "glob", path: "/*/conf.yaml"}, "/"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			tt.observation.path = filepath.FromSlash(tt.observation.path)
+			require.Equal(t, filepath.FromSlash(tt.expected), tt.observation.watchPath())
+		})
+	}
+}
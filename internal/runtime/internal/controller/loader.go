@@ -24,6 +24,7 @@ import (
 	"github.com/grafana/alloy/internal/dag"
 	"github.com/grafana/alloy/internal/featuregate"
 	"github.com/grafana/alloy/internal/nodeconf/foreach"
+	"github.com/grafana/alloy/internal/nodeconf/function"
 	"github.com/grafana/alloy/internal/runtime/internal/worker"
 	"github.com/grafana/alloy/internal/runtime/logging/level"
 	"github.com/grafana/alloy/internal/runtime/tracing"
@@ -60,6 +61,7 @@ type Loader struct {
 	cc                   *controllerCollector
 	moduleExportIndex    int
 	componentNodeManager *ComponentNodeManager
+	fileWatcher          *fileWatcher // Re-evaluates nodes when files they read change.
 }
 
 // LoaderOptions holds options for creating a Loader.
@@ -112,6 +114,7 @@ func NewLoader(opts LoaderOptions) *Loader {
 		cm:    newControllerMetrics(parent, id),
 	}
 	l.cc = newControllerCollector(l, parent, id)
+	l.fileWatcher = newFileWatcher(l.log, l.reevaluateNode)
 
 	if globals.Registerer != nil {
 		globals.Registerer.MustRegister(l.cc)
@@ -129,6 +132,7 @@ type ApplyOptions struct {
 	ComponentBlocks []*ast.BlockStmt // pieces of config that can be used to instantiate builtin components and services
 	ConfigBlocks    []*ast.BlockStmt // pieces of config that can be used to instantiate config nodes
 	DeclareBlocks   []*ast.BlockStmt // pieces of config that can be used as templates to instantiate custom components
+	FunctionBlocks  []*ast.BlockStmt // pieces of config that define functions which can be called in expressions
 
 	// CustomComponentRegistry holds custom component templates.
 	// The definition of a custom component instantiated inside of the loaded config
@@ -170,7 +174,7 @@ func (l *Loader) Apply(options ApplyOptions) diag.Diagnostics {
 	// Create a new CustomComponentRegistry based on the provided one.
 	// The provided one should be nil for the root config.
 	l.componentNodeManager.setCustomComponentRegistry(NewCustomComponentRegistry(options.CustomComponentRegistry, options.ArgScope))
-	newGraph, diags := l.loadNewGraph(options.Args, options.ComponentBlocks, options.ConfigBlocks, options.DeclareBlocks)
+	newGraph, diags := l.loadNewGraph(options.Args, options.ComponentBlocks, options.ConfigBlocks, options.DeclareBlocks, options.FunctionBlocks)
 	if diags.HasErrors() {
 		return diags
 	}
@@ -271,6 +275,7 @@ func (l *Loader) Apply(options ApplyOptions) diag.Diagnostics {
 	l.componentNodes = components
 	l.serviceNodes = services
 	l.graph = &newGraph
+	l.fileWatcher.retain(func(n BlockNode) bool { return newGraph.GetByID(n.NodeID()) == n })
 	err := l.cache.SyncIDs(componentIDs)
 	if err != nil {
 		diags.Add(diag.Diagnostic{
@@ -288,8 +293,35 @@ func (l *Loader) Apply(options ApplyOptions) diag.Diagnostics {
 	return diags
 }
 
+// DryRun builds the graph for the options without evaluating or running any
+// of its nodes. The graph is built by a separate loader so that the nodes of
+// l aren't updated.
+func (l *Loader) DryRun(options ApplyOptions) (*dag.Graph, diag.Diagnostics) {
+	globals := l.globals
+	// Metrics of the nodes of the dry run must not collide with the metrics of
+	// the loaded nodes.
+	globals.Registerer = nil
+
+	dry := NewLoader(LoaderOptions{
+		ComponentGlobals:  globals,
+		Services:          l.services,
+		Host:              l.host,
+		ComponentRegistry: l.componentNodeManager.builtinComponentReg,
+		WorkerPool:        l.workerPool,
+	})
+	defer dry.fileWatcher.Close()
+
+	if options.ArgScope != nil {
+		dry.cache.UpdateScopeVariables(options.ArgScope.Variables)
+	}
+	dry.componentNodeManager.setCustomComponentRegistry(NewCustomComponentRegistry(options.CustomComponentRegistry, options.ArgScope))
+	g, diags := dry.loadNewGraph(options.Args, options.ComponentBlocks, options.ConfigBlocks, options.DeclareBlocks, options.FunctionBlocks)
+	return &g, diags
+}
+
 // Cleanup unregisters any existing metrics and optionally stops the worker pool.
 func (l *Loader) Cleanup(stopWorkerPool bool) {
+	l.fileWatcher.Close()
 	if stopWorkerPool {
 		l.workerPool.Stop()
 	}
@@ -301,7 +333,7 @@ func (l *Loader) Cleanup(stopWorkerPool bool) {
 }
 
 // loadNewGraph creates a new graph from the provided blocks and validates it.
-func (l *Loader) loadNewGraph(args map[string]any, componentBlocks []*ast.BlockStmt, configBlocks []*ast.BlockStmt, declareBlocks []*ast.BlockStmt) (dag.Graph, diag.Diagnostics) {
+func (l *Loader) loadNewGraph(args map[string]any, componentBlocks []*ast.BlockStmt, configBlocks []*ast.BlockStmt, declareBlocks []*ast.BlockStmt, functionBlocks []*ast.BlockStmt) (dag.Graph, diag.Diagnostics) {
 	var g dag.Graph
 
 	// Split component blocks into blocks for components and services.
@@ -319,6 +351,11 @@ func (l *Loader) loadNewGraph(args map[string]any, componentBlocks []*ast.BlockS
 	configBlockDiags := l.populateConfigBlockNodes(args, &g, configBlocks)
 	diags = append(diags, configBlockDiags...)
 
+	// Register functions, must be done after the imports are registered and
+	// before the edges are wired.
+	functionDiags := l.populateFunctions(functionBlocks, componentBlocks)
+	diags = append(diags, functionDiags...)
+
 	// Fill our graph with components.
 	componentNodeDiags := l.populateComponentNodes(&g, componentBlocks)
 	diags = append(diags, componentNodeDiags...)
@@ -395,6 +432,92 @@ func (l *Loader) populateDeclareNodes(g *dag.Graph, declareBlocks []*ast.BlockSt
 	return diags
 }
 
+// populateFunctions registers the function blocks and caches them so that
+// they can be called in expressions. Functions can't share their name with a
+// variable of the scope or with the namespace of a component, as they would
+// be shadowed in expressions.
+func (l *Loader) populateFunctions(functionBlocks []*ast.BlockStmt, componentBlocks []*ast.BlockStmt) diag.Diagnostics {
+	var (
+		diags     diag.Diagnostics
+		reg       = l.componentNodeManager.customComponentReg
+		blockMap  = make(map[string]*ast.BlockStmt, len(functionBlocks))
+		functions = make(map[string]*customFunction, len(functionBlocks))
+		reserved  = map[string]string{argumentLabel: "the module arguments"}
+	)
+	if scope := reg.Scope(); scope != nil {
+		for name := range scope.Variables {
+			reserved[name] = "a variable"
+		}
+	}
+	for _, componentBlock := range componentBlocks {
+		reserved[BlockComponentID(componentBlock)[0]] = "a component namespace"
+	}
+	for _, functionBlock := range functionBlocks {
+		id := BlockComponentID(functionBlock).String()
+		if diag, defined := blockAlreadyDefined(blockMap, id, functionBlock); defined {
+			diags = append(diags, diag)
+			continue
+		}
+
+		if err := featuregate.CheckAllowed(function.StabilityLevel, l.globals.MinStability, fmt.Sprintf("function block %q", functionBlock.Label)); err != nil {
+			diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				Message:  err.Error(),
+				StartPos: ast.StartPos(functionBlock).Position(),
+				EndPos:   ast.EndPos(functionBlock).Position(),
+			})
+			continue
+		}
+
+		_, declareExists := reg.getDeclare(functionBlock.Label)
+		_, importExists := reg.getImport(functionBlock.Label)
+		if declareExists || importExists {
+			diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				Message:  fmt.Sprintf("function %q conflicts with a declare or import block with the same label", functionBlock.Label),
+				StartPos: functionBlock.NamePos.Position(),
+				EndPos:   functionBlock.NamePos.Add(len(id) - 1).Position(),
+			})
+			continue
+		}
+
+		if what, ok := reserved[functionBlock.Label]; ok {
+			diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				Message:  fmt.Sprintf("function %q conflicts with %s with the same name", functionBlock.Label, what),
+				StartPos: functionBlock.NamePos.Position(),
+				EndPos:   functionBlock.NamePos.Add(len(id) - 1).Position(),
+			})
+			continue
+		}
+
+		fn, err := newCustomFunction(functionBlock, l.cache.GetContext())
+		if err != nil {
+			var fnDiags diag.Diagnostics
+			if errors.As(err, &fnDiags) {
+				diags = append(diags, fnDiags...)
+			} else {
+				diags.Add(diag.Diagnostic{
+					Severity: diag.SeverityLevelError,
+					Message:  fmt.Sprintf("Failed to build function: %s", err),
+					StartPos: ast.StartPos(functionBlock).Position(),
+					EndPos:   ast.EndPos(functionBlock).Position(),
+				})
+			}
+			continue
+		}
+		functions[fn.name] = fn
+	}
+
+	diags = append(diags, checkFunctionCycles(functions)...)
+
+	for _, fn := range functions {
+		reg.registerFunction(fn)
+	}
+	l.cache.CacheFunctions(reg.functionVariables())
+	return diags
+}
+
 // blockAlreadyDefined returns (diag, true) if the given id is already in the provided blockMap.
 // else it adds the block to the map and returns (empty diag, false).
 func blockAlreadyDefined(blockMap map[string]*ast.BlockStmt, id string, block *ast.BlockStmt) (diag.Diagnostic, bool) {
@@ -652,6 +775,8 @@ func (l *Loader) wireGraphEdges(g *dag.Graph) diag.Diagnostics {
 			g.AddEdge(dag.Edge{From: n, To: ref.Target})
 		}
 		diags = append(diags, nodeDiags...)
+
+		l.wireImportedFunctions(g, n)
 	}
 
 	return diags
@@ -673,6 +798,21 @@ func (l *Loader) wireCustomComponentNode(g *dag.Graph, cc *CustomComponentNode)
 	}
 }
 
+// wireImportedFunctions adds edges between a node and the import nodes of the
+// functions it calls, so that the node is re-evaluated when the imported
+// functions change.
+func (l *Loader) wireImportedFunctions(g *dag.Graph, n dag.Node) {
+	bn, ok := n.(BlockNode)
+	if !ok || bn.Block() == nil {
+		return
+	}
+	for _, t := range expressionsFromBody(bn.Block().Body) {
+		if importNode, ok := l.importConfigNodes[t[0].Name]; ok && importNode != n {
+			g.AddEdge(dag.Edge{From: n, To: importNode})
+		}
+	}
+}
+
 // wireForEachNode add edges between a foreach node and declare/import nodes that are used in the foreach pipeline.
 func (l *Loader) wireForEachNode(g *dag.Graph, fn *ForeachConfigNode) {
 	refs := l.findCustomComponentReferences(fn.Block())
@@ -722,6 +862,12 @@ func (l *Loader) Graph() *dag.Graph {
 	return l.graph.Clone()
 }
 
+// ComponentRegistry returns the registry used to build the builtin components
+// of the Loader.
+func (l *Loader) ComponentRegistry() component.Registry {
+	return l.componentNodeManager.builtinComponentReg
+}
+
 // EvaluateDependants sends nodes which depend directly on nodes in updatedNodes for evaluation to the
 // workerPool. It should be called whenever nodes update their exports.
 // It is beneficial to call EvaluateDependants with a batch of nodes, as it will enqueue the entire batch before
@@ -755,7 +901,7 @@ func (l *Loader) EvaluateDependants(ctx context.Context, updatedNodes []*QueuedN
 			}
 		case *ImportConfigNode:
 			// Update the scope with the imported content.
-			l.componentNodeManager.customComponentReg.updateImportContent(parentNode)
+			l.updateImportContent(parentNode)
 		}
 		// We collect all nodes directly incoming to parent.
 		_ = dag.WalkIncomingNodes(l.graph, parent.Node, func(n dag.Node) error {
@@ -845,7 +991,9 @@ func (l *Loader) concurrentEvalFn(n dag.Node, spanCtx context.Context, tracer tr
 		// RLock before evaluate to prevent Evaluating while the config is being reloaded
 		l.mut.RLock()
 		ectx := l.cache.GetContext()
+		files := l.fileWatcher.record(ectx)
 		evalErr := n.Evaluate(ectx)
+		l.fileWatcher.track(n, files)
 
 		err = l.postEvaluate(l.log, n, evalErr)
 
@@ -881,10 +1029,38 @@ func (l *Loader) concurrentEvalFn(n dag.Node, spanCtx context.Context, tracer tr
 // evaluates it. mut must be held when calling evaluate.
 func (l *Loader) evaluate(logger log.Logger, bn BlockNode) error {
 	ectx := l.cache.GetContext()
+	files := l.fileWatcher.record(ectx)
 	err := bn.Evaluate(ectx)
+	l.fileWatcher.track(bn, files)
 	return l.postEvaluate(logger, bn, err)
 }
 
+// reevaluateNode submits n for evaluation to the workerPool. It's called when
+// a file read by n during its last evaluation changes. Nodes which were
+// removed from the graph by a reload are ignored.
+func (l *Loader) reevaluateNode(n BlockNode) {
+	l.mut.RLock()
+	defer l.mut.RUnlock()
+
+	if l.graph.GetByID(n.NodeID()) != n {
+		return
+	}
+
+	var (
+		tracer          = l.tracer.Tracer("")
+		queued          = &QueuedNode{Node: n, LastUpdatedTime: time.Now()}
+		globalUniqueKey = path.Join(l.globals.ControllerID, n.NodeID())
+	)
+	err := l.workerPool.SubmitWithKey(globalUniqueKey, func() {
+		l.concurrentEvalFn(n, context.Background(), tracer, queued)
+	})
+	if err != nil {
+		// The file is checked again on the next event or poll, so the evaluation
+		// will be retried later.
+		level.Warn(l.log).Log("msg", "failed to submit node for evaluation after a file changed", "node_id", n.NodeID(), "err", err)
+	}
+}
+
 // postEvaluate is called after a node has been evaluated. It updates the caches and logs any errors.
 // mut must be held when calling postEvaluate.
 // The evaluation err is passed as an argument to allow shadowing it with an error that could be more relevant to the user
@@ -906,7 +1082,16 @@ func (l *Loader) postEvaluate(logger log.Logger, bn BlockNode, err error) error
 			}
 		}
 	case *ArgumentConfigNode:
-		if _, found := l.cache.GetModuleArgument(c.Label()); !found {
+		if value, found := l.cache.GetModuleArgument(c.Label()); found {
+			// Custom components usually check the values of their arguments
+			// before loading them, but argument blocks which depend on the
+			// module can only be checked here.
+			if err == nil {
+				if err2 := c.Check(value); err2 != nil {
+					err = fmt.Errorf("invalid value for argument %q to module: %w", c.Label(), err2)
+				}
+			}
+		} else {
 			if c.Optional() {
 				l.cache.CacheModuleArgument(c.Label(), c.Default())
 			} else {
@@ -916,7 +1101,7 @@ func (l *Loader) postEvaluate(logger log.Logger, bn BlockNode, err error) error
 			}
 		}
 	case *ImportConfigNode:
-		l.componentNodeManager.customComponentReg.updateImportContent(c)
+		l.updateImportContent(c)
 	}
 
 	if err != nil {
@@ -926,6 +1111,14 @@ func (l *Loader) postEvaluate(logger log.Logger, bn BlockNode, err error) error
 	return nil
 }
 
+// updateImportContent updates the registry with the content of an import node
+// and refreshes the cached functions, which may include imported functions.
+func (l *Loader) updateImportContent(importNode *ImportConfigNode) {
+	reg := l.componentNodeManager.customComponentReg
+	reg.updateImportContent(importNode)
+	l.cache.CacheFunctions(reg.functionVariables())
+}
+
 func multierrToDiags(errors error) diag.Diagnostics {
 	var diags diag.Diagnostics
 	for _, err := range errors.(*multierror.Error).Errors {
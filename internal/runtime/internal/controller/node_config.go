@@ -19,7 +19,8 @@ const (
 
 // Add config blocks that are not GA. Config blocks that are not specified here are considered GA.
 var configBlocksUnstable = map[string]featuregate.Stability{
-	foreach.BlockName: foreach.StabilityLevel,
+	foreach.BlockName:         foreach.StabilityLevel,
+	importsource.BlockNameOCI: importsource.StabilityLevelOCI,
 }
 
 // NewConfigNode creates a new ConfigNode from an initial ast.BlockStmt.
@@ -46,7 +47,7 @@ func NewConfigNode(block *ast.BlockStmt, globals ComponentGlobals, customReg *Cu
 		return NewLoggingConfigNode(block, globals), nil
 	case tracingBlockID:
 		return NewTracingConfigNode(block, globals), nil
-	case importsource.BlockNameFile, importsource.BlockNameString, importsource.BlockNameHTTP, importsource.BlockNameGit:
+	case importsource.BlockNameFile, importsource.BlockNameString, importsource.BlockNameHTTP, importsource.BlockNameGit, importsource.BlockNameOCI:
 		return NewImportConfigNode(block, globals, importsource.GetSourceType(block.GetBlockName())), nil
 	case foreach.BlockName:
 		return NewForeachConfigNode(block, globals, customReg), nil
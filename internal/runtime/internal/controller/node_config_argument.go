@@ -15,11 +15,10 @@ type ArgumentConfigNode struct {
 	nodeID        string
 	componentName string
 
-	mut          sync.RWMutex
-	block        *ast.BlockStmt // Current Alloy blocks to derive config from
-	eval         *vm.Evaluator
-	defaultValue any
-	optional     bool
+	mut   sync.RWMutex
+	block *ast.BlockStmt // Current Alloy blocks to derive config from
+	eval  *vm.Evaluator
+	args  argument.Arguments
 }
 
 var _ BlockNode = (*ArgumentConfigNode)(nil)
@@ -52,8 +51,7 @@ func (cn *ArgumentConfigNode) Evaluate(scope *vm.Scope) error {
 		return fmt.Errorf("decoding configuration: %w", err)
 	}
 
-	cn.defaultValue = args.Default
-	cn.optional = args.Optional
+	cn.args = args
 
 	return nil
 }
@@ -61,13 +59,21 @@ func (cn *ArgumentConfigNode) Evaluate(scope *vm.Scope) error {
 func (cn *ArgumentConfigNode) Optional() bool {
 	cn.mut.RLock()
 	defer cn.mut.RUnlock()
-	return cn.optional
+	return cn.args.Optional
 }
 
 func (cn *ArgumentConfigNode) Default() any {
 	cn.mut.RLock()
 	defer cn.mut.RUnlock()
-	return cn.defaultValue
+	return cn.args.Default
+}
+
+// Check returns an error if value doesn't satisfy the type and validation
+// rules of the argument.
+func (cn *ArgumentConfigNode) Check(value any) error {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+	return cn.args.Check(value)
 }
 
 func (cn *ArgumentConfigNode) Label() string { return cn.label }
@@ -8,6 +8,7 @@ import (
 	"hash/fnv"
 	"path"
 	"reflect"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -15,10 +16,12 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
 
 	"github.com/grafana/alloy/internal/component"
 	"github.com/grafana/alloy/internal/nodeconf/foreach"
 	"github.com/grafana/alloy/internal/runner"
+	"github.com/grafana/alloy/internal/runtime/equality"
 	"github.com/grafana/alloy/internal/runtime/logging/level"
 	"github.com/grafana/alloy/syntax/ast"
 	"github.com/grafana/alloy/syntax/vm"
@@ -44,10 +47,21 @@ type ForeachConfigNode struct {
 
 	customComponents          map[string]CustomComponent // track the children
 	customComponentHashCounts map[string]int             // track the hash to avoid collisions
+	loadedInstances           map[string]loadedInstance  // what was last loaded into each child
 
 	forEachChildrenUpdateChan chan struct{} // used to trigger an update of the running children
 	forEachChildrenRunning    bool
 
+	// limiter limits the rate at which new children are created. It is nil if
+	// the rate isn't limited.
+	limiter *rate.Limiter
+	// pending are the new children waiting for the limiter, in the order of
+	// the collection.
+	pending []foreachInstance
+	// skipped is the number of items of the collection which aren't
+	// instantiated because of max_instances.
+	skipped int
+
 	mut   sync.RWMutex
 	block *ast.BlockStmt
 	args  foreach.Arguments
@@ -59,6 +73,8 @@ type ForeachConfigNode struct {
 	evalHealth component.Health // Health of the last evaluate
 	runHealth  component.Health // Health of running the component
 
+	instanceHealth map[string]component.Health // Health of each child
+
 	dataFlowEdgeMut  sync.RWMutex
 	dataFlowEdgeRefs []string
 
@@ -87,6 +103,8 @@ func NewForeachConfigNode(block *ast.BlockStmt, globals ComponentGlobals, custom
 		forEachChildrenUpdateChan: make(chan struct{}, 1),
 		customComponents:          make(map[string]CustomComponent, 0),
 		customComponentHashCounts: make(map[string]int, 0),
+		loadedInstances:           make(map[string]loadedInstance),
+		instanceHealth:            make(map[string]component.Health),
 	}
 }
 
@@ -128,8 +146,12 @@ func (fn *ForeachConfigNode) ID() ComponentID {
 func (fn *ForeachConfigNode) Evaluate(evalScope *vm.Scope) error {
 	err := fn.evaluate(evalScope)
 
-	switch err {
-	case nil:
+	skipped, maxInstances := fn.skippedInstances()
+	switch {
+	case err == nil && skipped > 0:
+		msg := fmt.Sprintf("foreach evaluated, but %d items of the collection were not instantiated because max_instances is %d", skipped, maxInstances)
+		fn.setEvalHealth(component.HealthTypeUnhealthy, msg)
+	case err == nil:
 		fn.setEvalHealth(component.HealthTypeHealthy, "foreach evaluated")
 	default:
 		msg := fmt.Sprintf("foreach evaluation failed: %s", err)
@@ -182,6 +204,7 @@ func (fn *ForeachConfigNode) evaluate(scope *vm.Scope) error {
 		// a frequent runtime toggle, the overhead of recreating components is acceptable.
 		fn.moduleController = fn.moduleControllerFactory(fn.moduleControllerOpts)
 		fn.customComponents = make(map[string]CustomComponent)
+		fn.loadedInstances = make(map[string]loadedInstance)
 		err := fn.runner.ApplyTasks(context.Background(), []*forEachChild{}) // stops all running children
 		if err != nil {
 			return fmt.Errorf("error stopping foreach children: %w", err)
@@ -189,10 +212,97 @@ func (fn *ForeachConfigNode) evaluate(scope *vm.Scope) error {
 	}
 
 	fn.args = args
+	fn.updateLimiter()
+
+	instances := fn.instances(scope, template)
+	if args.MaxInstances > 0 && len(instances) > args.MaxInstances {
+		instances, fn.skipped = fn.limitInstances(instances, args.MaxInstances), len(instances)-args.MaxInstances
+		level.Warn(fn.logger).Log("msg", "items of the collection were not instantiated because of max_instances", "max_instances", args.MaxInstances, "skipped", fn.skipped)
+	} else {
+		fn.skipped = 0
+	}
+
+	// Existing custom components are updated. New ones are created, unless
+	// the instantiation rate is exceeded: they are then created later by Run.
+	newCustomComponentIds := make(map[string]bool, len(instances))
+	fn.pending = nil
+	for _, instance := range instances {
+		newCustomComponentIds[instance.id] = true
+		// Once an instance is pending, the next new ones are pending too so
+		// that they are created in the order of the collection.
+		if _, exists := fn.customComponents[instance.id]; !exists && fn.limiter != nil && (len(fn.pending) > 0 || !fn.limiter.Allow()) {
+			fn.pending = append(fn.pending, instance)
+			continue
+		}
+		if err := fn.loadInstance(instance); err != nil {
+			return err
+		}
+	}
+	for _, instance := range fn.pending {
+		fn.setInstanceHealth(instance.id, component.HealthTypeUnknown, "waiting for the instantiation rate limit")
+	}
+
+	// Delete the custom components that are no longer in the foreach.
+	// The runner pkg will stop them properly.
+	for id := range fn.customComponents {
+		if _, exist := newCustomComponentIds[id]; !exist {
+			delete(fn.customComponents, id)
+			delete(fn.loadedInstances, id)
+		}
+	}
+	fn.deleteInstanceHealth(newCustomComponentIds)
+
+	// Trigger to stop previous children from running and to start running the new ones.
+	if fn.forEachChildrenRunning {
+		select {
+		case fn.forEachChildrenUpdateChan <- struct{}{}: // queued trigger
+		default: // trigger already queued; no-op
+		}
+	}
+	return nil
+}
+
+// foreachInstance is an item of the collection and the child running the
+// template for it.
+type foreachInstance struct {
+	id       string
+	vars     map[string]any
+	template *ast.BlockStmt
+}
 
-	// Loop through the items to create the custom components.
-	// On re-evaluation new components are added and existing ones are updated.
-	newCustomComponentIds := make(map[string]bool, len(args.Collection))
+// loadedInstance is what was last loaded into a child, which doesn't need to
+// be loaded again while it doesn't change.
+type loadedInstance struct {
+	vars       map[string]any // Comparable variables of the instance.
+	template   *ast.BlockStmt
+	generation uint64 // Generation of the custom component registry.
+}
+
+// comparableVars returns a copy of vars without the function values, which
+// can't be compared: the scope gets new closures on every evaluation, such as
+// the file functions. Changes to user-defined functions are tracked by the
+// generation of the custom component registry instead. Maps left empty are
+// dropped too, so that namespaces which only hold functions, or the exports
+// of blocks without exports, don't make the variables differ.
+func comparableVars(vars map[string]any) map[string]any {
+	res := make(map[string]any, len(vars))
+	for k, v := range vars {
+		if m, ok := v.(map[string]any); ok {
+			if m = comparableVars(m); len(m) > 0 {
+				res[k] = m
+			}
+		} else if v == nil || reflect.TypeOf(v).Kind() != reflect.Func {
+			res[k] = v
+		}
+	}
+	return res
+}
+
+// instances returns an instance for each item of the collection. Assumes
+// that a lock is held.
+func (fn *ForeachConfigNode) instances(scope *vm.Scope, template *ast.BlockStmt) []foreachInstance {
+	args := fn.args
+	instances := make([]foreachInstance, 0, len(args.Collection))
 	fn.customComponentHashCounts = make(map[string]int)
 	for i := 0; i < len(args.Collection); i++ {
 		// Using default value for id as whole collection object
@@ -218,12 +328,7 @@ func (fn *ForeachConfigNode) evaluate(scope *vm.Scope) error {
 		fn.customComponentHashCounts[customComponentID] = count + 1
 		customComponentID += fmt.Sprintf("_%d", count+1)
 
-		cc, created, err := fn.getOrCreateCustomComponent(customComponentID)
-		if err != nil {
-			return err
-		}
-
-		if created && args.HashStringId && id != nil && reflect.TypeOf(id).Kind() == reflect.String {
+		if _, exists := fn.customComponents[customComponentID]; !exists && args.HashStringId && id != nil && reflect.TypeOf(id).Kind() == reflect.String {
 			level.Debug(fn.logger).Log("msg", "a new foreach pipeline was created", "value", id, "fingerprint", customComponentID)
 		}
 
@@ -231,31 +336,113 @@ func (fn *ForeachConfigNode) evaluate(scope *vm.Scope) error {
 		vars := deepCopyMap(scope.Variables)
 		vars[args.Var] = args.Collection[i]
 
-		customComponentRegistry := NewCustomComponentRegistry(fn.customReg, vm.NewScope(vars))
-		if err := cc.LoadBody(template.Body, map[string]any{}, customComponentRegistry); err != nil {
-			return fmt.Errorf("updating custom component in foreach: %w", err)
-		}
-		newCustomComponentIds[customComponentID] = true
+		instances = append(instances, foreachInstance{id: customComponentID, vars: vars, template: template})
 	}
+	return instances
+}
 
-	// Delete the custom components that are no longer in the foreach.
-	// The runner pkg will stop them properly.
-	for id := range fn.customComponents {
-		if _, exist := newCustomComponentIds[id]; !exist {
-			delete(fn.customComponents, id)
+// limitInstances returns limit of the instances, in the order of the
+// collection. Instances which already exist are kept first so that they
+// aren't replaced by new items. Assumes that a lock is held.
+func (fn *ForeachConfigNode) limitInstances(instances []foreachInstance, limit int) []foreachInstance {
+	keep := make(map[string]struct{}, limit)
+	for _, instance := range instances {
+		if _, exists := fn.customComponents[instance.id]; exists && len(keep) < limit {
+			keep[instance.id] = struct{}{}
 		}
 	}
-
-	// Trigger to stop previous children from running and to start running the new ones.
-	if fn.forEachChildrenRunning {
-		select {
-		case fn.forEachChildrenUpdateChan <- struct{}{}: // queued trigger
-		default: // trigger already queued; no-op
+	for _, instance := range instances {
+		if len(keep) == limit {
+			break
 		}
+		keep[instance.id] = struct{}{}
 	}
+	return slices.DeleteFunc(instances, func(instance foreachInstance) bool {
+		_, ok := keep[instance.id]
+		return !ok
+	})
+}
+
+// updateLimiter updates the limiter to the instantiation rate of the
+// arguments. Assumes that a lock is held.
+func (fn *ForeachConfigNode) updateLimiter() {
+	switch {
+	case fn.args.InstantiationRate == 0:
+		fn.limiter = nil
+	case fn.limiter == nil:
+		fn.limiter = rate.NewLimiter(rate.Limit(fn.args.InstantiationRate), fn.args.Burst())
+	default:
+		fn.limiter.SetLimit(rate.Limit(fn.args.InstantiationRate))
+		fn.limiter.SetBurst(fn.args.Burst())
+	}
+}
+
+// loadInstance creates the child of the instance if it doesn't exist, and
+// loads the template into it unless it was already loaded with the same
+// variables. Assumes that a lock is held.
+func (fn *ForeachConfigNode) loadInstance(instance foreachInstance) error {
+	cc, created, err := fn.getOrCreateCustomComponent(instance.id)
+	if err != nil {
+		return err
+	}
+
+	loaded := loadedInstance{vars: comparableVars(instance.vars), template: instance.template, generation: fn.customReg.Generation()}
+	if last, ok := fn.loadedInstances[instance.id]; ok && !created && last.template == loaded.template &&
+		last.generation == loaded.generation && equality.DeepEqual(last.vars, loaded.vars) {
+		return nil
+	}
+
+	customComponentRegistry := NewCustomComponentRegistry(fn.customReg, vm.NewScope(instance.vars))
+	if err := cc.LoadBody(instance.template.Body, map[string]any{}, customComponentRegistry); err != nil {
+		delete(fn.loadedInstances, instance.id)
+		fn.setInstanceHealth(instance.id, component.HealthTypeUnhealthy, fmt.Sprintf("loading the template failed: %s", err))
+		return fmt.Errorf("updating custom component in foreach: %w", err)
+	}
+	fn.loadedInstances[instance.id] = loaded
+	fn.setInstanceHealth(instance.id, component.HealthTypeHealthy, "template loaded")
 	return nil
 }
 
+// instantiatePending creates the pending children allowed by the limiter,
+// and returns whether any was created. A child which fails to load stays
+// pending.
+func (fn *ForeachConfigNode) instantiatePending() (bool, error) {
+	fn.mut.Lock()
+	defer fn.mut.Unlock()
+
+	created := false
+	for len(fn.pending) > 0 && fn.limiter != nil && fn.limiter.Allow() {
+		if err := fn.loadInstance(fn.pending[0]); err != nil {
+			return created, err
+		}
+		fn.pending = fn.pending[1:]
+		created = true
+	}
+	return created, nil
+}
+
+// pendingDelay returns how long to wait before the next pending child can be
+// created, or false if there isn't any pending child.
+func (fn *ForeachConfigNode) pendingDelay() (time.Duration, bool) {
+	fn.mut.RLock()
+	defer fn.mut.RUnlock()
+
+	if len(fn.pending) == 0 || fn.limiter == nil {
+		return 0, false
+	}
+	r := fn.limiter.Reserve()
+	defer r.Cancel()
+	return r.Delay(), true
+}
+
+// skippedInstances returns the number of items which aren't instantiated
+// because of max_instances, and max_instances.
+func (fn *ForeachConfigNode) skippedInstances() (int, int) {
+	fn.mut.RLock()
+	defer fn.mut.RUnlock()
+	return fn.skipped, fn.args.MaxInstances
+}
+
 // Assumes that a lock is held,
 // so that fn.moduleController doesn't change while the function is running.
 func (fn *ForeachConfigNode) getOrCreateCustomComponent(customComponentID string) (CustomComponent, bool, error) {
@@ -300,6 +487,9 @@ func (fn *ForeachConfigNode) Run(ctx context.Context) error {
 				cc:           customComponent,
 				logger:       log.With(fn.logger, "foreach_path", fn.nodeID, "child_id", customComponentID),
 				healthUpdate: fn.setRunHealth,
+				instanceHealthUpdate: func(t component.HealthType, msg string) {
+					fn.setInstanceHealth(customComponentID, t, msg)
+				},
 			})
 		}
 		return fn.runner.ApplyTasks(newCtx, tasks)
@@ -320,15 +510,23 @@ func (fn *ForeachConfigNode) Run(ctx context.Context) error {
 
 func (fn *ForeachConfigNode) run(ctx context.Context, updateTasks func() error) {
 	for {
+		// Pending children are created when the instantiation rate allows it.
+		var instantiate <-chan time.Time
+		if delay, ok := fn.pendingDelay(); ok {
+			instantiate = time.After(delay)
+		}
+
 		select {
 		case <-fn.forEachChildrenUpdateChan:
-			err := updateTasks()
+			fn.updateChildren(updateTasks)
+		case <-instantiate:
+			created, err := fn.instantiatePending()
 			if err != nil {
-				level.Error(fn.logger).Log("msg", "error encountered while updating foreach children", "err", err)
-				fn.setRunHealth(component.HealthTypeUnhealthy, fmt.Sprintf("error encountered while updating foreach children: %s", err))
-				// the error is not fatal, the node can still run in unhealthy mode
-			} else {
-				fn.setRunHealth(component.HealthTypeHealthy, "foreach children updated successfully")
+				level.Error(fn.logger).Log("msg", "error encountered while creating foreach children", "err", err)
+				fn.setRunHealth(component.HealthTypeUnhealthy, fmt.Sprintf("error encountered while creating foreach children: %s", err))
+			}
+			if created {
+				fn.updateChildren(updateTasks)
 			}
 		case <-ctx.Done():
 			return
@@ -336,6 +534,17 @@ func (fn *ForeachConfigNode) run(ctx context.Context, updateTasks func() error)
 	}
 }
 
+func (fn *ForeachConfigNode) updateChildren(updateTasks func() error) {
+	err := updateTasks()
+	if err != nil {
+		level.Error(fn.logger).Log("msg", "error encountered while updating foreach children", "err", err)
+		fn.setRunHealth(component.HealthTypeUnhealthy, fmt.Sprintf("error encountered while updating foreach children: %s", err))
+		// the error is not fatal, the node can still run in unhealthy mode
+	} else {
+		fn.setRunHealth(component.HealthTypeHealthy, "foreach children updated successfully")
+	}
+}
+
 // CurrentHealth returns the current health of the ForeachConfigNode.
 //
 // The health of a ForeachConfigNode is determined by combining:
@@ -370,6 +579,85 @@ func (fn *ForeachConfigNode) setRunHealth(t component.HealthType, msg string) {
 	}
 }
 
+func (fn *ForeachConfigNode) setInstanceHealth(id string, t component.HealthType, msg string) {
+	fn.healthMut.Lock()
+	defer fn.healthMut.Unlock()
+
+	fn.instanceHealth[id] = component.Health{
+		Health:     t,
+		Message:    msg,
+		UpdateTime: time.Now(),
+	}
+}
+
+// deleteInstanceHealth deletes the health of the children which aren't in
+// ids.
+func (fn *ForeachConfigNode) deleteInstanceHealth(ids map[string]bool) {
+	fn.healthMut.Lock()
+	defer fn.healthMut.Unlock()
+
+	for id := range fn.instanceHealth {
+		if !ids[id] {
+			delete(fn.instanceHealth, id)
+		}
+	}
+}
+
+// ForeachDebugInfo is the debug info of a foreach block.
+type ForeachDebugInfo struct {
+	Instances        []ForeachInstanceInfo `alloy:"instance,block,optional"`
+	PendingInstances int                   `alloy:"pending_instances,attr"`
+	SkippedInstances int                   `alloy:"skipped_instances,attr"`
+}
+
+// ForeachInstanceInfo is the debug info of a child of a foreach block.
+type ForeachInstanceInfo struct {
+	ID         string    `alloy:"id,attr"`
+	Health     string    `alloy:"health,attr"`
+	Message    string    `alloy:"message,attr,optional"`
+	UpdateTime time.Time `alloy:"update_time,attr,optional"`
+}
+
+// healthReporter is implemented by custom components which report the
+// health of the components they run.
+type healthReporter interface {
+	CurrentHealth() component.Health
+}
+
+// DebugInfo returns the health of each child of the foreach block. The health
+// of a child combines the health of loading and running it with the health of
+// its components.
+func (fn *ForeachConfigNode) DebugInfo() ForeachDebugInfo {
+	fn.mut.RLock()
+	info := ForeachDebugInfo{
+		PendingInstances: len(fn.pending),
+		SkippedInstances: fn.skipped,
+	}
+	customComponents := make(map[string]CustomComponent, len(fn.customComponents))
+	for id, cc := range fn.customComponents {
+		customComponents[id] = cc
+	}
+	fn.mut.RUnlock()
+
+	fn.healthMut.RLock()
+	defer fn.healthMut.RUnlock()
+	for id, health := range fn.instanceHealth {
+		if hr, ok := customComponents[id].(healthReporter); ok && health.Health == component.HealthTypeHealthy {
+			if componentsHealth := hr.CurrentHealth(); componentsHealth.Health != component.HealthTypeUnknown {
+				health = component.LeastHealthy(health, componentsHealth)
+			}
+		}
+		info.Instances = append(info.Instances, ForeachInstanceInfo{
+			ID:         id,
+			Health:     health.Health.String(),
+			Message:    health.Message,
+			UpdateTime: health.UpdateTime,
+		})
+	}
+	slices.SortFunc(info.Instances, func(a, b ForeachInstanceInfo) int { return strings.Compare(a.ID, b.ID) })
+	return info
+}
+
 func (fn *ForeachConfigNode) AddDataFlowEdgeTo(nodeID string) {
 	fn.dataFlowEdgeMut.Lock()
 	defer fn.dataFlowEdgeMut.Unlock()
@@ -393,10 +681,11 @@ type forEachChildRunner struct {
 }
 
 type forEachChild struct {
-	cc           CustomComponent
-	id           string
-	logger       log.Logger
-	healthUpdate func(t component.HealthType, msg string)
+	cc                   CustomComponent
+	id                   string
+	logger               log.Logger
+	healthUpdate         func(t component.HealthType, msg string)
+	instanceHealthUpdate func(t component.HealthType, msg string)
 }
 
 func (fr *forEachChildRunner) Run(ctx context.Context) {
@@ -404,6 +693,7 @@ func (fr *forEachChildRunner) Run(ctx context.Context) {
 	if err != nil {
 		level.Error(fr.child.logger).Log("msg", "foreach child stopped running", "err", err)
 		fr.child.healthUpdate(component.HealthTypeUnhealthy, fmt.Sprintf("foreach child stopped running: %s", err))
+		fr.child.instanceHealthUpdate(component.HealthTypeUnhealthy, fmt.Sprintf("stopped running: %s", err))
 	}
 }
 
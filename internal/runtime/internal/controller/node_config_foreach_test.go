@@ -2,6 +2,7 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"os"
 	"testing"
 	"time"
@@ -367,6 +368,233 @@ func TestModuleControllerUpdate(t *testing.T) {
 	require.ElementsMatch(t, customComponentIds, []string{"foreach_1_1", "foreach_2_1", "foreach_3_1"})
 }
 
+func TestMaxInstances(t *testing.T) {
+	config := `foreach "default" {
+		collection    = [1, 2, 3]
+		var           = "num"
+		max_instances = 2
+		template {
+		}
+	}`
+	foreachConfigNode := NewForeachConfigNode(getBlockFromConfig(t, config), getComponentGlobals(t), nil)
+	require.NoError(t, foreachConfigNode.Evaluate(vm.NewScope(make(map[string]interface{}))))
+	customComponentIds := foreachConfigNode.moduleController.(*ModuleControllerMock).CustomComponents
+	require.ElementsMatch(t, customComponentIds, []string{"foreach_1_1", "foreach_2_1"})
+
+	health := foreachConfigNode.CurrentHealth()
+	require.Equal(t, component.HealthTypeUnhealthy, health.Health)
+	require.Equal(t, "foreach evaluated, but 1 items of the collection were not instantiated because max_instances is 2", health.Message)
+	require.Equal(t, 1, foreachConfigNode.DebugInfo().SkippedInstances)
+
+	// Existing instances are kept before new items of the collection.
+	newConfig := `foreach "default" {
+		collection    = [3, 2, 1]
+		var           = "num"
+		max_instances = 2
+		template {
+		}
+	}`
+	foreachConfigNode.moduleController.(*ModuleControllerMock).Reset()
+	foreachConfigNode.UpdateBlock(getBlockFromConfig(t, newConfig))
+	require.NoError(t, foreachConfigNode.Evaluate(vm.NewScope(make(map[string]interface{}))))
+	require.Empty(t, foreachConfigNode.moduleController.(*ModuleControllerMock).CustomComponents)
+	require.Equal(t, []string{"foreach_1_1", "foreach_2_1"}, instanceIDs(foreachConfigNode.DebugInfo()))
+
+	// The health recovers once every item is instantiated.
+	newConfig = `foreach "default" {
+		collection    = [3, 2, 1]
+		var           = "num"
+		max_instances = 3
+		template {
+		}
+	}`
+	foreachConfigNode.UpdateBlock(getBlockFromConfig(t, newConfig))
+	require.NoError(t, foreachConfigNode.Evaluate(vm.NewScope(make(map[string]interface{}))))
+	require.Equal(t, []string{"foreach_3_1"}, foreachConfigNode.moduleController.(*ModuleControllerMock).CustomComponents)
+	require.Equal(t, component.HealthTypeHealthy, foreachConfigNode.evalHealth.Health)
+}
+
+func TestInstantiationRate(t *testing.T) {
+	config := `foreach "default" {
+		collection          = [1, 2, 3, 4]
+		var                 = "num"
+		instantiation_rate  = 20
+		instantiation_burst = 2
+		template {
+		}
+	}`
+	foreachConfigNode := NewForeachConfigNode(getBlockFromConfig(t, config), getComponentGlobals(t), nil)
+	require.NoError(t, foreachConfigNode.Evaluate(vm.NewScope(make(map[string]interface{}))))
+
+	// Only the burst is created by Evaluate, in the order of the collection.
+	info := foreachConfigNode.DebugInfo()
+	require.Equal(t, 2, info.PendingInstances)
+	require.Equal(t, []ForeachInstanceInfo{
+		{ID: "foreach_1_1", Health: "healthy", Message: "template loaded"},
+		{ID: "foreach_2_1", Health: "healthy", Message: "template loaded"},
+		{ID: "foreach_3_1", Health: "unknown", Message: "waiting for the instantiation rate limit"},
+		{ID: "foreach_4_1", Health: "unknown", Message: "waiting for the instantiation rate limit"},
+	}, withoutUpdateTimes(info.Instances))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	go foreachConfigNode.Run(ctx)
+
+	// The pending instances are created and run by Run.
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		info := foreachConfigNode.DebugInfo()
+		assert.Equal(c, 0, info.PendingInstances)
+		for _, instance := range info.Instances {
+			assert.Equal(c, "healthy", instance.Health)
+		}
+
+		foreachConfigNode.mut.RLock()
+		defer foreachConfigNode.mut.RUnlock()
+		assert.Len(c, foreachConfigNode.customComponents, 4)
+		for _, cc := range foreachConfigNode.customComponents {
+			assert.True(c, cc.(*CustomComponentMock).IsRunning.Load())
+		}
+	}, 1*time.Second, 5*time.Millisecond)
+}
+
+func TestLoadChangedInstances(t *testing.T) {
+	config := `foreach "default" {
+		collection = [1, 2]
+		var        = "num"
+		template {
+		}
+	}`
+	customReg := NewCustomComponentRegistry(nil, vm.NewScope(nil))
+	foreachConfigNode := NewForeachConfigNode(getBlockFromConfig(t, config), getComponentGlobals(t), customReg)
+	loads := func() []int32 {
+		var loads []int32
+		for _, id := range []string{"foreach_1_1", "foreach_2_1"} {
+			loads = append(loads, foreachConfigNode.customComponents[id].(*CustomComponentMock).Loads.Load())
+		}
+		return loads
+	}
+
+	require.NoError(t, foreachConfigNode.Evaluate(vm.NewScope(map[string]any{"x": 1})))
+	require.Equal(t, []int32{1, 1}, loads())
+
+	// Nothing changed, so the templates aren't loaded again.
+	require.NoError(t, foreachConfigNode.Evaluate(vm.NewScope(map[string]any{"x": 1})))
+	require.Equal(t, []int32{1, 1}, loads())
+
+	// The scope changed.
+	require.NoError(t, foreachConfigNode.Evaluate(vm.NewScope(map[string]any{"x": 2})))
+	require.Equal(t, []int32{2, 2}, loads())
+
+	// The block was updated.
+	foreachConfigNode.UpdateBlock(getBlockFromConfig(t, config))
+	require.NoError(t, foreachConfigNode.Evaluate(vm.NewScope(map[string]any{"x": 2})))
+	require.Equal(t, []int32{3, 3}, loads())
+
+	// A custom component definition changed.
+	customReg.registerDeclare(getBlockFromConfig(t, `declare "custom" { }`))
+	require.NoError(t, foreachConfigNode.Evaluate(vm.NewScope(map[string]any{"x": 2})))
+	require.Equal(t, []int32{4, 4}, loads())
+}
+
+func TestLoaderReapplyUnchangedInstances(t *testing.T) {
+	file, err := parser.ParseFile("", []byte(`
+		function "double" {
+			argument "x" {}
+			result = argument.x.value * 2
+		}
+
+		foreach "default" {
+			collection = [1, 2]
+			var        = "num"
+			template {
+			}
+		}
+	`))
+	require.NoError(t, err)
+
+	globals := getComponentGlobals(t)
+	globals.MinStability = featuregate.StabilityExperimental
+	l := NewLoader(LoaderOptions{ComponentGlobals: globals})
+	options := ApplyOptions{
+		FunctionBlocks: []*ast.BlockStmt{file.Body[0].(*ast.BlockStmt)},
+		ConfigBlocks:   []*ast.BlockStmt{file.Body[1].(*ast.BlockStmt)},
+	}
+	loads := func() []int32 {
+		foreachConfigNode := l.Graph().GetByID("foreach.default").(*ForeachConfigNode)
+		var loads []int32
+		for _, id := range []string{"foreach_1_1", "foreach_2_1"} {
+			loads = append(loads, foreachConfigNode.customComponents[id].(*CustomComponentMock).Loads.Load())
+		}
+		return loads
+	}
+
+	require.NoError(t, l.Apply(options).ErrorOrNil())
+	require.Equal(t, []int32{1, 1}, loads())
+
+	// The foreach block is evaluated again with a scope holding new closures
+	// for the functions and the exports of the foreach block, which don't
+	// cause the templates to be loaded again.
+	require.NoError(t, l.Apply(options).ErrorOrNil())
+	require.Equal(t, []int32{1, 1}, loads())
+}
+
+func TestInstantiatePendingFailure(t *testing.T) {
+	config := `foreach "default" {
+		collection          = [1, 2]
+		var                 = "num"
+		instantiation_rate  = 100
+		instantiation_burst = 1
+		template {
+		}
+	}`
+	foreachConfigNode := NewForeachConfigNode(getBlockFromConfig(t, config), getComponentGlobals(t), nil)
+	require.NoError(t, foreachConfigNode.Evaluate(vm.NewScope(make(map[string]interface{}))))
+	require.Equal(t, 1, foreachConfigNode.DebugInfo().PendingInstances)
+
+	// The pending instance fails to load and stays pending.
+	foreachConfigNode.moduleController.(*ModuleControllerMock).LoadErr = errors.New("load failed")
+	time.Sleep(20 * time.Millisecond)
+	created, err := foreachConfigNode.instantiatePending()
+	require.ErrorContains(t, err, "load failed")
+	require.False(t, created)
+	require.Equal(t, 1, foreachConfigNode.DebugInfo().PendingInstances)
+
+	// It is created once it loads.
+	foreachConfigNode.customComponents["foreach_2_1"].(*CustomComponentMock).LoadErr = nil
+	time.Sleep(20 * time.Millisecond)
+	created, err = foreachConfigNode.instantiatePending()
+	require.NoError(t, err)
+	require.True(t, created)
+	require.Equal(t, 0, foreachConfigNode.DebugInfo().PendingInstances)
+}
+
+func TestForeachArgumentsValidation(t *testing.T) {
+	config := `foreach "default" {
+		collection          = [1]
+		var                 = "num"
+		instantiation_burst = 2
+		template {
+		}
+	}`
+	foreachConfigNode := NewForeachConfigNode(getBlockFromConfig(t, config), getComponentGlobals(t), nil)
+	require.ErrorContains(t, foreachConfigNode.Evaluate(vm.NewScope(make(map[string]interface{}))), "instantiation_burst requires instantiation_rate to be set")
+}
+
+func instanceIDs(info ForeachDebugInfo) []string {
+	var ids []string
+	for _, instance := range info.Instances {
+		ids = append(ids, instance.ID)
+	}
+	return ids
+}
+
+func withoutUpdateTimes(instances []ForeachInstanceInfo) []ForeachInstanceInfo {
+	for i := range instances {
+		instances[i].UpdateTime = time.Time{}
+	}
+	return instances
+}
+
 func getBlockFromConfig(t *testing.T, config string) *ast.BlockStmt {
 	file, err := parser.ParseFile("", []byte(config))
 	require.NoError(t, err)
@@ -390,6 +618,7 @@ func getComponentGlobals(t *testing.T) ComponentGlobals {
 
 type ModuleControllerMock struct {
 	CustomComponents []string
+	LoadErr          error // Error returned by LoadBody of new custom components.
 }
 
 func NewModuleControllerMock() ModuleController {
@@ -408,7 +637,7 @@ func (m *ModuleControllerMock) ModuleIDs() []string {
 
 func (m *ModuleControllerMock) NewCustomComponent(id string, export component.ExportFunc) (CustomComponent, error) {
 	m.CustomComponents = append(m.CustomComponents, id)
-	return &CustomComponentMock{}, nil
+	return &CustomComponentMock{LoadErr: m.LoadErr}, nil
 }
 
 func (m *ModuleControllerMock) Reset() {
@@ -417,10 +646,13 @@ func (m *ModuleControllerMock) Reset() {
 
 type CustomComponentMock struct {
 	IsRunning atomic.Bool
+	Loads     atomic.Int32 // Number of calls to LoadBody.
+	LoadErr   error        // Error returned by LoadBody.
 }
 
 func (c *CustomComponentMock) LoadBody(body ast.Body, args map[string]any, customComponentRegistry *CustomComponentRegistry) error {
-	return nil
+	c.Loads.Inc()
+	return c.LoadErr
 }
 
 func (c *CustomComponentMock) Run(ctx context.Context) error {
@@ -17,6 +17,8 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/nodeconf/function"
 	"github.com/grafana/alloy/internal/nodeconf/importsource"
 	"github.com/grafana/alloy/internal/runner"
 	"github.com/grafana/alloy/internal/runtime/logging/level"
@@ -26,8 +28,8 @@ import (
 	"github.com/grafana/alloy/syntax/vm"
 )
 
-// ImportConfigNode imports declare and import blocks via a managed import source.
-// The imported declare are stored in importedDeclares.
+// ImportConfigNode imports declare, function and import blocks via a managed import source.
+// The imported declare are stored in importedDeclares and the imported functions in importedFunctions.
 // For every imported import block, the ImportConfigNode will create ImportConfigNode children.
 // The children are evaluated and ran by the parent.
 // When an ImportConfigNode receives new content from its source, it updates its importedDeclares and recreates its children.
@@ -53,6 +55,7 @@ type ImportConfigNode struct {
 	importConfigNodesChildren map[string]*ImportConfigNode
 	importChildrenRunning     bool
 	importedDeclares          map[string]ast.Body
+	importedFunctions         map[string]*customFunction
 
 	// NOTE: To avoid deadlocks, whenever we need both locks we must always first lock the mut, then healthMut.
 	healthMut     sync.RWMutex
@@ -212,6 +215,7 @@ func (cn *ImportConfigNode) onContentUpdate(importedContent map[string]string) {
 		cn.importedContent[k] = v
 	}
 	cn.importedDeclares = make(map[string]ast.Body)
+	cn.importedFunctions = make(map[string]*customFunction)
 	cn.importConfigNodesChildren = make(map[string]*ImportConfigNode)
 
 	for f, ic := range importedContent {
@@ -222,7 +226,7 @@ func (cn *ImportConfigNode) onContentUpdate(importedContent map[string]string) {
 			return
 		}
 
-		// populate importedDeclares and importConfigNodesChildren
+		// populate importedDeclares, importedFunctions and importConfigNodesChildren
 		err = cn.processImportedContent(parsedImportedContent)
 		if err != nil {
 			level.Error(cn.logger).Log("msg", "failed to process imported content", "file", f, "err", err)
@@ -251,25 +255,30 @@ func (cn *ImportConfigNode) onContentUpdate(importedContent map[string]string) {
 	cn.OnBlockNodeUpdate(cn)
 }
 
-// processImportedContent processes declare and import blocks of the provided ast content.
+// processImportedContent processes declare, function and import blocks of the provided ast content.
 func (cn *ImportConfigNode) processImportedContent(content *ast.File) error {
 	for _, stmt := range content.Body {
 		blockStmt, ok := stmt.(*ast.BlockStmt)
 		if !ok {
-			return fmt.Errorf("only declare and import blocks are allowed in a module")
+			return fmt.Errorf("only declare, function and import blocks are allowed in a module")
 		}
 
 		componentName := strings.Join(blockStmt.Name, ".")
 		switch componentName {
 		case declareType:
 			cn.processDeclareBlock(blockStmt)
-		case importsource.BlockNameFile, importsource.BlockNameString, importsource.BlockNameHTTP, importsource.BlockNameGit:
+		case function.BlockName:
+			err := cn.processFunctionBlock(blockStmt)
+			if err != nil {
+				return err
+			}
+		case importsource.BlockNameFile, importsource.BlockNameString, importsource.BlockNameHTTP, importsource.BlockNameGit, importsource.BlockNameOCI:
 			err := cn.processImportBlock(blockStmt, componentName)
 			if err != nil {
 				return err
 			}
 		default:
-			return fmt.Errorf("only declare and import blocks are allowed in a module, got %s", componentName)
+			return fmt.Errorf("only declare, function and import blocks are allowed in a module, got %s", componentName)
 		}
 	}
 	return nil
@@ -284,12 +293,31 @@ func (cn *ImportConfigNode) processDeclareBlock(stmt *ast.BlockStmt) {
 	cn.importedDeclares[stmt.Label] = stmt.Body
 }
 
+// processFunctionBlock stores the function definition in the importedFunctions.
+func (cn *ImportConfigNode) processFunctionBlock(stmt *ast.BlockStmt) error {
+	if err := featuregate.CheckAllowed(function.StabilityLevel, cn.globals.MinStability, fmt.Sprintf("function block %q", stmt.Label)); err != nil {
+		return err
+	}
+	if _, ok := cn.importedFunctions[stmt.Label]; ok {
+		return fmt.Errorf("function block redefined %s", stmt.Label)
+	}
+	fn, err := newCustomFunction(stmt, cn.Scope())
+	if err != nil {
+		return err
+	}
+	cn.importedFunctions[stmt.Label] = fn
+	return nil
+}
+
 // processDeclareBlock creates an ImportConfigNode child from the provided import block.
 func (cn *ImportConfigNode) processImportBlock(stmt *ast.BlockStmt, fullName string) error {
 	sourceType := importsource.GetSourceType(fullName)
 	if _, ok := cn.importConfigNodesChildren[stmt.Label]; ok {
 		return fmt.Errorf("import block redefined %s", stmt.Label)
 	}
+	if err := checkFeatureStability(fullName, cn.globals.MinStability); err != nil {
+		return err
+	}
 	childGlobals := cn.globals
 	// Children have a special OnBlockNodeUpdate function which notifies the parent when its content changes.
 	childGlobals.OnBlockNodeUpdate = cn.onChildrenContentUpdate
@@ -434,6 +462,13 @@ func (cn *ImportConfigNode) ImportedDeclares() map[string]ast.Body {
 	return cn.importedDeclares
 }
 
+// ImportedFunctions returns all function blocks that it imported.
+func (cn *ImportConfigNode) ImportedFunctions() map[string]*customFunction {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+	return cn.importedFunctions
+}
+
 // Scope returns the scope associated with the import source.
 func (cn *ImportConfigNode) Scope() *vm.Scope {
 	return vm.NewScope(map[string]interface{}{
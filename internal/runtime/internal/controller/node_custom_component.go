@@ -11,8 +11,11 @@ import (
 	"github.com/go-kit/log"
 
 	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/nodeconf/argument"
 	"github.com/grafana/alloy/internal/runtime/equality"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
 	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/diag"
 	"github.com/grafana/alloy/syntax/vm"
 )
 
@@ -44,6 +47,8 @@ type CustomComponentNode struct {
 	managed CustomComponent     // Inner managed custom component
 	args    component.Arguments // Evaluated arguments for the managed component
 
+	argumentDefs map[*ast.BlockStmt]*argumentDef // Decoded argument blocks of the template
+
 	// NOTE(rfratto): health and exports have their own mutex because they may be
 	// set asynchronously while mut is still being held (i.e., when calling Evaluate
 	// and the managed custom component immediately creates new exports)
@@ -206,6 +211,10 @@ func (cn *CustomComponentNode) evaluate(evalScope *vm.Scope) error {
 		return fmt.Errorf("loading custom component controller: %w", err)
 	}
 
+	if diags := cn.checkArguments(template, args); diags.HasErrors() {
+		return diags
+	}
+
 	// Reload the custom component with new config
 	if err := cn.managed.LoadBody(template, args, customComponentRegistry); err != nil {
 		return fmt.Errorf("updating custom component: %w", err)
@@ -213,6 +222,70 @@ func (cn *CustomComponentNode) evaluate(evalScope *vm.Scope) error {
 	return nil
 }
 
+// argumentDef is a decoded argument block of a template.
+type argumentDef struct {
+	def argument.Arguments
+	err error // Set if the block couldn't be decoded.
+}
+
+// checkArguments checks the arguments passed by the caller block against the
+// type and validation rules of the argument blocks of template. Diagnostics
+// point at the attributes of the caller. Assumes that a lock is held.
+//
+// Argument blocks are decoded once per template, so that their type and
+// regex aren't parsed on every evaluation. Blocks which can't be evaluated on
+// their own, such as blocks referencing the module, are reported once and
+// skipped: their errors are reported when the custom component loads them.
+func (cn *CustomComponentNode) checkArguments(template ast.Body, args map[string]any) diag.Diagnostics {
+	var (
+		diags diag.Diagnostics
+		defs  = make(map[*ast.BlockStmt]*argumentDef)
+	)
+	for _, stmt := range template {
+		block, ok := stmt.(*ast.BlockStmt)
+		if !ok || block.GetBlockName() != argument.BlockName {
+			continue
+		}
+
+		d, ok := cn.argumentDefs[block]
+		if !ok {
+			d = &argumentDef{}
+			if d.err = vm.New(block.Body).Evaluate(vm.NewScope(nil), &d.def); d.err != nil {
+				level.Warn(cn.logger).Log("msg", "argument block can't be evaluated on its own, so the values passed to it aren't checked", "argument", block.Label, "err", d.err)
+			}
+		}
+		defs[block] = d
+
+		value, ok := args[block.Label]
+		if !ok || d.err != nil {
+			continue
+		}
+		if err := d.def.Check(value); err != nil {
+			var node ast.Node = cn.block
+			if attr := findAttribute(cn.block.Body, block.Label); attr != nil {
+				node = attr.Value
+			}
+			diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				StartPos: ast.StartPos(node).Position(),
+				EndPos:   ast.EndPos(node).Position(),
+				Message:  fmt.Sprintf("invalid value for argument %q: %s", block.Label, err),
+			})
+		}
+	}
+	cn.argumentDefs = defs
+	return diags
+}
+
+func findAttribute(body ast.Body, name string) *ast.AttributeStmt {
+	for _, stmt := range body {
+		if attr, ok := stmt.(*ast.AttributeStmt); ok && attr.Name.Name == name {
+			return attr
+		}
+	}
+	return nil
+}
+
 func (cn *CustomComponentNode) Run(ctx context.Context) error {
 	cn.mut.RLock()
 	managed := cn.managed
@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckArguments(t *testing.T) {
+	declare := getBlockFromConfig(t, `declare "a" {
+		argument "port" {
+			type = "number"
+		}
+		argument "target" {
+			default = local.file.target.content
+		}
+	}`)
+	var logs bytes.Buffer
+	cn := &CustomComponentNode{
+		block:  getBlockFromConfig(t, `a "example" { port = "80" }`),
+		logger: log.NewLogfmtLogger(&logs),
+	}
+	args := map[string]any{"port": "80", "target": "localhost"}
+
+	diags := cn.checkArguments(declare.Body, args)
+	require.EqualError(t, diags.ErrorOrNil(), `1:22: invalid value for argument "port": expected number, got string`)
+	defs := cn.argumentDefs
+
+	// The argument blocks are decoded once, and the block which can't be
+	// evaluated on its own is only reported once.
+	diags = cn.checkArguments(declare.Body, args)
+	require.EqualError(t, diags.ErrorOrNil(), `1:22: invalid value for argument "port": expected number, got string`)
+	require.Len(t, cn.argumentDefs, 2)
+	for block, def := range defs {
+		require.Same(t, def, cn.argumentDefs[block])
+	}
+	require.Equal(t, 1, strings.Count(logs.String(), "argument block can't be evaluated on its own"))
+	require.Contains(t, logs.String(), "argument=target")
+}
@@ -21,6 +21,7 @@ type valueCache struct {
 	moduleExports      map[string]any         // Export label -> Export value
 	moduleArguments    map[string]any         // Argument label -> Map with the key "value" that points to the Argument value
 	moduleChangedIndex int                    // Everytime a change occurs this is incremented
+	functions          map[string]any         // Function name or import namespace -> Function value or map of functions
 	scope              *vm.Scope              // scope provides additional context for the nodes in the module
 }
 
@@ -30,6 +31,7 @@ func newValueCache() *valueCache {
 		componentIds:    make(map[string]ComponentID, 0),
 		moduleExports:   make(map[string]any),
 		moduleArguments: make(map[string]any),
+		functions:       make(map[string]any),
 		scope:           vm.NewScope(make(map[string]any)),
 	}
 }
@@ -44,6 +46,13 @@ func (vc *valueCache) UpdateScopeVariables(variables map[string]any) {
 	vc.scope.Variables = deepCopyMap(variables)
 }
 
+// CacheFunctions replaces the cached user-defined functions.
+func (vc *valueCache) CacheFunctions(functions map[string]any) {
+	vc.mut.Lock()
+	defer vc.mut.Unlock()
+	vc.functions = functions
+}
+
 // CacheExports will cache the provided exports using the given id. exports may
 // be nil to store an empty object.
 func (vc *valueCache) CacheExports(id ComponentID, exports component.Exports) error {
@@ -70,11 +79,15 @@ func (vc *valueCache) CacheExports(id ComponentID, exports component.Exports) er
 	return nil
 }
 
+// GetModuleArgument returns the value of the module argument key.
 func (vc *valueCache) GetModuleArgument(key string) (any, bool) {
 	vc.mut.RLock()
 	defer vc.mut.RUnlock()
 	v, exist := vc.moduleArguments[key]
-	return v, exist
+	if !exist {
+		return nil, false
+	}
+	return v.(map[string]any)["value"], true
 }
 
 // CacheModuleArgument will cache the provided exports using the given id.
@@ -215,6 +228,28 @@ func (vc *valueCache) GetContext() *vm.Scope {
 		vars[argumentLabel] = deepCopyMap(vc.moduleArguments)
 	}
 
+	// Add user-defined functions. Imported functions share their namespace
+	// with the exports of imported custom components, so namespaces are merged.
+	// Local functions which conflict with other variables are rejected by
+	// Loader.populateFunctions; functions inherited from a parent module are
+	// shadowed by the variables of the module.
+	for name, fn := range vc.functions {
+		existing, ok := vars[name]
+		if !ok {
+			vars[name] = fn
+			continue
+		}
+		existingMap, ok1 := existing.(map[string]any)
+		fnMap, ok2 := fn.(map[string]any)
+		if ok1 && ok2 {
+			for k, v := range fnMap {
+				if _, exists := existingMap[k]; !exists {
+					existingMap[k] = v
+				}
+			}
+		}
+	}
+
 	return vm.NewScope(vars)
 }
 
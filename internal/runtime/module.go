@@ -186,6 +186,21 @@ func (c *module) Run(ctx context.Context) error {
 	return nil
 }
 
+// CurrentHealth returns the least healthy health of the components of the
+// module.
+func (c *module) CurrentHealth() component.Health {
+	var healths []component.Health
+	for _, cn := range c.f.loader.Components() {
+		health := cn.CurrentHealth()
+		health.Message = fmt.Sprintf("%s: %s", cn.NodeID(), health.Message)
+		healths = append(healths, health)
+	}
+	if len(healths) == 0 {
+		return component.Health{}
+	}
+	return component.LeastHealthy(healths[0], healths[1:]...)
+}
+
 // moduleControllerOptions holds static options for module controller.
 type moduleControllerOptions struct {
 	// Logger to use for controller logs and components. A no-op logger will be
@@ -0,0 +1,311 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/util"
+)
+
+// ReloadPolicy configures how the root controller rolls out a new source
+// after the initial load.
+type ReloadPolicy struct {
+	// HealthWindow is how long the health of components is watched after a
+	// new source is loaded. If a critical component becomes unhealthy during
+	// the window, the previous source is loaded again. Zero disables the
+	// policy.
+	HealthWindow time.Duration
+
+	// CriticalComponents are the IDs of the components whose health is
+	// watched, such as "prometheus.remote_write.default". IDs may be glob
+	// patterns, such as "prometheus.remote_write.*". Every component is
+	// watched if CriticalComponents is empty.
+	CriticalComponents []string
+
+	// OnRollback, if set, is called after the previous source was loaded
+	// again. It is called from the goroutine which watches the health of the
+	// components.
+	OnRollback func(err *RollbackError)
+}
+
+// minHealthCheckInterval is the shortest interval between two checks of the
+// health of the components.
+const minHealthCheckInterval = 10 * time.Millisecond
+
+// Validate checks that the patterns of critical components are valid.
+func (p ReloadPolicy) Validate() error {
+	if p.HealthWindow < 0 {
+		return fmt.Errorf("health window must not be negative")
+	}
+	for _, pattern := range p.CriticalComponents {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid critical component pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+func (p ReloadPolicy) enabled() bool {
+	return p.HealthWindow > 0
+}
+
+func (p ReloadPolicy) isCritical(id string) bool {
+	if len(p.CriticalComponents) == 0 {
+		return true
+	}
+	for _, pattern := range p.CriticalComponents {
+		if ok, _ := path.Match(pattern, id); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// RollbackError is passed to ReloadPolicy.OnRollback when a source was rolled
+// back because a critical component became unhealthy.
+type RollbackError struct {
+	ComponentID string           // Component which became unhealthy.
+	Health      component.Health // Health reported by the component.
+	Source      *Source          // Source which was rolled back.
+	Previous    *Source          // Source which was loaded again.
+	Err         error            // Error reported when loading the previous source, if any.
+}
+
+func (e *RollbackError) Error() string {
+	msg := fmt.Sprintf("config rolled back: component %s became unhealthy: %s", e.ComponentID, e.Health.Message)
+	if e.Err != nil {
+		msg += fmt.Sprintf("; loading the previous config failed: %s", e.Err)
+	}
+	return msg
+}
+
+func (e *RollbackError) Unwrap() error { return e.Err }
+
+// ErrRolloutSuperseded is returned by Rollout.Wait when another source was
+// loaded, or the controller exited, before the rollout finished.
+var ErrRolloutSuperseded = errors.New("the config was neither committed nor rolled back because it was superseded")
+
+// Rollout is the health watch of the components after a source was loaded.
+type Rollout struct {
+	done chan struct{}
+	err  error // Set before done is closed.
+}
+
+func newRollout() *Rollout {
+	return &Rollout{done: make(chan struct{})}
+}
+
+func (r *Rollout) finish(err error) {
+	r.err = err
+	close(r.done)
+}
+
+// Wait waits until the source is committed or rolled back. It returns nil if
+// the source was committed, a *RollbackError if it was rolled back, and
+// ErrRolloutSuperseded if the rollout was superseded. If ctx is canceled
+// first, its error is returned.
+func (r *Rollout) Wait(ctx context.Context) error {
+	select {
+	case <-r.done:
+		return r.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// appliedSource is a source loaded with LoadSource.
+type appliedSource struct {
+	source     *Source
+	args       map[string]any
+	configPath string
+}
+
+type rolloutMetrics struct {
+	rollbacks  prometheus.Counter
+	inProgress prometheus.Gauge
+}
+
+func newRolloutMetrics(reg prometheus.Registerer) *rolloutMetrics {
+	m := &rolloutMetrics{
+		rollbacks: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "alloy_config_rollbacks_total",
+			Help: "Total number of config reloads rolled back because a critical component became unhealthy.",
+		}),
+		inProgress: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "alloy_config_rollout_in_progress",
+			Help: "Whether the health of components is being watched after a config reload.",
+		}),
+	}
+	if reg != nil {
+		m.rollbacks = util.MustRegisterOrGet(reg, m.rollbacks).(prometheus.Counter)
+		m.inProgress = util.MustRegisterOrGet(reg, m.inProgress).(prometheus.Gauge)
+	}
+	return m
+}
+
+// rollout loads source and starts watching the health of the critical
+// components for the health window of the reload policy. It returns once
+// source is loaded; the last committed source is loaded again in the
+// background if one of the components becomes unhealthy.
+func (f *Runtime) rollout(source *Source, args map[string]any, configPath string) error {
+	f.rolloutMut.Lock()
+	defer f.rolloutMut.Unlock()
+
+	// A new source supersedes the rollout in progress, which is neither
+	// committed nor rolled back.
+	f.cancelRolloutLocked()
+	f.lastRollout = nil
+
+	// Components which were already unhealthy don't cause a rollback.
+	unhealthy := f.unhealthyComponents()
+
+	if err := f.applySource(source, args, configPath); err != nil {
+		return err
+	}
+
+	applied := &appliedSource{source: source, args: args, configPath: configPath}
+	if f.lastApplied == nil {
+		// There is nothing to roll back to after the initial load.
+		f.lastApplied = applied
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	f.cancelRollout = cancel
+	f.lastRollout = newRollout()
+	f.rolloutMetrics.inProgress.Set(1)
+
+	level.Info(f.log).Log("msg", "watching the health of components before committing the new config", "window", f.opts.ReloadPolicy.HealthWindow)
+
+	f.rollouts.Add(1)
+	go func(r *Rollout) {
+		defer f.rollouts.Done()
+		r.finish(f.finishRollout(ctx, applied, unhealthy))
+	}(f.lastRollout)
+	return nil
+}
+
+// finishRollout watches the health of the components after applied was
+// loaded, and then either commits applied or loads the last committed source
+// again. Nothing is done if ctx is canceled first. The returned error is the
+// outcome reported by Rollout.Wait.
+func (f *Runtime) finishRollout(ctx context.Context, applied *appliedSource, ignored map[string]struct{}) error {
+	policy := f.opts.ReloadPolicy
+	id, health, unhealthy := f.watchHealth(ctx, policy, ignored)
+
+	f.rolloutMut.Lock()
+	if ctx.Err() != nil {
+		// The rollout was superseded by a newer source or the controller exited.
+		f.rolloutMut.Unlock()
+		return ErrRolloutSuperseded
+	}
+	f.cancelRolloutLocked()
+
+	if !unhealthy {
+		f.lastApplied = applied
+		f.rolloutMut.Unlock()
+		level.Info(f.log).Log("msg", "committed the new config")
+		return nil
+	}
+
+	level.Error(f.log).Log("msg", "component became unhealthy, rolling back to the previous config", "component", id, "health", health.Message)
+	f.rolloutMetrics.rollbacks.Inc()
+	previous := f.lastApplied
+	rollbackErr := &RollbackError{
+		ComponentID: id,
+		Health:      health,
+		Source:      applied.source,
+		Previous:    previous.source,
+		Err:         f.applySource(previous.source, previous.args, previous.configPath),
+	}
+	f.rolloutMut.Unlock()
+
+	if rollbackErr.Err != nil {
+		level.Error(f.log).Log("msg", "failed to load the previous config", "err", rollbackErr.Err)
+	}
+	if policy.OnRollback != nil {
+		policy.OnRollback(rollbackErr)
+	}
+	return rollbackErr
+}
+
+// LastRollout returns the rollout of the source loaded by the last call to
+// LoadSource, or nil if the health of the components isn't watched after it.
+// Callers which load sources concurrently must serialize LoadSource and
+// LastRollout to get the rollout of their own source.
+func (f *Runtime) LastRollout() *Rollout {
+	f.rolloutMut.Lock()
+	defer f.rolloutMut.Unlock()
+	return f.lastRollout
+}
+
+// cancelRolloutLocked stops watching the health of the components, if a
+// rollout is in progress. rolloutMut must be held.
+func (f *Runtime) cancelRolloutLocked() {
+	if f.cancelRollout == nil {
+		return
+	}
+	f.cancelRollout()
+	f.cancelRollout = nil
+	f.rolloutMetrics.inProgress.Set(0)
+}
+
+// stopRollout cancels the rollout in progress, if any, and waits for it to
+// exit.
+func (f *Runtime) stopRollout() {
+	f.rolloutMut.Lock()
+	f.cancelRolloutLocked()
+	f.rolloutMut.Unlock()
+	f.rollouts.Wait()
+}
+
+// watchHealth checks the health of the critical components until the health
+// window ends or ctx is canceled, and returns the first one which became
+// unhealthy.
+func (f *Runtime) watchHealth(ctx context.Context, policy ReloadPolicy, ignored map[string]struct{}) (string, component.Health, bool) {
+	interval := max(min(policy.HealthWindow/10, time.Second), minHealthCheckInterval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	deadline := time.Now().Add(policy.HealthWindow)
+	for {
+		for _, cn := range f.loader.Components() {
+			id := cn.NodeID()
+			if _, ok := ignored[id]; ok || !policy.isCritical(id) {
+				continue
+			}
+			if health := cn.CurrentHealth(); isUnhealthy(health) {
+				return id, health, true
+			}
+		}
+		if !time.Now().Before(deadline) {
+			return "", component.Health{}, false
+		}
+		select {
+		case <-ctx.Done():
+			return "", component.Health{}, false
+		case <-ticker.C:
+		}
+	}
+}
+
+func (f *Runtime) unhealthyComponents() map[string]struct{} {
+	unhealthy := make(map[string]struct{})
+	for _, cn := range f.loader.Components() {
+		if isUnhealthy(cn.CurrentHealth()) {
+			unhealthy[cn.NodeID()] = struct{}{}
+		}
+	}
+	return unhealthy
+}
+
+func isUnhealthy(h component.Health) bool {
+	return h.Health == component.HealthTypeUnhealthy || h.Health == component.HealthTypeExited
+}
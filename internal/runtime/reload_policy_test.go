@@ -0,0 +1,277 @@
+package runtime
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/featuregate"
+)
+
+func TestReloadPolicy(t *testing.T) {
+	tt := []struct {
+		name             string
+		policy           ReloadPolicy
+		initial, reload  string
+		expectedError    string
+		expectedHealthy  map[string]bool // Health of the components after the reload.
+		expectedRollback bool
+	}{
+		{
+			name:    "healthy reload",
+			policy:  ReloadPolicy{HealthWindow: 200 * time.Millisecond},
+			initial: `health_reporter "a" { healthy = true }`,
+			reload: `health_reporter "a" { healthy = true }
+			                  health_reporter "b" { healthy = true }`,
+			expectedHealthy: map[string]bool{"health_reporter.a": true, "health_reporter.b": true},
+		},
+		{
+			name:             "unhealthy component",
+			policy:           ReloadPolicy{HealthWindow: 200 * time.Millisecond},
+			initial:          `health_reporter "a" { healthy = true }`,
+			reload:           `health_reporter "a" { healthy = false }`,
+			expectedError:    "config rolled back: component health_reporter.a became unhealthy: reporting unhealthy",
+			expectedHealthy:  map[string]bool{"health_reporter.a": true},
+			expectedRollback: true,
+		},
+		{
+			name:             "unhealthy component after a delay",
+			policy:           ReloadPolicy{HealthWindow: time.Second},
+			initial:          `health_reporter "a" { healthy = true }`,
+			reload:           `health_reporter "a" { unhealthy_after = "100ms" }`,
+			expectedError:    "config rolled back: component health_reporter.a became unhealthy: reporting unhealthy",
+			expectedHealthy:  map[string]bool{"health_reporter.a": true},
+			expectedRollback: true,
+		},
+		{
+			name:             "unhealthy new component",
+			policy:           ReloadPolicy{HealthWindow: 200 * time.Millisecond, CriticalComponents: []string{"health_reporter.*"}},
+			initial:          `health_reporter "a" { healthy = true }`,
+			reload:           `health_reporter "b" { healthy = false }`,
+			expectedError:    "config rolled back: component health_reporter.b became unhealthy: reporting unhealthy",
+			expectedHealthy:  map[string]bool{"health_reporter.a": true},
+			expectedRollback: true,
+		},
+		{
+			name:    "health window shorter than the check interval",
+			policy:  ReloadPolicy{HealthWindow: time.Nanosecond},
+			initial: `health_reporter "a" { healthy = true }`,
+			reload: `health_reporter "a" { healthy = true }
+			                  health_reporter "b" { healthy = true }`,
+			expectedHealthy: map[string]bool{"health_reporter.a": true, "health_reporter.b": true},
+		},
+		{
+			name:    "unhealthy component which isn't critical",
+			policy:  ReloadPolicy{HealthWindow: 200 * time.Millisecond, CriticalComponents: []string{"health_reporter.a"}},
+			initial: `health_reporter "a" { healthy = true }`,
+			reload: `health_reporter "a" { healthy = true }
+			                  health_reporter "b" { healthy = false }`,
+			expectedHealthy: map[string]bool{"health_reporter.a": true, "health_reporter.b": false},
+		},
+		{
+			name:    "component which was already unhealthy",
+			policy:  ReloadPolicy{HealthWindow: 200 * time.Millisecond},
+			initial: `health_reporter "a" { healthy = false }`,
+			reload: `health_reporter "a" { healthy = false }
+			                  health_reporter "b" { healthy = true }`,
+			expectedHealthy: map[string]bool{"health_reporter.a": false, "health_reporter.b": true},
+		},
+		{
+			name:            "disabled policy",
+			initial:         `health_reporter "a" { healthy = true }`,
+			reload:          `health_reporter "a" { healthy = false }`,
+			expectedHealthy: map[string]bool{"health_reporter.a": false},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			defer verifyNoGoroutineLeaks(t)
+			reg := prometheus.NewRegistry()
+
+			rollbacks := make(chan *RollbackError, 1)
+			opts := testOptions(t)
+			opts.Reg = reg
+			opts.ReloadPolicy = tc.policy
+			opts.ReloadPolicy.OnRollback = func(err *RollbackError) { rollbacks <- err }
+			ctrl := NewWithComponentRegistry(opts, healthReporterRegistry)
+
+			ctx, cancel := context.WithCancel(t.Context())
+			var wg sync.WaitGroup
+			defer func() {
+				cancel()
+				wg.Wait()
+			}()
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ctrl.Run(ctx)
+			}()
+
+			initial, reload := parseSource(t, tc.initial), parseSource(t, tc.reload)
+			require.NoError(t, ctrl.LoadSource(initial, nil, ""))
+			require.NoError(t, ctrl.LoadSource(reload, nil, ""))
+			rollout := ctrl.LastRollout()
+			require.Equal(t, tc.policy.enabled(), rollout != nil)
+
+			if tc.expectedError != "" {
+				select {
+				case err := <-rollbacks:
+					require.EqualError(t, err, tc.expectedError)
+					require.Same(t, reload, err.Source)
+					require.Same(t, initial, err.Previous)
+				case <-time.After(5 * time.Second):
+					require.FailNow(t, "timed out waiting for the rollback")
+				}
+
+				var rollbackErr *RollbackError
+				require.ErrorAs(t, rollout.Wait(t.Context()), &rollbackErr)
+				require.EqualError(t, rollbackErr, tc.expectedError)
+			} else if tc.policy.enabled() {
+				waitCtx, waitCancel := context.WithTimeout(t.Context(), 5*time.Second)
+				defer waitCancel()
+				require.NoError(t, rollout.Wait(waitCtx))
+				require.Equal(t, 0.0, testutil.ToFloat64(ctrl.rolloutMetrics.inProgress))
+				require.Empty(t, rollbacks)
+			}
+
+			healthy := make(map[string]bool)
+			for _, cn := range ctrl.loader.Components() {
+				healthy[cn.NodeID()] = !isUnhealthy(cn.CurrentHealth())
+			}
+			require.Equal(t, tc.expectedHealthy, healthy)
+
+			if tc.policy.enabled() {
+				expectedRollbacks := 0.0
+				if tc.expectedRollback {
+					expectedRollbacks = 1
+				}
+				require.Equal(t, expectedRollbacks, testutil.ToFloat64(ctrl.rolloutMetrics.rollbacks))
+				require.Equal(t, 0.0, testutil.ToFloat64(ctrl.rolloutMetrics.inProgress))
+			}
+		})
+	}
+}
+
+func TestReloadPolicy_Superseded(t *testing.T) {
+	defer verifyNoGoroutineLeaks(t)
+
+	opts := testOptions(t)
+	opts.Reg = prometheus.NewRegistry()
+	opts.ReloadPolicy = ReloadPolicy{
+		HealthWindow: time.Hour,
+		OnRollback: func(err *RollbackError) {
+			require.Fail(t, "unexpected rollback", err.Error())
+		},
+	}
+	ctrl := NewWithComponentRegistry(opts, healthReporterRegistry)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ctrl.Run(ctx)
+	}()
+
+	require.NoError(t, ctrl.LoadSource(parseSource(t, `health_reporter "a" { healthy = true }`), nil, ""))
+
+	// LoadSource returns without waiting for the health window.
+	require.NoError(t, ctrl.LoadSource(parseSource(t, `health_reporter "a" { unhealthy_after = "100ms" }`), nil, ""))
+	require.Equal(t, 1.0, testutil.ToFloat64(ctrl.rolloutMetrics.inProgress))
+	superseded := ctrl.LastRollout()
+
+	// The rollout of the unhealthy source is superseded before the component
+	// becomes unhealthy.
+	require.NoError(t, ctrl.LoadSource(parseSource(t, `health_reporter "a" { healthy = true }`), nil, ""))
+	require.ErrorIs(t, superseded.Wait(t.Context()), ErrRolloutSuperseded)
+	time.Sleep(300 * time.Millisecond)
+	require.Equal(t, 0.0, testutil.ToFloat64(ctrl.rolloutMetrics.rollbacks))
+	require.Equal(t, 1.0, testutil.ToFloat64(ctrl.rolloutMetrics.inProgress))
+
+	// Waiting for the rollout in progress stops when the context is canceled.
+	inProgress := ctrl.LastRollout()
+	waitCtx, waitCancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer waitCancel()
+	require.ErrorIs(t, inProgress.Wait(waitCtx), context.DeadlineExceeded)
+
+	// Stopping the controller stops the rollout in progress.
+	cancel()
+	wg.Wait()
+	require.Equal(t, 0.0, testutil.ToFloat64(ctrl.rolloutMetrics.inProgress))
+	require.ErrorIs(t, inProgress.Wait(t.Context()), ErrRolloutSuperseded)
+}
+
+func TestReloadPolicy_Validate(t *testing.T) {
+	require.NoError(t, ReloadPolicy{HealthWindow: time.Minute, CriticalComponents: []string{"prometheus.remote_write.*"}}.Validate())
+	require.EqualError(t, ReloadPolicy{HealthWindow: -time.Minute}.Validate(), "health window must not be negative")
+	require.EqualError(t, ReloadPolicy{CriticalComponents: []string{"prometheus.[remote_write"}}.Validate(), `invalid critical component pattern "prometheus.[remote_write": syntax error in pattern`)
+}
+
+func parseSource(t *testing.T, config string) *Source {
+	t.Helper()
+	f, err := ParseSource(t.Name(), []byte(config))
+	require.NoError(t, err)
+	return f
+}
+
+type healthReporterArgs struct {
+	Healthy        bool          `alloy:"healthy,attr,optional"`
+	UnhealthyAfter time.Duration `alloy:"unhealthy_after,attr,optional"`
+}
+
+// healthReporter reports the health set in its arguments.
+type healthReporter struct {
+	mut     sync.Mutex
+	healthy bool
+	after   time.Time // Time after which the component reports unhealthy, if set.
+}
+
+var healthReporterRegistry = component.NewRegistryMap(
+	featuregate.StabilityGenerallyAvailable,
+	true,
+	map[string]component.Registration{
+		"health_reporter": {
+			Name:      "health_reporter",
+			Stability: featuregate.StabilityGenerallyAvailable,
+			Args:      healthReporterArgs{},
+			Build: func(_ component.Options, args component.Arguments) (component.Component, error) {
+				c := &healthReporter{}
+				return c, c.Update(args)
+			},
+		},
+	},
+)
+
+func (c *healthReporter) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+func (c *healthReporter) Update(args component.Arguments) error {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	a := args.(healthReporterArgs)
+	c.healthy = a.Healthy
+	c.after = time.Time{}
+	if a.UnhealthyAfter > 0 {
+		c.healthy = true
+		c.after = time.Now().Add(a.UnhealthyAfter)
+	}
+	return nil
+}
+
+func (c *healthReporter) CurrentHealth() component.Health {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	if c.healthy && (c.after.IsZero() || time.Now().Before(c.after)) {
+		return component.Health{Health: component.HealthTypeHealthy, Message: "reporting healthy", UpdateTime: time.Now()}
+	}
+	return component.Health{Health: component.HealthTypeUnhealthy, Message: "reporting unhealthy", UpdateTime: time.Now()}
+}
@@ -100,9 +100,10 @@ type Service struct {
 	tracer trace.TracerProvider
 	opts   Options
 
-	sharder shard.Sharder
-	node    *ckit.Node
-	randGen *rand.Rand
+	sharder    shard.Sharder
+	node       *ckit.Node
+	randGen    *rand.Rand
+	httpClient *http.Client
 
 	// alloyCluster is given to components via calls to Data() and implements Cluster.
 	alloyCluster *alloyCluster
@@ -202,6 +203,7 @@ func New(opts Options) (*Service, error) {
 		sharder:             ckitConfig.Sharder,
 		node:                node,
 		randGen:             rand.New(rand.NewSource(time.Now().UnixNano())),
+		httpClient:          httpClient,
 		notifyClusterChange: make(chan struct{}, 1),
 	}
 	s.alloyCluster = newAlloyCluster(ckitConfig.Sharder, s.triggerClusterChangeNotification, opts, l)
@@ -506,6 +508,15 @@ func (s *Service) Update(_ any) error {
 	return fmt.Errorf("cluster service does not support configuration")
 }
 
+// PeerHTTPClient returns the HTTP client used to communicate with the other
+// peers of the cluster, and the URL scheme of their HTTP servers.
+func (s *Service) PeerHTTPClient() (*http.Client, string) {
+	if s.opts.EnableTLS {
+		return s.httpClient, "https"
+	}
+	return s.httpClient, "http"
+}
+
 // Data returns an instance of [Cluster].
 func (s *Service) Data() any {
 	return s.alloyCluster
@@ -567,7 +567,7 @@ func startNewNode(t *testing.T, state *testState, nodeName string) {
 		Gatherer: reg,
 
 		ReadyFunc:  func() bool { return true },
-		ReloadFunc: func() error { return nil },
+		ReloadFunc: func(context.Context, bool) error { return nil },
 
 		HTTPListenAddr: nodeAddress,
 	})
@@ -0,0 +1,72 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+)
+
+// ConfigDiffPath is the path of the endpoint returning the changes a config
+// would make to the running config.
+const ConfigDiffPath = "/-/config/diff"
+
+// maxConfigDiffBodySize limits the size of configs sent to the config diff
+// endpoint.
+const maxConfigDiffBodySize = 32 << 20
+
+// ConfigDiffRequest is the body of a request to the config diff endpoint
+// when its content type is application/json. Files maps the names of the
+// files of the config to their content. Other content types are read as a
+// single file named config.alloy.
+type ConfigDiffRequest struct {
+	Files map[string]string `json:"files"`
+}
+
+func (s *Service) configDiffHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sources, err := readConfigDiffRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		diff, err := s.opts.DiffFunc(sources)
+		if err != nil {
+			level.Debug(s.log).Log("msg", "failed to diff config", "err", err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(diff); err != nil {
+			level.Error(s.log).Log("msg", "failed to write config diff", "err", err.Error())
+		}
+	}
+}
+
+func readConfigDiffRequest(r *http.Request) (map[string][]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxConfigDiffBodySize))
+	if err != nil {
+		return nil, fmt.Errorf("reading request body: %w", err)
+	}
+
+	if r.Header.Get("Content-Type") != "application/json" {
+		return map[string][]byte{"config.alloy": body}, nil
+	}
+
+	var req ConfigDiffRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, fmt.Errorf("decoding request body: %w", err)
+	}
+	if len(req.Files) == 0 {
+		return nil, fmt.Errorf("the request doesn't have any file")
+	}
+	sources := make(map[string][]byte, len(req.Files))
+	for name, content := range req.Files {
+		sources[name] = []byte(content)
+	}
+	return sources, nil
+}
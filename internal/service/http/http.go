@@ -50,8 +50,17 @@ type Options struct {
 	Tracer   trace.TracerProvider // Where to send traces.
 	Gatherer prometheus.Gatherer  // Where to collect metrics from.
 
-	ReadyFunc  func() bool
-	ReloadFunc func() error
+	ReadyFunc func() bool
+
+	// ReloadFunc reloads the config. If wait is true, ReloadFunc also waits
+	// until the reloaded config is committed or rolled back, and returns the
+	// rollback as an error.
+	ReloadFunc func(ctx context.Context, wait bool) error
+
+	// DiffFunc returns the changes which loading the sources would make to
+	// the running config, without loading them. The result is encoded as
+	// JSON.
+	DiffFunc func(sources map[string][]byte) (any, error)
 
 	HTTPListenAddr   string                // Address to listen for HTTP traffic on.
 	MemoryListenAddr string                // Address to accept in-memory traffic on.
@@ -264,10 +273,11 @@ func (s *Service) Run(ctx context.Context, host service.Host) error {
 	}
 
 	if s.opts.ReloadFunc != nil {
-		r.HandleFunc("/-/reload", func(w http.ResponseWriter, _ *http.Request) {
+		r.HandleFunc("/-/reload", func(w http.ResponseWriter, r *http.Request) {
 			level.Info(s.log).Log("msg", "reload requested via /-/reload endpoint")
 
-			if err := s.opts.ReloadFunc(); err != nil {
+			wait, _ := strconv.ParseBool(r.URL.Query().Get("wait"))
+			if err := s.opts.ReloadFunc(r.Context(), wait); err != nil {
 				level.Error(s.log).Log("msg", "failed to reload config", "err", err.Error())
 				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
@@ -278,6 +288,10 @@ func (s *Service) Run(ctx context.Context, host service.Host) error {
 		}).Methods(http.MethodGet, http.MethodPost)
 	}
 
+	if s.opts.DiffFunc != nil {
+		r.HandleFunc(ConfigDiffPath, s.configDiffHandler()).Methods(http.MethodPost)
+	}
+
 	// Wire in support bundle generator
 	r.HandleFunc("/-/support", s.generateSupportBundleHandler(host)).Methods("GET")
 
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/grafana/alloy/internal/component"
@@ -69,6 +70,113 @@ func TestHTTP(t *testing.T) {
 	})
 }
 
+func TestConfigDiff(t *testing.T) {
+	ctx := componenttest.TestContext(t)
+
+	env, err := newTestEnvironment(t)
+	require.NoError(t, err)
+	require.NoError(t, env.ApplyConfig(`/* empty */`))
+
+	go func() {
+		require.NoError(t, env.Run(ctx))
+	}()
+
+	tt := []struct {
+		name, contentType, body string
+		expectedStatus          int
+		expectedBody            string
+	}{
+		{
+			name:           "single file",
+			contentType:    "text/plain",
+			body:           "a",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"config.alloy":"YQ=="}` + "\n",
+		},
+		{
+			name:           "files",
+			contentType:    "application/json",
+			body:           `{"files": {"a.alloy": "a", "b.alloy": "b"}}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"a.alloy":"YQ==","b.alloy":"Yg=="}` + "\n",
+		},
+		{
+			name:           "no files",
+			contentType:    "application/json",
+			body:           `{}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "the request doesn't have any file\n",
+		},
+		{
+			name:           "diff error",
+			contentType:    "application/json",
+			body:           `{"files": {"invalid.alloy": ""}}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid config\n",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			util.Eventually(t, func(t require.TestingT) {
+				resp, err := http.Post(fmt.Sprintf("http://%s%s", env.ListenAddr(), ConfigDiffPath), tc.contentType, strings.NewReader(tc.body))
+				require.NoError(t, err)
+				defer resp.Body.Close()
+
+				buf, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+				require.Equal(t, tc.expectedStatus, resp.StatusCode)
+				require.Equal(t, tc.expectedBody, string(buf))
+			})
+		})
+	}
+}
+
+func TestReload(t *testing.T) {
+	ctx := componenttest.TestContext(t)
+
+	env, err := newTestEnvironment(t)
+	require.NoError(t, err)
+	require.NoError(t, env.ApplyConfig(`/* empty */`))
+
+	go func() {
+		require.NoError(t, env.Run(ctx))
+	}()
+
+	tt := []struct {
+		name, query    string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "without waiting",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "config reloaded\n",
+		},
+		{
+			name:           "waiting for the rollout",
+			query:          "?wait=true",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "config rolled back\n",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			util.Eventually(t, func(t require.TestingT) {
+				resp, err := http.Post(fmt.Sprintf("http://%s/-/reload%s", env.ListenAddr(), tc.query), "text/plain", nil)
+				require.NoError(t, err)
+				defer resp.Body.Close()
+
+				buf, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+				require.Equal(t, tc.expectedStatus, resp.StatusCode)
+				require.Equal(t, tc.expectedBody, string(buf))
+			})
+		})
+	}
+}
+
 func TestTLS(t *testing.T) {
 	ctx := componenttest.TestContext(t)
 
@@ -347,8 +455,19 @@ func newTestEnvironment(t *testing.T) (*testEnvironment, error) {
 		Tracer:   noop.NewTracerProvider(),
 		Gatherer: prometheus.NewRegistry(),
 
-		ReadyFunc:  func() bool { return true },
-		ReloadFunc: func() error { return nil },
+		ReadyFunc: func() bool { return true },
+		ReloadFunc: func(_ context.Context, wait bool) error {
+			if wait {
+				return fmt.Errorf("config rolled back")
+			}
+			return nil
+		},
+		DiffFunc: func(sources map[string][]byte) (any, error) {
+			if _, ok := sources["invalid.alloy"]; ok {
+				return nil, fmt.Errorf("invalid config")
+			}
+			return sources, nil
+		},
 
 		HTTPListenAddr:   fmt.Sprintf("127.0.0.1:%d", port),
 		MemoryListenAddr: "alloy.internal:12345",
@@ -1,5 +1,10 @@
 package livedebugging
 
+import (
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+)
+
 type DataType string
 
 const (
@@ -9,6 +14,7 @@ const (
 	OtelMetric       DataType = "otel_metric"
 	OtelLog          DataType = "otel_log"
 	OtelTrace        DataType = "otel_trace"
+	PyroscopeProfile DataType = "pyroscope_profile"
 )
 
 type DataOption func(Data) Data
@@ -20,6 +26,37 @@ func WithTargetComponentIDs(ids []string) DataOption {
 	}
 }
 
+// WithLabels sets the labels of the data, which live debugging consumers can
+// filter on.
+func WithLabels(lbls labels.Labels) DataOption {
+	return func(d Data) Data {
+		d.Labels = lbls
+		return d
+	}
+}
+
+// WithLabelsFunc is like WithLabels, for labels which are expensive to compute.
+// fn is only called if a consumer filters on labels.
+func WithLabelsFunc(fn func() labels.Labels) DataOption {
+	return func(d Data) Data {
+		d.LabelsFunc = fn
+		return d
+	}
+}
+
+// WithLabelSet is like WithLabels for a label set, such as the labels of a log
+// stream. The label set is only converted if a consumer filters on labels.
+func WithLabelSet(ls model.LabelSet) DataOption {
+	return WithLabelsFunc(func() labels.Labels {
+		b := labels.NewScratchBuilder(len(ls))
+		for name, value := range ls {
+			b.Add(string(name), string(value))
+		}
+		b.Sort()
+		return b.Labels()
+	})
+}
+
 type Data struct {
 	// ID of the component that created the data.
 	ComponentID ComponentID
@@ -33,6 +70,10 @@ type Data struct {
 	Count uint64
 	// The data string is passed as a function to only compute the string if needed.
 	DataFunc func() string
+	// The labels of the data (series, stream or target labels), used to filter the data.
+	// LabelsFunc computes the labels when set, for labels which are expensive to compute.
+	Labels     labels.Labels
+	LabelsFunc func() labels.Labels
 }
 
 func NewData(componentID ComponentID, dataType DataType, count uint64, dataFunc func() string, opts ...DataOption) Data {
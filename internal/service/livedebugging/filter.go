@@ -0,0 +1,151 @@
+package livedebugging
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+	"golang.org/x/time/rate"
+)
+
+var dataTypes = []DataType{Target, PrometheusMetric, LokiLog, OtelMetric, OtelLog, OtelTrace, PyroscopeProfile}
+
+// Filter selects the debugging data sent to a live debugging consumer.
+//
+// The data is checked in order of cost: its type, its labels and the sampling
+// probability are checked before the data is rendered, so that the data which
+// is filtered out is never rendered. The substring and the regex are checked on
+// the rendered data. The items per second budget is checked last.
+type Filter struct {
+	types      []DataType
+	matchers   []*labels.Matcher
+	contains   string
+	regex      *regexp.Regexp
+	sampleProb float64
+	limiter    *rate.Limiter
+}
+
+// ParseFilter creates a Filter from the query parameters of a live debugging
+// request:
+//
+//   - type: the data types to keep, as a comma separated list or repeated
+//     parameters.
+//   - selector: a label selector, such as {job="api", level=~"warn|error"},
+//     which the labels of the data must match. Data without labels never
+//     matches a selector.
+//   - contains: a substring of the rendered data.
+//   - regex: a regex which must match part of the rendered data.
+//   - sampleProb: the probability for data to be kept, between 0 and 1.
+//   - maxPerSecond: the maximum number of items kept per second.
+//
+// An empty query keeps all the data.
+func ParseFilter(query url.Values) (*Filter, error) {
+	f := &Filter{sampleProb: 1}
+
+	for _, param := range query["type"] {
+		for _, t := range strings.Split(param, ",") {
+			t := DataType(strings.TrimSpace(t))
+			if t == "" {
+				continue
+			}
+			if !slices.Contains(dataTypes, t) {
+				return nil, fmt.Errorf("invalid type %q", t)
+			}
+			f.types = append(f.types, t)
+		}
+	}
+
+	if selector := query.Get("selector"); selector != "" {
+		matchers, err := parser.ParseMetricSelector(selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector %q: %w", selector, err)
+		}
+		f.matchers = matchers
+	}
+
+	f.contains = query.Get("contains")
+
+	if expr := query.Get("regex"); expr != "" {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex %q: %w", expr, err)
+		}
+		f.regex = re
+	}
+
+	if param := query.Get("sampleProb"); param != "" {
+		prob, err := strconv.ParseFloat(param, 64)
+		if err != nil || prob < 0 || prob > 1 {
+			return nil, fmt.Errorf("invalid sample probability %q: must be a number between 0 and 1", param)
+		}
+		f.sampleProb = prob
+	}
+
+	if param := query.Get("maxPerSecond"); param != "" {
+		limit, err := strconv.ParseFloat(param, 64)
+		if err != nil || limit <= 0 || math.IsInf(limit, 0) {
+			return nil, fmt.Errorf("invalid maximum items per second %q: must be a positive number", param)
+		}
+		f.limiter = rate.NewLimiter(rate.Limit(limit), max(1, int(limit)))
+	}
+
+	return f, nil
+}
+
+// Apply returns the rendered data and true if the data passes the filter.
+// It's safe to call Apply concurrently.
+func (f *Filter) Apply(data Data) (string, bool) {
+	if len(f.types) > 0 && !slices.Contains(f.types, data.Type) {
+		return "", false
+	}
+	if len(f.matchers) > 0 && !f.matchLabels(data) {
+		return "", false
+	}
+	if f.sampleProb < 1 && rand.Float64() >= f.sampleProb {
+		return "", false
+	}
+
+	hasTextFilter := f.contains != "" || f.regex != nil
+	if f.limiter != nil && !hasTextFilter && !f.limiter.Allow() {
+		return "", false
+	}
+	// When the budget is exhausted, the data isn't rendered just to be dropped.
+	if f.limiter != nil && hasTextFilter && f.limiter.Tokens() < 1 {
+		return "", false
+	}
+
+	rendered := data.DataFunc()
+	if f.contains != "" && !strings.Contains(rendered, f.contains) {
+		return "", false
+	}
+	if f.regex != nil && !f.regex.MatchString(rendered) {
+		return "", false
+	}
+	if f.limiter != nil && hasTextFilter && !f.limiter.Allow() {
+		return "", false
+	}
+	return rendered, true
+}
+
+func (f *Filter) matchLabels(data Data) bool {
+	lbls := data.Labels
+	if data.LabelsFunc != nil {
+		lbls = data.LabelsFunc()
+	}
+	if lbls.IsEmpty() {
+		return false
+	}
+	for _, m := range f.matchers {
+		if !m.Matches(lbls.Get(m.Name)) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,121 @@
+package livedebugging
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFilterErrors(t *testing.T) {
+	tt := map[string]string{
+		"type=log":           `invalid type "log"`,
+		"selector={job=":     `invalid selector "{job="`,
+		"regex=(":            `invalid regex "("`,
+		"sampleProb=2":       `invalid sample probability "2": must be a number between 0 and 1`,
+		"maxPerSecond=0":     `invalid maximum items per second "0": must be a positive number`,
+		"maxPerSecond=a_lot": `invalid maximum items per second "a_lot": must be a positive number`,
+		"sampleProb=-0.5":    `invalid sample probability "-0.5": must be a number between 0 and 1`,
+	}
+	for query, expected := range tt {
+		values, err := url.ParseQuery(query)
+		require.NoError(t, err)
+		_, err = ParseFilter(values)
+		require.ErrorContains(t, err, expected, query)
+	}
+}
+
+func TestFilterApply(t *testing.T) {
+	metric := NewData("a", PrometheusMetric, 1, func() string { return "sample: up{job=\"api\"} 1" },
+		WithLabels(labels.FromStrings("__name__", "up", "job", "api")))
+	log := NewData("a", LokiLog, 1, func() string { return "entry: level=error msg=timeout" },
+		WithLabelSet(model.LabelSet{"job": "web", "level": "error"}))
+	target := NewData("a", Target, 1, func() string { return "targets: 1 (+1, -0)" })
+
+	tt := []struct {
+		query    string
+		expected []Data
+	}{
+		{query: "", expected: []Data{metric, log, target}},
+		{query: "type=loki_log,target", expected: []Data{log, target}},
+		{query: "type=loki_log&type=prometheus_metric", expected: []Data{metric, log}},
+		{query: `selector={job="api"}`, expected: []Data{metric}},
+		{query: `selector={job=~"api|web", level!="info"}`, expected: []Data{metric, log}},
+		{query: `selector={job!="api"}`, expected: []Data{log}},
+		{query: "contains=timeout", expected: []Data{log}},
+		{query: "regex=^(sample|targets):", expected: []Data{metric, target}},
+		{query: "sampleProb=0", expected: nil},
+		{query: "sampleProb=1", expected: []Data{metric, log, target}},
+	}
+	for _, tc := range tt {
+		t.Run(tc.query, func(t *testing.T) {
+			values, err := url.ParseQuery(tc.query)
+			require.NoError(t, err)
+			f, err := ParseFilter(values)
+			require.NoError(t, err)
+
+			var actual []string
+			for _, d := range []Data{metric, log, target} {
+				if rendered, ok := f.Apply(d); ok {
+					actual = append(actual, rendered)
+				}
+			}
+			var expected []string
+			for _, d := range tc.expected {
+				expected = append(expected, d.DataFunc())
+			}
+			require.Equal(t, expected, actual)
+		})
+	}
+}
+
+func TestFilterDoesNotRenderFilteredData(t *testing.T) {
+	f, err := ParseFilter(url.Values{"type": {"loki_log"}, "selector": {`{job="api"}`}, "sampleProb": {"0"}})
+	require.NoError(t, err)
+
+	rendered := false
+	dataFunc := func() string {
+		rendered = true
+		return ""
+	}
+	labelsFunc := func() labels.Labels { return labels.FromStrings("job", "api") }
+
+	_, ok := f.Apply(NewData("a", Target, 1, dataFunc, WithLabelsFunc(labelsFunc)))
+	require.False(t, ok)
+	_, ok = f.Apply(NewData("a", LokiLog, 1, dataFunc, WithLabels(labels.FromStrings("job", "web"))))
+	require.False(t, ok)
+	_, ok = f.Apply(NewData("a", LokiLog, 1, dataFunc, WithLabelsFunc(labelsFunc)))
+	require.False(t, ok)
+	require.False(t, rendered)
+}
+
+func TestFilterMaxPerSecond(t *testing.T) {
+	f, err := ParseFilter(url.Values{"maxPerSecond": {"3"}})
+	require.NoError(t, err)
+
+	renderCount := 0
+	data := NewData("a", Target, 1, func() string {
+		renderCount++
+		return "targets: 1 (+1, -0)"
+	})
+	kept := 0
+	for range 10 {
+		if _, ok := f.Apply(data); ok {
+			kept++
+		}
+	}
+	require.Equal(t, 3, kept)
+	require.Equal(t, 3, renderCount)
+
+	// Data which doesn't match the text filters doesn't use the budget.
+	f, err = ParseFilter(url.Values{"maxPerSecond": {"1"}, "contains": {"error"}})
+	require.NoError(t, err)
+	_, ok := f.Apply(NewData("a", LokiLog, 1, func() string { return "info" }))
+	require.False(t, ok)
+	_, ok = f.Apply(NewData("a", LokiLog, 1, func() string { return "error" }))
+	require.True(t, ok)
+	_, ok = f.Apply(NewData("a", LokiLog, 1, func() string { return "error" }))
+	require.False(t, ok)
+}
@@ -1,6 +1,7 @@
 package validator
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/grafana/alloy/internal/component"
@@ -9,20 +10,30 @@ import (
 
 func newComponentRegistry(cr component.Registry) *componentRegistry {
 	return &componentRegistry{
-		parent: cr,
-		custom: make(map[string]component.Registration),
+		parent:  cr,
+		custom:  make(map[string]component.Registration),
+		modules: make(map[string]*componentRegistry),
 	}
 }
 
 // componentRegistry wraps a component.Registry and is used to register
-// custom components (declare blocks).
+// custom components (declare blocks) and resolved modules.
 type componentRegistry struct {
 	parent component.Registry
 	custom map[string]component.Registration
+	// modules holds the registries of the declares of resolved modules by import label.
+	modules map[string]*componentRegistry
 }
 
 func (cr *componentRegistry) Get(name string) (component.Registration, error) {
 	parts := strings.Split(name, ".")
+	if module, ok := cr.modules[parts[0]]; ok {
+		if reg, ok := module.getDeclared(parts[1:]); ok {
+			return reg, nil
+		}
+		return component.Registration{}, fmt.Errorf("module %q does not declare %q", parts[0], strings.Join(parts[1:], "."))
+	}
+
 	// FIXME(kalleep): right now we register modules as custom components and only validate the
 	// namespace part. We can't really know what components are contained within a module without
 	// importing it first. Maybe we could have an option for validation to resolve modules so we could
@@ -34,8 +45,52 @@ func (cr *componentRegistry) Get(name string) (component.Registration, error) {
 	return cr.parent.Get(name)
 }
 
+// isCustom reports whether name refers to a custom component registered in cr
+// or in any of its parents.
+func (cr *componentRegistry) isCustom(name string) bool {
+	namespace := strings.Split(name, ".")[0]
+	if _, ok := cr.custom[namespace]; ok {
+		return true
+	}
+	if _, ok := cr.modules[namespace]; ok {
+		return true
+	}
+	if parent, ok := cr.parent.(*componentRegistry); ok {
+		return parent.isCustom(name)
+	}
+	return false
+}
+
 func (cr *componentRegistry) registerCustomComponent(c *ast.BlockStmt, args any) {
 	// FIXME(kalleep): Figure out how to resolve args and exports for declares and how we could
 	// support doing proper checks of modules.
 	cr.custom[c.Label] = component.Registration{Name: c.Label, Args: args}
 }
+
+// registerModule registers the declares of a resolved module, so that they
+// can be looked up with the label of the import block as a namespace.
+func (cr *componentRegistry) registerModule(c *ast.BlockStmt, module *componentRegistry) {
+	cr.modules[c.Label] = module
+}
+
+// getDeclared looks up a custom component defined in the module of cr. Unlike
+// Get, it never falls back to the parent registry: builtin components can't be
+// referenced through a module namespace.
+func (cr *componentRegistry) getDeclared(parts []string) (component.Registration, bool) {
+	if len(parts) == 0 {
+		return component.Registration{}, false
+	}
+	if module, ok := cr.modules[parts[0]]; ok {
+		return module.getDeclared(parts[1:])
+	}
+	reg, ok := cr.custom[parts[0]]
+	return reg, ok
+}
+
+// base returns the registry of builtin components wrapped by cr.
+func (cr *componentRegistry) base() component.Registry {
+	if parent, ok := cr.parent.(*componentRegistry); ok {
+		return parent.base()
+	}
+	return cr.parent
+}
@@ -44,7 +44,7 @@ func validateGraph(s *state) diag.Diagnostics {
 			// Add any diagnostic for node that should be before type check.
 			diags.Merge(node.diags)
 			if node.args != nil {
-				diags.Merge(typecheck.Block(node.block, node.args))
+				diags.Merge(typecheck.BlockWithScope(node.block, node.args, s))
 			}
 		case *componentNode:
 			name := node.block.GetBlockName()
@@ -65,7 +65,7 @@ func validateGraph(s *state) diag.Diagnostics {
 			if reg.Args == nil {
 				continue
 			}
-			diags.Merge(typecheck.Block(node.block, reg.CloneArguments()))
+			diags.Merge(typecheck.BlockWithScope(node.block, reg.CloneArguments(), s))
 		case *subNode:
 			diags.Merge(validateGraph(node.state))
 		}
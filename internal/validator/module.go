@@ -0,0 +1,155 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/diag"
+	"github.com/grafana/alloy/syntax/parser"
+	"github.com/grafana/alloy/syntax/vm"
+
+	"github.com/grafana/alloy/internal/nodeconf/function"
+	"github.com/grafana/alloy/internal/nodeconf/importsource"
+	"github.com/grafana/alloy/internal/util"
+)
+
+// resolveImport reads and validates the module imported by node, if it's a
+// local module and resolving local imports is enabled. The returned state
+// holds the declares of the module.
+//
+// Imports whose arguments can't be evaluated without running the
+// configuration, like imports referencing component exports, aren't resolved.
+func (v *validator) resolveImport(node *blockNode, s *state) (*state, bool) {
+	if !v.resolveLocalImports {
+		return nil, false
+	}
+
+	var (
+		scope      = vm.NewScope(map[string]any{importsource.ModulePath: s.modulePath})
+		sources    map[string][]byte
+		modulePath string
+	)
+
+	switch node.block.GetBlockName() {
+	case importsource.BlockNameFile:
+		var args importsource.FileArguments
+		if err := vm.New(node.block.Body).Evaluate(scope, &args); err != nil {
+			return nil, false
+		}
+
+		path, err := filepath.Abs(args.Filename)
+		if err != nil {
+			return nil, false
+		}
+		if _, ok := v.importing[path]; ok {
+			node.diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				StartPos: ast.StartPos(node.block).Position(),
+				EndPos:   ast.EndPos(node.block).Position(),
+				Message:  fmt.Sprintf("import cycle: module %q is already being imported", args.Filename),
+			})
+			return nil, false
+		}
+		v.importing[path] = struct{}{}
+		defer delete(v.importing, path)
+
+		sources, err = importsource.ReadModuleFiles(args.Filename)
+		if err != nil {
+			msg := fmt.Sprintf("failed to read module: %s", err)
+			if errors.Is(err, fs.ErrNotExist) {
+				msg = fmt.Sprintf("module %q does not exist", args.Filename)
+			}
+			node.diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				StartPos: ast.StartPos(node.block).Position(),
+				EndPos:   ast.EndPos(node.block).Position(),
+				Message:  msg,
+			})
+			return nil, false
+		}
+		modulePath, _ = util.ExtractDirPath(args.Filename)
+
+	case importsource.BlockNameString:
+		var args importsource.StringArguments
+		if err := vm.New(node.block.Body).Evaluate(scope, &args); err != nil {
+			return nil, false
+		}
+
+		// The module doesn't have a file of its own, so name it after the
+		// import block and the file defining it.
+		name := fmt.Sprintf("%s:%s", ast.StartPos(node.block).Position().Filename, blockID(node.block))
+		sources = map[string][]byte{name: []byte(args.Content.Value)}
+		modulePath = s.modulePath
+
+	default:
+		return nil, false
+	}
+
+	return v.validateModule(node, sources, modulePath, s), true
+}
+
+// validateModule validates the sources of a module imported by node.
+func (v *validator) validateModule(node *blockNode, sources map[string][]byte, modulePath string, s *state) *state {
+	module := &state{
+		root:       false,
+		graph:      newGraph(),
+		cr:         newComponentRegistry(s.cr.base()),
+		modulePath: modulePath,
+	}
+
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		// Keep the sources of the module so diagnostics can be reported.
+		v.sources[name] = sources[name]
+
+		file, err := parser.ParseFile(name, sources[name])
+		if err != nil {
+			// The parser always reports errors as diagnostics.
+			node.diags.Merge(err.(diag.Diagnostics))
+			continue
+		}
+
+		for _, stmt := range file.Body {
+			b, ok := stmt.(*ast.BlockStmt)
+			if !ok {
+				node.diags.Add(moduleStatementDiag(stmt, ""))
+				continue
+			}
+
+			switch name := b.GetBlockName(); name {
+			case "declare":
+				module.declares = append(module.declares, b)
+			case function.BlockName, importsource.BlockNameFile, importsource.BlockNameString, importsource.BlockNameHTTP, importsource.BlockNameGit, importsource.BlockNameOCI:
+				module.configs = append(module.configs, b)
+			default:
+				node.diags.Add(moduleStatementDiag(stmt, name))
+			}
+		}
+	}
+
+	return v.validate(module)
+}
+
+// moduleStatementDiag reports a statement which isn't allowed in a module.
+// blockName is empty if the statement isn't a block.
+func moduleStatementDiag(stmt ast.Stmt, blockName string) diag.Diagnostic {
+	msg := "only declare, function and import blocks are allowed in a module"
+	if blockName != "" {
+		msg += ", got " + blockName
+	}
+	return diag.Diagnostic{
+		Severity: diag.SeverityLevelError,
+		StartPos: ast.StartPos(stmt).Position(),
+		EndPos:   ast.EndPos(stmt).Position(),
+		Message:  msg,
+	}
+}
@@ -0,0 +1,45 @@
+package validator
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/grafana/alloy/syntax/typecheck"
+)
+
+var _ typecheck.Scope = (*state)(nil)
+
+// LookupType implements typecheck.Scope by resolving references to the exports
+// of components. Components defined in the state of an enclosing foreach block
+// are visible too.
+//
+// The exports of custom components, like declares and imported modules, aren't
+// known without evaluating them, so references to them are never resolved.
+func (s *state) LookupType(ref []string) (reflect.Type, int, bool) {
+	// Component names are made of several identifiers, so look for the longest
+	// prefix of ref naming a component.
+	for n := len(ref); n > 0; n-- {
+		block, ok := s.componentsByID[strings.Join(ref[:n], ".")]
+		if !ok {
+			continue
+		}
+
+		name := block.GetBlockName()
+		if s.cr.isCustom(name) {
+			return nil, 0, false
+		}
+		reg, err := s.cr.Get(name)
+		if err != nil {
+			return nil, 0, false
+		}
+		if reg.Exports == nil {
+			return nil, n, true
+		}
+		return reflect.TypeOf(reg.Exports), n, true
+	}
+
+	if s.parent != nil {
+		return s.parent.LookupType(ref)
+	}
+	return nil, 0, false
+}
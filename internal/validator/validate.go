@@ -19,15 +19,19 @@ import (
 	"github.com/grafana/alloy/internal/nodeconf/argument"
 	"github.com/grafana/alloy/internal/nodeconf/export"
 	"github.com/grafana/alloy/internal/nodeconf/foreach"
+	"github.com/grafana/alloy/internal/nodeconf/function"
 	"github.com/grafana/alloy/internal/nodeconf/importsource"
 	alloy_runtime "github.com/grafana/alloy/internal/runtime"
 	"github.com/grafana/alloy/internal/runtime/logging"
 	"github.com/grafana/alloy/internal/runtime/tracing"
 	"github.com/grafana/alloy/internal/service"
+	"github.com/grafana/alloy/internal/util"
 )
 
 type Options struct {
-	// Sources are all source files to validate.
+	// Sources are all source files to validate. When local imports are
+	// resolved, the sources of the imported modules are added to Sources so
+	// that diagnostics in them can be reported.
 	Sources map[string][]byte
 	// ServiceDefinitions is used to validate service config.
 	ServiceDefinitions []service.Definition
@@ -36,6 +40,13 @@ type Options struct {
 	// MinStability is the minimum stability level of features that can be used by the collector. It is defined by
 	// the user, for example, via command-line flags.
 	MinStability featuregate.Stability
+	// ResolveLocalImports enables validating the modules imported with
+	// import.file and import.string blocks, and the custom components declared
+	// in them. Remote modules are never fetched.
+	ResolveLocalImports bool
+	// ConfigPath is the path of the validated configuration. It's used to set
+	// module_path when resolving local imports.
+	ConfigPath string
 }
 
 func Validate(opts Options) error {
@@ -44,9 +55,14 @@ func Validate(opts Options) error {
 }
 
 type validator struct {
-	minStability featuregate.Stability
-	sources      map[string][]byte
-	sm           map[string]service.Definition
+	minStability        featuregate.Stability
+	sources             map[string][]byte
+	sm                  map[string]service.Definition
+	resolveLocalImports bool
+	configPath          string
+	// importing holds the paths of the modules being resolved, to detect
+	// import cycles.
+	importing map[string]struct{}
 }
 
 func newValidator(opts Options) *validator {
@@ -56,9 +72,12 @@ func newValidator(opts Options) *validator {
 	}
 
 	return &validator{
-		minStability: opts.MinStability,
-		sources:      opts.Sources,
-		sm:           sm,
+		minStability:        opts.MinStability,
+		sources:             opts.Sources,
+		sm:                  sm,
+		resolveLocalImports: opts.ResolveLocalImports,
+		configPath:          opts.ConfigPath,
+		importing:           make(map[string]struct{}),
 	}
 }
 
@@ -74,11 +93,14 @@ func (v *validator) run(cr *componentRegistry) error {
 		root:       true,
 		graph:      newGraph(),
 		declares:   s.Declares(),
-		configs:    s.Configs(),
+		configs:    slices.Concat(s.Configs(), s.Functions()),
 		components: components,
 		services:   services,
 		cr:         cr,
 	}
+	if v.configPath != "" {
+		rootState.modulePath, _ = util.ExtractDirPath(v.configPath)
+	}
 
 	diags := validateGraph(v.validate(rootState))
 	if diags.HasErrors() {
@@ -99,6 +121,12 @@ type state struct {
 	cr         *componentRegistry
 	// arguments registered by module
 	arguments []*ast.BlockStmt
+	// componentsByID holds the first definition of each component.
+	componentsByID map[string]*ast.BlockStmt
+	// parent is the state of the enclosing foreach block, if any.
+	parent *state
+	// modulePath is the value of module_path used to resolve local imports.
+	modulePath string
 }
 
 func (v *validator) validate(s *state) *state {
@@ -148,6 +176,7 @@ func (v *validator) validateDeclares(s *state) {
 			services:   services,
 			components: components,
 			cr:         newComponentRegistry(s.cr),
+			modulePath: s.modulePath,
 		}
 
 		// Add module state as node to graph
@@ -182,7 +211,7 @@ func (v *validator) validateConfigs(s *state) {
 		}
 
 		// In configs we store blocks for logging, tracing, argument, export, import.file,
-		// import.string, import.http, import.git and foreach.
+		// import.string, import.http, import.git, foreach and function.
 		switch node.block.GetBlockName() {
 		case "logging":
 			node.args = &logging.Options{}
@@ -198,6 +227,8 @@ func (v *validator) validateConfigs(s *state) {
 			s.graph.Add(node)
 		case foreach.BlockName:
 			v.validateForeach(node, s)
+		case function.BlockName:
+			v.validateFunction(node, s)
 		case argument.BlockName:
 			node.args = &argument.Arguments{}
 			if s.root {
@@ -258,11 +289,29 @@ func (v *validator) validateImport(node *blockNode, register bool, s *state) {
 	case importsource.BlockNameGit:
 		node.args = &importsource.GitArguments{}
 		s.graph.Add(node)
+	case importsource.BlockNameOCI:
+		if err := featuregate.CheckAllowed(importsource.StabilityLevelOCI, v.minStability, fmt.Sprintf("config block %q", importsource.BlockNameOCI)); err != nil {
+			name := node.block.GetBlockName()
+			node.diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				StartPos: node.block.NamePos.Position(),
+				EndPos:   node.block.NamePos.Add(len(name) - 1).Position(),
+				Message:  err.Error(),
+			})
+		}
+		node.args = &importsource.OCIArguments{}
+		s.graph.Add(node)
 	}
 
-	if register {
-		s.cr.registerCustomComponent(node.block, nil)
+	if !register {
+		return
+	}
+	if module, ok := v.resolveImport(node, s); ok {
+		s.graph.Add(newSubNode(node, module))
+		s.cr.registerModule(node.block, module.cr)
+		return
 	}
+	s.cr.registerCustomComponent(node.block, nil)
 }
 
 func (v *validator) validateForeach(node *blockNode, s *state) {
@@ -320,15 +369,42 @@ func (v *validator) validateForeach(node *blockNode, s *state) {
 	s.graph.Add(newSubNode(node, v.validate(&state{
 		root:       s.root,
 		foreach:    true,
+		parent:     s,
 		graph:      newGraph(),
 		declares:   declares,
 		configs:    configs,
 		services:   services,
 		components: components,
 		cr:         newComponentRegistry(s.cr),
+		modulePath: s.modulePath,
 	})))
 }
 
+func (v *validator) validateFunction(node *blockNode, s *state) {
+	name := node.block.GetBlockName()
+
+	// Check required stability level.
+	if err := featuregate.CheckAllowed(function.StabilityLevel, v.minStability, fmt.Sprintf("function block %q", node.block.Label)); err != nil {
+		node.diags.Add(diag.Diagnostic{
+			Severity: diag.SeverityLevelError,
+			StartPos: node.block.NamePos.Position(),
+			EndPos:   node.block.NamePos.Add(len(name) - 1).Position(),
+			Message:  err.Error(),
+		})
+	}
+
+	def, diags := function.Parse(node.block)
+	node.diags.Merge(diags)
+
+	// Type check the argument blocks, the result is only known when the
+	// function is called.
+	for _, arg := range def.Arguments {
+		node.diags.Merge(typecheck.Block(arg, &argument.Arguments{}))
+	}
+
+	s.graph.Add(node)
+}
+
 // validateComponents will perform validation on component blocks.
 func (v *validator) validateComponents(s *state) {
 	mem := make(map[string]*ast.BlockStmt, len(s.components))
@@ -357,6 +433,8 @@ func (v *validator) validateComponents(s *state) {
 
 		s.graph.Add(node)
 	}
+
+	s.componentsByID = mem
 }
 
 func (v *validator) validateServices(s *state) {
@@ -394,8 +472,8 @@ func (v *validator) validateServices(s *state) {
 }
 
 var configBlockNames = [...]string{
-	foreach.BlockName, argument.BlockName, export.BlockName, "logging", "tracing",
-	importsource.BlockNameFile, importsource.BlockNameString, importsource.BlockNameHTTP, importsource.BlockNameGit,
+	foreach.BlockName, function.BlockName, argument.BlockName, export.BlockName, "logging", "tracing",
+	importsource.BlockNameFile, importsource.BlockNameString, importsource.BlockNameHTTP, importsource.BlockNameGit, importsource.BlockNameOCI,
 }
 
 // extractBlocks extracts configs, declares and components blocks from body
@@ -38,11 +38,13 @@ const (
 
 func TestValidate(t *testing.T) {
 	// Test with default config.
-	testDirectory(t, "./testdata/ga", featuregate.StabilityGenerallyAvailable, false)
-	testDirectory(t, "./testdata/default", featuregate.StabilityExperimental, false)
+	testDirectory(t, "./testdata/ga", featuregate.StabilityGenerallyAvailable, false, false)
+	testDirectory(t, "./testdata/default", featuregate.StabilityExperimental, false, false)
+	// Test with local imports resolved. Modules are imported relative to the directory of the test.
+	testDirectory(t, "./testdata/modules", featuregate.StabilityExperimental, false, true)
 }
 
-func testDirectory(t *testing.T, dir string, minStability featuregate.Stability, enableCommunityComps bool) {
+func testDirectory(t *testing.T, dir string, minStability featuregate.Stability, enableCommunityComps bool, resolveLocalImports bool) {
 	require.NoError(t, filepath.WalkDir(dir, func(path string, d fs.DirEntry, _ error) error {
 		if d.IsDir() && path != dir {
 			return filepath.SkipDir
@@ -70,7 +72,9 @@ func testDirectory(t *testing.T, dir string, minStability featuregate.Stability,
 						&remotecfg.Service{},
 						&ui.Service{},
 					),
-					MinStability: minStability,
+					MinStability:        minStability,
+					ResolveLocalImports: resolveLocalImports,
+					ConfigPath:          path,
 				})
 
 				diagsFile := strings.TrimSuffix(path, txtarSuffix) + diagsSuffix
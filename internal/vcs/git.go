@@ -34,6 +34,9 @@ type GitRepo struct {
 // 1. If storagePath is empty on disk, NewGitRepo initializes GitRepo by cloning the repository.
 // 2. After GitRepo is initialized/opened, a git fetch is don.
 // 3. Then, a git checkout is done to the Revision specified in GitRepoOptions.
+//
+// If the repository was opened but couldn't be updated, NewGitRepo returns
+// both the GitRepo and an UpdateFailedError.
 func NewGitRepo(ctx context.Context, storagePath string, opts GitRepoOptions) (*GitRepo, error) {
 	var (
 		repo *git.Repository
@@ -84,6 +87,10 @@ func NewGitRepo(ctx context.Context, storagePath string, opts GitRepoOptions) (*
 
 	err = gitRepo.Update(ctx)
 	if err != nil {
+		// The content on disk can still be read if only the update failed.
+		if errors.As(err, &UpdateFailedError{}) {
+			return gitRepo, err
+		}
 		return nil, err
 	}
 
@@ -5,8 +5,8 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
-	"math/rand"
 	"net/http"
 	"path"
 	"strconv"
@@ -53,7 +53,7 @@ func (a *AlloyAPI) RegisterRoutes(urlPrefix string, r *mux.Router) {
 	r.Handle(path.Join(urlPrefix, "/remotecfg/components/{id:.+}"), httputil.CompressionHandler{Handler: getComponentHandlerRemoteCfg(a.alloy)})
 
 	r.Handle(path.Join(urlPrefix, "/peers"), httputil.CompressionHandler{Handler: getClusteringPeersHandler(a.alloy)})
-	r.Handle(path.Join(urlPrefix, "/debug/{id:.+}"), liveDebugging(a.alloy, a.CallbackManager, a.logger))
+	r.Handle(path.Join(urlPrefix, "/debug/{id:.+}"), liveDebugging(a.alloy, a.CallbackManager, a.logger, urlPrefix))
 
 	r.Handle(path.Join(urlPrefix, "/graph"), graph(a.alloy, a.CallbackManager, a.logger))
 	r.Handle(path.Join(urlPrefix, "/graph/{moduleID:.+}"), graph(a.alloy, a.CallbackManager, a.logger))
@@ -265,7 +265,7 @@ func graph(h service.Host, callbackManager livedebugging.CallbackManager, logger
 	}
 }
 
-func liveDebugging(h service.Host, callbackManager livedebugging.CallbackManager, logger log.Logger) http.HandlerFunc {
+func liveDebugging(h service.Host, callbackManager livedebugging.CallbackManager, logger log.Logger, urlPrefix string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		vars := mux.Vars(r)
 		componentID := livedebugging.ComponentID(vars["id"])
@@ -276,10 +276,27 @@ func liveDebugging(h service.Host, callbackManager livedebugging.CallbackManager
 			return
 		}
 
-		dataCh := make(chan string, 1000)
-		ctx := r.Context()
+		filter, err := livedebugging.ParseFilter(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 
-		sampleProb := setSampleProb(w, r.URL.Query().Get("sampleProb"))
+		// When the stream is cluster-wide, the data of every peer is tagged
+		// with the name of the peer.
+		var peerStreams *peerStreams
+		localPrefix := ""
+		if r.URL.Query().Get("cluster") == "true" {
+			peerStreams, err = newPeerStreams(h, path.Join(urlPrefix, "debug", string(componentID)), r.URL.Query(), logger)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			localPrefix = peerPrefix(peerStreams.self)
+		}
+
+		dataCh := make(chan string, 1000)
+		ctx, cancel := context.WithCancel(r.Context())
 
 		id := livedebugging.CallbackID(uuid.New().String())
 
@@ -289,12 +306,13 @@ func liveDebugging(h service.Host, callbackManager livedebugging.CallbackManager
 			case <-ctx.Done():
 				return
 			default:
-				if sampleProb < 1 && rand.Float64() > sampleProb {
+				rendered, ok := filter.Apply(data)
+				if !ok {
 					return
 				}
 				// Avoid blocking the channel when the channel is full
 				select {
-				case dataCh <- data.DataFunc():
+				case dataCh <- localPrefix + rendered:
 				default:
 					if !droppedData {
 						level.Warn(logger).Log("msg", "data throughput is very high, not all debugging data can be sent the live debugging stream")
@@ -305,15 +323,24 @@ func liveDebugging(h service.Host, callbackManager livedebugging.CallbackManager
 		})
 
 		if err != nil {
+			cancel()
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
+		if peerStreams != nil {
+			peerStreams.Start(ctx, dataCh)
+		}
+
 		flushTicker := time.NewTicker(time.Second)
 
 		defer func() {
-			close(dataCh)
+			cancel()
 			callbackManager.DeleteCallback(id, componentID)
+			if peerStreams != nil {
+				peerStreams.Wait()
+			}
+			close(dataCh)
 			flushTicker.Stop()
 		}()
 
@@ -323,7 +350,7 @@ func liveDebugging(h service.Host, callbackManager livedebugging.CallbackManager
 				var builder strings.Builder
 				builder.WriteString(data)
 				// |;| delimiter is added at the end of every chunk
-				builder.WriteString("|;|")
+				builder.WriteString(LiveDebuggingDelimiter)
 				_, writeErr := w.Write([]byte(builder.String()))
 				if writeErr != nil {
 					return
@@ -348,19 +375,6 @@ func resolveServiceHost(host service.Host, id string) (service.Host, error) {
 	return host, nil
 }
 
-func setSampleProb(w http.ResponseWriter, sampleProbParam string) (sampleProb float64) {
-	sampleProb = 1.0
-	if sampleProbParam != "" {
-		var err error
-		sampleProb, err = strconv.ParseFloat(sampleProbParam, 64)
-		if err != nil || sampleProb < 0 || sampleProb > 1 {
-			http.Error(w, "Invalid sample probability", http.StatusBadRequest)
-			return 1.0
-		}
-	}
-	return sampleProb
-}
-
 // window is expected to be in seconds, between 1 and 60.
 func setWindow(w http.ResponseWriter, windowParam string) time.Duration {
 	const defaultWindow = 5 * time.Second
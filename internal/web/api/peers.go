@@ -0,0 +1,147 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/ckit/peer"
+
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/service"
+	"github.com/grafana/alloy/internal/service/cluster"
+)
+
+// LiveDebuggingDelimiter separates the items of a live debugging stream.
+const LiveDebuggingDelimiter = "|;|"
+
+// peerStreams merges the live debugging streams of the other peers of the
+// cluster into a cluster-wide stream.
+type peerStreams struct {
+	self   peer.Peer
+	peers  []peer.Peer
+	client *http.Client
+	scheme string
+	path   string
+	query  url.Values
+	logger log.Logger
+
+	wg sync.WaitGroup
+}
+
+// newPeerStreams prepares streaming the live debugging data at path from
+// the other peers of the cluster, with the query parameters of the local
+// request.
+func newPeerStreams(host service.Host, path string, query url.Values, logger log.Logger) (*peerStreams, error) {
+	svc, found := host.GetService(cluster.ServiceName)
+	if !found {
+		return nil, fmt.Errorf("cluster service not running")
+	}
+	clusterService, ok := svc.(*cluster.Service)
+	if !ok {
+		return nil, fmt.Errorf("cluster service doesn't support cluster-wide live debugging")
+	}
+	client, scheme := clusterService.PeerHTTPClient()
+
+	// The peers stream their own data only.
+	peerQuery := url.Values{}
+	for k, v := range query {
+		if k != "cluster" {
+			peerQuery[k] = v
+		}
+	}
+
+	ps := &peerStreams{
+		self:   peer.Peer{Name: "local", Self: true},
+		client: client,
+		scheme: scheme,
+		path:   path,
+		query:  peerQuery,
+		logger: logger,
+	}
+	for _, p := range svc.Data().(cluster.Cluster).Peers() {
+		if p.Self {
+			ps.self = p
+			continue
+		}
+		ps.peers = append(ps.peers, p)
+	}
+	return ps, nil
+}
+
+// Start streams the data of every peer to dataCh until ctx is canceled.
+func (ps *peerStreams) Start(ctx context.Context, dataCh chan<- string) {
+	for _, p := range ps.peers {
+		ps.wg.Add(1)
+		go func() {
+			defer ps.wg.Done()
+			if err := ps.stream(ctx, p, dataCh); err != nil && ctx.Err() == nil {
+				level.Warn(ps.logger).Log("msg", "failed to stream live debugging data from peer", "peer", p.Name, "err", err)
+				select {
+				case dataCh <- peerPrefix(p) + "live debugging unavailable: " + err.Error():
+				case <-ctx.Done():
+				}
+			}
+		}()
+	}
+}
+
+// Wait waits for the streams started by Start to stop.
+func (ps *peerStreams) Wait() {
+	ps.wg.Wait()
+}
+
+func (ps *peerStreams) stream(ctx context.Context, p peer.Peer, dataCh chan<- string) error {
+	u := url.URL{Scheme: ps.scheme, Host: p.Addr, Path: ps.path, RawQuery: ps.query.Encode()}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := ps.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	prefix := peerPrefix(p)
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(nil, 16*1024*1024)
+	scanner.Split(SplitLiveDebuggingData)
+	for scanner.Scan() {
+		select {
+		case dataCh <- prefix + scanner.Text():
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// peerPrefix returns the tag of the data of p in a cluster-wide stream.
+func peerPrefix(p peer.Peer) string {
+	return "[" + p.Name + "] "
+}
+
+// SplitLiveDebuggingData is a bufio.SplitFunc which splits a live debugging
+// stream into items.
+func SplitLiveDebuggingData(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if i := bytes.Index(data, []byte(LiveDebuggingDelimiter)); i >= 0 {
+		return i + len(LiveDebuggingDelimiter), data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/ckit/peer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitLiveDebuggingData(t *testing.T) {
+	scanner := bufio.NewScanner(strings.NewReader("a|;|multi\nline|;||;|last"))
+	scanner.Split(SplitLiveDebuggingData)
+
+	var items []string
+	for scanner.Scan() {
+		items = append(items, scanner.Text())
+	}
+	require.NoError(t, scanner.Err())
+	require.Equal(t, []string{"a", "multi\nline", "", "last"}, items)
+}
+
+func TestPeerStreams(t *testing.T) {
+	var query url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v0/web/debug/loki.process.default" {
+			http.Error(w, "component not found", http.StatusInternalServerError)
+			return
+		}
+		query = r.URL.Query()
+		_, _ = w.Write([]byte("first|;|second|;|"))
+	}))
+	defer srv.Close()
+	addr := strings.TrimPrefix(srv.URL, "http://")
+
+	ps := &peerStreams{
+		peers: []peer.Peer{
+			{Name: "peer-1", Addr: addr},
+		},
+		client: srv.Client(),
+		scheme: "http",
+		path:   "/api/v0/web/debug/loki.process.default",
+		query:  url.Values{"sampleProb": {"0.5"}},
+		logger: log.NewNopLogger(),
+	}
+
+	dataCh := make(chan string, 10)
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+	ps.Start(ctx, dataCh)
+	ps.Wait()
+	close(dataCh)
+
+	var items []string
+	for item := range dataCh {
+		items = append(items, item)
+	}
+	require.Equal(t, []string{"[peer-1] first", "[peer-1] second"}, items)
+	require.Equal(t, url.Values{"sampleProb": {"0.5"}}, query)
+
+	// Errors of peers are sent to the stream.
+	ps.path = "/api/v0/web/debug/loki.process.missing"
+	dataCh = make(chan string, 10)
+	ps.Start(ctx, dataCh)
+	ps.Wait()
+	close(dataCh)
+
+	items = nil
+	for item := range dataCh {
+		items = append(items, item)
+	}
+	require.Equal(t, []string{"[peer-1] live debugging unavailable: 500 Internal Server Error: component not found"}, items)
+}
@@ -4,6 +4,7 @@ export const useLiveDebugging = (
   componentID: string,
   enabled: boolean,
   sampleProb: number,
+  cluster: boolean,
   setData: React.Dispatch<React.SetStateAction<string[]>>
 ) => {
   const [loading, setLoading] = useState(false);
@@ -22,7 +23,7 @@ export const useLiveDebugging = (
       setLoading(true);
 
       try {
-        const response = await fetch(`./api/v0/web/debug/${componentID}?sampleProb=${sampleProb}`, {
+        const response = await fetch(`./api/v0/web/debug/${componentID}?sampleProb=${sampleProb}&cluster=${cluster}`, {
           signal: abortController.signal,
           cache: 'no-cache',
           credentials: 'same-origin',
@@ -77,7 +78,7 @@ export const useLiveDebugging = (
     return () => {
       abortController.abort();
     };
-  }, [componentID, enabled, sampleProb, setData]);
+  }, [componentID, enabled, sampleProb, cluster, setData]);
 
   return { loading, error };
 };
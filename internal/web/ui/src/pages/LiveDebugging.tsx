@@ -4,7 +4,7 @@ import AutoScroll from '@brianmcallister/react-auto-scroll';
 import { faBroom, faBug, faCopy, faRoad, faStop } from '@fortawesome/free-solid-svg-icons';
 import { FontAwesomeIcon } from '@fortawesome/react-fontawesome';
 
-import { Field, Input, Slider } from '@grafana/ui';
+import { Checkbox, Field, Input, Slider } from '@grafana/ui';
 
 import Page from '../features/layout/Page';
 import { useLiveDebugging } from '../hooks/liveDebugging';
@@ -18,7 +18,8 @@ function PageLiveDebugging() {
   const [sampleProb, setSampleProb] = useState(1);
   const [sliderProb, setSliderProb] = useState(100);
   const [filterValue, setFilterValue] = useState('');
-  const { loading, error } = useLiveDebugging(String(componentID), enabled, sampleProb, setData);
+  const [cluster, setCluster] = useState(false);
+  const { loading, error } = useLiveDebugging(String(componentID), enabled, sampleProb, cluster, setData);
 
   const filteredData = data.filter((n) => n.toLowerCase().includes(filterValue.toLowerCase()));
 
@@ -88,10 +89,21 @@ function PageLiveDebugging() {
     </Field>
   );
 
+  const clusterControl = (
+    <div className={styles.clusterToggle}>
+      <Checkbox
+        label="All peers"
+        value={cluster}
+        onChange={(event) => setCluster(event.currentTarget.checked)}
+      />
+    </div>
+  );
+
   const controls = (
     <>
       {filterControl}
       {samplingControl}
+      {clusterControl}
       {toggleEnableButton()}
       <div className={styles.debugLink}>
         <button className={styles.clearButton} onClick={() => setData([])}>
@@ -168,6 +168,15 @@ type BinaryExpr struct {
 	Secret bool
 }
 
+// ConditionalExpr evaluates to one of two expressions depending on the value
+// of a boolean condition. Only the chosen expression is evaluated.
+type ConditionalExpr struct {
+	Cond, Then, Else      Expr
+	QuestionPos, ColonPos token.Pos
+
+	Secret bool
+}
+
 // ParenExpr represents an expression wrapped in parentheses.
 type ParenExpr struct {
 	Inner                Expr
@@ -193,6 +202,7 @@ var (
 	_ Node = (*CallExpr)(nil)
 	_ Node = (*UnaryExpr)(nil)
 	_ Node = (*BinaryExpr)(nil)
+	_ Node = (*ConditionalExpr)(nil)
 	_ Node = (*ParenExpr)(nil)
 
 	_ Stmt = (*AttributeStmt)(nil)
@@ -207,62 +217,67 @@ var (
 	_ Expr = (*CallExpr)(nil)
 	_ Expr = (*UnaryExpr)(nil)
 	_ Expr = (*BinaryExpr)(nil)
+	_ Expr = (*ConditionalExpr)(nil)
 	_ Expr = (*ParenExpr)(nil)
 )
 
-func (n *File) astNode()           {}
-func (n Body) astNode()            {}
-func (n CommentGroup) astNode()    {}
-func (n *Comment) astNode()        {}
-func (n *AttributeStmt) astNode()  {}
-func (n *BlockStmt) astNode()      {}
-func (n *Ident) astNode()          {}
-func (n *IdentifierExpr) astNode() {}
-func (n *LiteralExpr) astNode()    {}
-func (n *ArrayExpr) astNode()      {}
-func (n *ObjectExpr) astNode()     {}
-func (n *AccessExpr) astNode()     {}
-func (n *IndexExpr) astNode()      {}
-func (n *CallExpr) astNode()       {}
-func (n *UnaryExpr) astNode()      {}
-func (n *BinaryExpr) astNode()     {}
-func (n *ParenExpr) astNode()      {}
+func (n *File) astNode()            {}
+func (n Body) astNode()             {}
+func (n CommentGroup) astNode()     {}
+func (n *Comment) astNode()         {}
+func (n *AttributeStmt) astNode()   {}
+func (n *BlockStmt) astNode()       {}
+func (n *Ident) astNode()           {}
+func (n *IdentifierExpr) astNode()  {}
+func (n *LiteralExpr) astNode()     {}
+func (n *ArrayExpr) astNode()       {}
+func (n *ObjectExpr) astNode()      {}
+func (n *AccessExpr) astNode()      {}
+func (n *IndexExpr) astNode()       {}
+func (n *CallExpr) astNode()        {}
+func (n *UnaryExpr) astNode()       {}
+func (n *BinaryExpr) astNode()      {}
+func (n *ConditionalExpr) astNode() {}
+func (n *ParenExpr) astNode()       {}
 
 func (n *AttributeStmt) astStmt() {}
 func (n *BlockStmt) astStmt()     {}
 
-func (n *IdentifierExpr) astExpr() {}
-func (n *LiteralExpr) astExpr()    {}
-func (n *ArrayExpr) astExpr()      {}
-func (n *ObjectExpr) astExpr()     {}
-func (n *AccessExpr) astExpr()     {}
-func (n *IndexExpr) astExpr()      {}
-func (n *CallExpr) astExpr()       {}
-func (n *UnaryExpr) astExpr()      {}
-func (n *BinaryExpr) astExpr()     {}
-func (n *ParenExpr) astExpr()      {}
-
-func (n *IdentifierExpr) IsSecret() bool { return n.Secret }
-func (n *LiteralExpr) IsSecret() bool    { return n.Secret }
-func (n *ArrayExpr) IsSecret() bool      { return n.Secret }
-func (n *ObjectExpr) IsSecret() bool     { return n.Secret }
-func (n *AccessExpr) IsSecret() bool     { return n.Secret }
-func (n *IndexExpr) IsSecret() bool      { return n.Secret }
-func (n *CallExpr) IsSecret() bool       { return n.Secret }
-func (n *UnaryExpr) IsSecret() bool      { return n.Secret }
-func (n *BinaryExpr) IsSecret() bool     { return n.Secret }
-func (n *ParenExpr) IsSecret() bool      { return n.Secret }
-
-func (n *IdentifierExpr) SetSecret(s bool) { n.Secret = s }
-func (n *LiteralExpr) SetSecret(s bool)    { n.Secret = s }
-func (n *ArrayExpr) SetSecret(s bool)      { n.Secret = s }
-func (n *ObjectExpr) SetSecret(s bool)     { n.Secret = s }
-func (n *AccessExpr) SetSecret(s bool)     { n.Secret = s }
-func (n *IndexExpr) SetSecret(s bool)      { n.Secret = s }
-func (n *CallExpr) SetSecret(s bool)       { n.Secret = s }
-func (n *UnaryExpr) SetSecret(s bool)      { n.Secret = s }
-func (n *BinaryExpr) SetSecret(s bool)     { n.Secret = s }
-func (n *ParenExpr) SetSecret(s bool)      { n.Secret = s }
+func (n *IdentifierExpr) astExpr()  {}
+func (n *LiteralExpr) astExpr()     {}
+func (n *ArrayExpr) astExpr()       {}
+func (n *ObjectExpr) astExpr()      {}
+func (n *AccessExpr) astExpr()      {}
+func (n *IndexExpr) astExpr()       {}
+func (n *CallExpr) astExpr()        {}
+func (n *UnaryExpr) astExpr()       {}
+func (n *BinaryExpr) astExpr()      {}
+func (n *ConditionalExpr) astExpr() {}
+func (n *ParenExpr) astExpr()       {}
+
+func (n *IdentifierExpr) IsSecret() bool  { return n.Secret }
+func (n *LiteralExpr) IsSecret() bool     { return n.Secret }
+func (n *ArrayExpr) IsSecret() bool       { return n.Secret }
+func (n *ObjectExpr) IsSecret() bool      { return n.Secret }
+func (n *AccessExpr) IsSecret() bool      { return n.Secret }
+func (n *IndexExpr) IsSecret() bool       { return n.Secret }
+func (n *CallExpr) IsSecret() bool        { return n.Secret }
+func (n *UnaryExpr) IsSecret() bool       { return n.Secret }
+func (n *BinaryExpr) IsSecret() bool      { return n.Secret }
+func (n *ConditionalExpr) IsSecret() bool { return n.Secret }
+func (n *ParenExpr) IsSecret() bool       { return n.Secret }
+
+func (n *IdentifierExpr) SetSecret(s bool)  { n.Secret = s }
+func (n *LiteralExpr) SetSecret(s bool)     { n.Secret = s }
+func (n *ArrayExpr) SetSecret(s bool)       { n.Secret = s }
+func (n *ObjectExpr) SetSecret(s bool)      { n.Secret = s }
+func (n *AccessExpr) SetSecret(s bool)      { n.Secret = s }
+func (n *IndexExpr) SetSecret(s bool)       { n.Secret = s }
+func (n *CallExpr) SetSecret(s bool)        { n.Secret = s }
+func (n *UnaryExpr) SetSecret(s bool)       { n.Secret = s }
+func (n *BinaryExpr) SetSecret(s bool)      { n.Secret = s }
+func (n *ConditionalExpr) SetSecret(s bool) { n.Secret = s }
+func (n *ParenExpr) SetSecret(s bool)       { n.Secret = s }
 
 // StartPos returns the position of the first character belonging to a Node.
 func StartPos(n Node) token.Pos {
@@ -308,6 +323,8 @@ func StartPos(n Node) token.Pos {
 		return n.KindPos
 	case *BinaryExpr:
 		return StartPos(n.Left)
+	case *ConditionalExpr:
+		return StartPos(n.Cond)
 	case *ParenExpr:
 		return n.LParenPos
 	default:
@@ -359,6 +376,8 @@ func EndPos(n Node) token.Pos {
 		return EndPos(n.Value)
 	case *BinaryExpr:
 		return EndPos(n.Right)
+	case *ConditionalExpr:
+		return EndPos(n.Else)
 	case *ParenExpr:
 		return n.RParenPos
 	default:
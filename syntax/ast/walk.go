@@ -63,6 +63,10 @@ func Walk(v Visitor, node Node) {
 	case *BinaryExpr:
 		Walk(v, n.Left)
 		Walk(v, n.Right)
+	case *ConditionalExpr:
+		Walk(v, n.Cond)
+		Walk(v, n.Then)
+		Walk(v, n.Else)
 	case *ParenExpr:
 		Walk(v, n.Inner)
 	default:
@@ -0,0 +1,327 @@
+package stdlib
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/grafana/alloy/syntax/internal/value"
+)
+
+// maxRangeLength is the maximum number of elements array.range can generate.
+// It protects against allocating huge arrays because of a typo.
+const maxRangeLength = 1 << 16
+
+// arrayLength returns the number of elements in an array.
+var arrayLength = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if len(args) != 1 {
+		return value.Null, fmt.Errorf("length: expected 1 argument, got %d", len(args))
+	}
+	if err := checkArgType(funcValue, args, 0, value.TypeArray); err != nil {
+		return value.Null, err
+	}
+	return value.Int(int64(args[0].Len())), nil
+})
+
+// arrayContains returns whether an array contains a value.
+var arrayContains = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if len(args) != 2 {
+		return value.Null, fmt.Errorf("contains: expected 2 arguments, got %d", len(args))
+	}
+	if err := checkArgType(funcValue, args, 0, value.TypeArray); err != nil {
+		return value.Null, err
+	}
+
+	for i := 0; i < args[0].Len(); i++ {
+		if value.DeepEqual(args[0].Index(i), args[1]) {
+			return value.Bool(true), nil
+		}
+	}
+	return value.Bool(false), nil
+})
+
+// arrayDistinct removes duplicate elements from an array, keeping the first
+// occurrence of each element.
+var arrayDistinct = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if len(args) != 1 {
+		return value.Null, fmt.Errorf("distinct: expected 1 argument, got %d", len(args))
+	}
+	if err := checkArgType(funcValue, args, 0, value.TypeArray); err != nil {
+		return value.Null, err
+	}
+
+	// Values aren't hashable, so elements are compared with every distinct
+	// element found so far.
+	res := make([]value.Value, 0, args[0].Len())
+	for i := 0; i < args[0].Len(); i++ {
+		elem := args[0].Index(i)
+		if !slices.ContainsFunc(res, func(v value.Value) bool { return value.DeepEqual(v, elem) }) {
+			res = append(res, elem)
+		}
+	}
+	return value.Array(res...), nil
+})
+
+// arrayFlatten replaces nested arrays with their elements, recursively.
+var arrayFlatten = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if len(args) != 1 {
+		return value.Null, fmt.Errorf("flatten: expected 1 argument, got %d", len(args))
+	}
+	if err := checkArgType(funcValue, args, 0, value.TypeArray); err != nil {
+		return value.Null, err
+	}
+
+	var flatten func(arr value.Value, res []value.Value) []value.Value
+	flatten = func(arr value.Value, res []value.Value) []value.Value {
+		for i := 0; i < arr.Len(); i++ {
+			elem := arr.Index(i)
+			if elem.Type() == value.TypeArray {
+				res = flatten(elem, res)
+			} else {
+				res = append(res, elem)
+			}
+		}
+		return res
+	}
+	return value.Array(flatten(args[0], make([]value.Value, 0, args[0].Len()))...), nil
+})
+
+// arraySlice returns the elements of an array from a start index (inclusive)
+// to an end index (exclusive).
+var arraySlice = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if len(args) != 3 {
+		return value.Null, fmt.Errorf("slice: expected 3 arguments, got %d", len(args))
+	}
+	if err := checkArgType(funcValue, args, 0, value.TypeArray); err != nil {
+		return value.Null, err
+	}
+	if err := checkArgType(funcValue, args, 1, value.TypeNumber); err != nil {
+		return value.Null, err
+	}
+	if err := checkArgType(funcValue, args, 2, value.TypeNumber); err != nil {
+		return value.Null, err
+	}
+
+	var (
+		length     = int64(args[0].Len())
+		start, end = args[1].Int(), args[2].Int()
+	)
+	if start < 0 || start > length {
+		return value.Null, value.ArgError{
+			Function: funcValue,
+			Argument: args[1],
+			Index:    1,
+			Inner:    fmt.Errorf("start index %d out of range for array of length %d", start, length),
+		}
+	}
+	if end < start || end > length {
+		return value.Null, value.ArgError{
+			Function: funcValue,
+			Argument: args[2],
+			Index:    2,
+			Inner:    fmt.Errorf("end index %d out of range for array of length %d starting at %d", end, length, start),
+		}
+	}
+
+	res := make([]value.Value, 0, end-start)
+	for i := start; i < end; i++ {
+		res = append(res, args[0].Index(int(i)))
+	}
+	return value.Array(res...), nil
+})
+
+// arrayRange generates an array of numbers from start (inclusive) to end
+// (exclusive). The step defaults to 1, or -1 if end is lower than start.
+var arrayRange = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if len(args) != 2 && len(args) != 3 {
+		return value.Null, fmt.Errorf("range: expected 2 or 3 arguments, got %d", len(args))
+	}
+	for i := range args {
+		if err := checkArgType(funcValue, args, i, value.TypeNumber); err != nil {
+			return value.Null, err
+		}
+	}
+
+	start, end := args[0].Int(), args[1].Int()
+	step := int64(1)
+	if end < start {
+		step = -1
+	}
+	if len(args) == 3 {
+		step = args[2].Int()
+	}
+	if step == 0 || (step > 0 && end < start) || (step < 0 && end > start) {
+		return value.Null, value.ArgError{
+			Function: funcValue,
+			Argument: args[len(args)-1],
+			Index:    len(args) - 1,
+			Inner:    fmt.Errorf("step %d never reaches %d from %d", step, end, start),
+		}
+	}
+
+	var res []value.Value
+	for i := start; (step > 0 && i < end) || (step < 0 && i > end); i += step {
+		if len(res) == maxRangeLength {
+			return value.Null, fmt.Errorf("range: cannot generate more than %d elements", maxRangeLength)
+		}
+		res = append(res, value.Int(i))
+	}
+	return value.Array(res...), nil
+})
+
+// arraySort sorts an array of strings or numbers. When a field name is
+// provided, it sorts an array of objects by the value of that field instead.
+// The sort is stable.
+var arraySort = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return value.Null, fmt.Errorf("sort: expected 1 or 2 arguments, got %d", len(args))
+	}
+	if err := checkArgType(funcValue, args, 0, value.TypeArray); err != nil {
+		return value.Null, err
+	}
+
+	var (
+		elems = make([]value.Value, args[0].Len())
+		keys  = make([]value.Value, args[0].Len())
+	)
+	for i := range elems {
+		elems[i] = args[0].Index(i)
+		keys[i] = elems[i]
+	}
+
+	if len(args) == 2 {
+		if err := checkArgType(funcValue, args, 1, value.TypeString); err != nil {
+			return value.Null, err
+		}
+		field := args[1].Text()
+		for i, elem := range elems {
+			obj, ok := objectValue(elem)
+			if !ok {
+				return value.Null, elementTypeError(funcValue, args, 0, i, elem, value.TypeObject)
+			}
+			key, ok := obj.Key(field)
+			if !ok {
+				return value.Null, elementError(funcValue, args, 0, i, fmt.Errorf("is missing field %q", field))
+			}
+			keys[i] = key
+		}
+	}
+
+	// All keys must have the same type for the order to be meaningful.
+	for i, key := range keys {
+		if key.Type() != value.TypeString && key.Type() != value.TypeNumber {
+			return value.Null, elementTypeError(funcValue, args, 0, i, key, value.TypeString)
+		}
+		if key.Type() != keys[0].Type() {
+			return value.Null, elementTypeError(funcValue, args, 0, i, key, keys[0].Type())
+		}
+	}
+
+	idx := make([]int, len(elems))
+	for i := range idx {
+		idx[i] = i
+	}
+	slices.SortStableFunc(idx, func(a, b int) int { return compareValues(keys[a], keys[b]) })
+
+	res := make([]value.Value, len(idx))
+	for i, j := range idx {
+		res[i] = elems[j]
+	}
+	return value.Array(res...), nil
+})
+
+// arrayFilter returns the objects of an array which have a field set to a
+// value. Objects without the field are dropped.
+var arrayFilter = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if len(args) != 3 {
+		return value.Null, fmt.Errorf("filter: expected 3 arguments, got %d", len(args))
+	}
+	if err := checkArgType(funcValue, args, 0, value.TypeArray); err != nil {
+		return value.Null, err
+	}
+	if err := checkArgType(funcValue, args, 1, value.TypeString); err != nil {
+		return value.Null, err
+	}
+
+	var (
+		field = args[1].Text()
+		res   = []value.Value{}
+	)
+	for i := 0; i < args[0].Len(); i++ {
+		elem := args[0].Index(i)
+		obj, ok := objectValue(elem)
+		if !ok {
+			return value.Null, elementTypeError(funcValue, args, 0, i, elem, value.TypeObject)
+		}
+		if v, ok := obj.Key(field); ok && value.DeepEqual(v, args[2]) {
+			res = append(res, elem)
+		}
+	}
+	return value.Array(res...), nil
+})
+
+// compareValues compares two strings or two numbers.
+func compareValues(a, b value.Value) int {
+	if a.Type() == value.TypeString {
+		return strings.Compare(a.Text(), b.Text())
+	}
+
+	aNum, bNum := a.Number(), b.Number()
+	switch value.FitNumberKinds(aNum.Kind(), bNum.Kind()) {
+	case value.NumberKindUint:
+		return cmp.Compare(aNum.Uint(), bNum.Uint())
+	case value.NumberKindInt:
+		return cmp.Compare(aNum.Int(), bNum.Int())
+	default:
+		return cmp.Compare(aNum.Float(), bNum.Float())
+	}
+}
+
+// objectValue returns v as an object. Capsules which can be converted into
+// objects, like targets, are converted.
+func objectValue(v value.Value) (value.Value, bool) {
+	if v.Type() == value.TypeObject {
+		return v, true
+	}
+	if obj, ok := v.TryConvertToObject(); ok {
+		return value.Object(obj), true
+	}
+	return value.Null, false
+}
+
+// checkArgType returns an error if the argument at index doesn't have the
+// expected type.
+func checkArgType(funcValue value.Value, args []value.Value, index int, expected value.Type) error {
+	if args[index].Type() == expected {
+		return nil
+	}
+	return value.ArgError{
+		Function: funcValue,
+		Argument: args[index],
+		Index:    index,
+		Inner: value.TypeError{
+			Value:    args[index],
+			Expected: expected,
+		},
+	}
+}
+
+// elementError returns an error for the element at elemIndex of the array
+// argument at argIndex. The element index is part of the message, since the
+// error is reported on the argument.
+func elementError(funcValue value.Value, args []value.Value, argIndex int, elemIndex int, inner error) error {
+	return value.ArgError{
+		Function: funcValue,
+		Argument: args[argIndex],
+		Index:    argIndex,
+		Inner:    fmt.Errorf("element %d %w", elemIndex, inner),
+	}
+}
+
+// elementTypeError returns an error for the element at elemIndex of the array
+// argument at argIndex, or for a value derived from it, which doesn't have
+// the expected type.
+func elementTypeError(funcValue value.Value, args []value.Value, argIndex int, elemIndex int, elem value.Value, expected value.Type) error {
+	return elementError(funcValue, args, argIndex, elemIndex, fmt.Errorf("should be %s, got %s", expected, elem.Type()))
+}
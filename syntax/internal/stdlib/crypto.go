@@ -0,0 +1,159 @@
+package stdlib
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/fnv"
+
+	"github.com/grafana/alloy/syntax/alloytypes"
+	"github.com/grafana/alloy/syntax/internal/value"
+)
+
+// The crypto functions accept strings and secrets. When any of the arguments
+// is a secret, the result is a secret too: a digest of a secret can only be
+// used as a plain string by explicitly converting it with
+// convert.nonsensitive. fnv32 is the exception, see its documentation.
+var crypto = map[string]interface{}{
+	"sha256":      digestFunction("sha256", sha256.New),
+	"sha1":        digestFunction("sha1", sha1.New),
+	"md5":         digestFunction("md5", md5.New),
+	"fnv32":       fnv32,
+	"hmac_sha256": hmacSHA256,
+	"uuid":        uuidV5,
+}
+
+// uuidNamespaceDNS is the RFC 4122 namespace used to generate name-based
+// UUIDs.
+var uuidNamespaceDNS = [16]byte{
+	0x6b, 0xa7, 0xb8, 0x10, 0x9d, 0xad, 0x11, 0xd1,
+	0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8,
+}
+
+// digestFunction returns a function which computes the hex-encoded digest of
+// its argument using the hash returned by newHash.
+func digestFunction(name string, newHash func() hash.Hash) value.RawFunction {
+	return func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+		if len(args) != 1 {
+			return value.Null, fmt.Errorf("%s: expected 1 argument, got %d", name, len(args))
+		}
+		in, secret, err := cryptoInput(funcValue, args[0], 0)
+		if err != nil {
+			return value.Null, err
+		}
+
+		h := newHash()
+		h.Write([]byte(in))
+		return cryptoResult(hex.EncodeToString(h.Sum(nil)), secret), nil
+	}
+}
+
+// fnv32 computes the 32-bit FNV-1a hash of its argument. The hash is returned
+// as a number so that it can be used to compute shards.
+//
+// Secrets are rejected rather than hashed, because a number can't be kept
+// secret and a 32-bit FNV hash of a short secret is easy to reverse. The only
+// opt-in is to convert the secret itself with convert.nonsensitive before
+// hashing it, which makes it explicit that the secret is no longer protected.
+var fnv32 = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if len(args) != 1 {
+		return value.Null, fmt.Errorf("fnv32: expected 1 argument, got %d", len(args))
+	}
+	in, secret, err := cryptoInput(funcValue, args[0], 0)
+	if err != nil {
+		return value.Null, err
+	}
+	if secret {
+		return value.Null, value.ArgError{
+			Function: funcValue,
+			Argument: args[0],
+			Index:    0,
+			Inner:    errors.New("fnv32 doesn't accept secrets, use convert.nonsensitive to hash a secret"),
+		}
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(in))
+	return value.Uint(uint64(h.Sum32())), nil
+})
+
+// hmacSHA256 computes the hex-encoded HMAC-SHA256 of a message with a key.
+var hmacSHA256 = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if len(args) != 2 {
+		return value.Null, fmt.Errorf("hmac_sha256: expected 2 arguments, got %d", len(args))
+	}
+	message, messageSecret, err := cryptoInput(funcValue, args[0], 0)
+	if err != nil {
+		return value.Null, err
+	}
+	key, keySecret, err := cryptoInput(funcValue, args[1], 1)
+	if err != nil {
+		return value.Null, err
+	}
+
+	h := hmac.New(sha256.New, []byte(key))
+	h.Write([]byte(message))
+	return cryptoResult(hex.EncodeToString(h.Sum(nil)), messageSecret || keySecret), nil
+})
+
+// uuidV5 generates a name-based UUID (version 5) from its argument. The same
+// name always generates the same UUID.
+var uuidV5 = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if len(args) != 1 {
+		return value.Null, fmt.Errorf("uuid: expected 1 argument, got %d", len(args))
+	}
+	name, secret, err := cryptoInput(funcValue, args[0], 0)
+	if err != nil {
+		return value.Null, err
+	}
+
+	h := sha1.New()
+	h.Write(uuidNamespaceDNS[:])
+	h.Write([]byte(name))
+	var uuid [16]byte
+	copy(uuid[:], h.Sum(nil))
+	uuid[6] = (uuid[6] & 0x0f) | 0x50 // Version 5.
+	uuid[8] = (uuid[8] & 0x3f) | 0x80 // RFC 4122 variant.
+
+	text := fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:16])
+	return cryptoResult(text, secret), nil
+})
+
+// cryptoInput returns the text of a string or secret argument, and whether
+// the argument is a secret.
+func cryptoInput(funcValue value.Value, arg value.Value, index int) (string, bool, error) {
+	switch arg.Type() {
+	case value.TypeString:
+		return arg.Text(), false, nil
+	case value.TypeCapsule:
+		switch v := arg.Interface().(type) {
+		case alloytypes.Secret:
+			return string(v), true, nil
+		case alloytypes.OptionalSecret:
+			return v.Value, v.IsSecret, nil
+		}
+	}
+
+	return "", false, value.ArgError{
+		Function: funcValue,
+		Argument: arg,
+		Index:    index,
+		Inner: value.TypeError{
+			Value:    arg,
+			Expected: value.TypeString,
+		},
+	}
+}
+
+// cryptoResult wraps text into a secret if secret is true.
+func cryptoResult(text string, secret bool) value.Value {
+	if secret {
+		return value.Encapsulate(alloytypes.Secret(text))
+	}
+	return value.String(text)
+}
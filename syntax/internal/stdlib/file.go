@@ -0,0 +1,63 @@
+package stdlib
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// The file functions which access the filesystem are evaluated every time the
+// expression calling them is evaluated. The Alloy controller replaces them
+// with variants which also re-evaluate the calling block when the files they
+// read change.
+var file = map[string]interface{}{
+	"path_join": filepath.Join,
+	"read":      fileRead,
+	"exists":    fileExists,
+	"glob":      fileGlob,
+	"read_dir":  fileReadDir,
+}
+
+func fileRead(path string) (string, error) {
+	bb, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(bb), nil
+}
+
+func fileExists(path string) (bool, error) {
+	_, err := os.Stat(path)
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, fs.ErrNotExist):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+func fileGlob(pattern string) ([]string, error) {
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if matches == nil {
+		return []string{}, nil
+	}
+	return matches, nil
+}
+
+func fileReadDir(path string) ([]string, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names, nil
+}
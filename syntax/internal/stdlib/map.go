@@ -0,0 +1,144 @@
+package stdlib
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/grafana/alloy/syntax/internal/value"
+)
+
+var mapNamespace = map[string]interface{}{
+	"keys":   mapKeys,
+	"values": mapValues,
+	"merge":  mapMerge,
+	"pick":   mapPick,
+	"omit":   mapOmit,
+}
+
+// mapKeys returns the keys of an object in lexical order.
+var mapKeys = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if len(args) != 1 {
+		return value.Null, fmt.Errorf("keys: expected 1 argument, got %d", len(args))
+	}
+	obj, err := objectArg(funcValue, args, 0)
+	if err != nil {
+		return value.Null, err
+	}
+
+	keys := sortedKeys(obj)
+	res := make([]value.Value, len(keys))
+	for i, key := range keys {
+		res[i] = value.String(key)
+	}
+	return value.Array(res...), nil
+})
+
+// mapValues returns the values of an object, ordered by their keys.
+var mapValues = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if len(args) != 1 {
+		return value.Null, fmt.Errorf("values: expected 1 argument, got %d", len(args))
+	}
+	obj, err := objectArg(funcValue, args, 0)
+	if err != nil {
+		return value.Null, err
+	}
+
+	keys := sortedKeys(obj)
+	res := make([]value.Value, len(keys))
+	for i, key := range keys {
+		res[i], _ = obj.Key(key)
+	}
+	return value.Array(res...), nil
+})
+
+// mapMerge merges any number of objects. If a key exists in multiple objects,
+// the value from the last object is used.
+var mapMerge = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	res := make(map[string]value.Value)
+	for i := range args {
+		obj, err := objectArg(funcValue, args, i)
+		if err != nil {
+			return value.Null, err
+		}
+		for _, key := range obj.Keys() {
+			res[key], _ = obj.Key(key)
+		}
+	}
+	return value.Object(res), nil
+})
+
+// mapPick returns an object with only the given keys of an object. Keys which
+// don't exist in the object are ignored.
+var mapPick = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if len(args) != 2 {
+		return value.Null, fmt.Errorf("pick: expected 2 arguments, got %d", len(args))
+	}
+	obj, keys, err := objectAndKeysArgs(funcValue, args)
+	if err != nil {
+		return value.Null, err
+	}
+
+	res := make(map[string]value.Value, len(keys))
+	for _, key := range keys {
+		if v, ok := obj.Key(key); ok {
+			res[key] = v
+		}
+	}
+	return value.Object(res), nil
+})
+
+// mapOmit returns an object without the given keys of an object.
+var mapOmit = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if len(args) != 2 {
+		return value.Null, fmt.Errorf("omit: expected 2 arguments, got %d", len(args))
+	}
+	obj, keys, err := objectAndKeysArgs(funcValue, args)
+	if err != nil {
+		return value.Null, err
+	}
+
+	res := make(map[string]value.Value)
+	for _, key := range obj.Keys() {
+		if !slices.Contains(keys, key) {
+			res[key], _ = obj.Key(key)
+		}
+	}
+	return value.Object(res), nil
+})
+
+// objectArg returns the argument at index as an object.
+func objectArg(funcValue value.Value, args []value.Value, index int) (value.Value, error) {
+	obj, ok := objectValue(args[index])
+	if !ok {
+		return value.Null, checkArgType(funcValue, args, index, value.TypeObject)
+	}
+	return obj, nil
+}
+
+// objectAndKeysArgs returns the arguments of functions which take an object
+// and an array of keys.
+func objectAndKeysArgs(funcValue value.Value, args []value.Value) (value.Value, []string, error) {
+	obj, err := objectArg(funcValue, args, 0)
+	if err != nil {
+		return value.Null, nil, err
+	}
+	if err := checkArgType(funcValue, args, 1, value.TypeArray); err != nil {
+		return value.Null, nil, err
+	}
+
+	keys := make([]string, args[1].Len())
+	for i := range keys {
+		key := args[1].Index(i)
+		if key.Type() != value.TypeString {
+			return value.Null, nil, elementTypeError(funcValue, args, 1, i, key, value.TypeString)
+		}
+		keys[i] = key.Text()
+	}
+	return obj, keys, nil
+}
+
+func sortedKeys(obj value.Value) []string {
+	keys := obj.Keys()
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,89 @@
+package stdlib
+
+import (
+	"regexp"
+	"sync"
+)
+
+var regex = map[string]interface{}{
+	"match":    regexMatch,
+	"find_all": regexFindAll,
+	"replace":  regexReplace,
+	"split":    regexSplit,
+}
+
+// regexCacheSize is the maximum number of compiled expressions kept by
+// regexCache. Expressions are usually literals, so the cache only overflows
+// when patterns are built dynamically.
+const regexCacheSize = 1000
+
+// regexCache caches compiled regular expressions by pattern so that
+// expressions which are evaluated repeatedly don't recompile their patterns.
+// regexp.Regexp is safe for concurrent use, so compiled expressions are shared
+// between callers.
+type regexCache struct {
+	mut   sync.RWMutex
+	cache map[string]*regexp.Regexp
+}
+
+var compiledRegexes = &regexCache{cache: make(map[string]*regexp.Regexp)}
+
+// compile returns the compiled expression for pattern, compiling it if it
+// isn't cached yet. The cache is reset when it's full.
+func (c *regexCache) compile(pattern string) (*regexp.Regexp, error) {
+	c.mut.RLock()
+	re, ok := c.cache[pattern]
+	c.mut.RUnlock()
+	if ok {
+		return re, nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	if len(c.cache) >= regexCacheSize {
+		clear(c.cache)
+	}
+	c.cache[pattern] = re
+	return re, nil
+}
+
+func regexMatch(in string, pattern string) (bool, error) {
+	re, err := compiledRegexes.compile(pattern)
+	if err != nil {
+		return false, err
+	}
+	return re.MatchString(in), nil
+}
+
+func regexFindAll(in string, pattern string) ([]string, error) {
+	re, err := compiledRegexes.compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	matches := re.FindAllString(in, -1)
+	if matches == nil {
+		return []string{}, nil
+	}
+	return matches, nil
+}
+
+func regexReplace(in string, pattern string, replacement string) (string, error) {
+	re, err := compiledRegexes.compile(pattern)
+	if err != nil {
+		return "", err
+	}
+	return re.ReplaceAllString(in, replacement), nil
+}
+
+func regexSplit(in string, pattern string) ([]string, error) {
+	re, err := compiledRegexes.compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return re.Split(in, -1), nil
+}
@@ -0,0 +1,35 @@
+package stdlib
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegexCache(t *testing.T) {
+	c := &regexCache{cache: make(map[string]*regexp.Regexp)}
+
+	re1, err := c.compile("^foo$")
+	require.NoError(t, err)
+	re2, err := c.compile("^foo$")
+	require.NoError(t, err)
+	require.Same(t, re1, re2, "compiled expression should be reused")
+
+	_, err = c.compile("(")
+	require.Error(t, err)
+	require.NotContains(t, c.cache, "(", "invalid expressions should not be cached")
+
+	for i := range regexCacheSize {
+		_, err := c.compile(fmt.Sprintf("^%d$", i))
+		require.NoError(t, err)
+	}
+	require.LessOrEqual(t, len(c.cache), regexCacheSize)
+}
+
+func BenchmarkRegexMatch(b *testing.B) {
+	for b.Loop() {
+		_, _ = regexMatch("app-1234", "^app-[0-9]+$")
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"maps"
 	"os"
-	"path/filepath"
 	"strings"
 
 	"github.com/ohler55/ojg/jp"
@@ -62,7 +61,10 @@ var Identifiers = map[string]interface{}{
 	"array":    array,
 	"encoding": encoding,
 	"string":   str,
+	"regex":    regex,
+	"crypto":   crypto,
 	"file":     file,
+	"map":      mapNamespace,
 }
 
 func init() {
@@ -70,10 +72,6 @@ func init() {
 	maps.Copy(Identifiers, DeprecatedIdentifiers)
 }
 
-var file = map[string]interface{}{
-	"path_join": filepath.Join,
-}
-
 var encoding = map[string]interface{}{
 	"from_json":      jsonDecode,
 	"from_yaml":      yamlDecode,
@@ -206,6 +204,14 @@ var array = map[string]interface{}{
 	"concat":       concat,
 	"combine_maps": combineMaps,
 	"group_by":     groupBy,
+	"length":       arrayLength,
+	"contains":     arrayContains,
+	"distinct":     arrayDistinct,
+	"flatten":      arrayFlatten,
+	"slice":        arraySlice,
+	"range":        arrayRange,
+	"sort":         arraySort,
+	"filter":       arrayFilter,
 }
 
 var convert = map[string]interface{}{
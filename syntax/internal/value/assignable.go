@@ -0,0 +1,89 @@
+package value
+
+import "reflect"
+
+var (
+	goConvertibleFromCapsule = reflect.TypeOf((*ConvertibleFromCapsule)(nil)).Elem()
+	goConvertibleIntoCapsule = reflect.TypeOf((*ConvertibleIntoCapsule)(nil)).Elem()
+)
+
+// AssignableType reports whether a Go value of type from may be decoded into
+// a Go value of type into. It's a static approximation of Decode: when the
+// result depends on the value being decoded, like when converting a string
+// into a number or when a capsule implements custom conversion rules,
+// AssignableType returns true.
+//
+// A nil type is treated as unknown and is assignable to and from any type.
+func AssignableType(from, into reflect.Type) bool {
+	if from == nil || into == nil || from == goAny || into == goAny || from == into {
+		return true
+	}
+
+	for into.Kind() == reflect.Pointer {
+		into = into.Elem()
+	}
+	intoPtr := reflect.PointerTo(into)
+
+	// Types with custom decoding rules.
+	switch {
+	case intoPtr == goDurationPtr, intoPtr.Implements(goTextUnmarshaler):
+		return AssignableType(from, goString)
+	case intoPtr.Implements(goAlloyDecoder):
+		return true
+	}
+
+	fromType, intoType := AlloyType(from), AlloyType(into)
+	for from.Kind() == reflect.Pointer {
+		from = from.Elem()
+	}
+
+	// Capsules with custom conversion rules.
+	switch {
+	case fromType == TypeCapsule && (from.Implements(goConvertibleIntoCapsule) || reflect.PointerTo(from).Implements(goConvertibleIntoCapsule)):
+		return true
+	case intoType == TypeCapsule && intoPtr.Implements(goConvertibleFromCapsule):
+		return true
+	}
+
+	if fromType != intoType {
+		switch {
+		case fromType == TypeNumber && intoType == TypeString:
+			return true
+		case fromType == TypeString && intoType == TypeNumber:
+			return true
+		case from == goByteSlice && into == goString, from == goString && into == goByteSlice:
+			return true
+		}
+		return false
+	}
+
+	switch fromType {
+	case TypeArray:
+		if isListKind(from) && isListKind(into) {
+			return AssignableType(from.Elem(), into.Elem())
+		}
+	case TypeObject:
+		if from.Kind() == reflect.Map && into.Kind() == reflect.Map {
+			return AssignableType(from.Elem(), into.Elem())
+		}
+	case TypeFunction:
+		return from == into
+	case TypeCapsule:
+		if from == into {
+			return true
+		}
+		if into.Kind() == reflect.Interface {
+			return from.Implements(into) || reflect.PointerTo(from).Implements(into)
+		}
+		// A value of an interface type may hold a value of type into.
+		if from.Kind() == reflect.Interface {
+			return into.Implements(from) || intoPtr.Implements(from)
+		}
+		return false
+	}
+	return true
+}
+
+func isListKind(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice || t.Kind() == reflect.Array
+}
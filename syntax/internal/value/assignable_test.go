@@ -0,0 +1,64 @@
+package value_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/grafana/alloy/syntax/internal/value"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssignableType(t *testing.T) {
+	type Object struct {
+		Name string `alloy:"name,attr"`
+	}
+
+	tt := []struct {
+		from, into any
+		expect     bool
+	}{
+		{int(0), int(0), true},
+		{int(0), float64(0), true},
+		{int(0), "", true},
+		{"", int(0), true},
+		{"", []byte(nil), true},
+		{"", time.Duration(0), true},
+		{int(0), false, false},
+		{false, "", false},
+		{"", []string(nil), false},
+
+		{[]int(nil), []string(nil), true},
+		{[]bool(nil), []string(nil), false},
+		{map[string]int(nil), map[string]string(nil), true},
+		{map[string]bool(nil), map[string]string(nil), false},
+		{map[string]any(nil), Object{}, true},
+		{[]any(nil), Object{}, false},
+
+		{customCapsule(false), customCapsule(false), true},
+		{customCapsule(false), "", false},
+		{"", customCapsule(false), false},
+		{customCapsule(false), (*fmt.Stringer)(nil), false},
+
+		// Capsules with custom conversion rules may be converted from and into
+		// other values.
+		{boolish(0), false, true},
+		{false, boolish(0), true},
+	}
+
+	for _, tc := range tt {
+		var (
+			from = reflect.TypeOf(tc.from)
+			into = reflect.TypeOf(tc.into)
+		)
+		t.Run(fmt.Sprintf("%s to %s", from, into), func(t *testing.T) {
+			require.Equal(t, tc.expect, value.AssignableType(from, into))
+		})
+	}
+
+	t.Run("unknown types", func(t *testing.T) {
+		require.True(t, value.AssignableType(nil, reflect.TypeOf("")))
+		require.True(t, value.AssignableType(reflect.TypeOf(""), nil))
+	})
+}
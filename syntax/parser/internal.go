@@ -379,9 +379,36 @@ func (n blockName) ValidAttribute() bool {
 
 // ParseExpression parses a single expression.
 //
-//	Expression = BinOpExpr
+//	Expression = CondExpr
 func (p *parser) ParseExpression() ast.Expr {
-	return p.parseBinOp(1)
+	return p.parseCondExpr()
+}
+
+// parseCondExpr parses a conditional expression. If there is no conditional
+// expression in the current state, the binary expression (or single operand)
+// will be returned instead.
+//
+//	CondExpr = BinOpExpr [ "?" Expression ":" Expression ]
+//
+// Conditional expressions have the lowest precedence and are
+// right-associative, so a ? b : c ? d : e is parsed as a ? b : (c ? d : e).
+func (p *parser) parseCondExpr() ast.Expr {
+	cond := p.parseBinOp(1)
+	if p.tok != token.QUESTION {
+		return cond
+	}
+
+	questionPos, _, _ := p.expect(token.QUESTION)
+	then := p.ParseExpression()
+	colonPos, _, _ := p.expect(token.COLON)
+
+	return &ast.ConditionalExpr{
+		Cond:        cond,
+		QuestionPos: questionPos,
+		Then:        then,
+		ColonPos:    colonPos,
+		Else:        p.ParseExpression(),
+	}
 }
 
 // parseBinOp is the entrypoint for binary expressions. If there is no binary
@@ -175,6 +175,13 @@ func (w *walker) walkExpr(e ast.Expr) {
 		w.p.Write(wsBlank, e.KindPos, e.Kind, wsBlank)
 		w.walkExpr(e.Right)
 
+	case *ast.ConditionalExpr:
+		w.walkExpr(e.Cond)
+		w.p.Write(wsBlank, e.QuestionPos, token.QUESTION, wsBlank)
+		w.walkExpr(e.Then)
+		w.p.Write(wsBlank, e.ColonPos, token.COLON, wsBlank)
+		w.walkExpr(e.Else)
+
 	case *ast.ParenExpr:
 		w.p.Write(token.LPAREN)
 		w.walkExpr(e.Inner)
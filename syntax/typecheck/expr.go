@@ -0,0 +1,291 @@
+package typecheck
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/diag"
+	"github.com/grafana/alloy/syntax/internal/tagcache"
+	"github.com/grafana/alloy/syntax/internal/value"
+	"github.com/grafana/alloy/syntax/token"
+)
+
+// Scope provides the types of the values that expressions can reference, like
+// the exports of components.
+type Scope interface {
+	// LookupType returns the type of the value named by the first n names of
+	// ref. A nil type means that the value doesn't have any fields. ok is false
+	// if the type of the value referenced by ref isn't known.
+	LookupType(ref []string) (t reflect.Type, n int, ok bool)
+}
+
+var (
+	goBool    = reflect.TypeOf(false)
+	goInt     = reflect.TypeOf(int(0))
+	goFloat64 = reflect.TypeOf(float64(0))
+	goString  = reflect.TypeOf("")
+	goAny     = reflect.TypeOf((*any)(nil)).Elem()
+)
+
+// checkExpr reports references to fields which don't exist and values which
+// can never be decoded into a Go value of type into.
+func checkExpr(scope Scope, expr ast.Expr, into reflect.Type) diag.Diagnostics {
+	for into.Kind() == reflect.Pointer {
+		into = into.Elem()
+	}
+
+	// Check the elements of arrays and objects individually so that
+	// diagnostics point to the offending element.
+	switch expr := expr.(type) {
+	case *ast.ParenExpr:
+		return checkExpr(scope, expr.Inner, into)
+
+	case *ast.ArrayExpr:
+		if into.Kind() == reflect.Slice || into.Kind() == reflect.Array {
+			var diags diag.Diagnostics
+			for _, elem := range expr.Elements {
+				diags.Merge(checkExpr(scope, elem, into.Elem()))
+			}
+			return diags
+		}
+
+	case *ast.ObjectExpr:
+		if into.Kind() == reflect.Map && into.Key() == goString {
+			var diags diag.Diagnostics
+			for _, field := range expr.Fields {
+				diags.Merge(checkExpr(scope, field.Value, into.Elem()))
+			}
+			return diags
+		}
+
+	case *ast.ConditionalExpr:
+		_, diags := inferType(scope, expr.Cond)
+		diags.Merge(checkExpr(scope, expr.Then, into))
+		diags.Merge(checkExpr(scope, expr.Else, into))
+		return diags
+	}
+
+	t, diags := inferType(scope, expr)
+	if !value.AssignableType(t, into) {
+		diags.Add(diag.Diagnostic{
+			Severity: diag.SeverityLevelError,
+			StartPos: ast.StartPos(expr).Position(),
+			EndPos:   ast.EndPos(expr).Position(),
+			Message:  fmt.Sprintf("expected %s, got %s", describeType(into), describeType(t)),
+		})
+	}
+	return diags
+}
+
+// inferType returns the Go type of the value expr evaluates to, or nil if it
+// can't be known without evaluating expr.
+func inferType(scope Scope, expr ast.Expr) (reflect.Type, diag.Diagnostics) {
+	switch expr := expr.(type) {
+	case *ast.LiteralExpr:
+		switch expr.Kind {
+		case token.NUMBER:
+			return goInt, nil
+		case token.FLOAT:
+			return goFloat64, nil
+		case token.STRING:
+			return goString, nil
+		case token.BOOL:
+			return goBool, nil
+		}
+		return nil, nil
+
+	case *ast.IdentifierExpr, *ast.AccessExpr:
+		return inferReference(scope, expr)
+
+	case *ast.IndexExpr:
+		t, diags := inferType(scope, expr.Value)
+		_, indexDiags := inferType(scope, expr.Index)
+		diags.Merge(indexDiags)
+		for t != nil && t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map) {
+			return t.Elem(), diags
+		}
+		return nil, diags
+
+	case *ast.ArrayExpr:
+		var (
+			diags diag.Diagnostics
+			elem  reflect.Type
+		)
+		for i, e := range expr.Elements {
+			t, elemDiags := inferType(scope, e)
+			diags.Merge(elemDiags)
+			if i == 0 || t == elem {
+				elem = t
+			} else {
+				elem = goAny
+			}
+		}
+		if elem == nil {
+			elem = goAny
+		}
+		return reflect.SliceOf(elem), diags
+
+	case *ast.ObjectExpr:
+		var diags diag.Diagnostics
+		for _, field := range expr.Fields {
+			_, fieldDiags := inferType(scope, field.Value)
+			diags.Merge(fieldDiags)
+		}
+		return reflect.TypeOf(map[string]any(nil)), diags
+
+	case *ast.CallExpr:
+		var diags diag.Diagnostics
+		for _, arg := range expr.Args {
+			_, argDiags := inferType(scope, arg)
+			diags.Merge(argDiags)
+		}
+		return nil, diags
+
+	case *ast.UnaryExpr:
+		_, diags := inferType(scope, expr.Value)
+		if expr.Kind == token.NOT {
+			return goBool, diags
+		}
+		return nil, diags
+
+	case *ast.BinaryExpr:
+		_, diags := inferType(scope, expr.Left)
+		_, rightDiags := inferType(scope, expr.Right)
+		diags.Merge(rightDiags)
+		switch expr.Kind {
+		case token.OR, token.AND, token.EQ, token.NEQ, token.LT, token.LTE, token.GT, token.GTE:
+			return goBool, diags
+		}
+		return nil, diags
+
+	case *ast.ConditionalExpr:
+		_, diags := inferType(scope, expr.Cond)
+		thenType, thenDiags := inferType(scope, expr.Then)
+		elseType, elseDiags := inferType(scope, expr.Else)
+		diags.Merge(thenDiags)
+		diags.Merge(elseDiags)
+		if thenType == elseType {
+			return thenType, diags
+		}
+		return nil, diags
+
+	case *ast.ParenExpr:
+		return inferType(scope, expr.Inner)
+	}
+
+	return nil, nil
+}
+
+// inferReference returns the type of the value referenced by an identifier
+// or by a chain of field accesses.
+func inferReference(scope Scope, expr ast.Expr) (reflect.Type, diag.Diagnostics) {
+	names := referenceNames(expr)
+	if names == nil {
+		// Accesses on values which aren't references, like
+		// array[0].field.
+		access := expr.(*ast.AccessExpr)
+		t, diags := inferType(scope, access.Value)
+		if t == nil {
+			return nil, diags
+		}
+		t, fieldDiags := fieldType(t, access.Name, nil)
+		diags.Merge(fieldDiags)
+		return t, diags
+	}
+
+	ref := make([]string, len(names))
+	for i, name := range names {
+		ref[i] = name.Name
+	}
+	t, n, ok := scope.LookupType(ref)
+	if !ok {
+		return nil, nil
+	}
+	if t == nil && n < len(names) {
+		return nil, diag.Diagnostics{fieldError(names[n], ref[:n])}
+	}
+
+	for i, name := range names[n:] {
+		var diags diag.Diagnostics
+		t, diags = fieldType(t, name, ref[:n+i])
+		if t == nil {
+			return nil, diags
+		}
+	}
+	return t, nil
+}
+
+// referenceNames returns the names of an identifier followed by a chain of
+// field accesses, or nil if expr isn't one.
+func referenceNames(expr ast.Expr) []*ast.Ident {
+	switch expr := expr.(type) {
+	case *ast.IdentifierExpr:
+		return []*ast.Ident{expr.Ident}
+	case *ast.AccessExpr:
+		if names := referenceNames(expr.Value); names != nil {
+			return append(names, expr.Name)
+		}
+	}
+	return nil
+}
+
+// fieldType returns the type of the field name of a value of type t. The
+// returned type is nil if it isn't known. Diagnostics are returned for fields
+// which can't exist. path names the value of type t, if known.
+func fieldType(t reflect.Type, name *ast.Ident, path []string) (reflect.Type, diag.Diagnostics) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t.Kind() == reflect.Map && t.Key() == goString:
+		return t.Elem(), nil
+	case t.Kind() == reflect.Struct && value.AlloyType(t) == value.TypeObject:
+		tf, ok := tagcache.Get(t).TagLookup[name.Name]
+		if !ok {
+			return nil, diag.Diagnostics{fieldError(name, path)}
+		}
+		return t.FieldByIndex(tf.Index).Type, nil
+	}
+	return nil, nil
+}
+
+func fieldError(name *ast.Ident, path []string) diag.Diagnostic {
+	msg := fmt.Sprintf("field %q does not exist", name.Name)
+	if len(path) > 0 {
+		msg = fmt.Sprintf("field %q does not exist in %s", name.Name, strings.Join(path, "."))
+	}
+	return diag.Diagnostic{
+		Severity: diag.SeverityLevelError,
+		StartPos: ast.StartPos(name).Position(),
+		EndPos:   ast.EndPos(name).Position(),
+		Message:  msg,
+	}
+}
+
+// describeType returns the name of the Alloy type of values of Go type t.
+func describeType(t reflect.Type) string {
+	if t == nil {
+		return "unknown"
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch ty := value.AlloyType(t); ty {
+	case value.TypeCapsule:
+		return fmt.Sprintf("capsule(%q)", t)
+	case value.TypeArray:
+		if t.Elem() != goAny {
+			return fmt.Sprintf("list(%s)", describeType(t.Elem()))
+		}
+		return ty.String()
+	default:
+		return ty.String()
+	}
+}
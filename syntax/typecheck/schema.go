@@ -0,0 +1,102 @@
+package typecheck
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/grafana/alloy/syntax"
+	"github.com/grafana/alloy/syntax/internal/tagcache"
+)
+
+// Field describes an attribute or a block which may be set in the body of a
+// block.
+type Field struct {
+	Name     string       // Name of the attribute or block, like "targets" or "stage.json".
+	Block    bool         // Block is true if the field is set with a block rather than an attribute.
+	Optional bool         // Optional is true if the field doesn't have to be set.
+	Type     reflect.Type // Go type of the value of an attribute, or of a single block.
+
+	// Default is the default value of an optional attribute, or nil if it
+	// doesn't have a default.
+	Default any
+}
+
+// TypeName returns the name of the Alloy type of the field, like
+// "list(string)", or "block" for blocks.
+func (f Field) TypeName() string {
+	if f.Block {
+		return "block"
+	}
+	return describeType(f.Type)
+}
+
+// Fields returns the attributes and blocks which may be set in the body of a
+// block decoded into a Go value of type t, sorted by name. Blocks which are
+// part of an enum are listed individually. Fields returns nil if t isn't a
+// struct.
+func Fields(t reflect.Type) []Field {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	defaults := reflect.New(t)
+	if d, ok := defaults.Interface().(syntax.Defaulter); ok {
+		d.SetToDefault()
+	}
+
+	var (
+		info   = tagcache.Get(t)
+		fields = make([]Field, 0, len(info.TagLookup)+len(info.EnumLookup))
+	)
+	for name, tf := range info.TagLookup {
+		f := Field{
+			Name:     name,
+			Block:    tf.IsBlock(),
+			Optional: tf.IsOptional(),
+			Type:     t.FieldByIndex(tf.Index).Type,
+		}
+		if f.Block {
+			f.Type = blockType(f.Type)
+		} else if f.Optional {
+			f.Default = defaultValue(defaults.Elem(), tf.Index)
+		}
+		fields = append(fields, f)
+	}
+	for name, eb := range info.EnumLookup {
+		enumType := blockType(t.FieldByIndex(eb.EnumField.Index).Type)
+		fields = append(fields, Field{
+			Name:     name,
+			Block:    true,
+			Optional: true,
+			Type:     blockType(enumType.FieldByIndex(eb.BlockField.Index).Type),
+		})
+	}
+
+	sort.Slice(fields, func(i, j int) bool {
+		return strings.Compare(fields[i].Name, fields[j].Name) < 0
+	})
+	return fields
+}
+
+// blockType returns the type of a single block decoded into a field of type
+// t, removing slices and pointers.
+func blockType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	return t
+}
+
+// defaultValue returns the value of the field at index of rv, or nil if it
+// is the zero value. Fields behind nil pointers are considered to be zero.
+func defaultValue(rv reflect.Value, index []int) any {
+	fv, err := rv.FieldByIndexErr(index)
+	if err != nil || fv.IsZero() {
+		return nil
+	}
+	return fv.Interface()
+}
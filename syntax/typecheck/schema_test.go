@@ -0,0 +1,54 @@
+package typecheck_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/grafana/alloy/syntax/typecheck"
+	"github.com/stretchr/testify/require"
+)
+
+type schemaArgs struct {
+	Targets  []map[string]string `alloy:"targets,attr"`
+	Timeout  time.Duration       `alloy:"timeout,attr,optional"`
+	Name     string              `alloy:"name,attr,optional"`
+	Endpoint []schemaBlock       `alloy:"endpoint,block"`
+	Stages   []schemaStage       `alloy:"stage,enum,optional"`
+}
+
+func (a *schemaArgs) SetToDefault() {
+	*a = schemaArgs{Timeout: 10 * time.Second}
+}
+
+type schemaBlock struct {
+	URL string `alloy:"url,attr"`
+}
+
+type schemaStage struct {
+	JSON  *schemaBlock `alloy:"json,block,optional"`
+	Regex *schemaBlock `alloy:"regex,block,optional"`
+}
+
+func TestFields(t *testing.T) {
+	var (
+		blockType = reflect.TypeOf(schemaBlock{})
+		fields    = typecheck.Fields(reflect.TypeOf(&schemaArgs{}))
+	)
+	require.Equal(t, []typecheck.Field{
+		{Name: "endpoint", Block: true, Type: blockType},
+		{Name: "name", Optional: true, Type: reflect.TypeOf("")},
+		{Name: "stage.json", Block: true, Optional: true, Type: blockType},
+		{Name: "stage.regex", Block: true, Optional: true, Type: blockType},
+		{Name: "targets", Type: reflect.TypeOf([]map[string]string(nil))},
+		{Name: "timeout", Optional: true, Type: reflect.TypeOf(time.Duration(0)), Default: 10 * time.Second},
+	}, fields)
+
+	typeNames := make([]string, 0, len(fields))
+	for _, f := range fields {
+		typeNames = append(typeNames, f.TypeName())
+	}
+	require.Equal(t, []string{"block", "string", "block", "block", "list(object)", "string"}, typeNames)
+
+	require.Nil(t, typecheck.Fields(reflect.TypeOf("")))
+}
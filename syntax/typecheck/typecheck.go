@@ -12,17 +12,25 @@ import (
 )
 
 type structState struct {
+	scope      Scope
 	tags       *tagcache.TagInfo
 	seenAttrs  map[string]struct{}
 	blockCount map[string]int
 }
 
 func Block(b *ast.BlockStmt, args any) diag.Diagnostics {
+	return BlockWithScope(b, args, nil)
+}
+
+// BlockWithScope is like Block, but also checks the types of the values
+// assigned to attributes. The types of referenced values are looked up in
+// scope; references to values which aren't in scope are never reported.
+func BlockWithScope(b *ast.BlockStmt, args any, scope Scope) diag.Diagnostics {
 	rv := reflectutil.DeferencePointer(reflect.ValueOf(args))
-	return block(b, rv)
+	return block(b, rv, scope)
 }
 
-func block(b *ast.BlockStmt, rv reflect.Value) diag.Diagnostics {
+func block(b *ast.BlockStmt, rv reflect.Value, scope Scope) diag.Diagnostics {
 	var diags diag.Diagnostics
 
 	switch rv.Kind() {
@@ -34,6 +42,7 @@ func block(b *ast.BlockStmt, rv reflect.Value) diag.Diagnostics {
 		return checkMapBlock(b, rv)
 	case reflect.Struct:
 		s := structState{
+			scope:      scope,
 			tags:       tagcache.Get(rv.Type()),
 			seenAttrs:  make(map[string]struct{}),
 			blockCount: make(map[string]int),
@@ -46,9 +55,8 @@ func block(b *ast.BlockStmt, rv reflect.Value) diag.Diagnostics {
 			}
 		}
 
-		// FIXME(kallep): When we start to check that correct types are set for properties we most likely need to
-		// consider these interfaces.
-		// - value.Defaulter
+		// NOTE: the types of attribute values are checked with value.AssignableType, which considers the
+		// interfaces used for custom decoding:
 		// - value.Unmarshaler
 		// - value.ConvertibleFromCapsule
 		// - value.ConvertibleIntoCapsule
@@ -144,7 +152,7 @@ func checkStructBlock(s *structState, b *ast.BlockStmt, rv reflect.Value) diag.D
 	case reflect.Slice:
 		// NOTE: we do not need to store any values so we can always set len and cap to 1 and reuse the same slot
 		field.Set(reflect.MakeSlice(field.Type(), 1, 1))
-		return block(b, reflectutil.DeferencePointer(field.Index(0)))
+		return block(b, reflectutil.DeferencePointer(field.Index(0)), s.scope)
 	case reflect.Array:
 		if field.Len() != s.blockCount[name] {
 			return diag.Diagnostics{{
@@ -160,7 +168,7 @@ func checkStructBlock(s *structState, b *ast.BlockStmt, rv reflect.Value) diag.D
 			}}
 		}
 
-		return block(b, reflectutil.DeferencePointer(field.Index(0)))
+		return block(b, reflectutil.DeferencePointer(field.Index(0)), s.scope)
 	default:
 		if s.blockCount[name] > 1 {
 			return diag.Diagnostics{{
@@ -170,7 +178,7 @@ func checkStructBlock(s *structState, b *ast.BlockStmt, rv reflect.Value) diag.D
 				Message:  fmt.Sprintf("block %q may only be specified once", name),
 			}}
 		}
-		return block(b, reflectutil.DeferencePointer(field))
+		return block(b, reflectutil.DeferencePointer(field), s.scope)
 	}
 }
 
@@ -189,10 +197,10 @@ func checkStructEnum(s *structState, b *ast.BlockStmt, rv reflect.Value) diag.Di
 
 	elem := reflectutil.DeferencePointer(field.Index(0))
 
-	return block(b, reflectutil.DeferencePointer(reflectutil.GetOrAlloc(elem, tf.BlockField)))
+	return block(b, reflectutil.DeferencePointer(reflectutil.GetOrAlloc(elem, tf.BlockField)), s.scope)
 }
 
-func checkStructAttr(s *structState, a *ast.AttributeStmt, _ reflect.Value) diag.Diagnostics {
+func checkStructAttr(s *structState, a *ast.AttributeStmt, rv reflect.Value) diag.Diagnostics {
 	tf, ok := s.tags.TagLookup[a.Name.Name]
 	if !ok {
 		return diag.Diagnostics{{
@@ -220,5 +228,9 @@ func checkStructAttr(s *structState, a *ast.AttributeStmt, _ reflect.Value) diag
 	}
 
 	s.seenAttrs[a.Name.Name] = struct{}{}
-	return nil
+
+	if s.scope == nil {
+		return nil
+	}
+	return checkExpr(s.scope, a.Value, rv.Type().FieldByIndex(tf.Index).Type)
 }
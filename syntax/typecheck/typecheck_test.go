@@ -1,7 +1,10 @@
 package typecheck
 
 import (
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/grafana/alloy/syntax/ast"
 	"github.com/grafana/alloy/syntax/parser"
@@ -323,3 +326,147 @@ func TestBlockMap(t *testing.T) {
 	diag := Block(file.Body[0].(*ast.BlockStmt), &Args{})
 	require.Len(t, diag, 0)
 }
+
+type Receiver interface {
+	Receive()
+}
+
+type ScopeExports struct {
+	Output   string   `alloy:"output,attr"`
+	Values   []int    `alloy:"values,attr"`
+	Receiver Receiver `alloy:"receiver,attr"`
+}
+
+// testScope resolves references to the values with the longest matching name.
+type testScope map[string]reflect.Type
+
+func (s testScope) LookupType(ref []string) (reflect.Type, int, bool) {
+	for n := len(ref); n > 0; n-- {
+		if t, ok := s[strings.Join(ref[:n], ".")]; ok {
+			return t, n, true
+		}
+	}
+	return nil, 0, false
+}
+
+func TestBlockWithScope(t *testing.T) {
+	type Args struct {
+		Name      string            `alloy:"name,attr,optional"`
+		Count     int               `alloy:"count,attr,optional"`
+		Enabled   bool              `alloy:"enabled,attr,optional"`
+		Timeout   time.Duration     `alloy:"timeout,attr,optional"`
+		Receivers []Receiver        `alloy:"receivers,attr,optional"`
+		Labels    map[string]string `alloy:"labels,attr,optional"`
+		Block     *Block1           `alloy:"block,block,optional"`
+	}
+
+	scope := testScope{
+		"test.comp.a": reflect.TypeOf(ScopeExports{}),
+		"test.none.b": nil,
+	}
+
+	tests := []struct {
+		desc        string
+		src         string
+		expectedErr string
+	}{
+		{
+			desc: "valid references",
+			src: `
+				name      = test.comp.a.output
+				count     = test.comp.a.values[0]
+				enabled   = test.comp.a.output == "foo"
+				receivers = [test.comp.a.receiver]
+				labels    = { a = test.comp.a.output, b = "b" }
+			`,
+		},
+		{
+			desc: "values which may be converted",
+			src: `
+				name    = 1
+				count   = "5"
+				timeout = "5s"
+				labels  = { a = 1 }
+			`,
+		},
+		{
+			desc: "unknown values are not checked",
+			src: `
+				name    = sys.env("NAME")
+				count   = other.comp.b.anything
+				enabled = other.comp.b.flag ? sys.env("A") == "" : false
+			`,
+		},
+		{
+			desc: "unknown field",
+			src: `
+				name = test.comp.a.outputs
+			`,
+			expectedErr: `2:24: field "outputs" does not exist in test.comp.a`,
+		},
+		{
+			desc: "unknown field in function argument",
+			src: `
+				name = string.format("%s", test.comp.a.outputs)
+			`,
+			expectedErr: `2:44: field "outputs" does not exist in test.comp.a`,
+		},
+		{
+			desc: "value without fields",
+			src: `
+				name = test.none.b.output
+			`,
+			expectedErr: `2:24: field "output" does not exist in test.none.b`,
+		},
+		{
+			desc: "wrong literal type",
+			src: `
+				count = true
+			`,
+			expectedErr: `2:13: expected number, got bool`,
+		},
+		{
+			desc: "wrong reference type",
+			src: `
+				enabled = test.comp.a.values
+			`,
+			expectedErr: `2:15: expected bool, got list(number)`,
+		},
+		{
+			desc: "wrong array element type",
+			src: `
+				receivers = [test.comp.a.receiver, test.comp.a.output]
+			`,
+			expectedErr: `2:40: expected capsule("typecheck.Receiver"), got string`,
+		},
+		{
+			desc: "wrong object field type",
+			src: `
+				labels = { a = test.comp.a.receiver }
+			`,
+			expectedErr: `2:20: expected string, got capsule("typecheck.Receiver")`,
+		},
+		{
+			desc: "wrong type in block",
+			src: `
+				block {
+					arg2 = test.comp.a.values
+				}
+			`,
+			expectedErr: `3:13: expected string, got list(number)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			file, err := parser.ParseFile("", []byte("test {"+tt.src+"}"))
+			require.NoError(t, err)
+			diag := BlockWithScope(file.Body[0].(*ast.BlockStmt), &Args{}, scope)
+			if tt.expectedErr == "" {
+				require.Len(t, diag, 0)
+			} else {
+				require.EqualError(t, diag, tt.expectedErr)
+			}
+		})
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"errors"
 	"fmt"
 	"math"
-	"reflect"
 
 	"github.com/grafana/alloy/syntax/alloytypes"
 	"github.com/grafana/alloy/syntax/internal/value"
@@ -28,9 +27,9 @@ func evalBinop(lhs value.Value, op token.Token, rhs value.Value) (value.Value, e
 	// compare values of any two types.
 	switch op {
 	case token.EQ:
-		return value.Bool(valuesEqual(lhs, rhs)), nil
+		return value.Bool(value.DeepEqual(lhs, rhs)), nil
 	case token.NEQ:
-		return value.Bool(!valuesEqual(lhs, rhs)), nil
+		return value.Bool(!value.DeepEqual(lhs, rhs)), nil
 	}
 
 	// The type of lhs must be acceptable for the binary operator.
@@ -92,7 +91,7 @@ func evalBinop(lhs value.Value, op token.Token, rhs value.Value) (value.Value, e
 		}
 
 		lhsNum, rhsNum := lhs.Number(), rhs.Number()
-		switch fitNumberKinds(lhsNum.Kind(), rhsNum.Kind()) {
+		switch value.FitNumberKinds(lhsNum.Kind(), rhsNum.Kind()) {
 		case value.NumberKindUint:
 			return value.Uint(lhsNum.Uint() + rhsNum.Uint()), nil
 		case value.NumberKindInt:
@@ -103,7 +102,7 @@ func evalBinop(lhs value.Value, op token.Token, rhs value.Value) (value.Value, e
 
 	case token.SUB: // number - number
 		lhsNum, rhsNum := lhs.Number(), rhs.Number()
-		switch fitNumberKinds(lhsNum.Kind(), rhsNum.Kind()) {
+		switch value.FitNumberKinds(lhsNum.Kind(), rhsNum.Kind()) {
 		case value.NumberKindUint:
 			return value.Uint(lhsNum.Uint() - rhsNum.Uint()), nil
 		case value.NumberKindInt:
@@ -114,7 +113,7 @@ func evalBinop(lhs value.Value, op token.Token, rhs value.Value) (value.Value, e
 
 	case token.MUL: // number * number
 		lhsNum, rhsNum := lhs.Number(), rhs.Number()
-		switch fitNumberKinds(lhsNum.Kind(), rhsNum.Kind()) {
+		switch value.FitNumberKinds(lhsNum.Kind(), rhsNum.Kind()) {
 		case value.NumberKindUint:
 			return value.Uint(lhsNum.Uint() * rhsNum.Uint()), nil
 		case value.NumberKindInt:
@@ -148,7 +147,7 @@ func evalBinop(lhs value.Value, op token.Token, rhs value.Value) (value.Value, e
 				}
 			}
 		}
-		switch fitNumberKinds(lhsNum.Kind(), rhsNum.Kind()) {
+		switch value.FitNumberKinds(lhsNum.Kind(), rhsNum.Kind()) {
 		case value.NumberKindUint:
 			return value.Uint(lhsNum.Uint() / rhsNum.Uint()), nil
 		case value.NumberKindInt:
@@ -182,7 +181,7 @@ func evalBinop(lhs value.Value, op token.Token, rhs value.Value) (value.Value, e
 				}
 			}
 		}
-		switch fitNumberKinds(lhsNum.Kind(), rhsNum.Kind()) {
+		switch value.FitNumberKinds(lhsNum.Kind(), rhsNum.Kind()) {
 		case value.NumberKindUint:
 			return value.Uint(lhsNum.Uint() % rhsNum.Uint()), nil
 		case value.NumberKindInt:
@@ -193,7 +192,7 @@ func evalBinop(lhs value.Value, op token.Token, rhs value.Value) (value.Value, e
 
 	case token.POW: // number ^ number
 		lhsNum, rhsNum := lhs.Number(), rhs.Number()
-		switch fitNumberKinds(lhsNum.Kind(), rhsNum.Kind()) {
+		switch value.FitNumberKinds(lhsNum.Kind(), rhsNum.Kind()) {
 		case value.NumberKindUint:
 			return value.Uint(intPow(lhsNum.Uint(), rhsNum.Uint())), nil
 		case value.NumberKindInt:
@@ -210,7 +209,7 @@ func evalBinop(lhs value.Value, op token.Token, rhs value.Value) (value.Value, e
 
 		// Not a string; must be a number.
 		lhsNum, rhsNum := lhs.Number(), rhs.Number()
-		switch fitNumberKinds(lhsNum.Kind(), rhsNum.Kind()) {
+		switch value.FitNumberKinds(lhsNum.Kind(), rhsNum.Kind()) {
 		case value.NumberKindUint:
 			return value.Bool(lhsNum.Uint() < rhsNum.Uint()), nil
 		case value.NumberKindInt:
@@ -227,7 +226,7 @@ func evalBinop(lhs value.Value, op token.Token, rhs value.Value) (value.Value, e
 
 		// Not a string; must be a number.
 		lhsNum, rhsNum := lhs.Number(), rhs.Number()
-		switch fitNumberKinds(lhsNum.Kind(), rhsNum.Kind()) {
+		switch value.FitNumberKinds(lhsNum.Kind(), rhsNum.Kind()) {
 		case value.NumberKindUint:
 			return value.Bool(lhsNum.Uint() > rhsNum.Uint()), nil
 		case value.NumberKindInt:
@@ -244,7 +243,7 @@ func evalBinop(lhs value.Value, op token.Token, rhs value.Value) (value.Value, e
 
 		// Not a string; must be a number.
 		lhsNum, rhsNum := lhs.Number(), rhs.Number()
-		switch fitNumberKinds(lhsNum.Kind(), rhsNum.Kind()) {
+		switch value.FitNumberKinds(lhsNum.Kind(), rhsNum.Kind()) {
 		case value.NumberKindUint:
 			return value.Bool(lhsNum.Uint() <= rhsNum.Uint()), nil
 		case value.NumberKindInt:
@@ -261,7 +260,7 @@ func evalBinop(lhs value.Value, op token.Token, rhs value.Value) (value.Value, e
 
 		// Not a string; must be a number.
 		lhsNum, rhsNum := lhs.Number(), rhs.Number()
-		switch fitNumberKinds(lhsNum.Kind(), rhsNum.Kind()) {
+		switch value.FitNumberKinds(lhsNum.Kind(), rhsNum.Kind()) {
 		case value.NumberKindUint:
 			return value.Bool(lhsNum.Uint() >= rhsNum.Uint()), nil
 		case value.NumberKindInt:
@@ -345,81 +344,6 @@ func tryUnwrapString(lhs value.Value, lhsValue string, rhs value.Value) (value.V
 	}
 }
 
-// valuesEqual returns true if two Values are equal.
-func valuesEqual(lhs value.Value, rhs value.Value) bool {
-	if lhs.Type() != rhs.Type() {
-		// Two values with different types are never equal.
-		return false
-	}
-
-	switch lhs.Type() {
-	case value.TypeNull:
-		// Nothing to compare here: both lhs and rhs have the null type,
-		// so they're equal.
-		return true
-
-	case value.TypeNumber:
-		// Two numbers are equal if they have equal values. However, we have to
-		// determine what comparison we want to do and upcast the values to a
-		// different Go type as needed (so that 3 == 3.0 is true).
-		lhsNum, rhsNum := lhs.Number(), rhs.Number()
-		switch fitNumberKinds(lhsNum.Kind(), rhsNum.Kind()) {
-		case value.NumberKindUint:
-			return lhsNum.Uint() == rhsNum.Uint()
-		case value.NumberKindInt:
-			return lhsNum.Int() == rhsNum.Int()
-		case value.NumberKindFloat:
-			return lhsNum.Float() == rhsNum.Float()
-		}
-
-	case value.TypeString:
-		return lhs.Text() == rhs.Text()
-
-	case value.TypeBool:
-		return lhs.Bool() == rhs.Bool()
-
-	case value.TypeArray:
-		// Two arrays are equal if they have equal elements.
-		if lhs.Len() != rhs.Len() {
-			return false
-		}
-		for i := 0; i < lhs.Len(); i++ {
-			if !valuesEqual(lhs.Index(i), rhs.Index(i)) {
-				return false
-			}
-		}
-		return true
-
-	case value.TypeObject:
-		// Two objects are equal if they have equal elements.
-		if lhs.Len() != rhs.Len() {
-			return false
-		}
-		for _, key := range lhs.Keys() {
-			lhsElement, _ := lhs.Key(key)
-			rhsElement, inRHS := rhs.Key(key)
-			if !inRHS {
-				return false
-			}
-			if !valuesEqual(lhsElement, rhsElement) {
-				return false
-			}
-		}
-		return true
-
-	case value.TypeFunction:
-		// Two functions are never equal. We can't compare functions in Go, so
-		// there's no way to compare them in Alloy syntax right now.
-		return false
-
-	case value.TypeCapsule:
-		// Two capsules are only equal if the underlying values are deeply equal.
-		return reflect.DeepEqual(lhs.Interface(), rhs.Interface())
-	}
-
-	panic("syntax/vm: unreachable")
-}
-
 // binopAllowedTypes maps what type of values are permitted for a specific
 // binary operation.
 //
@@ -457,20 +381,6 @@ func acceptableBinopType(val value.Value, op token.Token) bool {
 	return false
 }
 
-func fitNumberKinds(a, b value.NumberKind) value.NumberKind {
-	aPrec, bPrec := numberKindPrec[a], numberKindPrec[b]
-	if aPrec > bPrec {
-		return a
-	}
-	return b
-}
-
-var numberKindPrec = map[value.NumberKind]int{
-	value.NumberKindUint:  0,
-	value.NumberKindInt:   1,
-	value.NumberKindFloat: 2,
-}
-
 func intPow[Number int64 | uint64](n, m Number) Number {
 	switch {
 	case m == 0 || n == 1:
@@ -316,6 +316,23 @@ func (vm *Evaluator) evaluateExpr(scope *Scope, assoc map[value.Value]ast.Node,
 		}
 		return evalBinop(lhs, expr.Kind, rhs)
 
+	case *ast.ConditionalExpr:
+		cond, err := vm.evaluateExpr(scope, assoc, expr.Cond)
+		if err != nil {
+			return value.Null, err
+		}
+		if cond.Type() != value.TypeBool {
+			return value.Null, value.TypeError{Value: cond, Expected: value.TypeBool}
+		}
+
+		// Only the chosen branch is evaluated so that the other branch may
+		// contain an expression which would fail, such as accessing a field
+		// which the condition checks for.
+		if cond.Bool() {
+			return vm.evaluateExpr(scope, assoc, expr.Then)
+		}
+		return vm.evaluateExpr(scope, assoc, expr.Else)
+
 	case *ast.ArrayExpr:
 		vals := make([]value.Value, len(expr.Elements))
 		for i, element := range expr.Elements {
@@ -2,6 +2,8 @@ package vm_test
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 
@@ -365,7 +367,289 @@ func TestStdlib_StringFunc(t *testing.T) {
 	}
 }
 
+func TestStdlib_RegexFunc(t *testing.T) {
+	scope := vm.NewScope(make(map[string]interface{}))
+
+	tt := []struct {
+		name   string
+		input  string
+		expect interface{}
+	}{
+		{"regex.match", `regex.match("app-1234", "^app-[0-9]+$")`, true},
+		{"regex.match no match", `regex.match("web-1234", "^app-[0-9]+$")`, false},
+		{"regex.match partial", `regex.match("my-app-1234", "app")`, true},
+		{"regex.find_all", `regex.find_all("a1b22c333", "[0-9]+")`, []string{"1", "22", "333"}},
+		{"regex.find_all no match", `regex.find_all("abc", "[0-9]+")`, []string{}},
+		{"regex.replace", `regex.replace("10.0.0.1:9090", ":[0-9]+$", "")`, "10.0.0.1"},
+		{"regex.replace capture groups", `regex.replace("10.0.0.1:9090", "^(.*):([0-9]+)$", "$2@$1")`, "9090@10.0.0.1"},
+		{"regex.replace named capture groups", `regex.replace("prod-eu-west", "^(?P<env>[a-z]+)-(?P<region>.*)$", "${region}/${env}")`, "eu-west/prod"},
+		{"regex.split", `regex.split("a, b;c  d", "[,; ]+")`, []string{"a", "b", "c", "d"}},
+		{"regex.split+index", `regex.split("key=value", "=")[1]`, "value"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := parser.ParseExpression(tc.input)
+			require.NoError(t, err)
+
+			eval := vm.New(expr)
+
+			rv := reflect.New(reflect.TypeOf(tc.expect))
+			require.NoError(t, eval.Evaluate(scope, rv.Interface()))
+			require.Equal(t, tc.expect, rv.Elem().Interface())
+		})
+	}
+}
+
+func TestStdlib_RegexFunc_Errors(t *testing.T) {
+	tt := []struct {
+		name        string
+		input       string
+		expectedErr string
+	}{
+		{"regex.match", `regex.match("foo", "(")`, "error parsing regexp: missing closing ): `(`"},
+		{"regex.find_all", `regex.find_all("foo", "[")`, "error parsing regexp: missing closing ]: `[`"},
+		{"regex.replace", `regex.replace("foo", "a**", "")`, "error parsing regexp: invalid nested repetition operator: `**`"},
+		{"regex.split", `regex.split("foo", "x{2,1}")`, "error parsing regexp: invalid repeat count: `{2,1}`"},
+		{"regex.match lookahead", `regex.match("foo", "foo(?=bar)")`, "error parsing regexp: invalid or unsupported Perl syntax: `(?=`"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := parser.ParseExpression(tc.input)
+			require.NoError(t, err)
+
+			eval := vm.New(expr)
+
+			var v interface{}
+			err = eval.Evaluate(nil, &v)
+			require.ErrorContains(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestStdlib_CryptoFunc(t *testing.T) {
+	scope := vm.NewScope(map[string]any{
+		"secret":         alloytypes.Secret("foo"),
+		"optionalSecret": alloytypes.OptionalSecret{Value: "foo"},
+		"key":            alloytypes.Secret("key"),
+	})
+
+	tt := []struct {
+		name   string
+		input  string
+		expect interface{}
+	}{
+		{"crypto.sha256", `crypto.sha256("foo")`, "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"},
+		{"crypto.sha1", `crypto.sha1("foo")`, "0beec7b5ea3f0fdbc95d0dd47f3c5bc275da8a33"},
+		{"crypto.md5", `crypto.md5("foo")`, "acbd18db4cc2f85cedef654fccc4a4d8"},
+		{"crypto.fnv32", `crypto.fnv32("foo")`, uint32(2851307223)},
+		{"crypto.fnv32 shard", `crypto.fnv32("foo") % 4`, 3},
+		{"crypto.fnv32 non-secret optional secret", `crypto.fnv32(optionalSecret) % 4`, 3},
+		{"crypto.hmac_sha256", `crypto.hmac_sha256("foo", "key")`, "6ea1d9f5e93a8f3ade026261ffe5d72a1c90804ed94404a69892a163b8a35497"},
+		{"crypto.uuid", `crypto.uuid("host-1.example.com")`, "6b65ed22-6e2a-59b1-8c6d-c3654f830ed4"},
+
+		// Hashing a secret returns a secret.
+		{"crypto.sha256 secret", `crypto.sha256(secret)`, alloytypes.Secret("2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae")},
+		{"crypto.hmac_sha256 secret key", `crypto.hmac_sha256("foo", key)`, alloytypes.Secret("6ea1d9f5e93a8f3ade026261ffe5d72a1c90804ed94404a69892a163b8a35497")},
+		{"crypto.uuid secret", `crypto.uuid(secret)`, alloytypes.Secret("b84ed8ed-a7b1-502f-83f6-90132e68adef")},
+		{"crypto.sha256 non-secret optional secret", `crypto.sha256(optionalSecret)`, "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"},
+
+		// The digest of a secret can be converted to a string explicitly.
+		{"crypto.sha256 secret opt-in", `convert.nonsensitive(crypto.sha256(secret))`, "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := parser.ParseExpression(tc.input)
+			require.NoError(t, err)
+
+			eval := vm.New(expr)
+
+			rv := reflect.New(reflect.TypeOf(tc.expect))
+			require.NoError(t, eval.Evaluate(scope, rv.Interface()))
+			require.Equal(t, tc.expect, rv.Elem().Interface())
+		})
+	}
+}
+
+func TestStdlib_CryptoFunc_Errors(t *testing.T) {
+	scope := vm.NewScope(map[string]any{
+		"secret": alloytypes.Secret("foo"),
+	})
+
+	tt := []struct {
+		name        string
+		input       string
+		expectedErr string
+	}{
+		{"crypto.sha256 number", `crypto.sha256(1)`, "1 should be string, got number"},
+		{"crypto.sha256 arguments", `crypto.sha256("foo", "bar")`, "sha256: expected 1 argument, got 2"},
+		{"crypto.hmac_sha256 arguments", `crypto.hmac_sha256("foo")`, "hmac_sha256: expected 2 arguments, got 1"},
+		{"crypto.sha256 secret into string", `crypto.sha256(secret)`, "secrets may not be converted into strings"},
+		{"crypto.fnv32 secret", `crypto.fnv32(secret) % 4`, "fnv32 doesn't accept secrets"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := parser.ParseExpression(tc.input)
+			require.NoError(t, err)
+
+			eval := vm.New(expr)
+
+			var s string
+			err = eval.Evaluate(scope, &s)
+			require.ErrorContains(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestStdlib_ArrayFunc(t *testing.T) {
+	tt := []struct {
+		name   string
+		input  string
+		expect interface{}
+	}{
+		{"array.length", `array.length([1, 2, 3])`, 3},
+		{"array.length empty", `array.length([])`, 0},
+		{"array.contains", `array.contains(["a", "b"], "b")`, true},
+		{"array.contains missing", `array.contains(["a", "b"], "c")`, false},
+		{"array.contains number kinds", `array.contains([1, 2], 2.0)`, true},
+		{"array.contains object", `array.contains([{"a" = 1}, {"b" = 2}], {"b" = 2})`, true},
+		{"array.distinct", `array.distinct(["a", "b", "a", "c", "b"])`, []string{"a", "b", "c"}},
+		{"array.distinct objects", `array.distinct([{"a" = 1}, {"a" = 2}, {"a" = 1}])`, []map[string]int{{"a": 1}, {"a": 2}}},
+		{"array.flatten", `array.flatten([1, [2, [3, 4]], [], 5])`, []int{1, 2, 3, 4, 5}},
+		{"array.slice", `array.slice(["a", "b", "c", "d"], 1, 3)`, []string{"b", "c"}},
+		{"array.slice empty", `array.slice(["a", "b"], 2, 2)`, []string{}},
+		{"array.range", `array.range(0, 4)`, []int{0, 1, 2, 3}},
+		{"array.range step", `array.range(0, 10, 3)`, []int{0, 3, 6, 9}},
+		{"array.range descending", `array.range(3, 0)`, []int{3, 2, 1}},
+		{"array.range empty", `array.range(2, 2)`, []int{}},
+		{"array.sort strings", `array.sort(["b", "c", "a"])`, []string{"a", "b", "c"}},
+		{"array.sort numbers", `array.sort([10, 2.5, 1, -3])`, []float64{-3, 1, 2.5, 10}},
+		{
+			"array.sort by field",
+			`array.sort([{"n" = "b", "i" = 1}, {"n" = "a", "i" = 2}, {"n" = "b", "i" = 0}], "n")`,
+			[]map[string]interface{}{{"n": "a", "i": 2}, {"n": "b", "i": 1}, {"n": "b", "i": 0}},
+		},
+		{
+			"array.filter",
+			`array.filter([{"team" = "a", "id" = 1}, {"team" = "b", "id" = 2}, {"id" = 3}, {"team" = "a", "id" = 4}], "team", "a")`,
+			[]map[string]interface{}{{"team": "a", "id": 1}, {"team": "a", "id": 4}},
+		},
+		{"array.filter no match", `array.filter([{"team" = "a"}], "team", "b")`, []map[string]string{}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := parser.ParseExpression(tc.input)
+			require.NoError(t, err)
+
+			eval := vm.New(expr)
+
+			rv := reflect.New(reflect.TypeOf(tc.expect))
+			require.NoError(t, eval.Evaluate(nil, rv.Interface()))
+			require.Equal(t, tc.expect, rv.Elem().Interface())
+		})
+	}
+}
+
+func TestStdlib_ArrayFunc_Errors(t *testing.T) {
+	tt := []struct {
+		name        string
+		input       string
+		expectedErr string
+	}{
+		{"array.length not an array", `array.length("abc")`, `"abc" should be array, got string`},
+		{"array.contains arguments", `array.contains([1])`, "contains: expected 2 arguments, got 1"},
+		{"array.slice start out of range", `array.slice([1, 2], 3, 3)`, "start index 3 out of range for array of length 2"},
+		{"array.slice end before start", `array.slice([1, 2], 1, 0)`, "end index 0 out of range for array of length 2 starting at 1"},
+		{"array.range zero step", `array.range(0, 10, 0)`, "step 0 never reaches 10 from 0"},
+		{"array.range wrong direction", `array.range(0, 10, -1)`, "step -1 never reaches 10 from 0"},
+		{"array.range too long", `array.range(0, 100000000)`, "range: cannot generate more than 65536 elements"},
+		{"array.sort mixed types", `array.sort(["a", 1])`, `["a", 1] element 1 should be string, got number`},
+		{"array.sort unsupported type", `array.sort([true])`, "[true] element 0 should be string, got bool"},
+		{"array.sort missing field", `array.sort([{"a" = 1}, {"b" = 2}], "a")`, `element 1 is missing field "a"`},
+		{"array.filter not an object", `array.filter(["a"], "team", "a")`, `["a"] element 0 should be object, got string`},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := parser.ParseExpression(tc.input)
+			require.NoError(t, err)
+
+			eval := vm.New(expr)
+
+			var v interface{}
+			err = eval.Evaluate(nil, &v)
+			require.ErrorContains(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestStdlib_MapFunc(t *testing.T) {
+	tt := []struct {
+		name   string
+		input  string
+		expect interface{}
+	}{
+		{"map.keys", `map.keys({"b" = 1, "a" = 2, "c" = 3})`, []string{"a", "b", "c"}},
+		{"map.keys empty", `map.keys({})`, []string{}},
+		{"map.values", `map.values({"b" = 1, "a" = 2, "c" = 3})`, []int{2, 1, 3}},
+		{"map.merge", `map.merge({"a" = 1, "b" = 1}, {"b" = 2}, {"c" = 3})`, map[string]int{"a": 1, "b": 2, "c": 3}},
+		{"map.merge no arguments", `map.merge()`, map[string]int{}},
+		{"map.pick", `map.pick({"a" = 1, "b" = 2, "c" = 3}, ["a", "c", "d"])`, map[string]int{"a": 1, "c": 3}},
+		{"map.omit", `map.omit({"a" = 1, "b" = 2, "c" = 3}, ["a", "d"])`, map[string]int{"b": 2, "c": 3}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := parser.ParseExpression(tc.input)
+			require.NoError(t, err)
+
+			eval := vm.New(expr)
+
+			rv := reflect.New(reflect.TypeOf(tc.expect))
+			require.NoError(t, eval.Evaluate(nil, rv.Interface()))
+			require.Equal(t, tc.expect, rv.Elem().Interface())
+		})
+	}
+}
+
+func TestStdlib_MapFunc_Errors(t *testing.T) {
+	tt := []struct {
+		name        string
+		input       string
+		expectedErr string
+	}{
+		{"map.keys not an object", `map.keys(["a"])`, `["a"] should be object, got array`},
+		{"map.merge not an object", `map.merge({"a" = 1}, "b")`, `"b" should be object, got string`},
+		{"map.pick keys not strings", `map.pick({"a" = 1}, ["a", 1])`, `["a", 1] element 1 should be string, got number`},
+		{"map.omit arguments", `map.omit({"a" = 1})`, "omit: expected 2 arguments, got 1"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := parser.ParseExpression(tc.input)
+			require.NoError(t, err)
+
+			eval := vm.New(expr)
+
+			var v interface{}
+			err = eval.Evaluate(nil, &v)
+			require.ErrorContains(t, err, tc.expectedErr)
+		})
+	}
+}
+
 func TestStdlibFileFunc(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("hello"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.txt"), []byte("world"), 0o644))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0o755))
+
+	scope := vm.NewScope(map[string]interface{}{"dir": dir})
+
 	tt := []struct {
 		name   string
 		input  string
@@ -373,6 +657,13 @@ func TestStdlibFileFunc(t *testing.T) {
 	}{
 		{"file.path_join", `file.path_join("this/is", "a/path")`, "this/is/a/path"},
 		{"file.path_join empty", `file.path_join()`, ""},
+		{"file.read", `file.read(file.path_join(dir, "a.txt"))`, "hello"},
+		{"file.exists", `file.exists(file.path_join(dir, "a.txt"))`, true},
+		{"file.exists directory", `file.exists(file.path_join(dir, "sub"))`, true},
+		{"file.exists missing", `file.exists(file.path_join(dir, "missing.txt"))`, false},
+		{"file.glob", `file.glob(file.path_join(dir, "*.txt"))`, []string{filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")}},
+		{"file.glob no matches", `file.glob(file.path_join(dir, "*.yaml"))`, []string{}},
+		{"file.read_dir", `file.read_dir(dir)`, []string{"a.txt", "b.txt", "sub"}},
 	}
 
 	for _, tc := range tt {
@@ -383,12 +674,39 @@ func TestStdlibFileFunc(t *testing.T) {
 			eval := vm.New(expr)
 
 			rv := reflect.New(reflect.TypeOf(tc.expect))
-			require.NoError(t, eval.Evaluate(nil, rv.Interface()))
+			require.NoError(t, eval.Evaluate(scope, rv.Interface()))
 			require.Equal(t, tc.expect, rv.Elem().Interface())
 		})
 	}
 }
 
+func TestStdlibFileFunc_Errors(t *testing.T) {
+	scope := vm.NewScope(map[string]interface{}{"dir": t.TempDir()})
+
+	tt := []struct {
+		name        string
+		input       string
+		expectedErr string
+	}{
+		{"file.read missing", `file.read(file.path_join(dir, "missing.txt"))`, "missing.txt"},
+		{"file.read_dir missing", `file.read_dir(file.path_join(dir, "missing"))`, "missing"},
+		{"file.glob bad pattern", `file.glob("[")`, "syntax error in pattern"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := parser.ParseExpression(tc.input)
+			require.NoError(t, err)
+
+			eval := vm.New(expr)
+
+			var v interface{}
+			err = eval.Evaluate(scope, &v)
+			require.ErrorContains(t, err, tc.expectedErr)
+		})
+	}
+}
+
 func BenchmarkConcat(b *testing.B) {
 	// There's a bit of setup work to do here: we want to create a scope holding
 	// a slice of the Person type, which has a fair amount of data in it.
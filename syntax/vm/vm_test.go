@@ -181,6 +181,15 @@ func TestVM_Evaluate(t *testing.T) {
 		{`!true`, bool(false)},
 		{`!false`, bool(true)},
 		{`-15`, int(-15)},
+
+		// Conditional
+		{`true ? 1 : 2`, int(1)},
+		{`false ? 1 : 2`, int(2)},
+		{`foobar > 40 ? "big" : "small"`, string("big")},
+		{`false ? 1 : true ? 2 : 3`, int(2)},
+		{`(true ? [1] : [2])[0]`, int(1)},
+		{`true ? 1 : {}.missing`, int(1)}, // Branch not taken is never evaluated
+		{`false ? [][0] : 5`, int(5)},
 	}
 
 	for _, tc := range tt {